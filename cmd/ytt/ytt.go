@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/k14s/ytt/pkg/cmd"
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
 )
 
 func main() {
@@ -16,7 +17,9 @@ func main() {
 
 	err := command.Execute()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if _, reported := err.(cmdcore.ReportedError); !reported {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorCyan  = "\x1b[36m"
+)
+
+var (
+	// Error messages are listed as '- msg' and
+	// positions as '    file.yml:12 | line contents'
+	colorErrMsgLine = regexp.MustCompile(`^(\s*)(- .*)$`)
+	colorErrPosLine = regexp.MustCompile(`^(\s+)(\S+:\d+)( \|.*)$`)
+)
+
+// ColorEnabled decides whether diagnostic output written to given file
+// (typically stderr) should be colored; auto enables color only for
+// terminals, unless NO_COLOR environment variable is set or TERM is dumb
+func ColorEnabled(mode string, file *os.File) (bool, error) {
+	switch mode {
+	case ColorNever:
+		return false, nil
+
+	case ColorAlways:
+		return true, nil
+
+	case ColorAuto, "":
+		if _, found := os.LookupEnv("NO_COLOR"); found || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		return IsTerminal(file), nil
+
+	default:
+		return false, fmt.Errorf("Unknown color mode '%s' (expected %s, %s or %s)", mode, ColorAuto, ColorAlways, ColorNever)
+	}
+}
+
+// ColorizeError returns error message (as printed to stderr) with
+// error prefix and messages in red, and file positions highlighted
+func ColorizeError(err error) string {
+	lines := strings.Split(err.Error(), "\n")
+
+	for i, line := range lines {
+		switch {
+		case colorErrPosLine.MatchString(line):
+			lines[i] = colorErrPosLine.ReplaceAllString(line, "${1}"+colorCyan+"${2}"+colorReset+"${3}")
+		case colorErrMsgLine.MatchString(line):
+			lines[i] = colorErrMsgLine.ReplaceAllString(line, "${1}"+colorRed+"${2}"+colorReset)
+		}
+	}
+
+	return colorize(colorBold+colorRed, "Error:") + " " + strings.Join(lines, "\n")
+}
+
+// IsTerminal returns true if given file (eg os.Stderr) is a terminal
+func IsTerminal(file *os.File) bool {
+	fi, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return (fi.Mode() & os.ModeCharDevice) != 0
+}
+
+func colorize(code, str string) string {
+	return code + str + colorReset
+}
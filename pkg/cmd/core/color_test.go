@@ -0,0 +1,35 @@
+package core_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+)
+
+func TestColorizeError(t *testing.T) {
+	err := fmt.Errorf("\n- undefined: x\n    tpl.yml:2 | b: #@ x")
+
+	expected := "\x1b[1m\x1b[31mError:\x1b[0m \n" +
+		"\x1b[31m- undefined: x\x1b[0m\n" +
+		"    \x1b[36mtpl.yml:2\x1b[0m | b: #@ x"
+
+	if result := cmdcore.ColorizeError(err); result != expected {
+		t.Fatalf("Expected colorized error to match, but was: %q", result)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	for mode, expected := range map[string]bool{cmdcore.ColorAlways: true, cmdcore.ColorNever: false} {
+		result, err := cmdcore.ColorEnabled(mode, os.Stderr)
+		if err != nil || result != expected {
+			t.Fatalf("Expected mode '%s' to be %t, but was %t (err: %v)", mode, expected, result, err)
+		}
+	}
+
+	_, err := cmdcore.ColorEnabled("rainbow", os.Stderr)
+	if err == nil || err.Error() != "Unknown color mode 'rainbow' (expected auto, always or never)" {
+		t.Fatalf("Expected unknown mode err, but was: %v", err)
+	}
+}
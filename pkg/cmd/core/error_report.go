@@ -0,0 +1,44 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/k14s/ytt/pkg/filepos"
+)
+
+const (
+	ErrorsFormatText = "text"
+	ErrorsFormatJSON = "json"
+)
+
+type errorReport struct {
+	Message string                `json:"message"`
+	Errors  []filepos.ErrorDetail `json:"errors"`
+}
+
+// PrintErrorJSON prints error as a single line JSON object with
+// complete message and per error details (kind, file, line, column, message)
+func PrintErrorJSON(writer io.Writer, err error) error {
+	report := errorReport{
+		Message: err.Error(),
+		Errors:  filepos.ErrorDetailsFromError(err),
+	}
+
+	bs, marshalErr := json.Marshal(report)
+	if marshalErr != nil {
+		return fmt.Errorf("Marshaling error report: %s", marshalErr)
+	}
+
+	_, writeErr := fmt.Fprintf(writer, "%s\n", bs)
+	return writeErr
+}
+
+// ReportedError indicates that error was already presented
+// to the user and only needs to result in non-zero exit code
+type ReportedError struct {
+	Err error
+}
+
+func (e ReportedError) Error() string { return e.Err.Error() }
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	ProgressAuto   = "auto"
+	ProgressAlways = "always"
+	ProgressNever  = "never"
+
+	// DefaultProgressThreshold is number of files above
+	// which progress is shown in auto mode
+	DefaultProgressThreshold = 100
+
+	progressInterval = 100 * time.Millisecond
+)
+
+// ProgressFunc returns function that reports number of written files
+// to given writer (typically stderr), or nil if progress is not shown.
+// Terminals get single line updated in place; otherwise (always mode
+// only) one summary line is printed once all files are written.
+func ProgressFunc(mode string, out io.Writer) (func(written, total int), error) {
+	file, isFile := out.(*os.File)
+	interactive := isFile && IsTerminal(file)
+
+	switch mode {
+	case ProgressNever:
+		return nil, nil
+
+	case ProgressAlways:
+		return newProgressPrinter(out, interactive, 0).Print, nil
+
+	case ProgressAuto, "":
+		if !interactive {
+			return nil, nil
+		}
+		return newProgressPrinter(out, interactive, DefaultProgressThreshold).Print, nil
+
+	default:
+		return nil, fmt.Errorf("Unknown progress mode '%s' (expected %s, %s or %s)",
+			mode, ProgressAuto, ProgressAlways, ProgressNever)
+	}
+}
+
+type progressPrinter struct {
+	out         io.Writer
+	interactive bool
+	threshold   int
+	lastPrint   time.Time
+}
+
+func newProgressPrinter(out io.Writer, interactive bool, threshold int) *progressPrinter {
+	return &progressPrinter{out: out, interactive: interactive, threshold: threshold}
+}
+
+func (p *progressPrinter) Print(written, total int) {
+	if total <= p.threshold {
+		return
+	}
+
+	done := written == total
+
+	if !p.interactive {
+		if done {
+			fmt.Fprintf(p.out, "%d/%d files written\n", written, total)
+		}
+		return
+	}
+
+	// Avoid flooding terminal when writing many small files
+	if !done && time.Since(p.lastPrint) < progressInterval {
+		return
+	}
+	p.lastPrint = time.Now()
+
+	fmt.Fprintf(p.out, "\r%d/%d files written", written, total)
+	if done {
+		fmt.Fprintf(p.out, "\n")
+	}
+}
@@ -0,0 +1,38 @@
+package core_test
+
+import (
+	"bytes"
+	"testing"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+)
+
+func TestProgressFuncNonInteractive(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	for _, mode := range []string{cmdcore.ProgressAuto, cmdcore.ProgressNever} {
+		progressFunc, err := cmdcore.ProgressFunc(mode, buf)
+		if err != nil || progressFunc != nil {
+			t.Fatalf("Expected mode '%s' to not show progress for non-terminal (err: %v)", mode, err)
+		}
+	}
+
+	progressFunc, err := cmdcore.ProgressFunc(cmdcore.ProgressAlways, buf)
+	if err != nil {
+		t.Fatalf("Expected always mode to succeed: %s", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		progressFunc(i, 3)
+	}
+
+	// Only summary line is printed for non-terminals
+	if buf.String() != "3/3 files written\n" {
+		t.Fatalf("Expected summary line, but was: >>>%s<<<", buf.String())
+	}
+
+	_, err = cmdcore.ProgressFunc("sometimes", buf)
+	if err == nil || err.Error() != "Unknown progress mode 'sometimes' (expected auto, always or never)" {
+		t.Fatalf("Expected unknown mode err, but was: %v", err)
+	}
+}
@@ -4,31 +4,132 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/k14s/ytt/pkg/files"
 )
 
 type PlainUI struct {
-	debug bool
+	debug    bool
+	quiet    bool // suppresses everything except regular output
+	out      io.Writer
+	debugOut io.Writer
+	color    bool // only applies to diagnostic output
+
+	// warnings is shared between copies of UI
+	warnings *warningsCounter
+}
+
+type warningsCounter struct {
+	lock  sync.Mutex
+	count int
 }
 
 var _ files.UI = PlainUI{}
 
-func NewPlainUI(debug bool) PlainUI { return PlainUI{debug} }
+func NewPlainUI(debug bool) PlainUI {
+	return PlainUI{debug, false, os.Stdout, os.Stderr, false, &warningsCounter{}}
+}
+
+// NewWriterUI returns UI that writes regular output to out
+// and debug output (if enabled) to debugOut instead of process stdout/stderr
+func NewWriterUI(out, debugOut io.Writer, debug bool) PlainUI {
+	return PlainUI{debug, false, out, debugOut, false, &warningsCounter{}}
+}
+
+// WithColor returns copy of UI that colors debug output section headers
+// (eg '### result'); regular output is never colored
+func (ui PlainUI) WithColor(color bool) PlainUI {
+	ui.color = color
+	return ui
+}
+
+// WithQuiet returns copy of UI that only prints regular output:
+// debug output, informational messages and warnings are suppressed
+// (warnings are still counted)
+func (ui PlainUI) WithQuiet(quiet bool) PlainUI {
+	ui.quiet = quiet
+	return ui
+}
+
+// WithWriter returns copy of UI that writes regular output to given writer
+func (ui PlainUI) WithWriter(out io.Writer) PlainUI {
+	ui.out = out
+	return ui
+}
+
+func (ui PlainUI) ColorEnabled() bool { return ui.color }
 
 func (ui PlainUI) Printf(str string, args ...interface{}) {
-	fmt.Printf(str, args...)
+	fmt.Fprintf(ui.out, str, args...)
 }
 
+// Infof prints informational message (eg files written
+// to output directory) unless UI is quiet
+func (ui PlainUI) Infof(str string, args ...interface{}) {
+	if !ui.quiet {
+		fmt.Fprintf(ui.out, str, args...)
+	}
+}
+
+func (ui PlainUI) Quiet() bool { return ui.quiet }
+
+// Writer returns writer for regular (non-debug) output
+func (ui PlainUI) Writer() io.Writer { return ui.out }
+
+// ErrWriter returns writer for diagnostic output (eg stderr)
+// that is not part of regular output, regardless of debug flag
+func (ui PlainUI) ErrWriter() io.Writer { return ui.debugOut }
+
 func (ui PlainUI) Debugf(str string, args ...interface{}) {
-	if ui.debug {
-		fmt.Fprintf(os.Stderr, str, args...)
+	if ui.debug && !ui.quiet {
+		if ui.color && strings.HasPrefix(str, "#") {
+			// Only color section header (first line)
+			msg := fmt.Sprintf(str, args...)
+			header, rest := msg, ""
+			if idx := strings.Index(msg, "\n"); idx >= 0 {
+				header, rest = msg[:idx], msg[idx:]
+			}
+			fmt.Fprint(ui.debugOut, colorize(colorBold+colorCyan, header)+rest)
+			return
+		}
+		fmt.Fprintf(ui.debugOut, str, args...)
+	}
+}
+
+// Colored returns string wrapped in given color codes if color is enabled
+func (ui PlainUI) Colored(code, str string) string {
+	if !ui.color {
+		return str
 	}
+	return colorize(code, str)
+}
+
+// Bold returns bold string if color is enabled
+func (ui PlainUI) Bold(str string) string { return ui.Colored(colorBold, str) }
+
+// Warnf prints warning to diagnostic output and counts it
+func (ui PlainUI) Warnf(str string, args ...interface{}) {
+	ui.warnings.lock.Lock()
+	ui.warnings.count++
+	ui.warnings.lock.Unlock()
+
+	if !ui.quiet {
+		fmt.Fprintf(ui.debugOut, str, args...)
+	}
+}
+
+// WarningsCount returns number of warnings printed so far
+func (ui PlainUI) WarningsCount() int {
+	ui.warnings.lock.Lock()
+	defer ui.warnings.lock.Unlock()
+	return ui.warnings.count
 }
 
 func (ui PlainUI) DebugWriter() io.Writer {
-	if ui.debug {
-		return os.Stderr
+	if ui.debug && !ui.quiet {
+		return ui.debugOut
 	}
 	return noopWriter{}
 }
@@ -0,0 +1,37 @@
+package core_test
+
+import (
+	"bytes"
+	"testing"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+)
+
+func TestPlainUIQuiet(t *testing.T) {
+	var out, debugOut bytes.Buffer
+
+	ui := cmdcore.NewWriterUI(&out, &debugOut, true).WithQuiet(true)
+
+	ui.Printf("output\n")
+	ui.Infof("creating: a.yml\n")
+	ui.Debugf("### result\n")
+	ui.Warnf("warning\n")
+	ui.DebugWriter().Write([]byte("debug\n"))
+
+	if out.String() != "output\n" {
+		t.Fatalf("Expected only regular output to be printed, but was: %q", out.String())
+	}
+	if debugOut.Len() != 0 {
+		t.Fatalf("Expected no diagnostic output, but was: %q", debugOut.String())
+	}
+	if ui.WarningsCount() != 1 {
+		t.Fatalf("Expected warnings to be counted, but was %d", ui.WarningsCount())
+	}
+
+	ui = ui.WithQuiet(false)
+	ui.Infof("creating: a.yml\n")
+
+	if out.String() != "output\ncreating: a.yml\n" {
+		t.Fatalf("Expected informational output to be printed, but was: %q", out.String())
+	}
+}
@@ -0,0 +1,7 @@
+package core
+
+const (
+	// Version is defined here (instead of cmd package) so that
+	// commands can depend on it (eg as part of cache keys)
+	Version = "0.22.0"
+)
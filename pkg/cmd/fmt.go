@@ -41,7 +41,7 @@ func (o *FmtOptions) Run() error {
 		ui.Debugf("total: %s\n", time.Now().Sub(t1))
 	}()
 
-	filesToProcess, err := files.NewSortedFilesFromPaths(o.Files, files.SymlinkAllowOpts{})
+	filesToProcess, err := files.NewSortedFilesFromPaths(o.Files, files.SourceOpts{})
 	if err != nil {
 		return err
 	}
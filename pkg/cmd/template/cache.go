@@ -0,0 +1,239 @@
+package template
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+	"github.com/spf13/pflag"
+)
+
+const (
+	cacheEntryExt = ".ytt-cache"
+)
+
+var (
+	// Flags that only affect diagnostic output (or how inputs are read)
+	// do not contribute to cache keys
+	cacheKeyIgnoredFlags = map[string]struct{}{
+		"cache-dir":        {},
+		"cache-clear":      {},
+		"debug":            {},
+		"quiet":            {},
+		"cpuprofile":       {},
+		"memprofile":       {},
+		"trace":            {},
+		"write-lock":       {},
+		"verify-lock":      {},
+		"color":            {},
+		"errors-format":    {},
+		"read-concurrency": {},
+	}
+
+	// cacheKeyVersion makes entries of other ytt versions unreachable
+	cacheKeyVersion = cmdcore.Version
+)
+
+// runWithCache prints output (to stdout) saved by previous run with
+// same cache key, or processes templates and saves printed output.
+// Cache key is calculated from contents and properties of all input
+// files (after file marks are applied), data values sources (flags,
+// env variables and files), other flags and ytt version.
+func (o *TemplateOptions) runWithCache(in TemplateInput, ui cmdcore.PlainUI) error {
+	if o.BulkFilesSourceOpts.bulkOut || len(o.BulkFilesSourceOpts.bulkIn) > 0 {
+		return fmt.Errorf("Expected --cache-dir to not be used with bulk input or output")
+	}
+	if len(o.RegularFilesSourceOpts.outputDir) > 0 {
+		return fmt.Errorf("Expected --cache-dir to be used only when printing to stdout (not with --output-directory)")
+	}
+	if len(o.DataValuesFlags.Execs) > 0 {
+		return fmt.Errorf("Expected --cache-dir to not be used with --data-values-exec (command output cannot be part of cache key)")
+	}
+	if len(o.RegularFilesSourceOpts.postProcess) > 0 {
+		return fmt.Errorf("Expected --cache-dir to not be used with --post-process (command output cannot be part of cache key)")
+	}
+
+	key, err := o.cacheKey(in)
+	if err != nil {
+		return fmt.Errorf("Calculating cache key: %s", err)
+	}
+
+	entryPath := filepath.Join(o.CacheDir, key+cacheEntryExt)
+
+	cachedBytes, err := ioutil.ReadFile(entryPath)
+	if err == nil {
+		ui.Debugf("### cache hit %s\n", key)
+		_, err = ui.Writer().Write(cachedBytes)
+		return err
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("Reading cache entry: %s", err)
+	}
+
+	ui.Debugf("### cache miss %s\n", key)
+
+	buf := new(bytes.Buffer)
+	bufUI := ui.WithWriter(buf)
+	warningsCount := ui.WarningsCount()
+
+	err = o.runAndOutput(in, o.fileSources(bufUI), bufUI)
+
+	// Print partial output just like without caching
+	_, writeErr := ui.Writer().Write(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	// Warnings are not replayed for cached output, hence output is only
+	// cached if there were none (so that --warnings-as-errors is enforced)
+	if ui.WarningsCount() > warningsCount {
+		ui.Debugf("### cache skipped %s (warnings were printed)\n", key)
+		return nil
+	}
+
+	return o.writeCacheEntry(entryPath, buf.Bytes())
+}
+
+func (o *TemplateOptions) writeCacheEntry(entryPath string, data []byte) error {
+	err := os.MkdirAll(o.CacheDir, 0700)
+	if err != nil {
+		return fmt.Errorf("Creating cache directory: %s", err)
+	}
+
+	// Write via temporary file so that concurrent runs never see partial entries
+	tmpFile, err := ioutil.TempFile(o.CacheDir, "tmp-")
+	if err != nil {
+		return fmt.Errorf("Creating cache entry: %s", err)
+	}
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), entryPath)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("Writing cache entry: %s", err)
+	}
+
+	return nil
+}
+
+// clearCache only removes cache entries so that
+// other files in cache directory are left intact
+func (o *TemplateOptions) clearCache() error {
+	if len(o.CacheDir) == 0 {
+		return fmt.Errorf("Expected --cache-clear to be used together with --cache-dir")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(o.CacheDir, "*"+cacheEntryExt))
+	if err != nil {
+		return fmt.Errorf("Listing cache entries: %s", err)
+	}
+
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("Removing cache entry: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (o *TemplateOptions) cacheKey(in TemplateInput) (string, error) {
+	h := sha256.New()
+
+	writeCacheKeyField(h, "version", cacheKeyVersion)
+
+	if o.flags == nil {
+		return "", fmt.Errorf("Expected command flags to be available")
+	}
+
+	// Visits in lexicographical order
+	o.flags.Visit(func(f *pflag.Flag) {
+		if _, found := cacheKeyIgnoredFlags[f.Name]; !found {
+			writeCacheKeyField(h, "flag", f.Name, f.Value.String())
+		}
+	})
+
+	env := os.Environ()
+	sort.Strings(env)
+
+	for _, prefix := range append(append([]string{}, o.DataValuesFlags.EnvFromStrings...), o.DataValuesFlags.EnvFromYAML...) {
+		for _, envVar := range env {
+			if strings.HasPrefix(envVar, prefix+"_") {
+				writeCacheKeyField(h, "env", envVar)
+			}
+		}
+	}
+
+	for _, kv := range o.DataValuesFlags.KVsFromFiles {
+		pieces := strings.SplitN(kv, "=", 2)
+		if len(pieces) != 2 {
+			return "", fmt.Errorf("Expected data value file '%s' to be in format key=/file/path", kv)
+		}
+
+		contents, err := ioutil.ReadFile(pieces[1])
+		if err != nil {
+			return "", fmt.Errorf("Reading data value file '%s': %s", pieces[1], err)
+		}
+
+		writeCacheKeyField(h, "data-value-file", pieces[0], string(contents))
+	}
+
+	for _, path := range o.DataValuesFlags.FilesFromJSON {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("Reading data values JSON file '%s': %s", path, err)
+		}
+
+		writeCacheKeyField(h, "data-values-file-json", path, string(contents))
+	}
+
+	for _, file := range in.Files {
+		contents, err := file.Bytes()
+		if err != nil {
+			return "", fmt.Errorf("Reading %s: %s", file.Description(), err)
+		}
+
+		var annotations []string
+		for key, val := range file.Annotations() {
+			annotations = append(annotations, key+"="+val)
+		}
+		sort.Strings(annotations)
+
+		var mode string
+		if file.Mode() != nil {
+			mode = file.Mode().String()
+		}
+
+		writeCacheKeyField(h, "file", file.RelativePath(), file.OriginalRelativePath(),
+			file.OutputSubdir(), file.Type().String(), fmt.Sprintf("%t,%t", file.IsTemplate(), file.IsForOutput()),
+			mode, strings.Join(annotations, "\n"), string(contents))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeCacheKeyField length-prefixes values so that
+// different combinations of values never result in same key
+func writeCacheKeyField(h hash.Hash, name string, vals ...string) {
+	fmt.Fprintf(h, "%s:%d\n", name, len(vals))
+	for _, val := range vals {
+		fmt.Fprintf(h, "%d:%s\n", len(val), val)
+	}
+}
@@ -0,0 +1,231 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+)
+
+func TestCacheMissAndHit(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: #@ 1 + 1"})
+	defer os.RemoveAll(dirPath)
+
+	cacheDir := filepath.Join(dirPath, ".cache")
+	inputPath := filepath.Join(dirPath, "tpl.yml")
+
+	out, err := runCmd(t, "-f", inputPath, "--cache-dir", cacheDir)
+	if err != nil || out != "a: 2\n" {
+		t.Fatalf("Expected first run to render templates, but was: %s (err: %v)", out, err)
+	}
+
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*"+cacheEntryExt))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected single cache entry, but was: %#v (err: %v)", entries, err)
+	}
+
+	// Replacing cached output shows whether second run used it
+	err = ioutil.WriteFile(entries[0], []byte("cached\n"), 0600)
+	if err != nil {
+		t.Fatalf("Writing cache entry: %s", err)
+	}
+
+	out, err = runCmd(t, "-f", inputPath, "--cache-dir", cacheDir)
+	if err != nil || out != "cached\n" {
+		t.Fatalf("Expected second run to print cached output, but was: %s (err: %v)", out, err)
+	}
+}
+
+func TestCacheWithWarnings(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "#@ print(\"careful\")\na: 1"})
+	defer os.RemoveAll(dirPath)
+
+	cacheDir := filepath.Join(dirPath, ".cache")
+	inputPath := filepath.Join(dirPath, "tpl.yml")
+
+	// Output with warnings is never cached, hence warnings are printed on each run
+	for i := 0; i < 2; i++ {
+		out, errOut, err := runCmdWithErrOutput(t, "-f", inputPath, "--cache-dir", cacheDir, "--warnings-as-errors")
+		if err == nil || err.Error() != "Expected no warnings to be printed (--warnings-as-errors), but found 1" {
+			t.Fatalf("Expected run %d to fail due to warnings, but was: %v", i, err)
+		}
+		if out != "a: 1\n" || errOut != "careful\n" {
+			t.Fatalf("Expected run %d to print output and warning, but was: %s / %s", i, out, errOut)
+		}
+
+		out, errOut, err = runCmdWithErrOutput(t, "-f", inputPath, "--cache-dir", cacheDir)
+		if err != nil || out != "a: 1\n" || errOut != "careful\n" {
+			t.Fatalf("Expected run %d to print output and warning, but was: %s / %s (err: %v)", i, out, errOut, err)
+		}
+	}
+
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*"+cacheEntryExt))
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Expected no cache entries, but was: %#v (err: %v)", entries, err)
+	}
+}
+
+func TestCacheKeyChanges(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"tpl.yml":   "a: 1",
+		"value.txt": "value",
+	})
+	defer os.RemoveAll(dirPath)
+
+	inputPath := filepath.Join(dirPath, "tpl.yml")
+	valuePath := filepath.Join(dirPath, "value.txt")
+
+	defer os.Unsetenv("YTT_CACHE_TEST_name")
+
+	baseArgs := []string{"-f", inputPath, "--data-values-env", "YTT_CACHE_TEST", "--data-value-file", "file=" + valuePath}
+	baseKey := cacheKeyForArgs(t, baseArgs...)
+
+	if cacheKeyForArgs(t, baseArgs...) != baseKey {
+		t.Fatalf("Expected cache key to be stable")
+	}
+
+	// Diagnostic flags do not affect key
+	if cacheKeyForArgs(t, append(baseArgs, "--debug", "--trace")...) != baseKey {
+		t.Fatalf("Expected ignored flags to not affect cache key")
+	}
+
+	examples := []struct {
+		Desc   string
+		Change func() (args []string, undo func())
+	}{
+		{"file contents", func() ([]string, func()) {
+			mustWriteFile(t, inputPath, "a: 2")
+			return baseArgs, func() { mustWriteFile(t, inputPath, "a: 1") }
+		}},
+		{"file marks", func() ([]string, func()) {
+			return append(baseArgs, "--file-mark", "tpl.yml:type=yaml-plain"), func() {}
+		}},
+		{"data value flags", func() ([]string, func()) {
+			return append(baseArgs, "-v", "name=other"), func() {}
+		}},
+		{"env prefix values", func() ([]string, func()) {
+			os.Setenv("YTT_CACHE_TEST_name", "other")
+			return baseArgs, func() { os.Unsetenv("YTT_CACHE_TEST_name") }
+		}},
+		{"data value file contents", func() ([]string, func()) {
+			mustWriteFile(t, valuePath, "other")
+			return baseArgs, func() { mustWriteFile(t, valuePath, "value") }
+		}},
+		{"version", func() ([]string, func()) {
+			prevVersion := cacheKeyVersion
+			cacheKeyVersion = prevVersion + "-other"
+			return baseArgs, func() { cacheKeyVersion = prevVersion }
+		}},
+	}
+
+	for _, ex := range examples {
+		args, undo := ex.Change()
+		key := cacheKeyForArgs(t, args...)
+		undo()
+
+		if key == baseKey {
+			t.Fatalf("Expected cache key to change with %s", ex.Desc)
+		}
+		if cacheKeyForArgs(t, baseArgs...) != baseKey {
+			t.Fatalf("Expected cache key to be restored after reverting %s", ex.Desc)
+		}
+	}
+}
+
+func TestCacheRejections(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: 1"})
+	defer os.RemoveAll(dirPath)
+
+	cacheDir := filepath.Join(dirPath, ".cache")
+
+	examples := []struct {
+		Args        []string
+		ExpectedErr string
+	}{
+		{[]string{"-f", filepath.Join(dirPath, "tpl.yml"), "--output-directory", filepath.Join(dirPath, "out")},
+			"Expected --cache-dir to be used only when printing to stdout (not with --output-directory)"},
+		{[]string{"-f", filepath.Join(dirPath, "tpl.yml"), "--bulk-out"},
+			"Expected --cache-dir to not be used with bulk input or output"},
+	}
+
+	for _, ex := range examples {
+		_, err := runCmd(t, append(ex.Args, "--cache-dir", cacheDir)...)
+		if err == nil || err.Error() != ex.ExpectedErr {
+			t.Fatalf("Expected err '%s', but was: %v", ex.ExpectedErr, err)
+		}
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"tpl.yml":                          "a: 1",
+		".cache/old" + cacheEntryExt:       "old",
+		".cache/other.txt":                 "other",
+		".cache/old" + cacheEntryExt + "x": "other",
+	})
+	defer os.RemoveAll(dirPath)
+
+	cacheDir := filepath.Join(dirPath, ".cache")
+
+	_, err := runCmd(t, "-f", filepath.Join(dirPath, "tpl.yml"), "--cache-dir", cacheDir, "--cache-clear")
+	if err != nil {
+		t.Fatalf("Expected run to succeed: %s", err)
+	}
+
+	infos, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatalf("Reading cache dir: %s", err)
+	}
+
+	var names []string
+	for _, info := range infos {
+		if strings.HasSuffix(info.Name(), cacheEntryExt) {
+			// Entry written by this run
+			names = append(names, "<entry>")
+			continue
+		}
+		names = append(names, info.Name())
+	}
+
+	if strings.Join(names, ",") != "<entry>,old"+cacheEntryExt+"x,other.txt" {
+		t.Fatalf("Expected only cache entries to be removed, but was: %#v", names)
+	}
+
+	_, err = runCmd(t, "-f", filepath.Join(dirPath, "tpl.yml"), "--cache-clear")
+	if err == nil || err.Error() != "Expected --cache-clear to be used together with --cache-dir" {
+		t.Fatalf("Expected --cache-clear without --cache-dir to fail, but was: %v", err)
+	}
+}
+
+func cacheKeyForArgs(t *testing.T, args ...string) string {
+	opts := NewOptions()
+
+	err := NewCmd(opts).ParseFlags(args)
+	if err != nil {
+		t.Fatalf("Parsing flags %#v: %s", args, err)
+	}
+
+	ui := cmdcore.NewWriterUI(ioutil.Discard, ioutil.Discard, false)
+
+	in, err := NewRegularFilesSource(opts.RegularFilesSourceOpts, ui).Input()
+	if err != nil {
+		t.Fatalf("Reading input: %s", err)
+	}
+
+	key, err := opts.cacheKey(in)
+	if err != nil {
+		t.Fatalf("Calculating cache key: %s", err)
+	}
+
+	return key
+}
+
+func mustWriteFile(t *testing.T, path, contents string) {
+	err := ioutil.WriteFile(path, []byte(contents), 0600)
+	if err != nil {
+		t.Fatalf("Writing file: %s", err)
+	}
+}
@@ -2,20 +2,40 @@ package template
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
 	"github.com/k14s/ytt/pkg/files"
 	"github.com/k14s/ytt/pkg/workspace"
 	"github.com/k14s/ytt/pkg/yamlmeta"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type TemplateOptions struct {
 	IgnoreUnknownComments bool
 	StrictYAML            bool
-	Debug                 bool
-	InspectFiles          bool
+	PreserveComments      bool
+
+	EnvTemplateAllowMissing bool
+	MergeInputs             bool
+	MergeInputsArrays       string
+	Debug                   bool
+	Quiet                   bool
+	InspectFiles            bool
+	OverlayTrace            bool
+	ErrorsFormat            string
+	Color                   string
+	WarningsAsErrors        bool
+	CacheDir                string
+	CacheClear              bool
+	CPUProfile              string
+	MemProfile              string
+
+	// flags are used for calculating cache keys
+	flags *pflag.FlagSet
 
 	BulkFilesSourceOpts    BulkFilesSourceOpts
 	RegularFilesSourceOpts RegularFilesSourceOpts
@@ -31,6 +51,15 @@ type TemplateOutput struct {
 	DocSet *yamlmeta.DocumentSet
 	Err    error
 	Empty  bool
+
+	// EmptyDocs are documents left out of DocSet since they were empty
+	EmptyDocs []*yamlmeta.Document
+
+	// OverlayTrace is only available with --overlay-trace
+	OverlayTrace *yttoverlay.Trace
+
+	// InputFiles are files that output was produced from
+	InputFiles []*files.File
 }
 
 type FileSource interface {
@@ -56,8 +85,26 @@ func NewCmd(o *TemplateOptions) *cobra.Command {
 	cmd.Flags().BoolVar(&o.IgnoreUnknownComments, "ignore-unknown-comments", false,
 		"Configure whether unknown comments are considered as errors (comments that do not start with '#@' or '#!')")
 	cmd.Flags().BoolVarP(&o.StrictYAML, "strict", "s", false, "Configure to use _strict_ YAML subset")
+	cmd.Flags().BoolVar(&o.EnvTemplateAllowMissing, "env-template-allow-missing", false,
+		"Keep variables that are not found in data values as is in env-template files (instead of failing)")
+	cmd.Flags().BoolVar(&o.MergeInputs, "merge-inputs", false,
+		"Deep merge documents of all plain YAML input files (without template annotations) into a single document before templating (later files win)")
+	cmd.Flags().StringVar(&o.MergeInputsArrays, "merge-inputs-arrays", string(yamlmeta.ArrayMergeReplace),
+		"Treatment of arrays found in multiple documents with --merge-inputs (replace, append)")
+	cmd.Flags().BoolVar(&o.PreserveComments, "preserve-comments", false,
+		"Keep comments and anchors in YAML output of documents from plain (non-template) YAML files that were not changed (eg by overlays)")
 	cmd.Flags().BoolVar(&o.Debug, "debug", false, "Enable debug output")
+	cmd.Flags().BoolVarP(&o.Quiet, "quiet", "q", false, "Only print output (no informational messages or warnings); errors are still printed")
+	cmd.Flags().BoolVar(&o.OverlayTrace, "overlay-trace", false, "Print which overlay (file and line) last modified each node of output documents instead of output (slower)")
 	cmd.Flags().BoolVar(&o.InspectFiles, "files-inspect", false, "Inspect files")
+	cmd.Flags().StringVar(&o.ErrorsFormat, "errors-format", cmdcore.ErrorsFormatText, "Format of errors printed to stderr (text, json)")
+	cmd.Flags().BoolVar(&o.WarningsAsErrors, "warnings-as-errors", false, "Fail (after printing output) if any warnings were printed (eg via Starlark print())")
+	cmd.Flags().StringVar(&o.Color, "color", cmdcore.ColorAuto, "Color errors and debug output printed to stderr (auto, always, never)")
+	cmd.Flags().StringVar(&o.CacheDir, "cache-dir", "", "Reuse output previously printed to stdout if inputs, data values, flags and ytt version are unchanged (stores outputs in given directory)")
+	cmd.Flags().BoolVar(&o.CacheClear, "cache-clear", false, "Remove all cached outputs from --cache-dir before processing")
+	cmd.Flags().StringVar(&o.CPUProfile, "cpuprofile", "", "Write CPU profile (pprof format) of processing to given file (slows down processing)")
+	cmd.Flags().StringVar(&o.MemProfile, "memprofile", "", "Write heap profile (pprof format) taken once processing is done to given file")
+	o.flags = cmd.Flags()
 	o.BulkFilesSourceOpts.Set(cmd)
 	o.RegularFilesSourceOpts.Set(cmd)
 	o.DataValuesFlags.Set(cmd)
@@ -65,32 +112,119 @@ func NewCmd(o *TemplateOptions) *cobra.Command {
 }
 
 func (o *TemplateOptions) Run() error {
-	ui := cmdcore.NewPlainUI(o.Debug)
+	color, err := cmdcore.ColorEnabled(o.Color, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	switch o.ErrorsFormat {
+	case cmdcore.ErrorsFormatText, "":
+		err := o.run(color)
+		if err != nil && color {
+			fmt.Fprintf(os.Stderr, "%s\n", cmdcore.ColorizeError(err))
+			return cmdcore.ReportedError{Err: err}
+		}
+		return err
+
+	case cmdcore.ErrorsFormatJSON:
+		err := o.run(color)
+		if err != nil {
+			printErr := cmdcore.PrintErrorJSON(os.Stderr, err)
+			if printErr != nil {
+				return printErr
+			}
+			return cmdcore.ReportedError{Err: err}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("Unknown errors format '%s' (expected %s or %s)",
+			o.ErrorsFormat, cmdcore.ErrorsFormatText, cmdcore.ErrorsFormatJSON)
+	}
+}
+
+func (o *TemplateOptions) run(color bool) error {
+	if o.Debug && o.Quiet {
+		return fmt.Errorf("Expected only one of --debug or --quiet to be specified")
+	}
+
+	return o.runWithUI(cmdcore.NewPlainUI(o.Debug).WithColor(color).WithQuiet(o.Quiet))
+}
+
+func (o *TemplateOptions) runWithUI(ui cmdcore.PlainUI) (resultErr error) {
 	t1 := time.Now()
 
+	stopProfiles, err := o.startProfiles()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		profileErr := stopProfiles()
+		if resultErr == nil {
+			resultErr = profileErr
+		}
+	}()
+
 	defer func() {
 		ui.Debugf("total: %s\n", time.Now().Sub(t1))
 	}()
 
-	srcs := []FileSource{
-		NewBulkFilesSource(o.BulkFilesSourceOpts, ui),
-		NewRegularFilesSource(o.RegularFilesSourceOpts, ui),
+	if o.CacheClear {
+		err := o.clearCache()
+		if err != nil {
+			return err
+		}
 	}
 
+	srcs := o.fileSources(ui)
+
 	in, err := o.pickSource(srcs, func(s FileSource) bool { return s.HasInput() }).Input()
 	if err != nil {
 		return err
 	}
 
+	if len(o.CacheDir) > 0 && !o.RegularFilesSourceOpts.dryRun {
+		return o.runWithCache(in, ui)
+	}
+
+	return o.runAndOutput(in, srcs, ui)
+}
+
+func (o *TemplateOptions) fileSources(ui cmdcore.PlainUI) []FileSource {
+	return []FileSource{
+		NewBulkFilesSource(o.BulkFilesSourceOpts, ui),
+		NewRegularFilesSource(o.RegularFilesSourceOpts, ui),
+	}
+}
+
+func (o *TemplateOptions) runAndOutput(in TemplateInput, srcs []FileSource, ui cmdcore.PlainUI) error {
 	out := o.RunWithFiles(in, ui)
-	if out.Empty {
-		return nil
+
+	if !out.Empty {
+		err := o.pickSource(srcs, func(s FileSource) bool { return s.HasOutput() }).Output(out)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.WarningsAsErrors && ui.WarningsCount() > 0 {
+		return fmt.Errorf("Expected no warnings to be printed (--warnings-as-errors), but found %d", ui.WarningsCount())
 	}
 
-	return o.pickSource(srcs, func(s FileSource) bool { return s.HasOutput() }).Output(out)
+	return nil
 }
 
-func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui cmdcore.PlainUI) TemplateOutput {
+func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui files.UI) TemplateOutput {
+	if o.MergeInputs {
+		var err error
+
+		in, err = o.mergeInputs(in)
+		if err != nil {
+			return TemplateOutput{Err: err}
+		}
+	}
+
 	rootLibrary := workspace.NewRootLibrary(in.Files)
 	rootLibrary.Print(ui.DebugWriter())
 
@@ -108,6 +242,10 @@ func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui cmdcore.PlainUI) Tem
 	libraryLoader := workspace.NewLibraryLoader(rootLibrary, ui, workspace.TemplateLoaderOpts{
 		IgnoreUnknownComments: o.IgnoreUnknownComments,
 		StrictYAML:            o.StrictYAML,
+		PreserveComments:      o.PreserveComments,
+
+		EnvTemplateAllowMissing: o.EnvTemplateAllowMissing,
+		OverlayTrace:            o.OverlayTrace,
 	})
 
 	astValues, err = libraryLoader.Values(astValues)
@@ -124,7 +262,8 @@ func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui cmdcore.PlainUI) Tem
 		return TemplateOutput{Err: err}
 	}
 
-	return TemplateOutput{Files: result.Files, DocSet: result.DocSet}
+	return TemplateOutput{Files: result.Files, DocSet: result.DocSet, EmptyDocs: result.EmptyDocs,
+		OverlayTrace: result.OverlayTrace, InputFiles: in.Files}
 }
 
 func (o *TemplateOptions) pickSource(srcs []FileSource, pickFunc func(FileSource) bool) FileSource {
@@ -136,7 +275,7 @@ func (o *TemplateOptions) pickSource(srcs []FileSource, pickFunc func(FileSource
 	return srcs[len(srcs)-1]
 }
 
-func (o *TemplateOptions) inspectValues(values interface{}, ui cmdcore.PlainUI) TemplateOutput {
+func (o *TemplateOptions) inspectValues(values interface{}, ui files.UI) TemplateOutput {
 	docSet := &yamlmeta.DocumentSet{
 		Items: []*yamlmeta.Document{{Value: values}},
 	}
@@ -151,7 +290,7 @@ func (o *TemplateOptions) inspectValues(values interface{}, ui cmdcore.PlainUI)
 	return TemplateOutput{Empty: true}
 }
 
-func (o *TemplateOptions) inspectFiles(rootLibrary *workspace.Library, ui cmdcore.PlainUI) TemplateOutput {
+func (o *TemplateOptions) inspectFiles(rootLibrary *workspace.Library, ui files.UI) TemplateOutput {
 	files := rootLibrary.ListAccessibleFiles()
 	workspace.SortFilesInLibrary(files)
 
@@ -1,7 +1,13 @@
 package template_test
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
 	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
@@ -117,6 +123,147 @@ another:
 	}
 }
 
+func TestDataValuesWithJSONFile(t *testing.T) {
+	yamlTplData := []byte(`
+#@ load("@ytt:data", "data")
+values: #@ data.values`)
+
+	expectedYAMLTplData := `values:
+  int: 124
+  str: "123"
+  nested:
+    value: flag
+    other: json
+`
+
+	yamlData := []byte(`
+#@data/values
+---
+int: 123
+str: str
+nested:
+  value: default
+  other: default`)
+
+	dirPath, err := ioutil.TempDir("", "ytt-test")
+	if err != nil {
+		t.Fatalf("Creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	jsonPath := filepath.Join(dirPath, "values.json")
+
+	err = ioutil.WriteFile(jsonPath, []byte(`{"int": 124, "str": "123", "nested": {"value": "json", "other": "json"}}`), 0600)
+	if err != nil {
+		t.Fatalf("Writing file: %s", err)
+	}
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", yamlTplData)),
+		files.MustNewFileFromSource(files.NewBytesSource("data.yml", yamlData)),
+	})
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	// Inline flags take precedence over JSON files
+	opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+		FilesFromJSON:  []string{jsonPath},
+		KVsFromStrings: []string{"nested.value=flag"},
+	}
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if string(out.Files[0].Bytes()) != expectedYAMLTplData {
+		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", out.Files[0].Bytes())
+	}
+
+	err = ioutil.WriteFile(jsonPath, []byte(`[1, 2]`), 0600)
+	if err != nil {
+		t.Fatalf("Writing file: %s", err)
+	}
+
+	out = opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err == nil || out.Err.Error() != "Extracting data values from JSON file '"+jsonPath+"': Expected JSON to be an object, but was *yamlmeta.Array" {
+		t.Fatalf("Expected RunWithFiles to fail, but was: %v", out.Err)
+	}
+}
+
+func TestDataValuesWithExec(t *testing.T) {
+	yamlTplData := []byte(`
+#@ load("@ytt:data", "data")
+values: #@ data.values`)
+
+	expectedYAMLTplData := `values:
+  int: 124
+  nested:
+    value: flag
+    other: exec
+`
+
+	yamlData := []byte(`
+#@data/values
+---
+int: 123
+nested:
+  value: default
+  other: default`)
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", yamlTplData)),
+		files.MustNewFileFromSource(files.NewBytesSource("data.yml", yamlData)),
+	})
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	// Inline flags take precedence over command output
+	opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+		Execs:          []string{`echo '{"int": 124, "nested": {"value": "exec", "other": "exec"}}'`},
+		AllowExec:      true,
+		KVsFromStrings: []string{"nested.value=flag"},
+	}
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if string(out.Files[0].Bytes()) != expectedYAMLTplData {
+		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", out.Files[0].Bytes())
+	}
+
+	examples := []struct {
+		Exec        string
+		AllowExec   bool
+		ExpectedErr string
+	}{
+		{"echo 'int: 1'", false, "Expected --dangerous-allow-exec to be specified to allow running commands"},
+		{"sh -c 'echo failed >&2; exit 3'", true, "Expected command to succeed, but exited with code 3 (stderr: failed)"},
+		{"sleep 5", true, "Expected command to finish within 100ms"},
+		{"echo - 1", true, "Expected command output to be a YAML (or JSON) object, but was *yamlmeta.Array"},
+		{"echo 'unterminated", true, "Expected command to not end with unterminated quote or escape"},
+	}
+
+	for _, ex := range examples {
+		opts.DataValuesFlags = cmdtpl.DataValuesFlags{
+			Execs:       []string{ex.Exec},
+			AllowExec:   ex.AllowExec,
+			ExecTimeout: 100 * time.Millisecond,
+		}
+
+		out = opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+
+		expectedErr := "Extracting data values from command '" + ex.Exec + "': " + ex.ExpectedErr
+		if out.Err == nil || !strings.HasPrefix(out.Err.Error(), expectedErr) {
+			t.Fatalf("Expected RunWithFiles to fail with '%s', but was: %v", expectedErr, out.Err)
+		}
+	}
+}
+
 func TestDataValuesMultipleFiles(t *testing.T) {
 	yamlTplData := []byte(`
 #@ load("@ytt:data", "data")
@@ -420,3 +567,58 @@ data:
 		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", file.Bytes())
 	}
 }
+
+func TestDataValuesInspect(t *testing.T) {
+	yamlTplData := []byte(`
+#@ load("@ytt:data", "data")
+port: #@ data.values.port`)
+
+	yamlData1 := []byte(`
+#@data/values
+---
+port: 80
+db:
+  host: localhost
+  user: admin`)
+
+	yamlData2 := []byte(`
+#@ load("@ytt:overlay", "overlay")
+#@data/values
+---
+db:
+  host: db.internal
+  #@overlay/match missing_ok=True
+  tls: true`)
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", yamlTplData)),
+		files.MustNewFileFromSource(files.NewBytesSource("data1.yml", yamlData1)),
+		files.MustNewFileFromSource(files.NewBytesSource("data2.yml", yamlData2)),
+	})
+
+	expectedOutput := `port: 80
+db:
+  host: db.internal
+  user: admin
+  tls: true
+`
+
+	buf := new(bytes.Buffer)
+	ui := cmdcore.NewWriterUI(buf, ioutil.Discard, false)
+
+	opts := cmdtpl.NewOptions()
+	opts.DataValuesFlags.Inspect = true
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if !out.Empty || len(out.Files) != 0 {
+		t.Fatalf("Expected templates to not be evaluated")
+	}
+
+	if buf.String() != expectedOutput {
+		t.Fatalf("Expected merged data values to be printed, but was: >>>%s<<<", buf.String())
+	}
+}
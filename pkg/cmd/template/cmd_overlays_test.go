@@ -1,11 +1,15 @@
 package template_test
 
 import (
+	"fmt"
+	"io"
 	"testing"
 
 	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
 	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
 	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
 )
 
 func TestDocumentOverlays(t *testing.T) {
@@ -145,3 +149,92 @@ yamlfunc: yamlfunc`)
 		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", file.Bytes())
 	}
 }
+
+func TestOverlayTrace(t *testing.T) {
+	yamlTplData := []byte(`
+kind: Deployment
+spec:
+  replicas: 1
+  ports: [80]
+  containers:
+  - name: a
+`)
+
+	yamlOverlayTplData := []byte(`
+#@ load("@ytt:overlay", "overlay")
+#@overlay/match by=overlay.all
+---
+spec:
+  replicas: 3
+  ports:
+  #@overlay/append
+  - 443
+  containers:
+  #@overlay/match by="name", missing_ok=True
+  - name: b
+`)
+
+	expectedTrace := `document: tpl.yml:1
+nodes:
+- path:
+  - spec
+  - replicas
+  op: overlay/merge
+  overlay: overlay.yml:6
+- path:
+  - spec
+  - ports
+  - 1
+  op: overlay/append
+  overlay: overlay.yml:9
+- path:
+  - spec
+  - containers
+  - 1
+  op: overlay/append
+  overlay: overlay.yml:12
+`
+
+	filesToProcess := []*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", yamlTplData)),
+		files.MustNewFileFromSource(files.NewBytesSource("overlay.yml", yamlOverlayTplData)),
+	}
+
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if out.OverlayTrace != nil {
+		t.Fatalf("Expected overlay trace to only be recorded when requested")
+	}
+
+	opts.OverlayTrace = true
+
+	out = opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	traceBytes, err := out.DocSet.AsBytesWithPrinter(func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yttoverlay.NewTracePrinter(w, out.OverlayTrace)
+	})
+	if err != nil {
+		t.Fatalf("Expected printing trace to succeed: %s", err)
+	}
+
+	if string(traceBytes) != expectedTrace {
+		t.Fatalf("Expected overlay trace to match, but was: >>>%s<<<", traceBytes)
+	}
+
+	err = yttoverlay.NewTracePrinter(failingWriter{}, out.OverlayTrace).Print(out.DocSet.Items[0])
+	if err == nil || err.Error() != "writing overlay trace: write failed" {
+		t.Fatalf("Expected printing trace to fail when writing fails, but was: %v", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, fmt.Errorf("write failed") }
@@ -1,12 +1,15 @@
 package template_test
 
 import (
+	"bytes"
+	"fmt"
 	"strings"
 	"testing"
 
 	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
 	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
 	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
 )
 
 func TestLoad(t *testing.T) {
@@ -562,3 +565,533 @@ str: `)
 		t.Fatalf("Expected RunWithFiles to fail with err: %s", out.Err)
 	}
 }
+
+func TestJSONFileWithOverlay(t *testing.T) {
+	jsonData := []byte(`{
+  "name": "app",
+  "ports": [80]
+}`)
+
+	yamlOverlayTplData := []byte(`
+#@ load("@ytt:overlay", "overlay")
+#@overlay/match by=overlay.all
+---
+#@overlay/match missing_ok=True
+replicas: 2
+`)
+
+	expectedJSONData := `{"name":"app","ports":[80],"replicas":2}`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("config.json", jsonData)),
+		files.MustNewFileFromSource(files.NewBytesSource("overlay.yml", yamlOverlayTplData)),
+	})
+
+	filesToProcess[0].MarkType(files.TypeJSON)
+	filesToProcess[0].MarkTemplate(false)
+	filesToProcess[0].MarkForOutput(true)
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if len(out.Files) != 1 {
+		t.Fatalf("Expected number of output files to be 1, but was %d", len(out.Files))
+	}
+
+	file := out.Files[0]
+
+	if file.RelativePath() != "config.json" {
+		t.Fatalf("Expected output file to be config.json, but was %#v", file.RelativePath())
+	}
+
+	if string(file.Bytes()) != expectedJSONData {
+		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", file.Bytes())
+	}
+}
+
+func TestJSONFileParseErr(t *testing.T) {
+	jsonData := []byte(`{
+  "name": "app",
+  "ports": [80,]
+}`)
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("config.json", jsonData)),
+	})
+
+	filesToProcess[0].MarkForOutput(true)
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err == nil {
+		t.Fatalf("Expected RunWithFiles to fail")
+	}
+
+	expectedErr := "Unmarshaling JSON file 'config.json': line 3: invalid character ']' looking for beginning of value"
+
+	if out.Err.Error() != expectedErr {
+		t.Fatalf("Expected RunWithFiles to fail with err: %s", out.Err)
+	}
+}
+
+func TestFileAnnotations(t *testing.T) {
+	tplData := []byte(`
+#@ load("@ytt:data", "data")
+#@ load("helpers.lib.star", "helper_env")
+env: #@ data.annotations()["env"]
+tier: #@ data.annotations().get("tier", "none")
+helper: #@ helper_env()
+other: #@ data.annotations("other.yml")
+`)
+
+	starlarkData := []byte(`
+load("@ytt:data", "data")
+def helper_env():
+  return data.annotations().get("env", "unset")
+end
+`)
+
+	expectedYAMLTplData := `env: staging
+tier: none
+helper: staging
+other:
+  team: web
+`
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", tplData)),
+		files.MustNewFileFromSource(files.NewBytesSource("helpers.lib.star", starlarkData)),
+		files.MustNewFileFromSource(files.NewBytesSource("other.yml", []byte("{}"))),
+	})
+
+	filesToProcess[0].MarkAnnotation("env", "prod")
+	filesToProcess[0].MarkAnnotation("env", "staging")
+	filesToProcess[1].MarkAnnotation("env", "lib")
+	filesToProcess[2].MarkAnnotation("team", "web")
+	filesToProcess[2].MarkForOutput(false)
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if len(out.Files) != 1 {
+		t.Fatalf("Expected number of output files to be 1, but was %d", len(out.Files))
+	}
+
+	if string(out.Files[0].Bytes()) != expectedYAMLTplData {
+		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", out.Files[0].Bytes())
+	}
+}
+
+func TestYAMLFrontMatter(t *testing.T) {
+	mdData := []byte(`---
+#@ load("@ytt:data", "data")
+title: #@ "Guide for " + data.annotations()["env"]
+tags: [docs]
+---
+# Guide
+
+Body with ytt-like text (@= "kept" @) and #@ comments.
+---
+End without trailing newline`)
+
+	expectedMdData := `---
+title: Guide for prod
+tags:
+- docs
+---
+# Guide
+
+Body with ytt-like text (@= "kept" @) and #@ comments.
+---
+End without trailing newline`
+
+	plainMdData := []byte("# No front matter\n\n---\ntitle: #@ 1\n")
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("guide.md", mdData)),
+		files.MustNewFileFromSource(files.NewBytesSource("plain.md", plainMdData)),
+	})
+
+	for _, file := range filesToProcess {
+		file.MarkType(files.TypeYAMLFrontMatter)
+		file.MarkTemplate(true)
+	}
+	filesToProcess[0].MarkAnnotation("env", "prod")
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if len(out.Files) != 2 {
+		t.Fatalf("Expected number of output files to be 2, but was %d", len(out.Files))
+	}
+
+	if string(out.Files[0].Bytes()) != expectedMdData {
+		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", out.Files[0].Bytes())
+	}
+
+	if string(out.Files[1].Bytes()) != string(plainMdData) {
+		t.Fatalf("Expected output file without front matter to be kept as is, but was: >>>%s<<<", out.Files[1].Bytes())
+	}
+
+	if len(out.DocSet.Items) != 0 {
+		t.Fatalf("Expected front matter to not be included in combined output, but was: %d documents", len(out.DocSet.Items))
+	}
+}
+
+func TestEnvTemplate(t *testing.T) {
+	valuesData := []byte(`
+#@data/values
+---
+host: example.com
+port: 8080
+app:
+  name: web
+`)
+
+	envData := []byte("url=http://${host}:$port/${app.name}\nprice=$$5 $ ${ not_var } $1\n")
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", valuesData)),
+		files.MustNewFileFromSource(files.NewBytesSource("app.env", envData)),
+	})
+
+	filesToProcess[1].MarkType(files.TypeEnvTemplate)
+	filesToProcess[1].MarkTemplate(true)
+
+	out := cmdtpl.NewOptions().RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if len(out.Files) != 1 {
+		t.Fatalf("Expected number of output files to be 1, but was %d", len(out.Files))
+	}
+
+	expectedEnvData := "url=http://example.com:8080/web\nprice=$5 $ ${ not_var } $1\n"
+
+	if string(out.Files[0].Bytes()) != expectedEnvData {
+		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", out.Files[0].Bytes())
+	}
+
+	filesToProcess[1] = files.MustNewFileFromSource(files.NewBytesSource("app.env", []byte("a=1\nb=${missing}-$app\n")))
+	filesToProcess[1].MarkType(files.TypeEnvTemplate)
+	filesToProcess[1].MarkTemplate(true)
+
+	out = cmdtpl.NewOptions().RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err == nil || !strings.Contains(out.Err.Error(), "Expected variable 'missing' on line 2 to be a data value, but was not found") {
+		t.Fatalf("Expected missing variable err, but was: %v", out.Err)
+	}
+
+	opts := cmdtpl.NewOptions()
+	opts.EnvTemplateAllowMissing = true
+
+	out = opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err == nil || !strings.Contains(out.Err.Error(), "Substituting variable 'app' on line 2: Expected data value to be a scalar") {
+		t.Fatalf("Expected non-scalar variable err, but was: %v", out.Err)
+	}
+
+	filesToProcess[1] = files.MustNewFileFromSource(files.NewBytesSource("app.env", []byte("b=${missing}-$host\n")))
+	filesToProcess[1].MarkType(files.TypeEnvTemplate)
+	filesToProcess[1].MarkTemplate(true)
+
+	out = opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if string(out.Files[0].Bytes()) != "b=${missing}-example.com\n" {
+		t.Fatalf("Expected missing variable to be kept, but was: >>>%s<<<", out.Files[0].Bytes())
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	examples := []struct {
+		Path     string
+		Data     string
+		Expected string
+	}{
+		{
+			Path:     "tpl.yml",
+			Data:     "key: val\nyamlfunc yamlfunc",
+			Expected: `{"message":"Unmarshaling YAML template 'tpl.yml': yaml: line 3: could not find expected ':'","errors":[{"kind":"parse","file":"tpl.yml","line":3,"message":"yaml: line 3: could not find expected ':'"}]}`,
+		},
+		{
+			Path:     "tpl.yml",
+			Data:     "key: val\nother: #@ unknown_func()",
+			Expected: `{"message":"\n- undefined: unknown_func\n    tpl.yml:2 | other: #@ unknown_func()","errors":[{"kind":"template","file":"tpl.yml","line":2,"message":"undefined: unknown_func"}]}`,
+		},
+	}
+
+	for _, ex := range examples {
+		filesToProcess := []*files.File{
+			files.MustNewFileFromSource(files.NewBytesSource(ex.Path, []byte(ex.Data))),
+		}
+
+		out := cmdtpl.NewOptions().RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+		if out.Err == nil {
+			t.Fatalf("Expected RunWithFiles to fail")
+		}
+
+		buf := new(bytes.Buffer)
+
+		err := cmdcore.PrintErrorJSON(buf, out.Err)
+		if err != nil {
+			t.Fatalf("Expected printing error to succeed: %s", err)
+		}
+
+		if buf.String() != ex.Expected+"\n" {
+			t.Fatalf("Expected error report to match, but was: >>>%s<<<", buf.String())
+		}
+	}
+}
+
+func TestEmptyDocs(t *testing.T) {
+	tplData := []byte(`
+a: 1
+---
+--- null
+--- {}
+--- #@ "  "
+#! trailing comment`)
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", tplData)),
+	})
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	var emptyKinds []string
+	for _, docs := range [][]*yamlmeta.Document{out.EmptyDocs, out.DocSet.Items} {
+		for _, doc := range docs {
+			if kind, isEmpty := doc.EmptyKind(); isEmpty {
+				emptyKinds = append(emptyKinds, fmt.Sprintf("%s (%s)", kind, doc.Position.AsCompactString()))
+			}
+		}
+	}
+
+	expectedKinds := "null (tpl.yml:3), null (tpl.yml:4), empty map (tpl.yml:5), whitespace-only string (tpl.yml:6)"
+
+	if strings.Join(emptyKinds, ", ") != expectedKinds {
+		t.Fatalf("Expected empty documents to match, but was: %s", strings.Join(emptyKinds, ", "))
+	}
+}
+
+func TestOutputSubdir(t *testing.T) {
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("prod/app.yml", []byte("#@ load(\"common.lib.yml\", \"name\")\nname: #@ name\n"))),
+		files.MustNewFileFromSource(files.NewBytesSource("prod/common.lib.yml", []byte("#@ name = \"app\"\n"))),
+		files.MustNewFileFromSource(files.NewBytesSource("notes.txt", []byte("notes"))),
+	})
+
+	filesToProcess[0].MarkOutputSubdir("clusters/prod")
+	filesToProcess[2].MarkOutputSubdir("docs")
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	var paths []string
+	for _, file := range out.Files {
+		paths = append(paths, file.RelativePath())
+	}
+
+	if strings.Join(paths, ",") != "docs/notes.txt,clusters/prod/prod/app.yml" {
+		t.Fatalf("Expected output file paths to include subdirectories, but was: %s", strings.Join(paths, ","))
+	}
+
+	if string(out.Files[1].Bytes()) != "name: app\n" {
+		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", out.Files[1].Bytes())
+	}
+}
+
+func TestBinaryFiles(t *testing.T) {
+	binaryBs := []byte("#@ not a template\n\x89PNG\x00\xff")
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("app.yml", []byte("app: 1\n"))),
+		files.MustNewFileFromSource(files.NewBytesSource("img/logo.png", binaryBs)),
+	})
+
+	filesToProcess[1].MarkType(files.TypeBinary)
+	filesToProcess[1].MarkTemplate(false)
+	filesToProcess[1].MarkForOutput(true)
+
+	ui := cmdcore.NewPlainUI(false)
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if len(out.Files) != 2 {
+		t.Fatalf("Expected number of output files to be 2, but was %d", len(out.Files))
+	}
+
+	binaryFile := out.Files[0]
+
+	if binaryFile.RelativePath() != "img/logo.png" || !binaryFile.IsBinary() || binaryFile.DocSet() != nil {
+		t.Fatalf("Expected binary output file, but was: %s (binary: %t)", binaryFile.RelativePath(), binaryFile.IsBinary())
+	}
+
+	if !bytes.Equal(binaryFile.Bytes(), binaryBs) {
+		t.Fatalf("Expected binary file contents to be unchanged, but was: %q", binaryFile.Bytes())
+	}
+
+	// Binary files are never part of combined (stdout) output
+	outBs, err := out.DocSet.AsBytes()
+	if err != nil {
+		t.Fatalf("Expected marshaling to succeed: %s", err)
+	}
+
+	if string(outBs) != "app: 1\n" {
+		t.Fatalf("Expected combined output to only include YAML, but was: >>>%s<<<", outBs)
+	}
+}
+
+func TestStarlarkPrintWarnings(t *testing.T) {
+	tplBs := []byte(`
+#@ load("@ytt:overlay", "overlay")
+#@ def check(indexOrKey, left, right):
+#@   print("overlay: " + str(indexOrKey))
+#@   return True
+#@ end
+#@ print("template")
+name: app
+#@overlay/match by=check
+---
+#@overlay/match missing_ok=True
+replicas: 2
+`)
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", tplBs)),
+	})
+
+	outBuf := new(bytes.Buffer)
+	warnBuf := new(bytes.Buffer)
+	ui := cmdcore.NewWriterUI(outBuf, warnBuf, false)
+	opts := cmdtpl.NewOptions()
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, ui)
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if warnBuf.String() != "template\noverlay: 0\n" {
+		t.Fatalf("Expected print() output as warnings, but was: >>>%s<<<", warnBuf.String())
+	}
+	if ui.WarningsCount() != 2 {
+		t.Fatalf("Expected 2 warnings, but was %d", ui.WarningsCount())
+	}
+	if outBuf.Len() != 0 {
+		t.Fatalf("Expected no regular output, but was: >>>%s<<<", outBuf.String())
+	}
+}
+
+func TestMergeInputs(t *testing.T) {
+	aData := []byte(`
+app:
+  name: web
+  ports: [80]
+replicas: 1
+`)
+
+	bData := []byte(`
+app:
+  ports: [443]
+  env: prod
+replicas: 3
+`)
+
+	overlayData := []byte(`
+#@ load("@ytt:overlay", "overlay")
+#@overlay/match by=overlay.all
+---
+app:
+  #@overlay/match missing_ok=True
+  owner: team
+`)
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("a.yml", aData)),
+		files.MustNewFileFromSource(files.NewBytesSource("b.yml", bData)),
+		files.MustNewFileFromSource(files.NewBytesSource("overlay.yml", overlayData)),
+	})
+
+	opts := cmdtpl.NewOptions()
+	opts.MergeInputs = true
+
+	out := opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if len(out.Files) != 1 {
+		t.Fatalf("Expected number of output files to be 1, but was %d", len(out.Files))
+	}
+
+	file := out.Files[0]
+
+	if file.RelativePath() != "a.yml" {
+		t.Fatalf("Expected output file to be a.yml, but was %#v", file.RelativePath())
+	}
+
+	expectedData := `app:
+  name: web
+  ports:
+  - 443
+  env: prod
+  owner: team
+replicas: 3
+`
+
+	if string(file.Bytes()) != expectedData {
+		t.Fatalf("Expected output file to have specific data, but was: >>>%s<<<", file.Bytes())
+	}
+
+	opts.MergeInputsArrays = "append"
+
+	out = opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err != nil {
+		t.Fatalf("Expected RunWithFiles to succeed, but was error: %s", out.Err)
+	}
+
+	if !strings.Contains(string(out.Files[0].Bytes()), "  ports:\n  - 80\n  - 443\n") {
+		t.Fatalf("Expected arrays to be appended, but was: >>>%s<<<", out.Files[0].Bytes())
+	}
+
+	opts.MergeInputsArrays = "concat"
+
+	out = opts.RunWithFiles(cmdtpl.TemplateInput{Files: filesToProcess}, cmdcore.NewPlainUI(false))
+	if out.Err == nil || out.Err.Error() != "Unknown array merge strategy 'concat' (expected replace or append)" {
+		t.Fatalf("Expected unknown strategy err, but was: %v", out.Err)
+	}
+}
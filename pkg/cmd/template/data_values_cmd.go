@@ -0,0 +1,25 @@
+package template
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewDataValuesCmd returns command that accepts the same flags as
+// template command, but only prints data values (merged across all
+// data values files, as well as data value flags) without evaluating
+// templates (same as --data-values-inspect)
+func NewDataValuesCmd(o *TemplateOptions) *cobra.Command {
+	cmd := NewCmd(o)
+	cmd.Use = "data-values"
+	cmd.Aliases = []string{"dv"}
+	cmd.Short = "Print data values merged across input files (without evaluating templates)"
+	cmd.RunE = func(_ *cobra.Command, _ []string) error {
+		o.DataValuesFlags.Inspect = true
+		return o.Run()
+	}
+
+	// Always enabled for this command
+	cmd.Flags().MarkHidden("data-values-inspect")
+
+	return cmd
+}
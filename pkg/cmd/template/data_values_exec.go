@@ -0,0 +1,134 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+const (
+	DefaultDataValuesExecTimeout = 30 * time.Second
+
+	// Only the end of stderr is included into errors
+	commandMaxStderr = 1024
+)
+
+// exec runs command (without a shell) and returns values of YAML
+// (or JSON) object printed to its stdout flattened into dotted keys,
+// same as for JSON files
+func (s *DataValuesFlags) exec(cmdStr string, strict bool) (*orderedmap.Map, error) {
+	if !s.AllowExec {
+		return nil, fmt.Errorf("Expected --dangerous-allow-exec to be specified to allow running commands")
+	}
+
+	args, err := splitCommandArgs(cmdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := s.ExecTimeout
+	if timeout <= 0 {
+		timeout = DefaultDataValuesExecTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("Expected command to finish within %s (use --data-values-exec-timeout to change limit)", timeout)
+	}
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("Expected command to succeed, but exited with code %d%s",
+				exitErr.ExitCode(), commandStderrSuffix(stderr.Bytes()))
+		}
+		return nil, fmt.Errorf("Running command: %s", err)
+	}
+
+	val, err := s.parseYAML(stdout.String(), strict)
+	if err != nil {
+		return nil, fmt.Errorf("Deserializing command output: %s", err)
+	}
+
+	typedMap, ok := val.(*yamlmeta.Map)
+	if !ok {
+		return nil, fmt.Errorf("Expected command output to be a YAML (or JSON) object, but was %T", val)
+	}
+
+	return s.flattenedJSONMap(typedMap)
+}
+
+func commandStderrSuffix(stderr []byte) string {
+	stderr = bytes.TrimSpace(stderr)
+	if len(stderr) == 0 {
+		return ""
+	}
+	if len(stderr) > commandMaxStderr {
+		stderr = append([]byte("..."), stderr[len(stderr)-commandMaxStderr:]...)
+	}
+	return fmt.Sprintf(" (stderr: %s)", stderr)
+}
+
+// splitCommandArgs splits command into arguments on whitespace;
+// single and double quotes group arguments (eg 'cmd "a b"'), and
+// backslash escapes next character outside of single quotes
+func splitCommandArgs(cmdStr string) ([]string, error) {
+	var args []string
+	var curr strings.Builder
+	var inArg, escaped bool
+	var quote rune
+
+	for _, r := range cmdStr {
+		switch {
+		case escaped:
+			curr.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				curr.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, curr.String())
+				curr.Reset()
+				inArg = false
+			}
+		default:
+			curr.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("Expected command to not end with unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, curr.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("Expected command to be non-empty")
+	}
+
+	return args, nil
+}
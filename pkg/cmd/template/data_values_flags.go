@@ -1,10 +1,12 @@
 package template
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/k14s/ytt/pkg/orderedmap"
 	"github.com/k14s/ytt/pkg/yamlmeta"
@@ -19,6 +21,14 @@ type DataValuesFlags struct {
 	KVsFromYAML    []string
 	KVsFromFiles   []string
 
+	FilesFromJSON []string
+
+	// Execs are commands whose stdout (YAML or JSON object) is used
+	// as data values; they are only run if AllowExec is true
+	Execs       []string
+	AllowExec   bool
+	ExecTimeout time.Duration
+
 	Inspect bool
 }
 
@@ -30,6 +40,12 @@ func (s *DataValuesFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringArrayVar(&s.KVsFromYAML, "data-value-yaml", nil, "Set specific data value to given value, parsed as YAML (format: all.key1.subkey=true) (can be specified multiple times)")
 	cmd.Flags().StringArrayVar(&s.KVsFromFiles, "data-value-file", nil, "Set specific data value to given file contents, as string (format: all.key1.subkey=/file/path) (can be specified multiple times)")
 
+	cmd.Flags().StringArrayVar(&s.FilesFromJSON, "data-values-file-json", nil, "Set data values from JSON object in given file (format: /file/path) (can be specified multiple times)")
+
+	cmd.Flags().StringArrayVar(&s.Execs, "data-values-exec", nil, "Set data values from YAML or JSON object printed to stdout by given command (format: 'cmd arg1 arg2') (requires --dangerous-allow-exec) (can be specified multiple times)")
+	cmd.Flags().BoolVar(&s.AllowExec, "dangerous-allow-exec", false, "Allow running commands given via --data-values-exec")
+	cmd.Flags().DurationVar(&s.ExecTimeout, "data-values-exec-timeout", DefaultDataValuesExecTimeout, "Maximum duration of each command given via --data-values-exec")
+
 	cmd.Flags().BoolVar(&s.Inspect, "data-values-inspect", false, "Inspect data values")
 }
 
@@ -51,6 +67,24 @@ func (s *DataValuesFlags) Values(strict bool) (*orderedmap.Map, error) {
 
 	result := []*orderedmap.Map{}
 
+	// JSON files have lowest precedence so that specific values can be overridden
+	for _, path := range s.FilesFromJSON {
+		vals, err := s.jsonFile(path, strict)
+		if err != nil {
+			return nil, fmt.Errorf("Extracting data values from JSON file '%s': %s", path, err)
+		}
+		result = append(result, vals)
+	}
+
+	// Commands are treated same as JSON files
+	for _, cmdStr := range s.Execs {
+		vals, err := s.exec(cmdStr, strict)
+		if err != nil {
+			return nil, fmt.Errorf("Extracting data values from command '%s': %s", cmdStr, err)
+		}
+		result = append(result, vals)
+	}
+
 	for _, src := range []dataValuesFlagsSource{{s.EnvFromStrings, plainValFunc}, {s.EnvFromYAML, yamlValFunc}} {
 		for _, envPrefix := range src.Values {
 			vals, err := s.env(envPrefix, src.TransformFunc)
@@ -153,6 +187,66 @@ func (s *DataValuesFlags) file(kv string) (*orderedmap.Map, error) {
 	return result, nil
 }
 
+// jsonFile returns values of JSON object flattened into dotted keys
+// (eg 'key1.nested') so that they are merged with values from other
+// flags instead of replacing them
+func (s *DataValuesFlags) jsonFile(path string, strict bool) (*orderedmap.Map, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Reading file: %s", err)
+	}
+
+	// YAML parser accepts more than JSON hence validate strictly first
+	if !json.Valid(contents) {
+		return nil, fmt.Errorf("Expected file contents to be valid JSON")
+	}
+
+	// Parsed as YAML so that numbers keep their types (eg integers)
+	val, err := s.parseYAML(string(contents), strict)
+	if err != nil {
+		return nil, fmt.Errorf("Deserializing JSON: %s", err)
+	}
+
+	typedMap, ok := val.(*yamlmeta.Map)
+	if !ok {
+		return nil, fmt.Errorf("Expected JSON to be an object, but was %T", val)
+	}
+
+	return s.flattenedJSONMap(typedMap)
+}
+
+func (s *DataValuesFlags) flattenedJSONMap(typedMap *yamlmeta.Map) (*orderedmap.Map, error) {
+	result := orderedmap.NewMap()
+
+	err := s.flattenJSONMap(typedMap, "", result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *DataValuesFlags) flattenJSONMap(typedMap *yamlmeta.Map, prefix string, result *orderedmap.Map) error {
+	for _, item := range typedMap.Items {
+		key, ok := item.Key.(string)
+		if !ok || len(key) == 0 || strings.Contains(key, ".") {
+			return fmt.Errorf("Expected key '%v' to be a non-empty string without '.'", item.Key)
+		}
+
+		if nestedMap, ok := item.Value.(*yamlmeta.Map); ok && len(nestedMap.Items) > 0 {
+			err := s.flattenJSONMap(nestedMap, prefix+key+".", result)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		result.Set(prefix+key, item.Value)
+	}
+
+	return nil
+}
+
 func (s *DataValuesFlags) convertIntoNestedMap(multipleVals []*orderedmap.Map) (*orderedmap.Map, error) {
 	result := orderedmap.NewMap()
 	for _, vals := range multipleVals {
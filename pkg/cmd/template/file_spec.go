@@ -0,0 +1,115 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+// fileSpec describes input path (same as --file value) and how its
+// files are treated; it's an alternative to --file and --file-mark
+// flags for programmatic invocation
+type fileSpec struct {
+	Path      string `json:"path"`
+	Type      string `json:"type"`       // same values as type file mark
+	Template  *bool  `json:"template"`   // nil keeps default
+	ForOutput *bool  `json:"for_output"` // nil keeps default
+	Rename    string `json:"rename"`     // requires path to match a single file
+}
+
+// fileSpecs parses --file-spec values given inline ('[{...}]')
+// or as path of a JSON file prefixed with '@' (eg '@specs.json')
+func (s *RegularFilesSource) fileSpecs() ([]fileSpec, []string, error) {
+	var result []fileSpec
+	var descs []string
+
+	for _, val := range s.opts.fileSpecs {
+		desc := "--file-spec"
+		specBs := []byte(val)
+
+		if strings.HasPrefix(val, "@") {
+			desc = fmt.Sprintf("file spec '%s'", strings.TrimPrefix(val, "@"))
+
+			var err error
+			specBs, err = ioutil.ReadFile(strings.TrimPrefix(val, "@"))
+			if err != nil {
+				return nil, nil, fmt.Errorf("Reading %s: %s", desc, err)
+			}
+		}
+
+		var rawSpecs []json.RawMessage
+
+		err := json.Unmarshal(specBs, &rawSpecs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Expected %s to be a JSON array of objects: %s", desc, err)
+		}
+
+		for i, rawSpec := range rawSpecs {
+			var spec fileSpec
+
+			dec := json.NewDecoder(bytes.NewReader(rawSpec))
+			dec.DisallowUnknownFields()
+
+			err := dec.Decode(&spec)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Parsing %s at index %d: %s", desc, i, err)
+			}
+
+			specDesc := fmt.Sprintf("%s at index %d", desc, i)
+
+			if len(spec.Path) == 0 {
+				return nil, nil, fmt.Errorf("Expected %s to specify path", specDesc)
+			}
+
+			result = append(result, spec)
+			descs = append(descs, specDesc)
+		}
+	}
+
+	return result, descs, nil
+}
+
+// fileSpecFiles collects files for each file spec (in given order)
+// and marks them accordingly; --file-mark flags are applied afterwards
+func (s *RegularFilesSource) fileSpecFiles(sourceOpts files.SourceOpts) ([]*files.File, error) {
+	specs, descs, err := s.fileSpecs()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*files.File
+
+	for i, spec := range specs {
+		specFiles, err := files.NewSortedFilesFromPaths([]string{spec.Path}, sourceOpts)
+		if err != nil {
+			return nil, fmt.Errorf("Reading files for %s: %s", descs[i], err)
+		}
+
+		if len(spec.Rename) > 0 && len(specFiles) != 1 {
+			return nil, fmt.Errorf("Expected %s with rename to match exactly one file, but matched %d", descs[i], len(specFiles))
+		}
+
+		for _, file := range specFiles {
+			if len(spec.Type) > 0 && !markFileType(file, spec.Type) {
+				return nil, fmt.Errorf("Unknown type '%s' in %s", spec.Type, descs[i])
+			}
+			if spec.Template != nil {
+				file.MarkTemplate(*spec.Template)
+			}
+			if spec.ForOutput != nil {
+				file.MarkForOutput(*spec.ForOutput)
+			}
+			if len(spec.Rename) > 0 {
+				file.MarkRelativePath(spec.Rename)
+			}
+		}
+
+		result = append(result, specFiles...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSpecs(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"in/tpl.yml":   "#@ load(\"@ytt:data\", \"data\")\nname: #@ data.values.name\n",
+		"in/plain.yml": "name: #@ data.values.name\n",
+		"values.yml":   "#@data/values\n---\nname: app\n",
+		"assets/a.txt": "a",
+	})
+	defer os.RemoveAll(dirPath)
+
+	outPath := filepath.Join(dirPath, "out")
+	specPath := filepath.Join(dirPath, "specs.json")
+
+	err := ioutil.WriteFile(specPath, []byte(`[
+  {"path": "`+filepath.Join(dirPath, "values.yml")+`"},
+  {"path": "`+filepath.Join(dirPath, "assets/a.txt")+`", "type": "text-plain", "rename": "static/b.txt"}
+]`), 0600)
+	if err != nil {
+		t.Fatalf("Writing file spec: %s", err)
+	}
+
+	_, err = runCmd(t, "--output-directory", outPath,
+		"--file-spec", `[{"path": "`+filepath.Join(dirPath, "in/tpl.yml")+`"},
+{"path": "`+filepath.Join(dirPath, "in/plain.yml")+`", "template": false, "for_output": true}]`,
+		"--file-spec", "@"+specPath)
+	if err != nil {
+		t.Fatalf("Expected file specs to succeed: %s", err)
+	}
+
+	expectedFiles := map[string]string{
+		"tpl.yml":      "name: app\n",
+		"plain.yml":    "name: null\n",
+		"static/b.txt": "a",
+	}
+
+	for path, expected := range expectedFiles {
+		contents, err := ioutil.ReadFile(filepath.Join(outPath, path))
+		if err != nil || string(contents) != expected {
+			t.Fatalf("Expected file '%s' to be %q, but was %q (err: %v)", path, expected, contents, err)
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(outPath, "*"))
+	if err != nil || len(paths) != 3 {
+		t.Fatalf("Expected values file to not be written, but was: %#v (err: %v)", paths, err)
+	}
+}
+
+func TestFileSpecsErrs(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"in/a.yml": "a: 1\n",
+		"in/b.yml": "b: 1\n",
+	})
+	defer os.RemoveAll(dirPath)
+
+	inPath := filepath.Join(dirPath, "in")
+	missingPath := filepath.Join(dirPath, "missing.json")
+
+	examples := []struct {
+		Spec        string
+		ExpectedErr string
+	}{
+		{`[{"path": "` + inPath + `", "rename": "x.yml"}]`,
+			"Expected --file-spec at index 0 with rename to match exactly one file, but matched 2"},
+		{`[{"path": "` + inPath + `"}, {"path": "` + inPath + `", "templat": false}]`,
+			`Parsing --file-spec at index 1: json: unknown field "templat"`},
+		{`[{"type": "yaml"}]`,
+			"Expected --file-spec at index 0 to specify path"},
+		{`[{"path": "` + inPath + `", "type": "unknown"}]`,
+			"Unknown type 'unknown' in --file-spec at index 0"},
+		{"@" + missingPath,
+			"Reading file spec '" + missingPath + "': open " + missingPath + ": no such file or directory"},
+	}
+
+	for _, ex := range examples {
+		_, err := runCmd(t, "--file-spec", ex.Spec)
+		if err == nil || err.Error() != ex.ExpectedErr {
+			t.Fatalf("Expected file spec %s to fail with '%s', but was: %v", ex.Spec, ex.ExpectedErr, err)
+		}
+	}
+}
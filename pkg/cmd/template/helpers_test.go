@@ -0,0 +1,61 @@
+package template
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+)
+
+// runCmd parses given args the same way as ytt command line does
+// and runs template command; regular output is returned
+func runCmd(t *testing.T, args ...string) (string, error) {
+	out, _, err := runCmdWithErrOutput(t, args...)
+	return out, err
+}
+
+// runCmdWithErrOutput is same as runCmd but also returns
+// diagnostic output (eg --trace) printed to stderr
+func runCmdWithErrOutput(t *testing.T, args ...string) (string, string, error) {
+	opts := NewOptions()
+
+	err := NewCmd(opts).ParseFlags(args)
+	if err != nil {
+		t.Fatalf("Parsing flags %#v: %s", args, err)
+	}
+
+	outBuf := new(bytes.Buffer)
+	errBuf := new(bytes.Buffer)
+
+	err = opts.runWithUI(cmdcore.NewWriterUI(outBuf, errBuf, false))
+
+	return outBuf.String(), errBuf.String(), err
+}
+
+// writeInputDir writes files (relative path to contents) into
+// new temp dir; caller is expected to remove it
+func writeInputDir(t *testing.T, filesToWrite map[string]string) string {
+	dirPath, err := ioutil.TempDir("", "ytt-cmd-template")
+	if err != nil {
+		t.Fatalf("Creating temp dir: %s", err)
+	}
+
+	for path, contents := range filesToWrite {
+		fullPath := filepath.Join(dirPath, path)
+
+		err := os.MkdirAll(filepath.Dir(fullPath), 0700)
+		if err != nil {
+			t.Fatalf("Creating dir: %s", err)
+		}
+
+		err = ioutil.WriteFile(fullPath, []byte(contents), 0600)
+		if err != nil {
+			t.Fatalf("Writing file: %s", err)
+		}
+	}
+
+	return dirPath
+}
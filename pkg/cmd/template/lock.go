@@ -0,0 +1,47 @@
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+// checkLock verifies and/or writes lock of input files
+// (after file marks and file order are applied)
+func (s *RegularFilesSource) checkLock(filesToProcess []*files.File) error {
+	if len(s.opts.verifyLock) == 0 && len(s.opts.writeLock) == 0 {
+		return nil
+	}
+
+	lock, err := files.NewLock(filesToProcess)
+	if err != nil {
+		return fmt.Errorf("Calculating lock: %s", err)
+	}
+
+	if len(s.opts.verifyLock) > 0 {
+		expectedLock, err := files.ReadLock(s.opts.verifyLock)
+		if err != nil {
+			return err
+		}
+
+		err = lock.Verify(expectedLock)
+		if err != nil {
+			return fmt.Errorf("Verifying lock '%s': %s", s.opts.verifyLock, err)
+		}
+	}
+
+	if len(s.opts.writeLock) > 0 {
+		lockBs, err := lock.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(s.opts.writeLock, lockBs, 0644)
+		if err != nil {
+			return fmt.Errorf("Writing lock: %s", err)
+		}
+	}
+
+	return nil
+}
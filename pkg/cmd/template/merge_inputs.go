@@ -0,0 +1,115 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// mergeInputs deep merges documents of all plain YAML files (ie without
+// template annotations) that are included in output into a single document.
+// Merged document replaces first of such files; other files are dropped.
+// Template files (eg overlays, data values) are left as is.
+func (o *TemplateOptions) mergeInputs(in TemplateInput) (TemplateInput, error) {
+	arrays := yamlmeta.ArrayMergeReplace
+
+	if len(o.MergeInputsArrays) > 0 {
+		var err error
+
+		arrays, err = yamlmeta.ParseArrayMergeStrategy(o.MergeInputsArrays)
+		if err != nil {
+			return TemplateInput{}, err
+		}
+	}
+
+	var docs []*yamlmeta.Document
+	var mergedFiles []*files.File
+
+	for _, file := range in.Files {
+		if file.Type() != files.TypeYAML || !file.IsForOutput() || file.IsLibrary() {
+			continue
+		}
+
+		fileBs, err := file.Bytes()
+		if err != nil {
+			return TemplateInput{}, err
+		}
+
+		docSet, err := yamlmeta.NewDocumentSetFromBytes(fileBs, yamlmeta.DocSetOpts{
+			AssociatedName: file.RelativePath(),
+			Strict:         o.StrictYAML,
+		})
+		if err != nil {
+			return TemplateInput{}, fmt.Errorf("Parsing file '%s' for merging: %s", file.RelativePath(), err)
+		}
+
+		if o.hasTemplateAnnotations(docSet) {
+			continue
+		}
+
+		docs = append(docs, docSet.Items...)
+		mergedFiles = append(mergedFiles, file)
+	}
+
+	if len(mergedFiles) < 2 {
+		return in, nil
+	}
+
+	mergedDocSet := &yamlmeta.DocumentSet{
+		Items: []*yamlmeta.Document{yamlmeta.MergeDocuments(docs, arrays)},
+	}
+
+	mergedBs, err := mergedDocSet.AsBytes()
+	if err != nil {
+		return TemplateInput{}, fmt.Errorf("Marshaling merged inputs: %s", err)
+	}
+
+	firstFile := mergedFiles[0]
+
+	mergedFile, err := files.NewFileFromSource(files.NewBytesSource(firstFile.RelativePath(), mergedBs))
+	if err != nil {
+		return TemplateInput{}, err
+	}
+
+	if !firstFile.IsTemplate() {
+		mergedFile.MarkTemplate(false)
+	}
+	if len(firstFile.OutputSubdir()) > 0 {
+		mergedFile.MarkOutputSubdir(firstFile.OutputSubdir())
+	}
+
+	var result []*files.File
+
+	for _, file := range in.Files {
+		switch {
+		case file == firstFile:
+			result = append(result, mergedFile)
+		case o.containsFile(mergedFiles, file):
+			// skip since it's merged into first file
+		default:
+			result = append(result, file)
+		}
+	}
+
+	return TemplateInput{Files: result}, nil
+}
+
+func (TemplateOptions) hasTemplateAnnotations(docSet *yamlmeta.DocumentSet) bool {
+	for _, meta := range docSet.AllMetas {
+		if strings.HasPrefix(meta.Data, "@") {
+			return true
+		}
+	}
+	return false
+}
+
+func (TemplateOptions) containsFile(haystack []*files.File, needle *files.File) bool {
+	for _, file := range haystack {
+		if file == needle {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// onlyChanged keeps documents that were added or modified compared to
+// documents found in baseline directory (eg previous render written via
+// --output-directory) and returns trailer comment that lists all changes,
+// including deleted documents since they cannot be printed
+func (s *RegularFilesSource) onlyChanged(out TemplateOutput) (*yamlmeta.DocumentSet, []byte, error) {
+	baselineDocs, err := s.baselineDocs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	docIDs := map[*yamlmeta.Document]string{}
+	renderedIDs := map[string]struct{}{}
+
+	for _, file := range out.Files {
+		if file.DocSet() == nil {
+			continue
+		}
+		for i, doc := range file.DocSet().Items {
+			id := yamlmeta.DocumentIdentity(doc, file.RelativePath(), i)
+			docIDs[doc] = id
+			renderedIDs[id] = struct{}{}
+		}
+	}
+
+	result := &yamlmeta.DocumentSet{}
+	changes := map[yamlmeta.DocumentChange][]string{}
+
+	for _, doc := range out.DocSet.Items {
+		id, found := docIDs[doc]
+		if !found {
+			// Documents that do not belong to any file are always new
+			id = yamlmeta.DocumentIdentity(doc, "", len(result.Items))
+		}
+
+		change, err := yamlmeta.CompareDocuments(doc, baselineDocs[id])
+		if err != nil {
+			return nil, nil, fmt.Errorf("Comparing document '%s' to baseline: %s", id, err)
+		}
+		if change == yamlmeta.DocumentUnchanged {
+			continue
+		}
+
+		result.Items = append(result.Items, doc)
+		changes[change] = append(changes[change], id)
+	}
+
+	var deletedIDs []string
+
+	for id := range baselineDocs {
+		if _, found := renderedIDs[id]; !found {
+			deletedIDs = append(deletedIDs, id)
+		}
+	}
+
+	sort.Strings(deletedIDs)
+
+	var trailer bytes.Buffer
+
+	fmt.Fprintf(&trailer, "# only-changed: %d added, %d modified, %d deleted (compared to '%s')\n",
+		len(changes[yamlmeta.DocumentAdded]), len(changes[yamlmeta.DocumentModified]), len(deletedIDs), s.opts.baselineDir)
+
+	for _, change := range []yamlmeta.DocumentChange{yamlmeta.DocumentAdded, yamlmeta.DocumentModified} {
+		for _, id := range changes[change] {
+			fmt.Fprintf(&trailer, "# %s: %s\n", change, id)
+		}
+	}
+	for _, id := range deletedIDs {
+		fmt.Fprintf(&trailer, "# deleted: %s\n", id)
+	}
+
+	return result, trailer.Bytes(), nil
+}
+
+// baselineDocs returns non-empty documents of YAML files
+// found in baseline directory keyed by their identity
+func (s *RegularFilesSource) baselineDocs() (map[string]*yamlmeta.Document, error) {
+	baselineFiles, err := files.NewSortedFilesFromPaths([]string{s.opts.baselineDir}, files.SourceOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("Reading --baseline-directory: %s", err)
+	}
+
+	result := map[string]*yamlmeta.Document{}
+
+	for _, file := range baselineFiles {
+		if file.Type() != files.TypeYAML {
+			continue
+		}
+
+		fileBs, err := file.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("Reading baseline file '%s': %s", file.RelativePath(), err)
+		}
+
+		docSet, err := yamlmeta.NewDocumentSetFromBytes(fileBs, yamlmeta.DocSetOpts{AssociatedName: file.RelativePath()})
+		if err != nil {
+			return nil, fmt.Errorf("Parsing baseline file '%s': %s", file.RelativePath(), err)
+		}
+
+		var idx int
+
+		for _, doc := range docSet.Items {
+			// Empty documents are not included in output
+			if doc.IsEmpty() {
+				continue
+			}
+			result[yamlmeta.DocumentIdentity(doc, file.RelativePath(), idx)] = doc
+			idx++
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,202 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+type printerFuncConstructor func(s *RegularFilesSource) (func(io.Writer) yamlmeta.DocumentPrinter, error)
+
+type outputType struct {
+	Name string
+
+	// NewPrinterFunc returns nil printer func for default YAML printing
+	NewPrinterFunc printerFuncConstructor
+
+	// Streamable output is printed to stdout document by document;
+	// other printers may reject a later document (eg unsupported value
+	// for TOML), hence their output is printed only once fully marshaled
+	Streamable bool
+}
+
+// outputTypes is the single list of known output types: it determines
+// printer used for each type (printerFunc), whether it's streamed,
+// --output help and unknown output type errors
+var outputTypes = []outputType{
+	{"yaml", yamlPrinterFunc, true},
+	{"yaml-stream", yamlStreamPrinterFunc, true},
+	{"k8s-list", yamlPrinterFunc, true},
+	{"json", jsonPrinterFunc, true},
+	{"json-stream", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONLinesPrinter(w) }), true},
+	{"json-array", jsonPrinterFunc, true},
+	{"toml", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewTOMLPrinter(w) }), false},
+	{"hcl", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewHCLPrinter(w) }), false},
+	{"csv", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewCSVPrinter(w) }), false},
+	{"xml", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewXMLPrinter(w) }), false},
+	{"dotenv", dotenvPrinterFunc, false},
+	{"properties", propertiesPrinterFunc, false},
+	{"base64", yamlPrinterFunc, false},
+	{"sha256", canonicalPrinterFunc, false},
+	{"sha512", canonicalPrinterFunc, false},
+	{"source-map", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewSourceMapPrinter(w) }), false},
+	{"pos", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.WrappedFilePositionPrinter{Printer: yamlmeta.NewFilePositionPrinter(w)}
+	}), false},
+	{"pos-full", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewFullFilePositionPrinter(w) }), false},
+	{"pos-lsp", printerFuncOf(func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewFullFilePositionPrinterWithOpts(w, yamlmeta.FullFilePositionPrinterOpts{LSP: true})
+	}), false},
+}
+
+func outputTypeNames() []string {
+	var result []string
+	for _, outputType := range outputTypes {
+		result = append(result, outputType.Name)
+	}
+	return result
+}
+
+func (s *RegularFilesSource) printerFunc(name string) (func(io.Writer) yamlmeta.DocumentPrinter, error) {
+	for _, outputType := range outputTypes {
+		if outputType.Name == name {
+			return outputType.NewPrinterFunc(s)
+		}
+	}
+	return nil, newUnknownOutputTypeErr(name)
+}
+
+func streamableOutputType(name string) bool {
+	for _, outputType := range outputTypes {
+		if outputType.Name == name {
+			return outputType.Streamable
+		}
+	}
+	return false
+}
+
+// printerFuncOf is used for printers without any options
+func printerFuncOf(printerFunc func(io.Writer) yamlmeta.DocumentPrinter) printerFuncConstructor {
+	return func(_ *RegularFilesSource) (func(io.Writer) yamlmeta.DocumentPrinter, error) {
+		return printerFunc, nil
+	}
+}
+
+func yamlPrinterFunc(s *RegularFilesSource) (func(io.Writer) yamlmeta.DocumentPrinter, error) {
+	if s.hasDefaultYAMLFormatting() {
+		return nil, nil
+	}
+	yamlOpts, err := s.yamlPrinterOpts()
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewYAMLPrinterWithOpts(w, yamlOpts) }, nil
+}
+
+func yamlStreamPrinterFunc(s *RegularFilesSource) (func(io.Writer) yamlmeta.DocumentPrinter, error) {
+	yamlOpts, err := s.yamlPrinterOpts()
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewYAMLStreamPrinterWithOpts(w, yamlOpts) }, nil
+}
+
+// Checksums are calculated over canonical form
+func canonicalPrinterFunc(_ *RegularFilesSource) (func(io.Writer) yamlmeta.DocumentPrinter, error) {
+	return nil, nil
+}
+
+func jsonPrinterFunc(s *RegularFilesSource) (func(io.Writer) yamlmeta.DocumentPrinter, error) {
+	jsonOpts := yamlmeta.JSONPrinterOpts{Indent: s.jsonIndentStr()}
+	return func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONPrinterWithOpts(w, jsonOpts) }, nil
+}
+
+func dotenvPrinterFunc(s *RegularFilesSource) (func(io.Writer) yamlmeta.DocumentPrinter, error) {
+	dotenvOpts := yamlmeta.DotenvPrinterOpts{Flatten: s.opts.dotenvFlatten}
+	return func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewDotenvPrinterWithOpts(w, dotenvOpts) }, nil
+}
+
+func propertiesPrinterFunc(s *RegularFilesSource) (func(io.Writer) yamlmeta.DocumentPrinter, error) {
+	propertiesOpts := yamlmeta.PropertiesPrinterOpts{ListFormat: s.opts.propertiesLists}
+	return func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewPropertiesPrinterWithOpts(w, propertiesOpts)
+	}, nil
+}
+
+const (
+	// Suggestions further than this are unlikely to be typos
+	outputTypeSuggestionMaxDistance = 3
+)
+
+// checkOutputTypes validates requested output types before
+// templates are evaluated (printerFunc decides which types are known)
+func (s *RegularFilesSource) checkOutputTypes() error {
+	for _, outputType := range strings.Split(s.opts.outputType, ",") {
+		_, err := s.printerFunc(outputType)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newUnknownOutputTypeErr(outputType string) error {
+	var hint string
+
+	if suggestion, found := closestOutputType(outputType); found {
+		hint = fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+	}
+
+	return fmt.Errorf("Unknown output type '%s'%s; valid output types: %s",
+		outputType, hint, strings.Join(outputTypeNames(), ", "))
+}
+
+func closestOutputType(outputType string) (string, bool) {
+	var result string
+	minDistance := outputTypeSuggestionMaxDistance + 1
+
+	for _, knownType := range outputTypeNames() {
+		distance := levenshteinDistance(strings.ToLower(outputType), knownType)
+		if distance < minDistance {
+			result = knownType
+			minDistance = distance
+		}
+	}
+
+	return result, len(result) > 0
+}
+
+func levenshteinDistance(a, b string) int {
+	aRunes, bRunes := []rune(a), []rune(b)
+
+	prevRow := make([]int, len(bRunes)+1)
+	for j := range prevRow {
+		prevRow[j] = j
+	}
+
+	for i := 1; i <= len(aRunes); i++ {
+		row := make([]int, len(bRunes)+1)
+		row[0] = i
+
+		for j := 1; j <= len(bRunes); j++ {
+			cost := 1
+			if aRunes[i-1] == bRunes[j-1] {
+				cost = 0
+			}
+			row[j] = minInt(minInt(row[j-1]+1, prevRow[j]+1), prevRow[j-1]+cost)
+		}
+
+		prevRow = row
+	}
+
+	return prevRow[len(bRunes)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
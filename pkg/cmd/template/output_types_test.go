@@ -0,0 +1,108 @@
+package template
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+)
+
+func TestClosestOutputType(t *testing.T) {
+	examples := []struct {
+		OutputType string
+		Expected   string
+	}{
+		{"ymal", "yaml"},
+		{"JSON", "json"},
+		{"jsn", "json"},
+		{"json-strem", "json-stream"},
+		{"sha265", "sha256"},
+		{"postition", ""},
+		{"something-else", ""},
+	}
+
+	for _, ex := range examples {
+		result, found := closestOutputType(ex.OutputType)
+		if result != ex.Expected || found != (len(ex.Expected) > 0) {
+			t.Fatalf("Expected closest output type for '%s' to be '%s', but was '%s' (found: %t)",
+				ex.OutputType, ex.Expected, result, found)
+		}
+	}
+}
+
+func TestNewUnknownOutputTypeErr(t *testing.T) {
+	validTypes := "valid output types: yaml, yaml-stream, k8s-list, json, json-stream, json-array, toml, hcl, csv, " +
+		"xml, dotenv, properties, base64, sha256, sha512, source-map, pos, pos-full, pos-lsp"
+
+	err := newUnknownOutputTypeErr("ymal")
+	if err.Error() != "Unknown output type 'ymal' (did you mean 'yaml'?); "+validTypes {
+		t.Fatalf("Expected err to match, but was: %s", err)
+	}
+
+	err = newUnknownOutputTypeErr("something-else")
+	if err.Error() != "Unknown output type 'something-else'; "+validTypes {
+		t.Fatalf("Expected err to match, but was: %s", err)
+	}
+}
+
+func TestOutputTypesHavePrinters(t *testing.T) {
+	opts := NewOptions()
+
+	err := NewCmd(opts).ParseFlags(nil)
+	if err != nil {
+		t.Fatalf("Parsing flags: %s", err)
+	}
+
+	src := NewRegularFilesSource(opts.RegularFilesSourceOpts, cmdcore.NewPlainUI(false))
+
+	for _, name := range outputTypeNames() {
+		_, err := src.printerFunc(name)
+		if err != nil {
+			t.Fatalf("Expected output type '%s' to have printer, but was: %s", name, err)
+		}
+	}
+}
+
+func TestUnknownOutputTypeRejectedBeforeEvaluation(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"tpl.yml": `#@ fail("templates should not be evaluated")`,
+	})
+	defer os.RemoveAll(dirPath)
+
+	for _, outputType := range []string{"ymal", "yaml,jsn"} {
+		_, err := runCmd(t, "-f", dirPath, "-o", outputType)
+		if err == nil || !strings.Contains(err.Error(), "Unknown output type") {
+			t.Fatalf("Expected unknown output type err for '%s', but was: %v", outputType, err)
+		}
+	}
+
+	// Known output types do evaluate templates
+	_, err := runCmd(t, "-f", dirPath, "-o", "json")
+	if err == nil || !strings.Contains(err.Error(), "templates should not be evaluated") {
+		t.Fatalf("Expected template evaluation err, but was: %v", err)
+	}
+}
+
+func TestFailingPrinterPrintsNothing(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"tpl.yml": "a: 1\n---\nb: 2\n",
+	})
+	defer os.RemoveAll(dirPath)
+
+	for _, gzip := range []bool{false, true} {
+		args := []string{"-f", dirPath, "-o", "toml"}
+		if gzip {
+			args = append(args, "--output-gzip")
+		}
+
+		// Second document is rejected by TOML printer
+		out, err := runCmd(t, args...)
+		if err == nil || !strings.Contains(err.Error(), "TOML output does not support multiple documents") {
+			t.Fatalf("Expected TOML printer err (gzip: %t), but was: %v", gzip, err)
+		}
+		if len(out) > 0 {
+			t.Fatalf("Expected no output when printer fails (gzip: %t), but was: %q", gzip, out)
+		}
+	}
+}
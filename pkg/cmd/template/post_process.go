@@ -0,0 +1,45 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+const (
+	// Set for post-process command to relative path of
+	// file being processed (only with --output-directory or --output-file)
+	postProcessPathEnvVar = "YTT_OUTPUT_PATH"
+)
+
+// postProcess pipes data to post-process command (run without a shell)
+// and returns its stdout; relativePath may be empty (eg for stdout)
+func (s *RegularFilesSource) postProcess(relativePath string, data []byte) ([]byte, error) {
+	args, err := splitCommandArgs(s.opts.postProcess)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing --post-process: %s", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if len(relativePath) > 0 {
+		cmd.Env = append(os.Environ(), postProcessPathEnvVar+"="+relativePath)
+	}
+
+	err = cmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("Expected post-process command to succeed, but exited with code %d%s",
+				exitErr.ExitCode(), commandStderrSuffix(stderr.Bytes()))
+		}
+		return nil, fmt.Errorf("Running post-process command: %s", err)
+	}
+
+	return stdout.Bytes(), nil
+}
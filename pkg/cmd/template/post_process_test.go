@@ -0,0 +1,91 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPostProcessRequiresAllow(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: 1\n"})
+	defer os.RemoveAll(dirPath)
+
+	out, err := runCmd(t, "-f", dirPath, "--post-process", "cat")
+
+	expectedErr := "Expected --dangerous-allow-post-process to be specified to allow running --post-process command"
+	if err == nil || err.Error() != expectedErr {
+		t.Fatalf("Expected post-process to fail with '%s', but was: %v", expectedErr, err)
+	}
+	if out != "" {
+		t.Fatalf("Expected no output, but was: >>>%s<<<", out)
+	}
+}
+
+func TestPostProcessStdout(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: 1\n---\nb: 2\n"})
+	defer os.RemoveAll(dirPath)
+
+	examples := []struct {
+		Command  string
+		Expected string
+	}{
+		{"cat", "a: 1\n---\nb: 2\n"},
+		{"tr a-z A-Z", "A: 1\n---\nB: 2\n"},
+		{`sh -c "printf processed"`, "processed"},
+	}
+
+	for _, ex := range examples {
+		out, err := runCmd(t, "-f", dirPath, "--post-process", ex.Command, "--dangerous-allow-post-process")
+		if err != nil {
+			t.Fatalf("Expected post-process '%s' to succeed: %s", ex.Command, err)
+		}
+		if out != ex.Expected {
+			t.Fatalf("Expected post-process '%s' output to be %q, but was %q", ex.Command, ex.Expected, out)
+		}
+	}
+}
+
+func TestPostProcessOutputDirectory(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"in/a.yml":     "a: 1\n",
+		"in/sub/b.yml": "b: 2\n",
+	})
+	defer os.RemoveAll(dirPath)
+
+	outPath := filepath.Join(dirPath, "out")
+
+	_, err := runCmd(t, "-f", filepath.Join(dirPath, "in"), "--output-directory", outPath,
+		"--post-process", `sh -c "echo $YTT_OUTPUT_PATH; tr a-z A-Z"`, "--dangerous-allow-post-process")
+	if err != nil {
+		t.Fatalf("Expected post-process to succeed: %s", err)
+	}
+
+	expectedFiles := map[string]string{
+		"a.yml":     "a.yml\nA: 1\n",
+		"sub/b.yml": "sub/b.yml\nB: 2\n",
+	}
+
+	for path, expected := range expectedFiles {
+		contents, err := ioutil.ReadFile(filepath.Join(outPath, path))
+		if err != nil || string(contents) != expected {
+			t.Fatalf("Expected file '%s' to be %q, but was %q (err: %v)", path, expected, contents, err)
+		}
+	}
+}
+
+func TestPostProcessFailure(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: 1\n"})
+	defer os.RemoveAll(dirPath)
+
+	out, err := runCmd(t, "-f", dirPath, "--post-process", `sh -c "echo err >&2; exit 3"`, "--dangerous-allow-post-process")
+
+	expectedErr := "Expected post-process command to succeed, but exited with code 3 (stderr: err)"
+	if err == nil || !strings.Contains(err.Error(), expectedErr) {
+		t.Fatalf("Expected post-process to fail with '%s', but was: %v", expectedErr, err)
+	}
+	if out != "" {
+		t.Fatalf("Expected no output on failure, but was: >>>%s<<<", out)
+	}
+}
@@ -0,0 +1,66 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiles starts CPU profiling (--cpuprofile) and returns function
+// that stops it and writes heap profile (--memprofile); both are no-ops
+// when corresponding flags are not set
+func (o *TemplateOptions) startProfiles() (func() error, error) {
+	var cpuFile *os.File
+
+	if len(o.CPUProfile) > 0 {
+		var err error
+
+		cpuFile, err = os.Create(o.CPUProfile)
+		if err != nil {
+			return nil, fmt.Errorf("Creating --cpuprofile file: %s", err)
+		}
+
+		err = pprof.StartCPUProfile(cpuFile)
+		if err != nil {
+			cpuFile.Close()
+			return nil, fmt.Errorf("Starting CPU profile: %s", err)
+		}
+	}
+
+	stopFunc := func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+
+			err := cpuFile.Close()
+			if err != nil {
+				return fmt.Errorf("Writing --cpuprofile file: %s", err)
+			}
+		}
+
+		if len(o.MemProfile) > 0 {
+			return o.writeMemProfile()
+		}
+		return nil
+	}
+
+	return stopFunc, nil
+}
+
+func (o *TemplateOptions) writeMemProfile() error {
+	memFile, err := os.Create(o.MemProfile)
+	if err != nil {
+		return fmt.Errorf("Creating --memprofile file: %s", err)
+	}
+	defer memFile.Close()
+
+	// Profile reflects state as of most recent garbage collection
+	runtime.GC()
+
+	err = pprof.WriteHeapProfile(memFile)
+	if err != nil {
+		return fmt.Errorf("Writing --memprofile file: %s", err)
+	}
+
+	return memFile.Close()
+}
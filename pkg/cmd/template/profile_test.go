@@ -0,0 +1,64 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: #@ 1 + 2\n"})
+	defer os.RemoveAll(dirPath)
+
+	cpuPath := filepath.Join(dirPath, "cpu.prof")
+	memPath := filepath.Join(dirPath, "mem.prof")
+
+	out, err := runCmd(t, "-f", filepath.Join(dirPath, "tpl.yml"), "--cpuprofile", cpuPath, "--memprofile", memPath)
+	if err != nil {
+		t.Fatalf("Expected profiling to succeed: %s", err)
+	}
+	if out != "a: 3\n" {
+		t.Fatalf("Expected regular output, but was: >>>%s<<<", out)
+	}
+
+	for _, path := range []string{cpuPath, memPath} {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() == 0 {
+			t.Fatalf("Expected profile '%s' to be written, but was: %v", path, err)
+		}
+	}
+}
+
+func TestProfilesErrs(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: 1\n"})
+	defer os.RemoveAll(dirPath)
+
+	missingPath := filepath.Join(dirPath, "missing", "file.prof")
+
+	examples := []struct {
+		Args        []string
+		ExpectedErr string
+	}{
+		{[]string{"--cpuprofile", missingPath}, "Creating --cpuprofile file: open " + missingPath + ": no such file or directory"},
+		{[]string{"--memprofile", missingPath}, "Creating --memprofile file: open " + missingPath + ": no such file or directory"},
+	}
+
+	for _, ex := range examples {
+		_, err := runCmd(t, append([]string{"-f", dirPath}, ex.Args...)...)
+		if err == nil || err.Error() != ex.ExpectedErr {
+			t.Fatalf("Expected %#v to fail with '%s', but was: %v", ex.Args, ex.ExpectedErr, err)
+		}
+	}
+
+	// Failing to create CPU profile should not leave profiling enabled
+	cpuPath := filepath.Join(dirPath, "cpu.prof")
+
+	_, err := runCmd(t, "-f", filepath.Join(dirPath, "tpl.yml"), "--cpuprofile", cpuPath)
+	if err != nil {
+		t.Fatalf("Expected profiling after failure to succeed: %s", err)
+	}
+
+	if _, err := os.Stat(cpuPath); err != nil {
+		t.Fatalf("Expected CPU profile to be written: %s", err)
+	}
+}
@@ -0,0 +1,92 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// redact returns output with values at --redact paths replaced.
+// Documents are copied since they are shared with output files, which
+// keep real values unless --redact-output is specified.
+func (s *RegularFilesSource) redact(out TemplateOutput) (TemplateOutput, error) {
+	var paths []yamlmeta.RedactPath
+
+	for _, pointer := range s.opts.redactPaths {
+		path, err := yamlmeta.ParseRedactPath(pointer)
+		if err != nil {
+			return TemplateOutput{}, fmt.Errorf("Parsing --redact: %s", err)
+		}
+		paths = append(paths, path)
+	}
+
+	if out.OverlayTrace != nil {
+		return TemplateOutput{}, fmt.Errorf("Expected --redact to not be used with --overlay-trace")
+	}
+
+	if len(s.opts.outputDir) > 0 && !s.opts.redactOutput {
+		// Nothing would be redacted since documents are only written to disk
+		return TemplateOutput{}, fmt.Errorf("Expected --redact-output to be specified when --redact is used with --output-directory")
+	}
+	if len(s.opts.outputDir) == 0 && s.opts.redactOutput {
+		return TemplateOutput{}, fmt.Errorf("Expected --redact-output to be used together with --output-directory")
+	}
+
+	out.DocSet = out.DocSet.DeepCopy()
+	yamlmeta.Redact(out.DocSet, paths)
+
+	if s.opts.redactOutput {
+		var redactedFiles []files.OutputFile
+
+		for _, file := range out.Files {
+			redactedFile, err := s.redactOutputFile(file, paths)
+			if err != nil {
+				return TemplateOutput{}, err
+			}
+			redactedFiles = append(redactedFiles, redactedFile)
+		}
+
+		out.Files = redactedFiles
+	}
+
+	return out, nil
+}
+
+// redactOutputFile redacts YAML and JSON files; other files
+// (eg text templates) cannot be redacted, hence are kept as is
+func (s *RegularFilesSource) redactOutputFile(file files.OutputFile, paths []yamlmeta.RedactPath) (files.OutputFile, error) {
+	docSet := file.DocSet()
+	printerFunc := func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewYAMLPrinter(w) }
+
+	switch {
+	case docSet != nil:
+		docSet = docSet.DeepCopy()
+
+	case !file.IsBinary() && filepath.Ext(file.RelativePath()) == ".json":
+		// JSON files are kept in JSON, hence do not hold documents
+		var err error
+		docSet, err = yamlmeta.NewDocumentSetFromBytes(file.Bytes(), yamlmeta.DocSetOpts{AssociatedName: file.RelativePath()})
+		if err != nil {
+			return files.OutputFile{}, fmt.Errorf("Parsing '%s' for --redact-output: %s", file.RelativePath(), err)
+		}
+		printerFunc = func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONPrinter(w) }
+
+	default:
+		return file, nil
+	}
+
+	yamlmeta.Redact(docSet, paths)
+
+	docBytes, err := docSet.AsBytesWithPrinter(printerFunc)
+	if err != nil {
+		return files.OutputFile{}, fmt.Errorf("Marshaling redacted '%s': %s", file.RelativePath(), err)
+	}
+
+	if file.DocSet() == nil {
+		return file.WithContents(docBytes, nil), nil
+	}
+	return file.WithContents(docBytes, docSet), nil
+}
@@ -1,38 +1,185 @@
 package template
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
 	"fmt"
+	"hash"
 	"io"
+	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
 	"github.com/k14s/ytt/pkg/files"
 	"github.com/k14s/ytt/pkg/yamlmeta"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
 	"github.com/spf13/cobra"
 )
 
 type RegularFilesSourceOpts struct {
-	files     []string
-	fileMarks []string
+	files       []string
+	fileMarks   []string
+	fileSpecs   []string
+	fileOrder   []string
+	fileHeaders []string
+	fileTimeout time.Duration
 
-	outputDir  string
-	outputType string
+	fileGitToken string
+
+	fileOCIPlainHTTP bool
+
+	fileK8sKubeconfig string
+	fileK8sContext    string
+
+	fileStdinFormat    string
+	stdinPath          string
+	fileArchiveMaxSize int64
+	maxFileSize        int64
+	readConcurrency    int
+	fileNoIgnore       bool
+	fileNoGlob         bool
+	fileAllowEmptyGlob bool
+	fileAllowDupPaths  bool
+	fileNaturalSort    bool
+	writeLock          string
+	verifyLock         string
+	fileTrace          bool
+	inputFormat        string
+	inputEncoding      string
+	detectShebang      bool
+
+	outputDir          string
+	outputDirMode      string
+	outputFile         string
+	outputDirDiff      bool
+	outputSplit        bool
+	outputSplitNameTpl string
+	outputType         string
+	jsonIndent         string
+	jsonArrayNulls     bool
+	yamlLineWidth      int
+	yamlIndent         int
+	yamlQuoteStyle     string
+	yamlFlowStyle      string
+	base64Wrap         bool
+	checksumName       string
+	dotenvFlatten      bool
+	propertiesLists    string
+	posQuery           string
+	sortKeys           bool
+	outputGzip         bool
+	outputManifest     string
+	progress           string
+	outputFollowLinks  bool
+	outputFileExt      string
+	trailingNewline    string
+	dryRun             bool
+	plan               bool
+	rejectEmptyDocs    bool
+	outputFilters      []string
+	requireMatch       bool
+	sortDocuments      []string
+	onlyChanged        bool
+	baselineDir        string
+	redactPaths        []string
+	sourceChecksum     bool
+	redactOutput       bool
+	postProcess        string
+	allowPostProcess   bool
 
 	files.SymlinkAllowOpts
 }
 
 func (s *RegularFilesSourceOpts) Set(cmd *cobra.Command) {
-	cmd.Flags().StringArrayVarP(&s.files, "file", "f", nil, "File (ie local path, HTTP URL, -) (can be specified multiple times)")
+	cmd.Flags().StringArrayVarP(&s.files, "file", "f", nil, "File (ie local path, HTTP URL, -, relative/path.yml=env:NAME) (can be specified multiple times)")
 	cmd.Flags().StringArrayVar(&s.fileMarks, "file-mark", nil, "File mark (ie change file path, mark as non-template) (format: file:key=value) (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&s.fileSpecs, "file-spec", nil, "JSON array of file specs ({path, type, template, for_output, rename}) given inline or read from file via @path (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&s.fileOrder, "file-order", nil, "Relative paths of files to process first in given order; other files follow in default order (format: path1,path2)")
+	cmd.Flags().StringArrayVar(&s.fileHeaders, "file-header", nil, "Header set on HTTP requests for files with matching URL prefix (format: url:Header-Name=value) (can be specified multiple times)")
+	cmd.Flags().StringVar(&s.fileGitToken, "file-git-token", "", "Token used for fetching files via git+<url> paths (by default standard git credential mechanisms are used)")
+	cmd.Flags().StringVar(&s.fileK8sKubeconfig, "file-k8s-kubeconfig", "", "Path to kubeconfig used for fetching files via k8s:// paths (by default $KUBECONFIG, in-cluster configuration or ~/.kube/config)")
+	cmd.Flags().StringVar(&s.fileK8sContext, "file-k8s-context", "", "Kubeconfig context used for fetching files via k8s:// paths (by default current context)")
+	cmd.Flags().BoolVar(&s.fileOCIPlainHTTP, "file-oci-plain-http", false, "Use plain HTTP (instead of HTTPS) for fetching files via oci:// paths (eg from local registry)")
+	cmd.Flags().DurationVar(&s.fileTimeout, "file-timeout", 0, "Timeout for fetching HTTP, OCI and Kubernetes files (eg 30s) (default no timeout)")
+	cmd.Flags().StringVar(&s.fileStdinFormat, "file-stdin-format", files.StdinFormatFile, "Format of stdin provided via '-f -' (file, zip)")
+	cmd.Flags().StringVar(&s.stdinPath, "stdin-path", "", "Relative path of file provided via '-f -' used for type detection, file marks and --file-order (default stdin.yml)")
+	cmd.Flags().Int64Var(&s.fileArchiveMaxSize, "file-archive-max-size", files.DefaultArchiveMaxSize,
+		"Maximum total uncompressed size of archive contents in bytes (0 means no limit)")
+	cmd.Flags().Int64Var(&s.maxFileSize, "max-file-size", files.DefaultMaxFileSize,
+		"Maximum size of any single input file (including HTTP files and archive entries) in bytes (0 means no limit)")
+	cmd.Flags().IntVar(&s.readConcurrency, "read-concurrency", runtime.GOMAXPROCS(0),
+		"Number of input files read in parallel (0 means files are read sequentially as they are used)")
+
+	cmd.Flags().StringVar(&s.inputEncoding, "input-encoding", "", "Encoding of input files (utf-8, utf-16, utf-16le, utf-16be, latin-1) (file marks take precedence)")
+	cmd.Flags().StringVar(&s.inputFormat, "input-format", "", "Type of stdin and files with unrecognized extensions (yaml, text, starlark, data) (file marks take precedence)")
+	cmd.Flags().BoolVar(&s.detectShebang, "detect-shebang", false, "Set type of local files with unrecognized extensions based on shebang line (eg '#!/usr/bin/env starlark')")
+	cmd.Flags().BoolVar(&s.fileNoIgnore, "file-no-ignore", false, "Do not skip files listed in "+files.IgnoreFileName+" at the root of input directories")
+	cmd.Flags().BoolVar(&s.fileNoGlob, "no-glob", false, "Treat file paths literally instead of expanding glob patterns (eg 'config/**/*.yml')")
+	cmd.Flags().BoolVar(&s.fileAllowEmptyGlob, "allow-empty-glob", false, "Allow file glob patterns that do not match any files")
+	cmd.Flags().BoolVar(&s.fileNaturalSort, "natural-sort", false, "Sort files within directories comparing numbers numerically (eg 2-x.yml before 10-x.yml)")
+	cmd.Flags().BoolVar(&s.fileAllowDupPaths, "allow-duplicate-paths", false, "Allow multiple input files with the same relative path (last one is used)")
+	cmd.Flags().StringVar(&s.writeLock, "write-lock", "", "Write lock file recording path, type, template flag and SHA256 of each input file (eg --write-lock lock.yml)")
+	cmd.Flags().StringVar(&s.verifyLock, "verify-lock", "", "Fail before evaluating templates if input files do not match given lock file (written via --write-lock)")
+	cmd.Flags().BoolVar(&s.fileTrace, "trace", false, "Print type, template and output flags, and path of each input file (after file marks are applied) to stderr")
 
 	cmd.Flags().StringVar(&s.outputDir, "output-directory", "", "Output destination directory")
-	cmd.Flags().StringVarP(&s.outputType, "output", "o", "yaml", "Output type (yaml, json, pos)")
+	cmd.Flags().StringVar(&s.outputDirMode, "output-directory-mode", string(files.OutputDirectoryModeClean),
+		"Treatment of existing output directory contents (clean: remove previously written files, merge: overwrite matching files, error: fail if not empty)")
+	cmd.Flags().BoolVar(&s.outputDirDiff, "output-directory-diff", false, "Print diff between output directory contents and rendered files instead of writing them (fails if there are differences)")
+	cmd.Flags().StringVar(&s.outputFile, "output-file", "", "Write combined result into a single file (relative path within output directory)")
+	cmd.Flags().BoolVar(&s.outputSplit, "output-files-split", false, "Write each YAML document into a separate file in output directory")
+	cmd.Flags().StringVar(&s.outputSplitNameTpl, "output-files-split-name", files.DefaultSplitNameTemplate,
+		"Name template for split files based on document keys (falls back to index-based name if keys are missing)")
+	cmd.Flags().StringVarP(&s.outputType, "output", "o", "yaml", "Output type ("+strings.Join(outputTypeNames(), ", ")+") (comma-separated list writes each type with --output-directory)")
+	cmd.Flags().BoolVar(&s.dotenvFlatten, "dotenv-flatten", false, "Join keys of nested maps with underscore in dotenv output (nested maps are rejected otherwise)")
+	cmd.Flags().StringVar(&s.propertiesLists, "properties-list-format", yamlmeta.PropertiesListFormatIndexed, "Format of arrays in properties output (indexed: 'items.0=a', comma: 'items=a,b')")
+	cmd.Flags().BoolVar(&s.base64Wrap, "base64-wrap", false, "Wrap base64 output into lines of 76 characters (MIME)")
+	cmd.Flags().StringVar(&s.checksumName, "checksum-name", "", "Print given name after digest with sha256 and sha512 output types (sha256sum format)")
+	cmd.Flags().StringVar(&s.posQuery, "pos-query", "", "Print position of a single node selected via JSON pointer (eg /spec/containers/0/image) with pos output type")
+	cmd.Flags().BoolVar(&s.sortKeys, "sort-keys", false, "Sort map keys recursively in output")
+	cmd.Flags().IntVar(&s.yamlLineWidth, "yaml-line-width", defaultYAMLLineWidth, "Fold long strings in YAML output at given line width (0 or -1 disables folding)")
+	cmd.Flags().IntVar(&s.yamlIndent, "indent", defaultYAMLIndent, "Number of spaces per indentation level in YAML output (2 to 9)")
+	cmd.Flags().StringVar(&s.yamlQuoteStyle, "quote-style", string(yamlmeta.YAMLQuoteMinimal), "Quoting of strings in YAML output (minimal: only when necessary, single, double: all string values)")
+	cmd.Flags().StringVar(&s.yamlFlowStyle, "flow-style", string(yamlmeta.YAMLFlowBlock), "Flow style usage for maps and arrays in YAML output (block, always, leaf: only ones that contain scalars)")
+	cmd.Flags().BoolVar(&s.jsonArrayNulls, "json-array-nulls", false, "Include null documents as null items in json-array output (skipped by default)")
+	cmd.Flags().StringVar(&s.jsonIndent, "json-indent", "", "Indent JSON output with given number of spaces or given string (default is compact output)")
+	cmd.Flags().StringVar(&s.trailingNewline, "trailing-newline", string(trailingNewlineAuto), "Trailing newline of stdout output (auto: as produced by output type, always: ensure output ends with newline, never: remove trailing newlines)")
+	cmd.Flags().BoolVar(&s.outputGzip, "output-gzip", false, "Gzip compress output (appends .gz to file names in output directory)")
+	cmd.Flags().StringVar(&s.outputManifest, "output-manifest", "", "Write manifest listing written files into output directory; "+
+		"unmodified files listed in previous manifest, but not written anymore, are removed (optional relative path, eg --output-manifest=manifest.json)")
+	cmd.Flags().Lookup("output-manifest").NoOptDefVal = files.DefaultManifestFileName
+	cmd.Flags().StringVar(&s.outputFileExt, "out-file-extension", "", "Replace extension of all files written to output directory, except binary ones (eg yaml)")
+	cmd.Flags().BoolVar(&s.outputFollowLinks, "output-follow-symlinks", false, "Write files through existing symlinks in output directory to their targets (by default symlinks are replaced with regular files)")
+	cmd.Flags().StringVar(&s.progress, "progress", cmdcore.ProgressAuto, "Show number of files written to output directory on stderr (auto: only on terminal when writing many files, always, never)")
+	cmd.Flags().BoolVar(&s.dryRun, "dry-run", false, "Render templates without writing output")
+	cmd.Flags().BoolVar(&s.plan, "plan", false, "Print sorted paths of files that would be written to output directory (after file marks and output options are applied) to stdout without writing them")
+	cmd.Flags().StringArrayVar(&s.outputFilters, "output-filter", nil, "Only output documents whose value at dotted key path matches (format: 'path=value' or 'path!=value') (can be specified multiple times, all must match)")
+	cmd.Flags().StringSliceVar(&s.sortDocuments, "sort-documents", nil, "Order output documents by values at dotted key paths; documents lacking keys sort last (format: key1,key2) (eg kind,metadata.name)")
+	cmd.Flags().BoolVar(&s.onlyChanged, "only-changed", false, "Only print documents that were added or modified compared to --baseline-directory (changes are listed in trailing comment)")
+	cmd.Flags().StringVar(&s.baselineDir, "baseline-directory", "", "Directory with previous render used by --only-changed (eg written via --output-directory)")
+	cmd.Flags().BoolVar(&s.sourceChecksum, "annotate-source-checksum", false, "Add '"+sourceChecksumAnnotation+"' annotation with sha256 of input file to each output document that has metadata map")
+	cmd.Flags().StringArrayVar(&s.redactPaths, "redact", nil, "Replace values at JSON pointer with '"+yamlmeta.RedactedValue+"' in printed output; '*' matches any key or index (eg '/data/*') (can be specified multiple times)")
+	cmd.Flags().BoolVar(&s.redactOutput, "redact-output", false, "Also apply --redact to files written to --output-directory (by default real values are written)")
+	cmd.Flags().BoolVar(&s.requireMatch, "require-match", false, "Fail if --output-filter does not match any document")
+	cmd.Flags().StringVar(&s.postProcess, "post-process", "", "Pipe output to given command and use its stdout instead (format: 'cmd arg1 arg2'); "+
+		"runs once for stdout or --output-file, and once per file with --output-directory (requires --dangerous-allow-post-process)")
+	cmd.Flags().BoolVar(&s.allowPostProcess, "dangerous-allow-post-process", false, "Allow running command given via --post-process")
+	cmd.Flags().BoolVar(&s.rejectEmptyDocs, "reject-empty-docs", false, "Fail if any output document is null, empty map or array, or whitespace-only string")
 
 	cmd.Flags().BoolVar(&s.SymlinkAllowOpts.AllowAll, "dangerous-allow-all-symlink-destinations", false,
 		"Symlinks to all destinations are allowed")
 	cmd.Flags().StringSliceVar(&s.SymlinkAllowOpts.AllowedDstPaths, "allow-symlink-destination", nil,
-		"File paths to which symlinks are allowed (can be specified multiple times)")
+		"File paths to which symlinks are allowed; path segments may be glob patterns, '**' spans directories (can be specified multiple times)")
 }
 
 type RegularFilesSource struct {
@@ -44,45 +191,425 @@ func NewRegularFilesSource(opts RegularFilesSourceOpts, ui cmdcore.PlainUI) *Reg
 	return &RegularFilesSource{opts, ui}
 }
 
-func (s *RegularFilesSource) HasInput() bool  { return len(s.opts.files) > 0 }
+func (s *RegularFilesSource) HasInput() bool {
+	return len(s.opts.files) > 0 || len(s.opts.fileSpecs) > 0
+}
+
 func (s *RegularFilesSource) HasOutput() bool { return true }
 
 func (s *RegularFilesSource) Input() (TemplateInput, error) {
-	filesToProcess, err := files.NewSortedFilesFromPaths(s.opts.files, s.opts.SymlinkAllowOpts)
+	// Fail before (possibly slow) evaluation of templates
+	err := s.checkOutputTypes()
 	if err != nil {
 		return TemplateInput{}, err
 	}
 
+	httpHeaders, err := s.httpHeaders()
+	if err != nil {
+		return TemplateInput{}, err
+	}
+
+	defaultType, err := s.inputFormatType()
+	if err != nil {
+		return TemplateInput{}, err
+	}
+
+	var encoding files.Encoding
+
+	if len(s.opts.inputEncoding) > 0 {
+		encoding, err = files.ParseEncoding(s.opts.inputEncoding)
+		if err != nil {
+			return TemplateInput{}, err
+		}
+	}
+
+	sourceOpts := files.SourceOpts{
+		SymlinkAllowOpts: s.opts.SymlinkAllowOpts,
+		HTTPSourceOpts:   files.HTTPSourceOpts{Headers: httpHeaders, Timeout: s.opts.fileTimeout},
+		GitSourceOpts:    files.GitSourceOpts{Token: s.opts.fileGitToken},
+		OCISourceOpts:    files.OCISourceOpts{PlainHTTP: s.opts.fileOCIPlainHTTP, Timeout: s.opts.fileTimeout},
+		K8sSourceOpts:    files.K8sSourceOpts{Kubeconfig: s.opts.fileK8sKubeconfig, Context: s.opts.fileK8sContext, Timeout: s.opts.fileTimeout},
+		StdinFormat:      s.opts.fileStdinFormat,
+		StdinPath:        s.opts.stdinPath,
+		ArchiveMaxSize:   s.opts.fileArchiveMaxSize,
+		MaxFileSize:      s.opts.maxFileSize,
+		ReadConcurrency:  s.opts.readConcurrency,
+		NoIgnoreFile:     s.opts.fileNoIgnore,
+		NoGlob:           s.opts.fileNoGlob,
+		AllowEmptyGlob:   s.opts.fileAllowEmptyGlob,
+		DefaultType:      defaultType,
+		Encoding:         encoding,
+		DetectShebang:    s.opts.detectShebang,
+
+		AllowDuplicatePaths: s.opts.fileAllowDupPaths,
+		NaturalSort:         s.opts.fileNaturalSort,
+	}
+
+	filesToProcess, err := files.NewSortedFilesFromPaths(s.opts.files, sourceOpts)
+	if err != nil {
+		return TemplateInput{}, err
+	}
+
+	if len(s.opts.fileSpecs) > 0 {
+		specFiles, err := s.fileSpecFiles(sourceOpts)
+		if err != nil {
+			return TemplateInput{}, err
+		}
+		// Files from specs follow files given via --file
+		filesToProcess = files.NewSortedFiles(append(filesToProcess, specFiles...))
+	}
+
 	filesToProcess, err = s.applyFileMarks(filesToProcess)
 	if err != nil {
 		return TemplateInput{}, err
 	}
 
+	if len(s.opts.fileOrder) > 0 {
+		filesToProcess, err = files.NewSortedFilesWithOrder(filesToProcess, s.opts.fileOrder)
+		if err != nil {
+			return TemplateInput{}, err
+		}
+	}
+
+	if s.opts.fileTrace {
+		s.printFilesTrace(filesToProcess)
+	}
+
+	err = s.checkLock(filesToProcess)
+	if err != nil {
+		return TemplateInput{}, err
+	}
+
 	return TemplateInput{Files: filesToProcess}, nil
 }
 
+func (s *RegularFilesSource) printFilesTrace(filesToProcess []*files.File) {
+	buf := new(bytes.Buffer)
+	writer := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "Path\tType\tTemplate\tFor output\tOriginal path\n")
+
+	for _, file := range filesToProcess {
+		path := file.RelativePath()
+		if len(file.OutputSubdir()) > 0 {
+			path += " (output: " + file.OutputSubdir() + "/" + file.RelativePath() + ")"
+		}
+
+		origPath := ""
+		if file.OriginalRelativePath() != file.RelativePath() {
+			origPath = file.OriginalRelativePath()
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%t\t%t\t%s\n", path, file.Type(), file.IsTemplate(), file.IsForOutput(), origPath)
+	}
+
+	writer.Flush()
+
+	// Color header after alignment since escape codes would affect column widths
+	lines := strings.SplitN(buf.String(), "\n", 2)
+	fmt.Fprintf(s.ui.ErrWriter(), "%s\n%s", s.ui.Bold(lines[0]), lines[1])
+}
+
+func (s *RegularFilesSource) inputFormatType() (*files.Type, error) {
+	var result files.Type
+
+	switch s.opts.inputFormat {
+	case "":
+		return nil, nil
+	case "yaml":
+		result = files.TypeYAML
+	case "text":
+		result = files.TypeText
+	case "starlark":
+		result = files.TypeStarlark
+	case "data":
+		result = files.TypeUnknown
+	default:
+		return nil, fmt.Errorf("Unknown input format '%s' (expected yaml, text, starlark or data)", s.opts.inputFormat)
+	}
+
+	return &result, nil
+}
+
+var (
+	// URL is matched non-greedily since it contains colons itself (eg https://host:8080/path)
+	fileHeaderRegexp = regexp.MustCompile(`^(.+?):([A-Za-z0-9-]+)=(.*)$`)
+)
+
+func (s *RegularFilesSource) httpHeaders() ([]files.HTTPHeader, error) {
+	var result []files.HTTPHeader
+
+	for _, header := range s.opts.fileHeaders {
+		submatches := fileHeaderRegexp.FindStringSubmatch(header)
+		if len(submatches) != 4 {
+			return nil, fmt.Errorf("Expected file header '%s' to be in format url:Header-Name=value", header)
+		}
+		prefixURL, err := url.Parse(submatches[1])
+		if err != nil || len(prefixURL.Scheme) == 0 || len(prefixURL.Host) == 0 {
+			return nil, fmt.Errorf("Expected file header '%s' URL to include scheme and host (eg https://example.com/)", header)
+		}
+		result = append(result, files.HTTPHeader{
+			URLPrefix: submatches[1],
+			Name:      submatches[2],
+			Value:     submatches[3],
+		})
+	}
+
+	return result, nil
+}
+
 func (s *RegularFilesSource) Output(out TemplateOutput) error {
 	if out.Err != nil {
 		return out.Err
 	}
 
-	if len(s.opts.outputDir) > 0 {
-		return files.NewOutputDirectory(s.opts.outputDir, out.Files, s.ui).Write()
+	if s.opts.rejectEmptyDocs {
+		err := s.checkEmptyDocs(out)
+		if err != nil {
+			return err
+		}
 	}
 
-	var printerFunc func(io.Writer) yamlmeta.DocumentPrinter
+	if len(s.opts.postProcess) > 0 && !s.opts.allowPostProcess {
+		return fmt.Errorf("Expected --dangerous-allow-post-process to be specified to allow running --post-process command")
+	}
+
+	if s.opts.outputSplit && len(s.opts.outputDir) == 0 {
+		return fmt.Errorf("Expected --output-files-split to be used together with --output-directory")
+	}
+
+	if s.opts.outputDirDiff && len(s.opts.outputDir) == 0 {
+		return fmt.Errorf("Expected --output-directory-diff to be used together with --output-directory")
+	}
+
+	if len(s.opts.outputManifest) > 0 && len(s.opts.outputDir) == 0 {
+		return fmt.Errorf("Expected --output-manifest to be used together with --output-directory")
+	}
+
+	if s.opts.plan {
+		if len(s.opts.outputDir) == 0 {
+			return fmt.Errorf("Expected --plan to be used together with --output-directory")
+		}
+		if s.opts.dryRun || s.opts.outputDirDiff {
+			return fmt.Errorf("Expected --plan to not be used with --dry-run or --output-directory-diff")
+		}
+	}
+
+	newlineMode, err := s.trailingNewlineMode()
+	if err != nil {
+		return err
+	}
+
+	if s.opts.jsonArrayNulls && s.opts.outputType != "json-array" {
+		return fmt.Errorf("Expected --json-array-nulls to be used with json-array output type")
+	}
+
+	if len(s.opts.outputFileExt) > 0 && (len(s.opts.outputDir) == 0 || len(s.opts.outputFile) > 0) {
+		return fmt.Errorf("Expected --out-file-extension to be used together with --output-directory (and not --output-file)")
+	}
+
+	if len(s.opts.outputFilters) > 0 {
+		if len(s.opts.outputDir) > 0 && len(s.opts.outputFile) == 0 {
+			return fmt.Errorf("Expected --output-filter to not be used with --output-directory (unless --output-file is specified)")
+		}
+
+		filteredDocSet, err := s.filterDocSet(out.DocSet)
+		if err != nil {
+			return err
+		}
+		out.DocSet = filteredDocSet
+	} else if s.opts.requireMatch {
+		return fmt.Errorf("Expected --require-match to be used together with --output-filter")
+	}
+
+	if len(s.opts.sortDocuments) > 0 {
+		if len(s.opts.outputDir) > 0 && len(s.opts.outputFile) == 0 {
+			return fmt.Errorf("Expected --sort-documents to not be used with --output-directory (unless --output-file is specified)")
+		}
+
+		err := yamlmeta.SortDocuments(out.DocSet, s.opts.sortDocuments)
+		if err != nil {
+			return fmt.Errorf("Sorting documents: %s", err)
+		}
+	}
+
+	var onlyChangedTrailer []byte
+
+	if s.opts.onlyChanged {
+		if len(s.opts.baselineDir) == 0 {
+			return fmt.Errorf("Expected --only-changed to be used together with --baseline-directory")
+		}
+		if len(s.opts.outputDir) > 0 && len(s.opts.outputFile) == 0 {
+			return fmt.Errorf("Expected --only-changed to not be used with --output-directory (unless --output-file is specified)")
+		}
+		if s.opts.outputType != "yaml" && s.opts.outputType != "yaml-stream" {
+			return fmt.Errorf("Expected --only-changed to be used with yaml or yaml-stream output type, but was '%s'", s.opts.outputType)
+		}
+
+		changedDocSet, trailer, err := s.onlyChanged(out)
+		if err != nil {
+			return err
+		}
+		out.DocSet = changedDocSet
+		onlyChangedTrailer = trailer
+	} else if len(s.opts.baselineDir) > 0 {
+		return fmt.Errorf("Expected --baseline-directory to be used together with --only-changed")
+	}
+
+	if s.opts.sourceChecksum {
+		if len(s.opts.outputDir) > 0 && len(s.opts.outputFile) == 0 {
+			return fmt.Errorf("Expected --annotate-source-checksum to not be used with --output-directory (unless --output-file is specified)")
+		}
+
+		annotatedDocSet, err := s.annotateSourceChecksums(out)
+		if err != nil {
+			return err
+		}
+		out.DocSet = annotatedDocSet
+	}
+
+	if len(s.opts.redactPaths) > 0 {
+		redactedOut, err := s.redact(out)
+		if err != nil {
+			return err
+		}
+		out = redactedOut
+	} else if s.opts.redactOutput {
+		return fmt.Errorf("Expected --redact-output to be used together with --redact")
+	}
+
+	if out.OverlayTrace != nil {
+		if len(s.opts.outputDir) > 0 {
+			return fmt.Errorf("Expected --overlay-trace to be used only when printing to stdout (not with --output-directory)")
+		}
+		if s.opts.dryRun {
+			return nil
+		}
+		return out.DocSet.WriteWithPrinter(s.ui.Writer(), func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yttoverlay.NewTracePrinter(w, out.OverlayTrace)
+		})
+	}
+
+	if len(s.opts.outputFile) > 0 {
+		if len(s.opts.outputDir) == 0 {
+			return fmt.Errorf("Expected --output-file to be used together with --output-directory")
+		}
+		if s.opts.outputSplit {
+			return fmt.Errorf("Expected only one of --output-file or --output-files-split to be specified")
+		}
+		if err := s.checkOutputFilePath(); err != nil {
+			return err
+		}
+	}
+
+	outputTypes := strings.Split(s.opts.outputType, ",")
+
+	if len(s.opts.outputDir) > 0 && len(s.opts.outputFile) == 0 {
+		dirOpts := files.OutputDirectoryOpts{
+			SplitDocuments:    s.opts.outputSplit,
+			SplitNameTemplate: s.opts.outputSplitNameTpl,
+			Mode:              files.OutputDirectoryMode(s.opts.outputDirMode),
+			Gzip:              s.opts.outputGzip,
+			ManifestPath:      s.opts.outputManifest,
+			FollowSymlinks:    s.opts.outputFollowLinks,
+		}
+
+		// Post processing does not change paths, hence commands do not need to run for plan
+		if len(s.opts.postProcess) > 0 && !s.opts.plan {
+			dirOpts.PostProcess = s.postProcess
+		}
+
+		if len(s.opts.outputFileExt) > 0 {
+			ext, err := s.outputFileExtension()
+			if err != nil {
+				return err
+			}
+			dirOpts.FileExtension = ext
+		}
+
+		progressFunc, err := cmdcore.ProgressFunc(s.opts.progress, s.ui.ErrWriter())
+		if err != nil {
+			return err
+		}
+		if !s.ui.Quiet() {
+			dirOpts.Progress = progressFunc
+		}
+
+		// Keep files as is unless other output types (or formatting) are requested
+		if s.opts.outputType != "yaml" || !s.hasDefaultYAMLFormatting() {
+			for _, outputType := range outputTypes {
+				format, err := s.outputFormat(outputType)
+				if err != nil {
+					return err
+				}
+				dirOpts.Formats = append(dirOpts.Formats, format)
+			}
+		}
+
+		if s.opts.dryRun {
+			return nil
+		}
+		return s.writeOutputDirectory(files.NewOutputDirectoryWithOpts(s.opts.outputDir, out.Files, s.ui, dirOpts))
+	}
+
+	if len(outputTypes) > 1 {
+		return fmt.Errorf("Expected single output type when printing to stdout or --output-file, but was '%s' "+
+			"(multiple output types require --output-directory)", s.opts.outputType)
+	}
+
+	printerFunc, err := s.printerFunc(s.opts.outputType)
+	if err != nil {
+		return err
+	}
+
+	var posPrinters []*yamlmeta.FilePositionPrinter
+
+	if len(s.opts.posQuery) > 0 {
+		if s.opts.outputType != "pos" {
+			return fmt.Errorf("Expected --pos-query to be used with pos output type")
+		}
+
+		query, err := yamlmeta.ParseJSONPointer(s.opts.posQuery)
+		if err != nil {
+			return fmt.Errorf("Parsing --pos-query: %s", err)
+		}
 
-	switch s.opts.outputType {
-	case "yaml":
-		printerFunc = nil
-	case "json":
-		printerFunc = func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONPrinter(w) }
-	case "pos":
 		printerFunc = func(w io.Writer) yamlmeta.DocumentPrinter {
-			return yamlmeta.WrappedFilePositionPrinter{yamlmeta.NewFilePositionPrinter(w)}
+			printer := yamlmeta.NewFilePositionPrinterWithOpts(w, yamlmeta.FilePositionPrinterOpts{Query: query})
+			posPrinters = append(posPrinters, printer)
+			return yamlmeta.WrappedFilePositionPrinter{Printer: printer}
 		}
-	default:
-		return fmt.Errorf("Unknown output type '%s'", s.opts.outputType)
+	}
+
+	switch s.opts.outputType {
+	case "k8s-list":
+		out.DocSet = yamlmeta.NewK8sListDocSet(out.DocSet)
+	case "json-array":
+		out.DocSet = yamlmeta.NewArrayDocSet(out.DocSet, s.opts.jsonArrayNulls)
+	}
+
+	// Checksums are calculated over canonical form so that
+	// semantically equal results (eg differing key order) match
+	_, isChecksum := checksumOutputTypes[s.opts.outputType]
+
+	if s.opts.sortKeys || isChecksum {
+		yamlmeta.SortKeys(out.DocSet)
+	}
+
+	encodeFunc := s.encodeFunc(s.opts.outputType)
+
+	// Stream documents to stdout unless combined result is needed as a whole
+	// or printer may fail after some documents were already printed
+	if streamableOutputType(s.opts.outputType) && len(s.opts.outputFile) == 0 && encodeFunc == nil &&
+		len(s.opts.postProcess) == 0 && !s.opts.onlyChanged && !s.opts.dryRun {
+		s.ui.Debugf("### result\n")
+
+		err := s.writeCombinedDocSet(out.DocSet, printerFunc, newlineMode)
+		if err != nil {
+			return fmt.Errorf("Marshaling combined template result: %s", err)
+		}
+
+		return s.checkPosQueryMatched(posPrinters)
 	}
 
 	combinedDocBytes, err := out.DocSet.AsBytesWithPrinter(printerFunc)
@@ -90,14 +617,335 @@ func (s *RegularFilesSource) Output(out TemplateOutput) error {
 		return fmt.Errorf("Marshaling combined template result: %s", err)
 	}
 
+	err = s.checkPosQueryMatched(posPrinters)
+	if err != nil {
+		return err
+	}
+
+	combinedDocBytes = append(combinedDocBytes, onlyChangedTrailer...)
+
+	if encodeFunc != nil {
+		combinedDocBytes = encodeFunc(combinedDocBytes)
+	}
+
+	// Marshaling above still catches errors (eg unsupported values for TOML)
+	if s.opts.dryRun {
+		return nil
+	}
+
+	if len(s.opts.postProcess) > 0 && !s.opts.plan {
+		combinedDocBytes, err = s.postProcess(s.opts.outputFile, combinedDocBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(s.opts.outputFile) > 0 {
+		dirOpts := files.OutputDirectoryOpts{
+			Mode:           files.OutputDirectoryMode(s.opts.outputDirMode),
+			Gzip:           s.opts.outputGzip,
+			ManifestPath:   s.opts.outputManifest,
+			FollowSymlinks: s.opts.outputFollowLinks,
+		}
+		outputFiles := []files.OutputFile{
+			files.NewOutputFileWithDocSet(s.opts.outputFile, combinedDocBytes, out.DocSet),
+		}
+		return s.writeOutputDirectory(files.NewOutputDirectoryWithOpts(s.opts.outputDir, outputFiles, s.ui, dirOpts))
+	}
+
 	s.ui.Debugf("### result\n")
+
+	combinedDocBytes = withTrailingNewline(combinedDocBytes, newlineMode)
+
+	if s.opts.outputGzip {
+		return files.WriteGzip(s.ui.Writer(), combinedDocBytes)
+	}
+
 	s.ui.Printf("%s", combinedDocBytes) // no newline
 
 	return nil
 }
 
+func (s *RegularFilesSource) writeCombinedDocSet(docSet *yamlmeta.DocumentSet,
+	printerFunc func(io.Writer) yamlmeta.DocumentPrinter, newlineMode trailingNewlineMode) error {
+
+	writeFunc := func(writer io.Writer) error {
+		newlineWriter := newTrailingNewlineWriter(writer, newlineMode)
+
+		err := docSet.WriteWithPrinter(newlineWriter, printerFunc)
+		if err != nil {
+			return err
+		}
+		return newlineWriter.Close()
+	}
+
+	if !s.opts.outputGzip {
+		return writeFunc(s.ui.Writer())
+	}
+
+	gzipWriter := gzip.NewWriter(s.ui.Writer())
+
+	err := writeFunc(gzipWriter)
+	if err != nil {
+		gzipWriter.Close()
+		return err
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return fmt.Errorf("Compressing: %s", err)
+	}
+
+	return nil
+}
+
+func (s *RegularFilesSource) checkPosQueryMatched(posPrinters []*yamlmeta.FilePositionPrinter) error {
+	for _, printer := range posPrinters {
+		if !printer.Matched() {
+			return fmt.Errorf("Expected --pos-query '%s' to match node in at least one document, but did not", s.opts.posQuery)
+		}
+	}
+	return nil
+}
+
+func (s *RegularFilesSource) writeOutputDirectory(outputDir *files.OutputDirectory) error {
+	if s.opts.plan {
+		paths, err := outputDir.Paths()
+		if err != nil {
+			return err
+		}
+
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			s.ui.Printf("%s\n", path)
+		}
+		return nil
+	}
+
+	if !s.opts.outputDirDiff {
+		return outputDir.Write()
+	}
+
+	diff, err := outputDir.Diff()
+	if err != nil {
+		return err
+	}
+
+	if diff.HasChanges() {
+		return fmt.Errorf("Expected output directory '%s' to match rendered files, but found differences", s.opts.outputDir)
+	}
+
+	return nil
+}
+
+func (s *RegularFilesSource) checkEmptyDocs(out TemplateOutput) error {
+	// Empty documents are typically left out of DocSet,
+	// though some may still be produced (eg by overlays)
+	docs := append([]*yamlmeta.Document{}, out.EmptyDocs...)
+	if out.DocSet != nil {
+		docs = append(docs, out.DocSet.Items...)
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		posI, posJ := docs[i].Position, docs[j].Position
+		if !posI.IsKnown() || !posJ.IsKnown() {
+			return posI.IsKnown()
+		}
+		if posI.File() != posJ.File() {
+			return posI.File() < posJ.File()
+		}
+		return posI.Line() < posJ.Line()
+	})
+
+	var msgs []string
+
+	for _, doc := range docs {
+		kind, isEmpty := doc.EmptyKind()
+		if !isEmpty {
+			continue
+		}
+
+		posStr := "unknown position"
+		if doc.Position.IsKnown() {
+			posStr = doc.Position.AsCompactString()
+		}
+		msgs = append(msgs, fmt.Sprintf("- %s document (%s)", kind, posStr))
+	}
+
+	if len(msgs) > 0 {
+		return fmt.Errorf("Expected output documents to not be empty (--reject-empty-docs), but found:\n%s", strings.Join(msgs, "\n"))
+	}
+
+	return nil
+}
+
+func (s *RegularFilesSource) filterDocSet(docSet *yamlmeta.DocumentSet) (*yamlmeta.DocumentSet, error) {
+	var filters []yamlmeta.DocumentFilter
+
+	for _, filterStr := range s.opts.outputFilters {
+		filter, err := yamlmeta.ParseDocumentFilter(filterStr)
+		if err != nil {
+			return nil, fmt.Errorf("Parsing --output-filter: %s", err)
+		}
+		filters = append(filters, filter)
+	}
+
+	result := yamlmeta.FilterDocumentSet(docSet, filters)
+
+	if s.opts.requireMatch && len(result.Items) == 0 {
+		return nil, fmt.Errorf("Expected --output-filter '%s' to match at least one document (--require-match), but did not",
+			strings.Join(s.opts.outputFilters, "' and '"))
+	}
+
+	return result, nil
+}
+
+// outputFileExtension returns --out-file-extension with leading dot (eg '.yaml')
+func (s *RegularFilesSource) outputFileExtension() (string, error) {
+	ext := "." + strings.TrimPrefix(s.opts.outputFileExt, ".")
+
+	if ext == "." || strings.ContainsAny(ext[1:], "./\\") {
+		return "", fmt.Errorf("Expected --out-file-extension '%s' to be a single extension (eg 'yaml')", s.opts.outputFileExt)
+	}
+
+	return ext, nil
+}
+
+func (s *RegularFilesSource) checkOutputFilePath() error {
+	cleanPath := filepath.Clean(s.opts.outputFile)
+
+	if filepath.IsAbs(cleanPath) || cleanPath == "." || cleanPath == ".." ||
+		strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("Expected --output-file '%s' to be a relative file path within output directory", s.opts.outputFile)
+	}
+
+	return nil
+}
+
+var (
+	checksumOutputTypes = map[string]func() hash.Hash{
+		"sha256": sha256.New,
+		"sha512": sha512.New,
+	}
+
+	// YAML based output types keep original file extension
+	outputTypeExts = map[string]string{
+		"json":        ".json",
+		"json-stream": ".jsonl",
+		"toml":        ".toml",
+		"hcl":         ".hcl",
+		"csv":         ".csv",
+		"xml":         ".xml",
+		"dotenv":      ".env",
+		"properties":  ".properties",
+		"base64":      ".b64",
+	}
+)
+
+func (s *RegularFilesSource) outputFormat(outputType string) (files.OutputFormat, error) {
+	_, isChecksum := checksumOutputTypes[outputType]
+
+	if outputType == "pos" || outputType == "pos-full" || outputType == "pos-lsp" ||
+		outputType == "k8s-list" || outputType == "json-array" || isChecksum {
+		return files.OutputFormat{}, fmt.Errorf("Expected output type '%s' to not be used with --output-directory", outputType)
+	}
+
+	printerFunc, err := s.printerFunc(outputType)
+	if err != nil {
+		return files.OutputFormat{}, err
+	}
+
+	return files.OutputFormat{
+		Ext:     outputTypeExts[outputType],
+		Printer: printerFunc,
+		Encode:  s.encodeFunc(outputType),
+	}, nil
+}
+
+// encodeFunc returns function that transforms marshaled
+// output for given output type (nil if output is kept as is)
+func (s *RegularFilesSource) encodeFunc(outputType string) func([]byte) []byte {
+	if newHash, found := checksumOutputTypes[outputType]; found {
+		return func(data []byte) []byte { return files.EncodeChecksum(data, newHash, s.opts.checksumName) }
+	}
+
+	if outputType != "base64" {
+		return nil
+	}
+
+	var wrapColumns int
+	if s.opts.base64Wrap {
+		wrapColumns = files.Base64MIMEWrapColumns
+	}
+
+	return func(data []byte) []byte { return files.EncodeBase64(data, wrapColumns) }
+}
+
+const (
+	// defaultYAMLLineWidth and defaultYAMLIndent match ones used by YAML library
+	defaultYAMLLineWidth = 80
+	defaultYAMLIndent    = 2
+)
+
+func (s *RegularFilesSource) hasDefaultYAMLFormatting() bool {
+	return s.opts.yamlLineWidth == defaultYAMLLineWidth && s.opts.yamlIndent == defaultYAMLIndent &&
+		s.opts.yamlQuoteStyle == string(yamlmeta.YAMLQuoteMinimal) && s.opts.yamlFlowStyle == string(yamlmeta.YAMLFlowBlock)
+}
+
+func (s *RegularFilesSource) yamlPrinterOpts() (yamlmeta.YAMLPrinterOpts, error) {
+	var opts yamlmeta.YAMLPrinterOpts
+
+	switch {
+	case s.opts.yamlLineWidth == 0 || s.opts.yamlLineWidth == -1:
+		opts.LineWidth = -1
+	case s.opts.yamlLineWidth > 4:
+		opts.LineWidth = s.opts.yamlLineWidth
+	default:
+		// YAML library ignores widths that are not wider than indentation
+		return yamlmeta.YAMLPrinterOpts{}, fmt.Errorf("Expected --yaml-line-width to be greater than 4 "+
+			"(or 0 or -1 to disable folding), but was %d", s.opts.yamlLineWidth)
+	}
+
+	// YAML library ignores indentation outside of this range
+	if s.opts.yamlIndent < 2 || s.opts.yamlIndent > 9 {
+		return yamlmeta.YAMLPrinterOpts{}, fmt.Errorf("Expected --indent to be between 2 and 9, but was %d", s.opts.yamlIndent)
+	}
+	opts.Indent = s.opts.yamlIndent
+
+	if opts.LineWidth > 0 && opts.LineWidth <= 2*opts.Indent {
+		return yamlmeta.YAMLPrinterOpts{}, fmt.Errorf("Expected --yaml-line-width to be greater than "+
+			"twice the indentation (%d), but was %d", 2*opts.Indent, opts.LineWidth)
+	}
+
+	var err error
+
+	opts.QuoteStyle, err = yamlmeta.ParseYAMLQuoteStyle(s.opts.yamlQuoteStyle)
+	if err != nil {
+		return yamlmeta.YAMLPrinterOpts{}, err
+	}
+
+	opts.FlowStyle, err = yamlmeta.ParseYAMLFlowStyle(s.opts.yamlFlowStyle)
+	if err != nil {
+		return yamlmeta.YAMLPrinterOpts{}, err
+	}
+
+	return opts, nil
+}
+
+func (s *RegularFilesSource) jsonIndentStr() string {
+	// Numeric value is treated as number of spaces (eg --json-indent 2)
+	if num, err := strconv.Atoi(s.opts.jsonIndent); err == nil && num >= 0 {
+		return strings.Repeat(" ", num)
+	}
+	return s.opts.jsonIndent
+}
+
 func (s *RegularFilesSource) applyFileMarks(filesToProcess []*files.File) ([]*files.File, error) {
 	var exclusiveForOutputFiles []*files.File
+	var nonForOutputFiles []*files.File
+	renamedFiles := map[*files.File]string{}
+	subdirFiles := map[*files.File]string{}
 
 	for _, mark := range s.opts.fileMarks {
 		pieces := strings.SplitN(mark, ":", 2)
@@ -105,23 +953,81 @@ func (s *RegularFilesSource) applyFileMarks(filesToProcess []*files.File) ([]*fi
 			return nil, fmt.Errorf("Expected file mark '%s' to be in format path:key=value", mark)
 		}
 
-		path := pieces[0]
+		paths, err := s.expandFileMarkPath(pieces[0])
+		if err != nil {
+			return nil, fmt.Errorf("Expanding file mark '%s' path: %s", mark, err)
+		}
+
+		kvsStr := pieces[1]
 
-		kv := strings.SplitN(pieces[1], "=", 2)
-		if len(kv) != 2 {
-			return nil, fmt.Errorf("Expected file mark '%s' key-value portion to be in format key=value", mark)
+		// Paths are matched against original relative paths
+		// unless mark asks for current (resolved) paths
+		matchResolved := strings.HasPrefix(kvsStr, fileMarkResolvedPrefix)
+		if matchResolved {
+			kvsStr = strings.TrimPrefix(kvsStr, fileMarkResolvedPrefix)
+		}
+
+		kvs, err := s.fileMarkKVs(mark, kvsStr)
+		if err != nil {
+			return nil, err
 		}
 
-		var matched bool
+		// Files are matched once per mark (before any of its pairs are applied)
+		var matchedIdxs []int
 
 		for i, file := range filesToProcess {
-			if s.fileMarkMatches(file, path) {
-				matched = true
+			// Files may have been excluded by preceding marks
+			if file != nil && s.fileMarkMatches(file, paths, matchResolved) {
+				matchedIdxs = append(matchedIdxs, i)
+			}
+		}
+
+		if len(matchedIdxs) == 0 {
+			return nil, fmt.Errorf("Expected file mark '%s' to match at least one file by path, but did not", mark)
+		}
+
+		// Multiple pairs are applied in order, same as separate marks
+		for _, kv := range kvs {
+			for _, i := range matchedIdxs {
+				file := filesToProcess[i]
+				if file == nil {
+					continue // excluded by preceding pair
+				}
 
 				switch kv[0] {
 				case "path":
 					file.MarkRelativePath(kv[1])
 
+				case "encoding":
+					enc, err := files.ParseEncoding(kv[1])
+					if err != nil {
+						return nil, fmt.Errorf("Applying file mark '%s': %s", mark, err)
+					}
+					file.MarkEncoding(enc)
+
+				case "path-template":
+					err := files.CheckOutputPathTemplate(kv[1])
+					if err != nil {
+						return nil, fmt.Errorf("Applying file mark '%s': %s", mark, err)
+					}
+					file.MarkOutputPathTemplate(kv[1])
+
+				case "rename-regex":
+					newPath, err := s.renameRegexPath(file.RelativePath(), kv[1])
+					if err != nil {
+						return nil, fmt.Errorf("Applying file mark '%s': %s", mark, err)
+					}
+					file.MarkRelativePath(newPath)
+					renamedFiles[file] = mark
+
+				case "output-subdir":
+					cleanPath := filepath.ToSlash(filepath.Clean(kv[1]))
+					if filepath.IsAbs(kv[1]) || cleanPath == "." || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+						return nil, fmt.Errorf("Expected file mark '%s' value to be a relative directory path within output directory", mark)
+					}
+					file.MarkOutputSubdir(cleanPath)
+					subdirFiles[file] = mark
+
 				case "exclude":
 					switch kv[1] {
 					case "true":
@@ -131,24 +1037,15 @@ func (s *RegularFilesSource) applyFileMarks(filesToProcess []*files.File) ([]*fi
 					}
 
 				case "type":
+					if !markFileType(file, kv[1]) {
+						return nil, fmt.Errorf("Unknown value in file mark '%s'", mark)
+					}
+
+				case "template":
 					switch kv[1] {
-					case "yaml-template": // yaml template processing
-						file.MarkType(files.TypeYAML)
-						file.MarkTemplate(true)
-					case "yaml-plain": // no template processing
-						file.MarkType(files.TypeYAML)
-						file.MarkTemplate(false)
-					case "text-template":
-						file.MarkType(files.TypeText)
+					case "true":
 						file.MarkTemplate(true)
-					case "text-plain":
-						file.MarkType(files.TypeText)
-						file.MarkTemplate(false)
-					case "starlark":
-						file.MarkType(files.TypeStarlark)
-						file.MarkTemplate(false)
-					case "data":
-						file.MarkType(files.TypeUnknown)
+					case "false":
 						file.MarkTemplate(false)
 					default:
 						return nil, fmt.Errorf("Unknown value in file mark '%s'", mark)
@@ -158,10 +1055,37 @@ func (s *RegularFilesSource) applyFileMarks(filesToProcess []*files.File) ([]*fi
 					switch kv[1] {
 					case "true":
 						file.MarkForOutput(true)
+					case "false":
+						file.MarkForOutput(false)
+						nonForOutputFiles = append(nonForOutputFiles, file)
 					default:
 						return nil, fmt.Errorf("Unknown value in file mark '%s'", mark)
 					}
 
+				case "output-exclude":
+					switch kv[1] {
+					case "true":
+						// Same as for-output=false (files are still processed)
+						file.MarkForOutput(false)
+						nonForOutputFiles = append(nonForOutputFiles, file)
+					default:
+						return nil, fmt.Errorf("Unknown value in file mark '%s'", mark)
+					}
+
+				case "annotation":
+					annKV := strings.SplitN(kv[1], "=", 2)
+					if len(annKV) != 2 || len(annKV[0]) == 0 {
+						return nil, fmt.Errorf("Expected file mark '%s' annotation to be in format annotation=key=value", mark)
+					}
+					file.MarkAnnotation(annKV[0], annKV[1])
+
+				case "mode":
+					mode, err := strconv.ParseUint(kv[1], 8, 32)
+					if err != nil || mode > 0777 {
+						return nil, fmt.Errorf("Expected file mark '%s' value to be octal permissions (eg 0755)", mark)
+					}
+					file.MarkMode(os.FileMode(mode))
+
 				case "exclusive-for-output":
 					switch kv[1] {
 					case "true":
@@ -171,19 +1095,20 @@ func (s *RegularFilesSource) applyFileMarks(filesToProcess []*files.File) ([]*fi
 					}
 
 				default:
-					return nil, fmt.Errorf("Unknown key in file mark '%s'", mark)
+					return nil, fmt.Errorf("Unknown key '%s' in file mark '%s'", kv[0], mark)
 				}
 			}
 		}
-
-		if !matched {
-			return nil, fmt.Errorf("Expected file mark '%s' to match at least one file by path, but did not", mark)
-		}
 	}
 
 	// Remove files that were cleared out
 	filesToProcess = s.clearNils(filesToProcess)
 
+	err := s.checkRenamedPaths(filesToProcess, renamedFiles)
+	if err != nil {
+		return nil, err
+	}
+
 	// If there is at least filtered output file, mark all others as non-templates
 	if len(exclusiveForOutputFiles) > 0 {
 		for _, file := range filesToProcess {
@@ -194,19 +1119,255 @@ func (s *RegularFilesSource) applyFileMarks(filesToProcess []*files.File) ([]*fi
 		}
 	}
 
+	// Explicit exclusion from output (for-output=false or output-exclude=true)
+	// takes precedence over exclusive-for-output
+	for _, file := range nonForOutputFiles {
+		file.MarkForOutput(false)
+	}
+
+	err = s.checkOutputSubdirPaths(filesToProcess, subdirFiles)
+	if err != nil {
+		return nil, err
+	}
+
 	return filesToProcess, nil
 }
 
+const (
+	// Marks with this prefix (eg 'new/app.yml:@resolved:for-output=true')
+	// match against current relative paths (after preceding renames)
+	fileMarkResolvedPrefix = "@resolved:"
+)
+
 var (
-	quotedMultiLevel  = regexp.QuoteMeta("**/*")
-	quotedSingleLevel = regexp.QuoteMeta("*")
+	// Next key-value pair starts with a key (eg ',for-output=true')
+	fileMarkNextKVPrefix = regexp.MustCompile(`^[a-z][a-z-]*=`)
 )
 
-func (s *RegularFilesSource) fileMarkMatches(file *files.File, path string) bool {
-	path = regexp.QuoteMeta(path)
-	path = strings.Replace(path, quotedMultiLevel, ".+", 1)
-	path = strings.Replace(path, quotedSingleLevel, "[^/]+", 1)
-	return regexp.MustCompile("^" + path + "$").MatchString(file.OriginalRelativePath())
+// fileMarkKVs splits key-value portion of a file mark into pairs
+// separated by comma (eg 'type=text-template,for-output=true').
+// Comma only separates pairs if it's followed by a key, so that values
+// may contain commas (eg 'rename-regex=a{1,3}=b'); '\,' is a literal comma.
+func (s *RegularFilesSource) fileMarkKVs(mark, value string) ([][]string, error) {
+	var pieces []string
+	var curr strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value) && value[i+1] == ',':
+			curr.WriteByte(',')
+			i++
+		case value[i] == ',' && fileMarkNextKVPrefix.MatchString(value[i+1:]):
+			pieces = append(pieces, curr.String())
+			curr.Reset()
+		default:
+			curr.WriteByte(value[i])
+		}
+	}
+	pieces = append(pieces, curr.String())
+
+	var result [][]string
+
+	for _, piece := range pieces {
+		kv := strings.SplitN(piece, "=", 2)
+		if len(kv) != 2 {
+			if len(pieces) == 1 {
+				return nil, fmt.Errorf("Expected file mark '%s' key-value portion to be in format key=value", mark)
+			}
+			return nil, fmt.Errorf("Expected file mark '%s' key-value pair '%s' to be in format key=value", mark, piece)
+		}
+		result = append(result, kv)
+	}
+
+	return result, nil
+}
+
+var (
+	// Regexp may contain escaped '=' (ie '\=') hence split on first unescaped one
+	renameRegexpSeparator = regexp.MustCompile(`(^|[^\\])=`)
+)
+
+func (s *RegularFilesSource) renameRegexPath(path, value string) (string, error) {
+	loc := renameRegexpSeparator.FindStringIndex(value)
+	if loc == nil {
+		return "", fmt.Errorf("Expected rename-regex value to be in format regexp=replacement")
+	}
+
+	sepIdx := loc[1] - 1
+
+	re, err := regexp.Compile(value[:sepIdx])
+	if err != nil {
+		return "", fmt.Errorf("Compiling rename-regex regexp: %s", err)
+	}
+
+	newPath := re.ReplaceAllString(path, value[sepIdx+1:])
+	if len(newPath) == 0 {
+		return "", fmt.Errorf("Expected rename-regex to produce non-empty path for file '%s'", path)
+	}
+
+	return newPath, nil
+}
+
+// checkRenamedPaths ensures that files renamed via rename-regex
+// do not end up with the same path as some other file
+func (s *RegularFilesSource) checkRenamedPaths(filesToProcess []*files.File, renamedFiles map[*files.File]string) error {
+	if len(renamedFiles) == 0 {
+		return nil
+	}
+
+	filesByPath := map[string]*files.File{}
+
+	for _, file := range filesToProcess {
+		path := file.RelativePath()
+
+		if prevFile, found := filesByPath[path]; found {
+			mark, renamed := renamedFiles[file]
+			if !renamed {
+				mark = renamedFiles[prevFile]
+			}
+			if renamed || len(mark) > 0 {
+				return fmt.Errorf("Expected file mark '%s' to produce unique file paths, "+
+					"but files '%s' and '%s' both have path '%s'",
+					mark, prevFile.OriginalRelativePath(), file.OriginalRelativePath(), path)
+			}
+		}
+
+		filesByPath[path] = file
+	}
+
+	return nil
+}
+
+// markFileType changes type of the file as specified via type file mark
+// (or file spec); returns false if type is not known
+func markFileType(file *files.File, fileType string) bool {
+	switch fileType {
+	case "yaml-template": // yaml template processing
+		file.MarkType(files.TypeYAML)
+		file.MarkTemplate(true)
+	case "yaml-plain": // no template processing
+		file.MarkType(files.TypeYAML)
+		file.MarkTemplate(false)
+	case "text-template":
+		file.MarkType(files.TypeText)
+		file.MarkTemplate(true)
+	case "text-plain":
+		file.MarkType(files.TypeText)
+		file.MarkTemplate(false)
+	case "yaml-front-matter":
+		file.MarkType(files.TypeYAMLFrontMatter)
+		file.MarkTemplate(true)
+	case "env-template": // shell style variables substitution
+		file.MarkType(files.TypeEnvTemplate)
+		file.MarkTemplate(true)
+	case "json":
+		file.MarkType(files.TypeJSON)
+		file.MarkTemplate(false)
+		file.MarkForOutput(true)
+	case "starlark":
+		file.MarkType(files.TypeStarlark)
+		file.MarkTemplate(false)
+	case "data":
+		file.MarkType(files.TypeUnknown)
+		file.MarkTemplate(false)
+	case "binary": // copied to output directory as is
+		file.MarkType(files.TypeBinary)
+		file.MarkTemplate(false)
+		file.MarkForOutput(true)
+	default:
+		return false
+	}
+	return true
+}
+
+// checkOutputSubdirPaths ensures that files placed into subdirectories
+// via output-subdir are not written to the same path as some other file
+func (s *RegularFilesSource) checkOutputSubdirPaths(filesToProcess []*files.File, subdirFiles map[*files.File]string) error {
+	if len(subdirFiles) == 0 {
+		return nil
+	}
+
+	filesByPath := map[string]*files.File{}
+
+	for _, file := range filesToProcess {
+		if !file.IsForOutput() {
+			continue
+		}
+
+		path := file.RelativePath()
+		if len(file.OutputSubdir()) > 0 {
+			path = file.OutputSubdir() + "/" + path
+		}
+
+		if prevFile, found := filesByPath[path]; found {
+			mark, found := subdirFiles[file]
+			if !found {
+				mark, found = subdirFiles[prevFile]
+			}
+			if found {
+				return fmt.Errorf("Expected file mark '%s' to produce unique output paths, "+
+					"but files '%s' and '%s' are both written to '%s'",
+					mark, prevFile.OriginalRelativePath(), file.OriginalRelativePath(), path)
+			}
+		}
+
+		filesByPath[path] = file
+	}
+
+	return nil
+}
+
+func (s *RegularFilesSource) fileMarkMatches(file *files.File, paths []string, matchResolved bool) bool {
+	relPath := file.OriginalRelativePath()
+	if matchResolved {
+		relPath = file.RelativePath()
+	}
+
+	for _, path := range paths {
+		if files.NewMarkPathRegexp(path).MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandFileMarkPath expands brace alternatives (eg 'config/{prod,staging}/*.yml')
+// into multiple paths. Nested braces are not supported.
+func (s *RegularFilesSource) expandFileMarkPath(path string) ([]string, error) {
+	openIdx := strings.Index(path, "{")
+	closeIdx := strings.Index(path, "}")
+
+	switch {
+	case openIdx == -1 && closeIdx == -1:
+		return []string{path}, nil
+	case openIdx == -1 || (closeIdx != -1 && closeIdx < openIdx):
+		return nil, fmt.Errorf("Expected '}' to have matching '{'")
+	case closeIdx == -1:
+		return nil, fmt.Errorf("Expected '{' to have matching '}'")
+	}
+
+	alternatives := path[openIdx+1 : closeIdx]
+	if strings.Contains(alternatives, "{") {
+		return nil, fmt.Errorf("Nested braces are not supported")
+	}
+
+	restPaths, err := s.expandFileMarkPath(path[closeIdx+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, alt := range strings.Split(alternatives, ",") {
+		if len(alt) == 0 {
+			return nil, fmt.Errorf("Expected braces '{%s}' to not contain empty alternatives", alternatives)
+		}
+		for _, restPath := range restPaths {
+			result = append(result, path[:openIdx]+alt+restPath)
+		}
+	}
+
+	return result, nil
 }
 
 func (s *RegularFilesSource) clearNils(input []*files.File) []*files.File {
@@ -0,0 +1,617 @@
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestExpandFileMarkPath(t *testing.T) {
+	examples := []struct {
+		Path        string
+		Expected    []string
+		ExpectedErr string
+	}{
+		{Path: "config/*.yml", Expected: []string{"config/*.yml"}},
+		{Path: "config/{prod,staging}/*.yml", Expected: []string{"config/prod/*.yml", "config/staging/*.yml"}},
+		{Path: "{a,b}/**/*", Expected: []string{"a/**/*", "b/**/*"}},
+		{Path: "**/*.{yml,yaml}", Expected: []string{"**/*.yml", "**/*.yaml"}},
+		{Path: "{a,b}/{c,d}.yml", Expected: []string{"a/c.yml", "a/d.yml", "b/c.yml", "b/d.yml"}},
+		{Path: "{a}.yml", Expected: []string{"a.yml"}},
+		{Path: "{a,{b,c}}.yml", ExpectedErr: "Nested braces are not supported"},
+		{Path: "{a,}.yml", ExpectedErr: "Expected braces '{a,}' to not contain empty alternatives"},
+		{Path: "{}.yml", ExpectedErr: "Expected braces '{}' to not contain empty alternatives"},
+		{Path: "{a,b.yml", ExpectedErr: "Expected '{' to have matching '}'"},
+		{Path: "a,b}.yml", ExpectedErr: "Expected '}' to have matching '{'"},
+		{Path: "}{a,b}.yml", ExpectedErr: "Expected '}' to have matching '{'"},
+		{Path: "{a,b}/{c.yml", ExpectedErr: "Expected '{' to have matching '}'"},
+	}
+
+	for _, ex := range examples {
+		result, err := (&RegularFilesSource{}).expandFileMarkPath(ex.Path)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected expanding '%s' to fail with '%s', but was: %v", ex.Path, ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected expanding '%s' to succeed: %s", ex.Path, err)
+		}
+		if strings.Join(result, ",") != strings.Join(ex.Expected, ",") {
+			t.Fatalf("Expected '%s' to expand to %#v, but was: %#v", ex.Path, ex.Expected, result)
+		}
+	}
+}
+
+func TestExpandFileMarkPathMatches(t *testing.T) {
+	examples := []struct {
+		Path       string
+		Matches    []string
+		NonMatches []string
+	}{
+		{"{prod,staging}/*.yml", []string{"prod/a.yml", "staging/a.yml"}, []string{"dev/a.yml", "prod/x/a.yml"}},
+		{"{prod,staging}/**/*", []string{"prod/a.yml", "staging/x/y/a.yml"}, []string{"dev/x/a.yml", "a.yml"}},
+		{"**/*.{yml,yaml}", []string{"a.yml", "x/y/a.yaml"}, []string{"x/a.json"}},
+	}
+
+	for _, ex := range examples {
+		paths, err := (&RegularFilesSource{}).expandFileMarkPath(ex.Path)
+		if err != nil {
+			t.Fatalf("Expected expanding '%s' to succeed: %s", ex.Path, err)
+		}
+
+		matches := func(relPath string) bool {
+			for _, path := range paths {
+				if files.NewMarkPathRegexp(path).MatchString(relPath) {
+					return true
+				}
+			}
+			return false
+		}
+
+		for _, relPath := range ex.Matches {
+			if !matches(relPath) {
+				t.Fatalf("Expected '%s' to match '%s'", ex.Path, relPath)
+			}
+		}
+		for _, relPath := range ex.NonMatches {
+			if matches(relPath) {
+				t.Fatalf("Expected '%s' to not match '%s'", ex.Path, relPath)
+			}
+		}
+	}
+}
+
+func TestRenameRegexPath(t *testing.T) {
+	examples := []struct {
+		Path        string
+		Value       string
+		Expected    string
+		ExpectedErr string
+	}{
+		{Path: "app.tpl", Value: `\.tpl$=.yml`, Expected: "app.yml"},
+		{Path: "templates/app.yml", Value: "^templates/=", Expected: "app.yml"},
+		{Path: "app-prod.yml", Value: `^(\w+)-(\w+)\.yml$=$2/$1.yml`, Expected: "prod/app.yml"},
+		{Path: "a=b.yml", Value: `a\=b=c`, Expected: "c.yml"},
+		{Path: "a=b.yml", Value: `\==-`, Expected: "a-b.yml"},
+		{Path: "aaa.yml", Value: "a{2,3}=b", Expected: "b.yml"},
+		{Path: "other.yml", Value: `\.tpl$=.yml`, Expected: "other.yml"},
+		{Path: "app.yml", Value: ".*=", ExpectedErr: "Expected rename-regex to produce non-empty path for file 'app.yml'"},
+		{Path: "app.yml", Value: `app\.yml`, ExpectedErr: "Expected rename-regex value to be in format regexp=replacement"},
+		{Path: "app.yml", Value: `a\=b`, ExpectedErr: "Expected rename-regex value to be in format regexp=replacement"},
+		{Path: "app.yml", Value: "(=x", ExpectedErr: "Compiling rename-regex regexp: error parsing regexp: missing closing ): `(`"},
+	}
+
+	for _, ex := range examples {
+		result, err := (&RegularFilesSource{}).renameRegexPath(ex.Path, ex.Value)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected renaming '%s' via '%s' to fail with '%s', but was: %v", ex.Path, ex.Value, ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected renaming '%s' via '%s' to succeed: %s", ex.Path, ex.Value, err)
+		}
+		if result != ex.Expected {
+			t.Fatalf("Expected renaming '%s' via '%s' to result in '%s', but was '%s'", ex.Path, ex.Value, ex.Expected, result)
+		}
+	}
+}
+
+func TestRenameRegexFileMark(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"a.tpl":   "a: 1",
+		"a.yml":   "b: 1",
+		"c.tpl":   "c: 1",
+		"d=e.txt": "d",
+	})
+	defer os.RemoveAll(dirPath)
+
+	examples := []struct {
+		Marks       []string
+		Expected    string
+		ExpectedErr string
+	}{
+		{
+			Marks:    []string{"c.tpl:rename-regex=\\.tpl$=.yml", "a.tpl:exclude=true"},
+			Expected: "a.yml\nc.yml\nd=e.txt\n",
+		},
+		{
+			Marks:    []string{"c.tpl:rename-regex=^(c)\\.tpl$=$1/app.yml", "d=e.txt:rename-regex=^d\\==f-", "a.tpl:exclude=true"},
+			Expected: "a.yml\nc/app.yml\nf-e.txt\n",
+		},
+		{
+			Marks: []string{"*.tpl:rename-regex=\\.tpl$=.yml"},
+			ExpectedErr: "Expected file mark '*.tpl:rename-regex=\\.tpl$=.yml' to produce unique file paths, " +
+				"but files 'a.tpl' and 'a.yml' both have path 'a.yml'",
+		},
+		{
+			Marks:       []string{"c.tpl:rename-regex=.*="},
+			ExpectedErr: "Applying file mark 'c.tpl:rename-regex=.*=': Expected rename-regex to produce non-empty path for file 'c.tpl'",
+		},
+	}
+
+	for _, ex := range examples {
+		out, err := planWithFileMarks(t, dirPath, ex.Marks)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected marks %#v to fail with '%s', but was: %v", ex.Marks, ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected marks %#v to succeed: %s", ex.Marks, err)
+		}
+		if out != ex.Expected {
+			t.Fatalf("Expected marks %#v to result in '%s', but was '%s'", ex.Marks, ex.Expected, out)
+		}
+	}
+}
+
+func TestFileMarkKVs(t *testing.T) {
+	examples := []struct {
+		Value       string
+		Expected    [][]string
+		ExpectedErr string
+	}{
+		{Value: "type=yaml-plain", Expected: [][]string{{"type", "yaml-plain"}}},
+		{Value: "type=text-template,for-output=true", Expected: [][]string{{"type", "text-template"}, {"for-output", "true"}}},
+		{Value: "rename-regex=a{1,3}=b,exclude=true", Expected: [][]string{{"rename-regex", "a{1,3}=b"}, {"exclude", "true"}}},
+		{Value: `annotation=tags=a\,b=c`, Expected: [][]string{{"annotation", "tags=a,b=c"}}},
+		{Value: `annotation=tags=a\,b=c,for-output=true`, Expected: [][]string{{"annotation", "tags=a,b=c"}, {"for-output", "true"}}},
+		{Value: `path=a\,b.yml`, Expected: [][]string{{"path", "a,b.yml"}}},
+		{Value: `path=a\b.yml`, Expected: [][]string{{"path", `a\b.yml`}}},
+		{Value: "path=a,b.yml", Expected: [][]string{{"path", "a,b.yml"}}}, // 'b.yml' is not a key
+		{Value: "type=yaml-plain,", Expected: [][]string{{"type", "yaml-plain,"}}},
+		{Value: "type", ExpectedErr: "Expected file mark 'x.yml:type' key-value portion to be in format key=value"},
+		{Value: "", ExpectedErr: "Expected file mark 'x.yml:' key-value portion to be in format key=value"},
+		{Value: "bogus,type=yaml-plain,for-output=true",
+			ExpectedErr: "Expected file mark 'x.yml:bogus,type=yaml-plain,for-output=true' key-value pair 'bogus' to be in format key=value"},
+	}
+
+	for _, ex := range examples {
+		result, err := (&RegularFilesSource{}).fileMarkKVs("x.yml:"+ex.Value, ex.Value)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected splitting '%s' to fail with '%s', but was: %v", ex.Value, ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected splitting '%s' to succeed: %s", ex.Value, err)
+		}
+		if fmt.Sprintf("%q", result) != fmt.Sprintf("%q", ex.Expected) {
+			t.Fatalf("Expected splitting '%s' to result in %q, but was: %q", ex.Value, ex.Expected, result)
+		}
+	}
+}
+
+func TestFileMarkMultiplePairsInvalidValue(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"a.yml": "a: 1"})
+	defer os.RemoveAll(dirPath)
+
+	// Pair that does not look like key=value is part of preceding value
+	_, err := runCmd(t, "-f", dirPath, "--file-mark", "a.yml:for-output=true,bogus,exclude=true")
+	if err == nil || err.Error() != "Unknown value in file mark 'a.yml:for-output=true,bogus,exclude=true'" {
+		t.Fatalf("Expected unknown value err, but was: %v", err)
+	}
+}
+
+func TestFileMarkResolvedPaths(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"a.yml": "a: 1",
+		"b.yml": "b: 1",
+	})
+	defer os.RemoveAll(dirPath)
+
+	examples := []struct {
+		Marks       []string
+		Expected    string
+		ExpectedErr string
+	}{
+		{
+			Marks:    []string{"a.yml:path=new/a.yml", "new/a.yml:@resolved:for-output=false"},
+			Expected: "b.yml\n",
+		},
+		{
+			// Original path still matches after rename
+			Marks:    []string{"a.yml:path=new/a.yml", "a.yml:for-output=false"},
+			Expected: "b.yml\n",
+		},
+		{
+			Marks:    []string{"a.yml:path=new/a.yml", "*/*.yml:@resolved:path=other.yml"},
+			Expected: "b.yml\nother.yml\n",
+		},
+		{
+			// Matching happens once per mark, before its renames
+			Marks:    []string{"a.yml:path=new/a.yml,for-output=false"},
+			Expected: "b.yml\n",
+		},
+		{
+			Marks:       []string{"new/a.yml:@resolved:for-output=false", "a.yml:path=new/a.yml"},
+			ExpectedErr: "Expected file mark 'new/a.yml:@resolved:for-output=false' to match at least one file by path, but did not",
+		},
+		{
+			Marks:       []string{"a.yml:path=new/a.yml", "new/a.yml:for-output=false"},
+			ExpectedErr: "Expected file mark 'new/a.yml:for-output=false' to match at least one file by path, but did not",
+		},
+		{
+			Marks:       []string{"a.yml:path=new/a.yml", "a.yml:@resolved:for-output=false"},
+			ExpectedErr: "Expected file mark 'a.yml:@resolved:for-output=false' to match at least one file by path, but did not",
+		},
+	}
+
+	for _, ex := range examples {
+		out, err := planWithFileMarks(t, dirPath, ex.Marks)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected marks %#v to fail with '%s', but was: %v", ex.Marks, ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected marks %#v to succeed: %s", ex.Marks, err)
+		}
+		if out != ex.Expected {
+			t.Fatalf("Expected marks %#v to result in '%s', but was '%s'", ex.Marks, ex.Expected, out)
+		}
+	}
+}
+
+// planWithFileMarks returns --plan output for files in given dir
+// (as they would be written to an output directory)
+func planWithFileMarks(t *testing.T, dirPath string, marks []string) (string, error) {
+	args := []string{"-f", dirPath, "--plan", "--output-directory", filepath.Join(dirPath, "out")}
+	for _, mark := range marks {
+		args = append(args, "--file-mark", mark)
+	}
+	return runCmd(t, args...)
+}
+
+func TestFileMarkTypeAndTemplatePrecedence(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"a.yml": "a: #@ 1 + 1"})
+	defer os.RemoveAll(dirPath)
+
+	examples := []struct {
+		Marks       []string
+		Expected    string
+		ExpectedErr string
+	}{
+		{Marks: []string{"a.yml:template=false"}, Expected: "a: null\n"},
+		{Marks: []string{"a.yml:type=yaml-plain,template=true"}, Expected: "a: 2\n"},
+		{Marks: []string{"a.yml:template=true,type=yaml-plain"}, Expected: "a: null\n"},
+		{Marks: []string{"a.yml:template=false,type=yaml-template"}, Expected: "a: 2\n"},
+		{Marks: []string{"a.yml:type=yaml-plain", "a.yml:template=true"}, Expected: "a: 2\n"},
+		{Marks: []string{"a.yml:template=true", "a.yml:type=yaml-plain"}, Expected: "a: null\n"},
+		{Marks: []string{"a.yml:template=yes"}, ExpectedErr: "Unknown value in file mark 'a.yml:template=yes'"},
+	}
+
+	for _, ex := range examples {
+		args := []string{"-f", dirPath}
+		for _, mark := range ex.Marks {
+			args = append(args, "--file-mark", mark)
+		}
+
+		out, err := runCmd(t, args...)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected marks %#v to fail with '%s', but was: %v", ex.Marks, ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected marks %#v to succeed: %s", ex.Marks, err)
+		}
+		if out != ex.Expected {
+			t.Fatalf("Expected marks %#v to result in '%s', but was '%s'", ex.Marks, ex.Expected, out)
+		}
+	}
+}
+
+func TestFileMarkOutputExclude(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"a.yml": "a: 1",
+		"b.yml": "b: 1",
+		"c.yml": "c: 1",
+	})
+	defer os.RemoveAll(dirPath)
+
+	examples := []struct {
+		Marks       []string
+		Expected    string
+		ExpectedErr string
+	}{
+		{Marks: []string{"a.yml:output-exclude=true"}, Expected: "b.yml\nc.yml\n"},
+		{Marks: []string{"{a,b}.yml:exclusive-for-output=true"}, Expected: "a.yml\nb.yml\n"},
+		{Marks: []string{"{a,b}.yml:exclusive-for-output=true", "a.yml:output-exclude=true"}, Expected: "b.yml\n"},
+		{Marks: []string{"a.yml:output-exclude=true", "{a,b}.yml:exclusive-for-output=true"}, Expected: "b.yml\n"},
+		{Marks: []string{"a.yml:output-exclude=true", "c.yml:exclusive-for-output=true"}, Expected: "c.yml\n"},
+		{Marks: []string{"a.yml:output-exclude=false"}, ExpectedErr: "Unknown value in file mark 'a.yml:output-exclude=false'"},
+	}
+
+	for _, ex := range examples {
+		out, err := planWithFileMarks(t, dirPath, ex.Marks)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected marks %#v to fail with '%s', but was: %v", ex.Marks, ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected marks %#v to succeed: %s", ex.Marks, err)
+		}
+		if out != ex.Expected {
+			t.Fatalf("Expected marks %#v to result in '%s', but was '%s'", ex.Marks, ex.Expected, out)
+		}
+	}
+
+	// Same applies when printing to stdout
+	out, err := runCmd(t, "-f", dirPath, "--file-mark", "{a,b}.yml:exclusive-for-output=true", "--file-mark", "a.yml:output-exclude=true")
+	if err != nil || out != "b: 1\n" {
+		t.Fatalf("Expected only b.yml to be printed, but was: %s (err: %v)", out, err)
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"in/a.yml":    "a: 1",
+		"in/b.yml":    "b: 1",
+		"in/c.txt":    "c",
+		"out/old.yml": "old: 1",
+	})
+	defer os.RemoveAll(dirPath)
+
+	inPath := filepath.Join(dirPath, "in")
+	outPath := filepath.Join(dirPath, "out")
+
+	_, err := runCmd(t, "-f", inPath, "--output-directory", outPath, "--output-file", "all/combined.yml")
+	if err != nil {
+		t.Fatalf("Expected writing output file to succeed: %s", err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(outPath, "all/combined.yml"))
+	if err != nil || string(contents) != "a: 1\n---\nb: 1\n" {
+		t.Fatalf("Expected combined output file, but was: %s (err: %v)", contents, err)
+	}
+
+	// Output directory is cleaned, same as without --output-file
+	paths, err := filepath.Glob(filepath.Join(outPath, "*"))
+	if err != nil || len(paths) != 1 || paths[0] != filepath.Join(outPath, "all") {
+		t.Fatalf("Expected only output file to be written, but was: %#v (err: %v)", paths, err)
+	}
+}
+
+func TestOutputFileRejections(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"a.yml": "a: 1"})
+	defer os.RemoveAll(dirPath)
+
+	outPath := filepath.Join(dirPath, "out")
+
+	examples := []struct {
+		Args        []string
+		ExpectedErr string
+	}{
+		{[]string{"--output-file", "all.yml"},
+			"Expected --output-file to be used together with --output-directory"},
+		{[]string{"--output-directory", outPath, "--output-file", "all.yml", "--output-files-split"},
+			"Expected only one of --output-file or --output-files-split to be specified"},
+		{[]string{"--output-directory", outPath, "--output-file", "all.yml", "--out-file-extension", "yaml"},
+			"Expected --out-file-extension to be used together with --output-directory (and not --output-file)"},
+		{[]string{"--output-directory", outPath, "--output-file", "all.yml", "--trailing-newline", "never"},
+			"Expected --trailing-newline to be used only when printing to stdout (and not with --output-directory or --output-file)"},
+		{[]string{"--output-directory", outPath, "--output-file", "all.yml", "-o", "yaml,json"},
+			"Expected single output type when printing to stdout or --output-file, but was 'yaml,json' " +
+				"(multiple output types require --output-directory)"},
+		{[]string{"--output-directory", outPath, "--output-file", filepath.Join(dirPath, "all.yml")},
+			"Expected --output-file '" + filepath.Join(dirPath, "all.yml") + "' to be a relative file path within output directory"},
+		{[]string{"--output-directory", outPath, "--output-file", "../all.yml"},
+			"Expected --output-file '../all.yml' to be a relative file path within output directory"},
+		{[]string{"--output-directory", outPath, "--output-file", "x/../.."},
+			"Expected --output-file 'x/../..' to be a relative file path within output directory"},
+		{[]string{"--output-directory", outPath, "--output-file", "."},
+			"Expected --output-file '.' to be a relative file path within output directory"},
+	}
+
+	for _, ex := range examples {
+		_, err := runCmd(t, append([]string{"-f", dirPath}, ex.Args...)...)
+		if err == nil || err.Error() != ex.ExpectedErr {
+			t.Fatalf("Expected %#v to fail with '%s', but was: %v", ex.Args, ex.ExpectedErr, err)
+		}
+	}
+
+	if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+		t.Fatalf("Expected output directory to not be created, but was: %v", err)
+	}
+}
+
+func TestPlanMatchesWrittenFiles(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"in/a.yml":              "a: 1",
+		"in/b/x.yml":            "kind: X\nmetadata:\n  name: x\n---\nkind: Z\nmetadata:\n  name: z",
+		"in/c.star":             "x = 1",
+		"in/d.txt":              "d",
+		"in/e.yml":              "#@ load(\"@ytt:data\", \"data\")\ne: #@ data.values.e",
+		"in/values.yml":         "#@data/values\n---\ne: 1",
+		"in/_ytt_lib/lib/l.yml": "l: 1",
+	})
+	defer os.RemoveAll(dirPath)
+
+	inPath := filepath.Join(dirPath, "in")
+	outPath := filepath.Join(dirPath, "out")
+
+	examples := []struct {
+		Args     []string
+		Expected string
+	}{
+		{nil, "0-first/e.yml\nb/x.yml\nd.txt\nz/app.yml\n"},
+		{[]string{"--output-files-split", "-o", "yaml,json", "--output-manifest=manifest.json"},
+			"0-first/e-0.json\n0-first/e-0.yml\nb/x-x.json\nb/x-x.yml\nb/z-z.json\nb/z-z.yml\n" +
+				"d.txt\nmanifest.json\nz/app-0.json\nz/app-0.yml\n"},
+		{[]string{"--output-file", "all.yml"}, "all.yml\n"},
+	}
+
+	for _, ex := range examples {
+		args := append([]string{"-f", inPath, "--output-directory", outPath, "--file-mark", "a.yml:path=z/app.yml",
+			"--file-mark", "e.yml:output-subdir=0-first"}, ex.Args...)
+
+		out, err := runCmd(t, append(args, "--plan")...)
+		if err != nil {
+			t.Fatalf("Expected plan for %#v to succeed: %s", ex.Args, err)
+		}
+		if out != ex.Expected {
+			t.Fatalf("Expected plan for %#v to print sorted paths '%s', but was '%s'", ex.Args, ex.Expected, out)
+		}
+
+		if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+			t.Fatalf("Expected plan to not write output directory, but was: %v", err)
+		}
+
+		_, err = runCmd(t, args...)
+		if err != nil {
+			t.Fatalf("Expected writing for %#v to succeed: %s", ex.Args, err)
+		}
+
+		var writtenPaths []string
+
+		err = filepath.Walk(outPath, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				relPath, _ := filepath.Rel(outPath, path)
+				writtenPaths = append(writtenPaths, filepath.ToSlash(relPath)+"\n")
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Walking output directory: %s", err)
+		}
+
+		if strings.Join(writtenPaths, "") != out {
+			t.Fatalf("Expected plan for %#v to match written files, but was: %#v", ex.Args, writtenPaths)
+		}
+
+		os.RemoveAll(outPath)
+	}
+
+	_, err := runCmd(t, "-f", inPath, "--plan")
+	if err == nil || err.Error() != "Expected --plan to be used together with --output-directory" {
+		t.Fatalf("Expected --plan without --output-directory to fail, but was: %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"in/a.yml":    "a: 1\n",
+		"in/b.txt":    "b",
+		"out/old.yml": "old: 1\n",
+	})
+	defer os.RemoveAll(dirPath)
+
+	inPath := filepath.Join(dirPath, "in")
+	outPath := filepath.Join(dirPath, "out")
+	newOutPath := filepath.Join(dirPath, "new-out")
+
+	examples := [][]string{
+		{},
+		{"-o", "json"},
+		{"--output-directory", outPath},
+		{"--output-directory", outPath, "--output-files-split", "-o", "yaml,json"},
+		{"--output-directory", outPath, "--output-file", "all.yml"},
+		{"--output-directory", newOutPath},
+	}
+
+	for _, args := range examples {
+		out, err := runCmd(t, append([]string{"-f", inPath, "--dry-run"}, args...)...)
+		if err != nil {
+			t.Fatalf("Expected dry run for %#v to succeed: %s", args, err)
+		}
+		if out != "" {
+			t.Fatalf("Expected dry run for %#v to not print output, but was: >>>%s<<<", args, out)
+		}
+
+		paths, err := filepath.Glob(filepath.Join(outPath, "*"))
+		if err != nil || len(paths) != 1 || paths[0] != filepath.Join(outPath, "old.yml") {
+			t.Fatalf("Expected dry run for %#v to keep output directory as is, but was: %#v (err: %v)", args, paths, err)
+		}
+
+		if _, err := os.Stat(newOutPath); !os.IsNotExist(err) {
+			t.Fatalf("Expected dry run for %#v to not create output directory, but was: %v", args, err)
+		}
+	}
+}
+
+func TestDryRunTemplateErr(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: #@ missing\n"})
+	defer os.RemoveAll(dirPath)
+
+	out, err := runCmd(t, "-f", dirPath, "--dry-run")
+	if err == nil || !strings.Contains(err.Error(), "undefined: missing") {
+		t.Fatalf("Expected dry run to report template error, but was: %v", err)
+	}
+	if out != "" {
+		t.Fatalf("Expected dry run to not print output, but was: >>>%s<<<", out)
+	}
+}
+
+func TestFilesTrace(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{
+		"a.yml":      "a: 1\n",
+		"c.bin":      "c",
+		"plain.txt":  "plain",
+		"sub/b.txt":  "b",
+		"values.yml": "#@data/values\n---\nx: 1\n",
+	})
+	defer os.RemoveAll(dirPath)
+
+	out, errOut, err := runCmdWithErrOutput(t, "-f", dirPath, "--trace",
+		"--file-mark", "c.bin:type=binary",
+		"--file-mark", "sub/b.txt:path=sub/renamed.txt",
+		"--file-mark", "a.yml:output-subdir=cfg",
+		"--file-mark", "plain.txt:template=false",
+		"--file-mark", "values.yml:for-output=false")
+	if err != nil {
+		t.Fatalf("Expected trace to succeed: %s", err)
+	}
+
+	expectedTrace := strings.Replace(`Path                       Type    Template  For output  Original path
+a.yml (output: cfg/a.yml)  yaml    true      true        _
+c.bin                      binary  false     true        _
+plain.txt                  text    false     true        _
+sub/renamed.txt            text    true      true        sub/b.txt
+values.yml                 yaml    true      false       _
+`, "_\n", "\n", -1)
+
+	if errOut != expectedTrace {
+		t.Fatalf("Expected trace to match, but was: >>>%s<<<", errOut)
+	}
+
+	// Trace does not affect regular output
+	if out != "a: 1\n" {
+		t.Fatalf("Expected regular output, but was: >>>%s<<<", out)
+	}
+}
@@ -0,0 +1,67 @@
+package template
+
+import (
+	"io/ioutil"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+type RenderOpts struct {
+	IgnoreUnknownComments bool
+	StrictYAML            bool
+	DataValuesFlags       DataValuesFlags
+
+	// UI receives debug output (eg cmdcore.NewWriterUI(...));
+	// if not set, all output is discarded
+	UI files.UI
+}
+
+// Render evaluates templates from given input without relying on
+// command line flags, stdout or stderr. It is meant to be used when
+// embedding ytt as a library. Output is not written anywhere; use
+// TemplateOutput.Files (eg with files.NewOutputDirectory) or
+// TemplateOutput.DocSet to consume results.
+func Render(in TemplateInput, opts RenderOpts) (TemplateOutput, error) {
+	ui := opts.UI
+	if ui == nil {
+		ui = cmdcore.NewWriterUI(ioutil.Discard, ioutil.Discard, false)
+	}
+
+	// Inspection only prints results, hence it does not apply here
+	dataValuesFlags := opts.DataValuesFlags
+	dataValuesFlags.Inspect = false
+
+	tplOpts := &TemplateOptions{
+		IgnoreUnknownComments: opts.IgnoreUnknownComments,
+		StrictYAML:            opts.StrictYAML,
+		DataValuesFlags:       dataValuesFlags,
+	}
+
+	out := tplOpts.RunWithFiles(in, ui)
+	if out.Err != nil {
+		return TemplateOutput{}, out.Err
+	}
+
+	return out, nil
+}
+
+// RenderEachDocument evaluates templates the same way as Render and
+// calls given function for each resulting document in output order
+// (eg to apply Kubernetes resources one at a time). Documents are
+// passed after all templates are evaluated. If function returns an
+// error, remaining documents are skipped and error is returned as is.
+func RenderEachDocument(in TemplateInput, opts RenderOpts, fn func(doc *yamlmeta.Document) error) error {
+	out, err := Render(in, opts)
+	if err != nil {
+		return err
+	}
+
+	// Output without any templates does not have document set
+	if out.DocSet == nil {
+		return nil
+	}
+
+	return out.DocSet.EachDocument(fn)
+}
@@ -0,0 +1,125 @@
+package template_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+	cmdtpl "github.com/k14s/ytt/pkg/cmd/template"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestRender(t *testing.T) {
+	tplData := []byte(`
+#@ load("@ytt:data", "data")
+name: #@ data.values.name
+`)
+
+	valuesData := []byte(`
+#@data/values
+---
+name: default
+`)
+
+	filesToProcess := files.NewSortedFiles([]*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", tplData)),
+		files.MustNewFileFromSource(files.NewBytesSource("values.yml", valuesData)),
+	})
+
+	outBuf := new(bytes.Buffer)
+	debugBuf := new(bytes.Buffer)
+
+	out, err := cmdtpl.Render(cmdtpl.TemplateInput{Files: filesToProcess}, cmdtpl.RenderOpts{
+		DataValuesFlags: cmdtpl.DataValuesFlags{KVsFromStrings: []string{"name=custom"}},
+		UI:              cmdcore.NewWriterUI(outBuf, debugBuf, true),
+	})
+	if err != nil {
+		t.Fatalf("Expected Render to succeed, but was error: %s", err)
+	}
+
+	docBytes, err := out.DocSet.AsBytes()
+	if err != nil {
+		t.Fatalf("Expected marshaling to succeed: %s", err)
+	}
+
+	if string(docBytes) != "name: custom\n" {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", docBytes)
+	}
+
+	if len(out.Files) != 1 || out.Files[0].RelativePath() != "tpl.yml" {
+		t.Fatalf("Expected single output file, but was: %#v", out.Files)
+	}
+
+	if outBuf.Len() != 0 {
+		t.Fatalf("Expected no regular output, but was: >>>%s<<<", outBuf)
+	}
+
+	if !strings.Contains(debugBuf.String(), "## file tpl.yml") {
+		t.Fatalf("Expected debug output to be written to given writer, but was: >>>%s<<<", debugBuf)
+	}
+}
+
+func TestRenderErr(t *testing.T) {
+	filesToProcess := []*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", []byte("key: #@ unknown_var"))),
+	}
+
+	_, err := cmdtpl.Render(cmdtpl.TemplateInput{Files: filesToProcess}, cmdtpl.RenderOpts{})
+	if err == nil {
+		t.Fatalf("Expected Render to fail")
+	}
+
+	if !strings.Contains(err.Error(), "unknown_var") {
+		t.Fatalf("Expected error to mention undefined variable, but was: %s", err)
+	}
+}
+
+func TestRenderEachDocument(t *testing.T) {
+	tplData := []byte(`
+#@ for i in range(3):
+---
+name: #@ "doc-{}".format(i)
+#@ end
+`)
+
+	filesToProcess := []*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("tpl.yml", tplData)),
+	}
+
+	var names []string
+
+	err := cmdtpl.RenderEachDocument(cmdtpl.TemplateInput{Files: filesToProcess}, cmdtpl.RenderOpts{},
+		func(doc *yamlmeta.Document) error {
+			docBytes, err := doc.AsYAMLBytes()
+			if err != nil {
+				return err
+			}
+			names = append(names, strings.TrimSpace(string(docBytes)))
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Expected RenderEachDocument to succeed, but was error: %s", err)
+	}
+
+	if strings.Join(names, ",") != "name: doc-0,name: doc-1,name: doc-2" {
+		t.Fatalf("Expected documents to be passed in order, but was: %#v", names)
+	}
+
+	var calls int
+
+	err = cmdtpl.RenderEachDocument(cmdtpl.TemplateInput{Files: filesToProcess}, cmdtpl.RenderOpts{},
+		func(doc *yamlmeta.Document) error {
+			calls++
+			return fmt.Errorf("apply failed")
+		})
+	if err == nil || err.Error() != "apply failed" {
+		t.Fatalf("Expected callback error to be returned, but was: %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("Expected iteration to stop after first error, but callback was called %d times", calls)
+	}
+}
@@ -0,0 +1,52 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+const (
+	sourceChecksumAnnotation = "ytt.io/source-checksum"
+)
+
+// annotateSourceChecksums returns copy of output documents annotated
+// with sha256 of input file each document came from; documents without
+// known input file (eg generated by merging inputs) or without metadata
+// map (ie not Kubernetes resources) are left as is
+func (s *RegularFilesSource) annotateSourceChecksums(out TemplateOutput) (*yamlmeta.DocumentSet, error) {
+	checksums := map[string]string{}
+
+	for _, file := range out.InputFiles {
+		contents, err := file.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("Reading %s: %s", file.Description(), err)
+		}
+
+		sum := sha256.Sum256(contents)
+		checksums[file.RelativePath()] = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	// Documents are shared with output files
+	docSet := out.DocSet.DeepCopy()
+
+	for _, doc := range docSet.Items {
+		if !doc.Position.IsKnown() {
+			continue
+		}
+
+		checksum, found := checksums[doc.Position.File()]
+		if !found {
+			continue
+		}
+
+		_, err := yamlmeta.SetK8sAnnotation(doc, sourceChecksumAnnotation, checksum)
+		if err != nil {
+			return nil, fmt.Errorf("Annotating document %s: %s", doc.Position.AsCompactString(), err)
+		}
+	}
+
+	return docSet, nil
+}
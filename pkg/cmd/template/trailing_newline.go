@@ -0,0 +1,107 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+type trailingNewlineMode string
+
+const (
+	// trailingNewlineAuto keeps output as produced by output type
+	trailingNewlineAuto   trailingNewlineMode = "auto"
+	trailingNewlineAlways trailingNewlineMode = "always"
+	trailingNewlineNever  trailingNewlineMode = "never"
+)
+
+func (s *RegularFilesSource) trailingNewlineMode() (trailingNewlineMode, error) {
+	mode := trailingNewlineMode(s.opts.trailingNewline)
+
+	switch mode {
+	case trailingNewlineAuto, trailingNewlineAlways, trailingNewlineNever:
+	default:
+		return "", fmt.Errorf("Unknown --trailing-newline value '%s' (valid values: auto, always, never)", s.opts.trailingNewline)
+	}
+
+	if mode != trailingNewlineAuto && (len(s.opts.outputDir) > 0 || len(s.opts.outputFile) > 0) {
+		return "", fmt.Errorf("Expected --trailing-newline to be used only when printing to stdout " +
+			"(and not with --output-directory or --output-file)")
+	}
+
+	return mode, nil
+}
+
+// withTrailingNewline adjusts end of combined output the same way
+// as trailingNewlineWriter
+func withTrailingNewline(data []byte, mode trailingNewlineMode) []byte {
+	switch mode {
+	case trailingNewlineAlways:
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			return append(data, '\n')
+		}
+	case trailingNewlineNever:
+		return bytes.TrimRight(data, "\n")
+	}
+	return data
+}
+
+// trailingNewlineWriter adjusts end of written output according to mode
+// without buffering whole output: with 'always' newline is added on
+// Close unless output is empty or already ends with one; with 'never'
+// newlines are held back until more content follows, and
+// dropped on Close.
+type trailingNewlineWriter struct {
+	writer io.Writer
+	mode   trailingNewlineMode
+
+	written         bool
+	endsWithNewline bool
+	pendingNewlines int
+}
+
+var _ io.WriteCloser = &trailingNewlineWriter{}
+
+func newTrailingNewlineWriter(writer io.Writer, mode trailingNewlineMode) *trailingNewlineWriter {
+	return &trailingNewlineWriter{writer: writer, mode: mode}
+}
+
+func (w *trailingNewlineWriter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	w.written = true
+	w.endsWithNewline = data[len(data)-1] == '\n'
+
+	if w.mode != trailingNewlineNever {
+		return w.writer.Write(data)
+	}
+
+	content := bytes.TrimRight(data, "\n")
+
+	if len(content) > 0 {
+		_, err := w.writer.Write(bytes.Repeat([]byte("\n"), w.pendingNewlines))
+		if err != nil {
+			return 0, err
+		}
+		w.pendingNewlines = 0
+
+		_, err = w.writer.Write(content)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	w.pendingNewlines += len(data) - len(content)
+
+	return len(data), nil
+}
+
+func (w *trailingNewlineWriter) Close() error {
+	if w.mode == trailingNewlineAlways && w.written && !w.endsWithNewline {
+		_, err := w.writer.Write([]byte("\n"))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package template
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTrailingNewlineWriter(t *testing.T) {
+	examples := []struct {
+		Mode   trailingNewlineMode
+		Chunks []string
+	}{
+		{trailingNewlineNever, []string{"a: 1\n", "---\n", "b: 2\n"}},
+		{trailingNewlineNever, []string{"a: 1\n\n", "\n", "", "b: 2\n\n"}},
+		{trailingNewlineNever, []string{"a", "\n", "\n", "b", "\n"}},
+		{trailingNewlineNever, []string{"\n", "\n"}},
+		{trailingNewlineNever, []string{"a"}},
+		{trailingNewlineAlways, []string{"a: 1\n", "b: 2\n"}},
+		{trailingNewlineAlways, []string{"a: 1\n", "b: 2"}},
+		{trailingNewlineAlways, []string{"a\n", ""}},
+		{trailingNewlineAlways, []string{"\n"}},
+		{trailingNewlineAuto, []string{"a", "\n", "b"}},
+		// Empty output stays empty regardless of mode
+		{trailingNewlineNever, nil},
+		{trailingNewlineAlways, nil},
+		{trailingNewlineAlways, []string{"", ""}},
+		{trailingNewlineAuto, nil},
+	}
+
+	for _, ex := range examples {
+		expected := string(withTrailingNewline([]byte(strings.Join(ex.Chunks, "")), ex.Mode))
+
+		var buf bytes.Buffer
+		writer := newTrailingNewlineWriter(&buf, ex.Mode)
+
+		for _, chunk := range ex.Chunks {
+			n, err := writer.Write([]byte(chunk))
+			if err != nil || n != len(chunk) {
+				t.Fatalf("Expected write to succeed, but was: %d (err: %v)", n, err)
+			}
+		}
+
+		err := writer.Close()
+		if err != nil {
+			t.Fatalf("Expected close to succeed: %s", err)
+		}
+
+		if buf.String() != expected {
+			t.Fatalf("Expected %s output for chunks %#v to be %q, but was %q", ex.Mode, ex.Chunks, expected, buf.String())
+		}
+	}
+}
+
+func TestTrailingNewlineWithGzip(t *testing.T) {
+	dirPath := writeInputDir(t, map[string]string{"tpl.yml": "a: 1\n---\nb: 2\n"})
+	defer os.RemoveAll(dirPath)
+
+	examples := []struct {
+		Args     []string
+		Expected string
+	}{
+		{[]string{"--trailing-newline", "never"}, "a: 1\n---\nb: 2"},
+		{[]string{"--trailing-newline", "always"}, "a: 1\n---\nb: 2\n"},
+		{[]string{"--trailing-newline", "always", "-o", "json-array"}, `[{"a":1},{"b":2}]` + "\n"},
+		{[]string{"--trailing-newline", "always", "-o", "base64"}, "YTogMQotLS0KYjogMgo=\n"}, // not streamed
+	}
+
+	for _, ex := range examples {
+		out, err := runCmd(t, append([]string{"-f", dirPath, "--output-gzip"}, ex.Args...)...)
+		if err != nil {
+			t.Fatalf("Expected %#v to succeed: %s", ex.Args, err)
+		}
+
+		gzipReader, err := gzip.NewReader(strings.NewReader(out))
+		if err != nil {
+			t.Fatalf("Expected gzip output for %#v: %s", ex.Args, err)
+		}
+
+		result, err := ioutil.ReadAll(gzipReader)
+		if err != nil {
+			t.Fatalf("Decompressing output for %#v: %s", ex.Args, err)
+		}
+
+		if string(result) != ex.Expected {
+			t.Fatalf("Expected output for %#v to be %q, but was %q", ex.Args, ex.Expected, result)
+		}
+	}
+}
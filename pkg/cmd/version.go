@@ -3,11 +3,12 @@ package cmd
 import (
 	"fmt"
 
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
 	"github.com/spf13/cobra"
 )
 
 const (
-	Version = "0.22.0"
+	Version = cmdcore.Version
 )
 
 type VersionOptions struct{}
@@ -0,0 +1,89 @@
+package filepos
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// ErrorDetail describes a single error in a machine-readable form;
+// zero values indicate that information is not known
+type ErrorDetail struct {
+	Kind    string `json:"kind"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// DetailedError is implemented by errors that carry structured details
+type DetailedError interface {
+	error
+	ErrorDetails() []ErrorDetail
+}
+
+// FileError associates error with a file while keeping
+// error message in the same form as fmt.Errorf("%s: %s", prefix, err)
+type FileError struct {
+	prefix string
+	err    error
+	detail ErrorDetail
+}
+
+var _ DetailedError = FileError{}
+
+// NewFileError returns error prefixed with given message. If err carries
+// details, they are kept (with file filled in when missing); otherwise
+// detail is built from given kind, file and err message.
+func NewFileError(prefix string, err error, kind, file string) FileError {
+	return FileError{prefix, err, ErrorDetail{Kind: kind, File: file}}
+}
+
+func (e FileError) Error() string { return e.prefix + ": " + e.err.Error() }
+
+func (e FileError) ErrorDetails() []ErrorDetail {
+	if detailedErr, ok := e.err.(DetailedError); ok {
+		var result []ErrorDetail
+		for _, detail := range detailedErr.ErrorDetails() {
+			if len(detail.File) == 0 {
+				detail.File = e.detail.File
+			}
+			result = append(result, detail)
+		}
+		return result
+	}
+
+	detail := e.detail
+	detail.Message = e.err.Error()
+	detail.Line, detail.Column = lineAndColumnFromMsg(detail.Message)
+	return []ErrorDetail{detail}
+}
+
+var (
+	// Parsers commonly include location as 'line 3' or 'line 3, column 5'
+	errorMsgLineRegexp   = regexp.MustCompile(`\bline (\d+)\b`)
+	errorMsgColumnRegexp = regexp.MustCompile(`\bcolumn (\d+)\b`)
+)
+
+func lineAndColumnFromMsg(msg string) (int, int) {
+	var line, column int
+
+	if matches := errorMsgLineRegexp.FindStringSubmatch(msg); len(matches) == 2 {
+		line, _ = strconv.Atoi(matches[1])
+	}
+	if matches := errorMsgColumnRegexp.FindStringSubmatch(msg); len(matches) == 2 {
+		column, _ = strconv.Atoi(matches[1])
+	}
+
+	return line, column
+}
+
+// ErrorDetailsFromError returns details of err if it carries
+// them; otherwise err is described by a single generic detail
+func ErrorDetailsFromError(err error) []ErrorDetail {
+	if detailedErr, ok := err.(DetailedError); ok {
+		if details := detailedErr.ErrorDetails(); len(details) > 0 {
+			return details
+		}
+	}
+	return []ErrorDetail{{Kind: "error", Message: err.Error()}}
+}
@@ -5,9 +5,26 @@ import (
 )
 
 type Position struct {
-	line  *int // 1 based
-	file  string
-	known bool
+	line   *int // 1 based
+	file   string
+	known  bool
+	extent *Extent
+}
+
+// Extent provides additional details about where node starts
+// and ends. Lines and columns are 1 based; offsets are 0 based
+// byte offsets from the beginning of the file. End is exclusive.
+// Columns count characters (code points), while UTF-16 columns
+// count UTF-16 code units (as used by editors, eg LSP).
+type Extent struct {
+	Column    int
+	Offset    int
+	EndLine   int
+	EndColumn int
+	EndOffset int
+
+	UTF16Column    int
+	EndUTF16Column int
 }
 
 func NewPosition(line int) *Position {
@@ -24,6 +41,17 @@ func NewUnknownPosition() *Position {
 
 func (p *Position) SetFile(file string) { p.file = file }
 
+func (p *Position) SetExtent(extent Extent) { p.extent = &extent }
+
+func (p *Position) Extent() (Extent, bool) {
+	if !p.IsKnown() || p.extent == nil {
+		return Extent{}, false
+	}
+	return *p.extent, true
+}
+
+func (p *Position) File() string { return p.file }
+
 func (p *Position) IsKnown() bool { return p != nil && p.known }
 
 func (p *Position) Line() int {
@@ -74,6 +102,10 @@ func (p *Position) DeepCopy() *Position {
 		lineVal := *p.line
 		newPos.line = &lineVal
 	}
+	if p.extent != nil {
+		extentVal := *p.extent
+		newPos.extent = &extentVal
+	}
 	return newPos
 }
 
@@ -86,5 +118,8 @@ func (p *Position) DeepCopyWithLineOffset(offset int) *Position {
 	}
 	newPos := p.DeepCopy()
 	*newPos.line += offset
+	if newPos.extent != nil {
+		newPos.extent.EndLine += offset
+	}
 	return newPos
 }
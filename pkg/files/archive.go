@@ -0,0 +1,334 @@
+package files
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+var (
+	tarArchiveExts = []string{".tar"}
+	tgzArchiveExts = []string{".tar.gz", ".tgz"}
+	allArchiveExts = append(append([]string{}, tarArchiveExts...), tgzArchiveExts...)
+)
+
+const (
+	archiveMaxLinkDepth = 10
+
+	StdinFormatFile = "file"
+	StdinFormatZip  = "zip"
+
+	DefaultArchiveMaxSize = 100 * 1024 * 1024
+)
+
+type ArchiveOpts struct {
+	SymlinkAllowOpts SymlinkAllowOpts
+	// MaxSize limits total uncompressed size of all entries; zero means no limit
+	MaxSize int64
+	// MaxFileSize limits uncompressed size of each entry; zero means no limit
+	MaxFileSize int64
+}
+
+// ArchiveFileSource represents a single entry within an archive
+type ArchiveFileSource struct {
+	archiveDesc string
+	path        string
+	data        []byte
+}
+
+var _ Source = ArchiveFileSource{}
+
+func NewArchiveFileSource(archiveDesc, path string, data []byte) ArchiveFileSource {
+	return ArchiveFileSource{archiveDesc, path, data}
+}
+
+func (s ArchiveFileSource) Description() string {
+	return fmt.Sprintf("file '%s' in %s", s.path, s.archiveDesc)
+}
+
+func (s ArchiveFileSource) RelativePath() (string, error) { return s.path, nil }
+func (s ArchiveFileSource) Bytes() ([]byte, error)        { return s.data, nil }
+
+func IsTarArchivePath(path string) bool {
+	return pathMatchesExt(path, allArchiveExts)
+}
+
+// NewFilesFromTarArchive returns files for each regular file entry within
+// tar archive. Archive is gzip decompressed if source path ends with .tar.gz or .tgz.
+func NewFilesFromTarArchive(src Source, opts ArchiveOpts) ([]*File, error) {
+	archiveBs, err := src.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("Reading %s: %s", src.Description(), err)
+	}
+
+	srcPath, err := src.RelativePath()
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = bytes.NewReader(archiveBs)
+
+	if pathMatchesExt(srcPath, tgzArchiveExts) {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("Decompressing %s: %s", src.Description(), err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	entries, links, err := readTarEntries(tar.NewReader(reader), newArchiveSizeLimit(opts.MaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("Reading archive %s: %s", src.Description(), err)
+	}
+
+	linkPaths := sortedLinkPaths(links)
+
+	if len(linkPaths) > 0 && !opts.SymlinkAllowOpts.AllowAll {
+		return nil, fmt.Errorf("Expected symlink entry '%s' -> '%s' in %s to be allowed, but was not "+
+			"(links within archives are only followed with --dangerous-allow-all-symlink-destinations)",
+			linkPaths[0], links[linkPaths[0]], src.Description())
+	}
+
+	for _, linkPath := range linkPaths {
+		data, err := resolveTarLink(linkPath, entries, links, 0)
+		if err != nil {
+			return nil, fmt.Errorf("Resolving symlink entry '%s' in %s: %s", linkPath, src.Description(), err)
+		}
+		entries[linkPath] = data
+	}
+
+	return newArchiveFiles(src, entries, opts)
+}
+
+// NewFilesFromZipArchive returns files for each regular file entry within zip archive.
+// Links are not supported within zip archives.
+func NewFilesFromZipArchive(src Source, opts ArchiveOpts) ([]*File, error) {
+	archiveBs, err := src.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("Reading %s: %s", src.Description(), err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archiveBs), int64(len(archiveBs)))
+	if err != nil {
+		return nil, fmt.Errorf("Reading archive %s: %s", src.Description(), err)
+	}
+
+	entries, err := readZipEntries(reader, newArchiveSizeLimit(opts.MaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("Reading archive %s: %s", src.Description(), err)
+	}
+
+	return newArchiveFiles(src, entries, opts)
+}
+
+func newArchiveFiles(src Source, entries map[string][]byte, opts ArchiveOpts) ([]*File, error) {
+	var result []*File
+
+	for _, entryPath := range sortedEntryPaths(entries) {
+		entrySrc := NewArchiveFileSource(src.Description(), entryPath, entries[entryPath])
+
+		err := checkFileSize(entrySrc.Description(), int64(len(entries[entryPath])), opts.MaxFileSize)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := NewFileFromSource(entrySrc)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+func readZipEntries(reader *zip.Reader, limit *archiveSizeLimit) (map[string][]byte, error) {
+	entries := map[string][]byte{}
+
+	for _, zipFile := range reader.File {
+		entryPath, err := cleanArchiveEntryPath(zipFile.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		mode := zipFile.Mode()
+
+		switch {
+		case mode.IsDir():
+			// do nothing
+
+		case mode.IsRegular():
+			entryReader, err := zipFile.Open()
+			if err != nil {
+				return nil, fmt.Errorf("Opening entry '%s': %s", entryPath, err)
+			}
+
+			data, err := limit.ReadAll(entryReader)
+			entryReader.Close()
+			if err != nil {
+				return nil, fmt.Errorf("Reading entry '%s': %s", entryPath, err)
+			}
+			entries[entryPath] = data
+
+		default:
+			return nil, fmt.Errorf("Expected entry '%s' to be a regular file or directory, but was not", entryPath)
+		}
+	}
+
+	return entries, nil
+}
+
+func readTarEntries(reader *tar.Reader, limit *archiveSizeLimit) (map[string][]byte, map[string]string, error) {
+	entries := map[string][]byte{}
+	links := map[string]string{}
+
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, err
+		}
+
+		entryPath, err := cleanArchiveEntryPath(header.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			// do nothing
+
+		case tar.TypeReg, tar.TypeRegA:
+			data, err := limit.ReadAll(reader)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Reading entry '%s': %s", entryPath, err)
+			}
+			entries[entryPath] = data
+
+		case tar.TypeSymlink:
+			// Symlink destination is relative to the directory of the entry
+			linkPath := header.Linkname
+			if !path.IsAbs(linkPath) {
+				linkPath = path.Join(path.Dir(entryPath), linkPath)
+			}
+			linkPath, err = cleanArchiveEntryPath(linkPath)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Checking symlink entry '%s': %s", entryPath, err)
+			}
+			links[entryPath] = linkPath
+
+		case tar.TypeLink:
+			// Hard link destination is relative to the archive root
+			linkPath, err := cleanArchiveEntryPath(header.Linkname)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Checking link entry '%s': %s", entryPath, err)
+			}
+			links[entryPath] = linkPath
+
+		default:
+			return nil, nil, fmt.Errorf("Expected entry '%s' to be a regular file, directory or link, but was not", entryPath)
+		}
+	}
+
+	return entries, links, nil
+}
+
+func resolveTarLink(linkPath string, entries map[string][]byte, links map[string]string, depth int) ([]byte, error) {
+	if depth > archiveMaxLinkDepth {
+		return nil, fmt.Errorf("Too many levels of symlinks")
+	}
+
+	dstPath := links[linkPath]
+
+	if data, found := entries[dstPath]; found {
+		return data, nil
+	}
+	if _, found := links[dstPath]; found {
+		return resolveTarLink(dstPath, entries, links, depth+1)
+	}
+
+	return nil, fmt.Errorf("Expected destination '%s' to be a file within archive", dstPath)
+}
+
+// archiveSizeLimit tracks total number of bytes read from
+// all entries to avoid decompressing unbounded amount of data
+type archiveSizeLimit struct {
+	max       int64
+	remaining int64
+}
+
+func newArchiveSizeLimit(max int64) *archiveSizeLimit {
+	return &archiveSizeLimit{max, max}
+}
+
+func (l *archiveSizeLimit) ReadAll(reader io.Reader) ([]byte, error) {
+	if l.max <= 0 {
+		return ioutil.ReadAll(reader)
+	}
+
+	// Read one extra byte to detect that limit was exceeded
+	data, err := ioutil.ReadAll(io.LimitReader(reader, l.remaining+1))
+	if err != nil {
+		return nil, err
+	}
+
+	l.remaining -= int64(len(data))
+
+	if l.remaining < 0 {
+		return nil, fmt.Errorf("Expected archive contents to not exceed %d bytes (uncompressed), but did", l.max)
+	}
+
+	return data, nil
+}
+
+// cleanArchiveEntryPath ensures that entry is not able to escape archive root
+func cleanArchiveEntryPath(entryPath string) (string, error) {
+	if path.IsAbs(entryPath) {
+		return "", fmt.Errorf("Expected archive entry '%s' to have relative path", entryPath)
+	}
+
+	cleanPath := path.Clean(entryPath)
+
+	if cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+		return "", fmt.Errorf("Expected archive entry '%s' to not refer to parent directory", entryPath)
+	}
+
+	return strings.TrimPrefix(cleanPath, "./"), nil
+}
+
+func sortedEntryPaths(entries map[string][]byte) []string {
+	var result []string
+	for entryPath := range entries {
+		result = append(result, entryPath)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sortedLinkPaths(links map[string]string) []string {
+	var result []string
+	for linkPath := range links {
+		result = append(result, linkPath)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func pathMatchesExt(path string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+package files_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+type tarEntry struct {
+	Name     string
+	Data     string
+	Linkname string
+}
+
+func TestTarArchiveFiles(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	entries := []tarEntry{
+		{Name: "./config/"},
+		{Name: "./config/b.yml", Data: "b: 1"},
+		{Name: "./config/a.yml", Data: "a: 1"},
+		{Name: "values.star", Data: "x = 1"},
+	}
+
+	for _, name := range []string{"in.tar", "in.tgz", "in.tar.gz"} {
+		archivePath := filepath.Join(dirPath, name)
+		writeTarArchive(t, archivePath, entries)
+
+		result, err := files.NewSortedFilesFromPaths([]string{archivePath}, files.SourceOpts{})
+		if err != nil {
+			t.Fatalf("Expected reading '%s' to succeed: %s", name, err)
+		}
+
+		var paths []string
+		for _, file := range result {
+			paths = append(paths, file.RelativePath())
+		}
+
+		if strings.Join(paths, ",") != "config/a.yml,config/b.yml,values.star" {
+			t.Fatalf("Expected archive '%s' files to match, but was: %#v", name, paths)
+		}
+
+		if result[0].Type() != files.TypeYAML || result[2].Type() != files.TypeStarlark {
+			t.Fatalf("Expected archive '%s' file types to be detected", name)
+		}
+
+		bs, err := result[1].Bytes()
+		if err != nil || string(bs) != "b: 1" {
+			t.Fatalf("Expected archive '%s' file contents to match, but was: %s (err: %v)", name, bs, err)
+		}
+	}
+}
+
+func TestTarArchiveFilesDisallowEscaping(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	examples := []struct {
+		Entry       tarEntry
+		ExpectedErr string
+	}{
+		{tarEntry{Name: "../a.yml", Data: "a: 1"}, "Expected archive entry '../a.yml' to not refer to parent directory"},
+		{tarEntry{Name: "/etc/a.yml", Data: "a: 1"}, "Expected archive entry '/etc/a.yml' to have relative path"},
+		{tarEntry{Name: "a.yml", Linkname: "../../etc/passwd"}, "Checking symlink entry 'a.yml': Expected archive entry '../../etc/passwd' to not refer to parent directory"},
+		{tarEntry{Name: "a.yml", Linkname: "b.yml"}, "Expected symlink entry 'a.yml' -> 'b.yml'"},
+	}
+
+	for _, ex := range examples {
+		archivePath := filepath.Join(dirPath, "in.tar")
+		writeTarArchive(t, archivePath, []tarEntry{ex.Entry, {Name: "b.yml", Data: "b: 1"}})
+
+		_, err := files.NewSortedFilesFromPaths([]string{archivePath}, files.SourceOpts{})
+		if err == nil {
+			t.Fatalf("Expected reading archive with '%s' to fail", ex.Entry.Name)
+		}
+		if !strings.Contains(err.Error(), ex.ExpectedErr) {
+			t.Fatalf("Expected err to contain '%s', but was: %s", ex.ExpectedErr, err)
+		}
+	}
+}
+
+func TestTarArchiveFilesAllowedSymlinks(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	archivePath := filepath.Join(dirPath, "in.tar")
+	writeTarArchive(t, archivePath, []tarEntry{
+		{Name: "dir/link.yml", Linkname: "../b.yml"},
+		{Name: "b.yml", Data: "b: 1"},
+	})
+
+	opts := files.SourceOpts{SymlinkAllowOpts: files.SymlinkAllowOpts{AllowAll: true}}
+
+	result, err := files.NewSortedFilesFromPaths([]string{archivePath}, opts)
+	if err != nil {
+		t.Fatalf("Expected reading archive to succeed: %s", err)
+	}
+
+	if len(result) != 2 || result[1].RelativePath() != "dir/link.yml" {
+		t.Fatalf("Expected symlink to be included")
+	}
+
+	bs, err := result[1].Bytes()
+	if err != nil || string(bs) != "b: 1" {
+		t.Fatalf("Expected symlink contents to match destination, but was: %s (err: %v)", bs, err)
+	}
+}
+
+func TestZipArchiveFiles(t *testing.T) {
+	archiveBs := buildZipArchive(t, []tarEntry{
+		{Name: "config/"},
+		{Name: "config/b.yml", Data: "b: 1"},
+		{Name: "./a.yml", Data: "a: 1"},
+	})
+
+	result, err := files.NewFilesFromZipArchive(files.NewBytesSource("stdin", archiveBs), files.ArchiveOpts{})
+	if err != nil {
+		t.Fatalf("Expected reading zip to succeed: %s", err)
+	}
+
+	if len(result) != 2 || result[0].RelativePath() != "a.yml" || result[1].RelativePath() != "config/b.yml" {
+		t.Fatalf("Expected zip files to match")
+	}
+
+	if result[1].Description() != "file 'config/b.yml' in stdin" {
+		t.Fatalf("Expected zip file description to match, but was: %s", result[1].Description())
+	}
+
+	bs, err := result[1].Bytes()
+	if err != nil || string(bs) != "b: 1" {
+		t.Fatalf("Expected zip file contents to match, but was: %s (err: %v)", bs, err)
+	}
+}
+
+func TestZipArchiveFilesDisallowEscaping(t *testing.T) {
+	archiveBs := buildZipArchive(t, []tarEntry{{Name: "../../a.yml", Data: "a: 1"}})
+
+	_, err := files.NewFilesFromZipArchive(files.NewBytesSource("stdin", archiveBs), files.ArchiveOpts{})
+	if err == nil {
+		t.Fatalf("Expected reading zip to fail")
+	}
+	if err.Error() != "Reading archive stdin: Expected archive entry '../../a.yml' to not refer to parent directory" {
+		t.Fatalf("Expected err to match, but was: %s", err)
+	}
+}
+
+func TestArchiveMaxSize(t *testing.T) {
+	entries := []tarEntry{
+		{Name: "a.yml", Data: strings.Repeat("a", 60)},
+		{Name: "b.yml", Data: strings.Repeat("b", 60)},
+	}
+	expectedErr := "Expected archive contents to not exceed 100 bytes (uncompressed), but did"
+
+	archiveBs := buildZipArchive(t, entries)
+
+	_, err := files.NewFilesFromZipArchive(files.NewBytesSource("stdin", archiveBs), files.ArchiveOpts{MaxSize: 100})
+	if err == nil || !strings.Contains(err.Error(), expectedErr) {
+		t.Fatalf("Expected zip size err, but was: %v", err)
+	}
+
+	_, err = files.NewFilesFromZipArchive(files.NewBytesSource("stdin", archiveBs), files.ArchiveOpts{MaxSize: 120})
+	if err != nil {
+		t.Fatalf("Expected zip within limit to succeed: %s", err)
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	archivePath := filepath.Join(dirPath, "in.tgz")
+	writeTarArchive(t, archivePath, entries)
+
+	_, err = files.NewSortedFilesFromPaths([]string{archivePath}, files.SourceOpts{ArchiveMaxSize: 100})
+	if err == nil || !strings.Contains(err.Error(), expectedErr) {
+		t.Fatalf("Expected tar size err, but was: %v", err)
+	}
+}
+
+func buildZipArchive(t *testing.T, entries []tarEntry) []byte {
+	buf := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buf)
+
+	for _, entry := range entries {
+		entryWriter, err := zipWriter.Create(entry.Name)
+		if err != nil {
+			t.Fatalf("Creating zip entry: %s", err)
+		}
+		_, err = entryWriter.Write([]byte(entry.Data))
+		if err != nil {
+			t.Fatalf("Writing zip entry: %s", err)
+		}
+	}
+
+	err := zipWriter.Close()
+	if err != nil {
+		t.Fatalf("Closing zip: %s", err)
+	}
+
+	return buf.Bytes()
+}
+
+func writeTarArchive(t *testing.T, path string, entries []tarEntry) {
+	buf := new(bytes.Buffer)
+	tarWriter := tar.NewWriter(buf)
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.Name, Mode: 0600, Size: int64(len(entry.Data))}
+		switch {
+		case len(entry.Linkname) > 0:
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.Linkname
+		case strings.HasSuffix(entry.Name, "/"):
+			header.Typeflag = tar.TypeDir
+		default:
+			header.Typeflag = tar.TypeReg
+		}
+
+		err := tarWriter.WriteHeader(header)
+		if err != nil {
+			t.Fatalf("Writing tar header: %s", err)
+		}
+		_, err = tarWriter.Write([]byte(entry.Data))
+		if err != nil {
+			t.Fatalf("Writing tar entry: %s", err)
+		}
+	}
+
+	err := tarWriter.Close()
+	if err != nil {
+		t.Fatalf("Closing tar: %s", err)
+	}
+
+	archiveBs := buf.Bytes()
+
+	if strings.HasSuffix(path, "gz") {
+		gzipBuf := new(bytes.Buffer)
+		gzipWriter := gzip.NewWriter(gzipBuf)
+		gzipWriter.Write(archiveBs)
+		gzipWriter.Close()
+		archiveBs = gzipBuf.Bytes()
+	}
+
+	err = ioutil.WriteFile(path, archiveBs, 0600)
+	if err != nil {
+		t.Fatalf("Writing archive: %s", err)
+	}
+}
@@ -0,0 +1,320 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	diffContextLines = 3
+)
+
+type diffOpKind byte
+
+const (
+	diffOpEqual  diffOpKind = ' '
+	diffOpDelete diffOpKind = '-'
+	diffOpInsert diffOpKind = '+'
+)
+
+type diffOp struct {
+	kind diffOpKind
+	line string
+}
+
+// unifiedDiff returns diff in unified format (with 3 lines of context);
+// empty string is returned if contents are the same
+func unifiedDiff(oldName, newName string, oldBs, newBs []byte) string {
+	if bytes.Equal(oldBs, newBs) {
+		return ""
+	}
+
+	if isBinaryDiffContent(oldBs) || isBinaryDiffContent(newBs) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", oldName, newName)
+	}
+
+	ops := diffLines(diffSplitLines(oldBs), diffSplitLines(newBs))
+
+	var result strings.Builder
+
+	fmt.Fprintf(&result, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, hunk := range diffHunks(ops) {
+		result.WriteString(hunk)
+	}
+
+	return result.String()
+}
+
+func isBinaryDiffContent(bs []byte) bool {
+	return bytes.IndexByte(bs, 0) != -1 || !utf8.Valid(bs)
+}
+
+func diffSplitLines(bs []byte) []string {
+	lines := strings.SplitAfter(string(bs), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines implements linear space variant of Myers diff algorithm:
+// common prefix and suffix are skipped, and remaining lines are split at
+// the middle snake of shortest edit script and diffed recursively.
+// Memory use is O(n+m) (instead of O((n+m)^2) needed to keep all
+// intermediate paths for backtracking).
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	diffLinesInto(a, b, &ops)
+	return diffGroupChanges(ops)
+}
+
+func diffLinesInto(a, b []string, ops *[]diffOp) {
+	prefixLen := 0
+	for prefixLen < len(a) && prefixLen < len(b) && a[prefixLen] == b[prefixLen] {
+		prefixLen++
+	}
+	for _, line := range a[:prefixLen] {
+		*ops = append(*ops, diffOp{diffOpEqual, line})
+	}
+	a, b = a[prefixLen:], b[prefixLen:]
+
+	suffixLen := 0
+	for suffixLen < len(a) && suffixLen < len(b) && a[len(a)-1-suffixLen] == b[len(b)-1-suffixLen] {
+		suffixLen++
+	}
+	suffix := a[len(a)-suffixLen:]
+	a, b = a[:len(a)-suffixLen], b[:len(b)-suffixLen]
+
+	x, y := -1, -1
+	if len(a) > 0 && len(b) > 0 {
+		x, y = diffMiddleSnake(a, b)
+	}
+
+	// Split point must make progress; without it (eg no common lines)
+	// all lines are replaced
+	if x <= 0 && y <= 0 || x >= len(a) && y >= len(b) {
+		for _, line := range a {
+			*ops = append(*ops, diffOp{diffOpDelete, line})
+		}
+		for _, line := range b {
+			*ops = append(*ops, diffOp{diffOpInsert, line})
+		}
+	} else {
+		diffLinesInto(a[:x], b[:y], ops)
+		diffLinesInto(a[x:], b[y:], ops)
+	}
+
+	for _, line := range suffix {
+		*ops = append(*ops, diffOp{diffOpEqual, line})
+	}
+}
+
+// diffMiddleSnake runs Myers algorithm from both ends at the same time
+// and returns point where paths overlap (it lies on a shortest edit
+// script); -1, -1 is returned if a and b do not have common lines
+func diffMiddleSnake(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	vOffset := maxD
+	vLen := 2*maxD + 2
+
+	// v1 keeps furthest x of forward paths, v2 of reverse
+	// paths (counted from the end) for each diagonal
+	v1, v2 := make([]int, vLen), make([]int, vLen)
+	for i := range v1 {
+		v1[i], v2[i] = -1, -1
+	}
+	v1[vOffset+1], v2[vOffset+1] = 0, 0
+
+	delta := n - m
+	// Forward path checks for overlap if delta is odd
+	front := delta%2 != 0
+
+	// Diagonals running off the grid are skipped
+	var k1Start, k1End, k2Start, k2End int
+
+	for d := 0; d < maxD; d++ {
+		for k1 := -d + k1Start; k1 <= d-k1End; k1 += 2 {
+			k1Offset := vOffset + k1
+
+			var x1 int
+			if k1 == -d || (k1 != d && v1[k1Offset-1] < v1[k1Offset+1]) {
+				x1 = v1[k1Offset+1]
+			} else {
+				x1 = v1[k1Offset-1] + 1
+			}
+			y1 := x1 - k1
+
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+			v1[k1Offset] = x1
+
+			switch {
+			case x1 > n:
+				k1End += 2
+			case y1 > m:
+				k1Start += 2
+			case front:
+				k2Offset := vOffset + delta - k1
+				if k2Offset >= 0 && k2Offset < vLen && v2[k2Offset] != -1 {
+					if x1 >= n-v2[k2Offset] {
+						return x1, y1
+					}
+				}
+			}
+		}
+
+		for k2 := -d + k2Start; k2 <= d-k2End; k2 += 2 {
+			k2Offset := vOffset + k2
+
+			var x2 int
+			if k2 == -d || (k2 != d && v2[k2Offset-1] < v2[k2Offset+1]) {
+				x2 = v2[k2Offset+1]
+			} else {
+				x2 = v2[k2Offset-1] + 1
+			}
+			y2 := x2 - k2
+
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+			v2[k2Offset] = x2
+
+			switch {
+			case x2 > n:
+				k2End += 2
+			case y2 > m:
+				k2Start += 2
+			case !front:
+				k1Offset := vOffset + delta - k2
+				if k1Offset >= 0 && k1Offset < vLen && v1[k1Offset] != -1 {
+					x1 := v1[k1Offset]
+					y1 := vOffset + x1 - k1Offset
+					if x1 >= n-x2 {
+						return x1, y1
+					}
+				}
+			}
+		}
+	}
+
+	return -1, -1
+}
+
+// diffGroupChanges moves deletions before insertions within each
+// block of consecutive changes (conventional for unified diffs)
+func diffGroupChanges(ops []diffOp) []diffOp {
+	result := make([]diffOp, 0, len(ops))
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == diffOpEqual {
+			result = append(result, ops[i])
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(ops) && ops[end].kind != diffOpEqual {
+			end++
+		}
+		for _, kind := range []diffOpKind{diffOpDelete, diffOpInsert} {
+			for _, op := range ops[i:end] {
+				if op.kind == kind {
+					result = append(result, op)
+				}
+			}
+		}
+		i = end
+	}
+
+	return result
+}
+
+func diffHunks(ops []diffOp) []string {
+	var result []string
+
+	for start := 0; start < len(ops); {
+		// Find next change
+		for start < len(ops) && ops[start].kind == diffOpEqual {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend hunk while changes are close enough to share context
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != diffOpEqual {
+				end = i
+			} else if i-end > 2*diffContextLines {
+				break
+			}
+		}
+
+		hunkStart := start - diffContextLines
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		hunkEnd := end + diffContextLines + 1
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		result = append(result, diffHunk(ops, hunkStart, hunkEnd))
+		start = hunkEnd
+	}
+
+	return result
+}
+
+func diffHunk(ops []diffOp, start, end int) string {
+	var oldLine, newLine int
+
+	for _, op := range ops[:start] {
+		if op.kind != diffOpInsert {
+			oldLine++
+		}
+		if op.kind != diffOpDelete {
+			newLine++
+		}
+	}
+
+	var oldCount, newCount int
+	var body strings.Builder
+
+	for _, op := range ops[start:end] {
+		if op.kind != diffOpInsert {
+			oldCount++
+		}
+		if op.kind != diffOpDelete {
+			newCount++
+		}
+
+		body.WriteByte(byte(op.kind))
+		body.WriteString(op.line)
+
+		if !strings.HasSuffix(op.line, "\n") {
+			body.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+
+	return fmt.Sprintf("@@ -%s +%s @@\n%s", diffRange(oldLine, oldCount), diffRange(newLine, newCount), body.String())
+}
+
+func diffRange(linesBefore, count int) string {
+	// Empty ranges refer to the line before them
+	if count == 0 {
+		return fmt.Sprintf("%d,0", linesBefore)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", linesBefore+1)
+	}
+	return fmt.Sprintf("%d,%d", linesBefore+1, count)
+}
@@ -0,0 +1,150 @@
+package files
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDiffLinesMinimal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		a := randomDiffLines(rnd, rnd.Intn(20))
+		b := randomDiffLines(rnd, rnd.Intn(20))
+
+		ops := diffLines(a, b)
+
+		var oldLines, newLines []string
+		changes := 0
+
+		for _, op := range ops {
+			if op.kind != diffOpInsert {
+				oldLines = append(oldLines, op.line)
+			}
+			if op.kind != diffOpDelete {
+				newLines = append(newLines, op.line)
+			}
+			if op.kind != diffOpEqual {
+				changes++
+			}
+		}
+
+		if strings.Join(oldLines, "") != strings.Join(a, "") || strings.Join(newLines, "") != strings.Join(b, "") {
+			t.Fatalf("Expected ops to reconstruct inputs %#v and %#v, but was: %#v", a, b, ops)
+		}
+
+		// Shortest edit script keeps longest common subsequence
+		expectedChanges := len(a) + len(b) - 2*lcsLen(a, b)
+		if changes != expectedChanges {
+			t.Fatalf("Expected %d changes for %#v and %#v, but was %d: %#v", expectedChanges, a, b, changes, ops)
+		}
+	}
+}
+
+func TestUnifiedDiffLargeRewrite(t *testing.T) {
+	var oldContent, newContent strings.Builder
+
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&oldContent, "old: %d\n", i)
+		fmt.Fprintf(&newContent, "new: %d\n", i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	result := unifiedDiff("a/x.yml", "b/x.yml", []byte(oldContent.String()), []byte(newContent.String()))
+
+	runtime.ReadMemStats(&after)
+
+	if !strings.HasPrefix(result, "--- a/x.yml\n+++ b/x.yml\n@@ -1,5000 +1,5000 @@\n-old: 0\n") ||
+		!strings.HasSuffix(result, "+new: 4999\n") || strings.Count(result, "\n") != 3+10000 {
+		t.Fatalf("Expected diff to replace all lines, but was: >>>%s<<<", result[:200])
+	}
+
+	// Quadratic memory would need gigabytes
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64*1024*1024 {
+		t.Fatalf("Expected diff to allocate less than 64MB, but allocated %d bytes", allocated)
+	}
+}
+
+func TestUnifiedDiffLargeWithEdits(t *testing.T) {
+	var oldLines, newLines []string
+
+	for i := 0; i < 5000; i++ {
+		line := fmt.Sprintf("line: %d\n", i)
+		oldLines = append(oldLines, line)
+		switch {
+		case i == 10:
+			newLines = append(newLines, "changed: 10\n")
+		case i == 2500:
+			// deleted
+		default:
+			newLines = append(newLines, line)
+		}
+	}
+	newLines = append(newLines, "added\n")
+
+	result := unifiedDiff("a/x.yml", "b/x.yml", []byte(strings.Join(oldLines, "")), []byte(strings.Join(newLines, "")))
+
+	expected := `--- a/x.yml
++++ b/x.yml
+@@ -8,7 +8,7 @@
+ line: 7
+ line: 8
+ line: 9
+-line: 10
++changed: 10
+ line: 11
+ line: 12
+ line: 13
+@@ -2498,7 +2498,6 @@
+ line: 2497
+ line: 2498
+ line: 2499
+-line: 2500
+ line: 2501
+ line: 2502
+ line: 2503
+@@ -4998,3 +4997,4 @@
+ line: 4997
+ line: 4998
+ line: 4999
++added
+`
+
+	if result != expected {
+		t.Fatalf("Expected diff to match, but was: >>>%s<<<", result)
+	}
+}
+
+func randomDiffLines(rnd *rand.Rand, count int) []string {
+	var result []string
+	for i := 0; i < count; i++ {
+		// Small alphabet results in many common lines
+		result = append(result, string(rune('a'+rnd.Intn(3)))+"\n")
+	}
+	return result
+}
+
+func lcsLen(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				table[i][j] = table[i+1][j+1] + 1
+			case table[i+1][j] > table[i][j+1]:
+				table[i][j] = table[i+1][j]
+			default:
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+	return table[0][0]
+}
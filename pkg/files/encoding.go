@@ -0,0 +1,96 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type Encoding string
+
+const (
+	EncodingUTF8    Encoding = "utf-8"
+	EncodingUTF16   Encoding = "utf-16" // byte order is detected via BOM (big endian without BOM)
+	EncodingUTF16LE Encoding = "utf-16le"
+	EncodingUTF16BE Encoding = "utf-16be"
+	EncodingLatin1  Encoding = "latin-1"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// ParseEncoding returns encoding by its name (case insensitive);
+// common aliases (eg 'utf8', 'iso-8859-1') are accepted as well
+func ParseEncoding(name string) (Encoding, error) {
+	switch strings.ToLower(name) {
+	case "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "utf-16", "utf16":
+		return EncodingUTF16, nil
+	case "utf-16le", "utf16le":
+		return EncodingUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return EncodingUTF16BE, nil
+	case "latin-1", "latin1", "iso-8859-1":
+		return EncodingLatin1, nil
+	default:
+		return "", fmt.Errorf("Unknown encoding '%s' (expected utf-8, utf-16, utf-16le, utf-16be or latin-1)", name)
+	}
+}
+
+// Decode converts bytes in given encoding to UTF-8 (without BOM)
+func (e Encoding) Decode(data []byte) ([]byte, error) {
+	switch e {
+	case EncodingUTF8:
+		data = bytes.TrimPrefix(data, bomUTF8)
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("Expected contents to be valid UTF-8")
+		}
+		return data, nil
+
+	case EncodingUTF16:
+		if bytes.HasPrefix(data, bomUTF16LE) {
+			return e.decodeUTF16(data[len(bomUTF16LE):], false)
+		}
+		return e.decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), true)
+
+	case EncodingUTF16LE:
+		return e.decodeUTF16(bytes.TrimPrefix(data, bomUTF16LE), false)
+
+	case EncodingUTF16BE:
+		return e.decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), true)
+
+	case EncodingLatin1:
+		// Latin-1 bytes map directly to first 256 code points
+		var result bytes.Buffer
+		for _, b := range data {
+			result.WriteRune(rune(b))
+		}
+		return result.Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("Unknown encoding '%s'", e)
+	}
+}
+
+func (e Encoding) decodeUTF16(data []byte, bigEndian bool) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("Expected contents to be valid %s, but had odd number of bytes", e)
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+
+	return []byte(string(utf16.Decode(units))), nil
+}
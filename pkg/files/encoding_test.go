@@ -0,0 +1,90 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestEncodingDecode(t *testing.T) {
+	examples := []struct {
+		Encoding string
+		Input    string
+		Expected string
+	}{
+		{"utf-8", "\xEF\xBB\xBFa: é", "a: é"},
+		{"UTF8", "a: é", "a: é"},
+		{"utf-16le", "\xFF\xFEa\x00:\x00 \x00\xE9\x00", "a: é"},
+		{"utf-16le", "a\x00:\x00 \x00\x3D\xD8\x00\xDE", "a: \U0001F600"},
+		{"utf-16be", "\xFE\xFF\x00a\x00:\x00 \x00\xE9", "a: é"},
+		{"utf-16", "\xFF\xFEa\x00", "a"},
+		{"utf-16", "\xFE\xFF\x00a", "a"},
+		{"utf-16", "\x00a", "a"},
+		{"latin-1", "a: caf\xE9", "a: café"},
+		{"iso-8859-1", "\xA9", "©"},
+	}
+
+	for _, ex := range examples {
+		enc, err := files.ParseEncoding(ex.Encoding)
+		if err != nil {
+			t.Fatalf("Expected parsing encoding to succeed: %s", err)
+		}
+
+		result, err := enc.Decode([]byte(ex.Input))
+		if err != nil {
+			t.Fatalf("Expected decoding %q as %s to succeed: %s", ex.Input, ex.Encoding, err)
+		}
+		if string(result) != ex.Expected {
+			t.Fatalf("Expected decoding %q as %s to produce %q, but was %q", ex.Input, ex.Encoding, ex.Expected, result)
+		}
+	}
+
+	_, err := files.EncodingUTF8.Decode([]byte("caf\xE9"))
+	if err == nil || err.Error() != "Expected contents to be valid UTF-8" {
+		t.Fatalf("Expected invalid UTF-8 err, but was: %v", err)
+	}
+
+	_, err = files.EncodingUTF16LE.Decode([]byte("a\x00b"))
+	if err == nil || err.Error() != "Expected contents to be valid utf-16le, but had odd number of bytes" {
+		t.Fatalf("Expected odd length err, but was: %v", err)
+	}
+
+	_, err = files.ParseEncoding("ebcdic")
+	if err == nil || !strings.Contains(err.Error(), "Unknown encoding 'ebcdic'") {
+		t.Fatalf("Expected unknown encoding err, but was: %v", err)
+	}
+}
+
+func TestEncodingFiles(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	for path, content := range map[string]string{"a.yml": "\xFF\xFEa\x00", "b.bin": "\xFF\xFE"} {
+		err := ioutil.WriteFile(filepath.Join(dirPath, path), []byte(content), 0600)
+		if err != nil {
+			t.Fatalf("Expected write to succeed: %s", err)
+		}
+	}
+
+	result, err := files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{Encoding: files.EncodingUTF16LE})
+	if err != nil {
+		t.Fatalf("Expected creating files to succeed: %s", err)
+	}
+
+	bs, err := result[0].Bytes()
+	if err != nil || string(bs) != "a" {
+		t.Fatalf("Expected file to be decoded, but was %q (err: %v)", bs, err)
+	}
+
+	// Binary files are kept as is
+	result[1].MarkType(files.TypeBinary)
+
+	bs, err = result[1].Bytes()
+	if err != nil || string(bs) != "\xFF\xFE" {
+		t.Fatalf("Expected binary file to be kept as is, but was %q (err: %v)", bs, err)
+	}
+}
@@ -4,12 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 )
 
 var (
 	yamlExts     = []string{".yaml", ".yml"}
+	jsonExts     = []string{".json"}
 	starlarkExts = []string{".star"}
 	textExts     = []string{".txt"}
 	libraryExt   = "lib" // eg .lib.yaml
@@ -22,23 +22,163 @@ const (
 	TypeYAML
 	TypeText
 	TypeStarlark
+	TypeJSON // parsed into same document model as YAML, but never templated
+	// TypeYAMLFrontMatter is a text file (eg Markdown) whose YAML front matter
+	// is templated, while the rest of the file is kept as is
+	TypeYAMLFrontMatter
+	// TypeEnvTemplate is a text file whose shell style
+	// variables (eg '${name}') are substituted with data values
+	TypeEnvTemplate
+	// TypeBinary is a file whose contents are written
+	// to output directory as is, without any processing
+	TypeBinary
 )
 
+// String returns type name as used in file marks and flags
+// (eg 'data' for files that are not templated or loaded as code)
+func (t Type) String() string {
+	switch t {
+	case TypeYAML:
+		return "yaml"
+	case TypeText:
+		return "text"
+	case TypeStarlark:
+		return "starlark"
+	case TypeJSON:
+		return "json"
+	case TypeYAMLFrontMatter:
+		return "yaml-front-matter"
+	case TypeEnvTemplate:
+		return "env-template"
+	case TypeBinary:
+		return "binary"
+	default:
+		return "data"
+	}
+}
+
 type File struct {
 	src     Source
 	relPath string
 
 	markedRelPath   *string
+	markedOutputDir *string
+	markedPathTpl   *string
 	markedType      *Type
+	defaultType     *Type
 	markedTemplate  *bool
 	markedForOutput *bool
+	markedMode      *os.FileMode
+	markedEncoding  *Encoding
+
+	annotations map[string]string
+
+	srcMode *os.FileMode // only available for local files
 
 	order int // lowest comes first; 0 is used to indicate unsorted
 }
 
-func NewSortedFilesFromPaths(paths []string, opts SymlinkAllowOpts) ([]*File, error) {
+type SourceOpts struct {
+	SymlinkAllowOpts SymlinkAllowOpts
+	HTTPSourceOpts   HTTPSourceOpts
+	GitSourceOpts    GitSourceOpts
+	OCISourceOpts    OCISourceOpts
+	K8sSourceOpts    K8sSourceOpts
+
+	// StdinFormat controls how '-' is read (file or zip); empty means file
+	StdinFormat    string
+	ArchiveMaxSize int64 // zero means no limit
+
+	// StdinPath is relative path given to stdin file (eg 'values.yml'),
+	// hence its extension determines type; empty means 'stdin.yml'
+	StdinPath string
+
+	// MaxFileSize limits size of any single input file (local,
+	// stdin, HTTP or archive entry); zero means no limit
+	MaxFileSize int64
+
+	// DefaultType is used for files with unrecognized extensions
+	// and for stdin; nil keeps such files as TypeUnknown (stdin as YAML)
+	DefaultType *Type
+
+	// Encoding is used to decode contents of all files (except binary
+	// ones) into UTF-8; empty keeps contents as is
+	Encoding Encoding
+
+	// DetectShebang sets type of local files with unrecognized
+	// extensions based on shebang line (eg '#!/usr/bin/env starlark')
+	DetectShebang bool
+
+	// NoIgnoreFile disables skipping of files listed in
+	// ignore file (IgnoreFileName) at the root of input directories
+	NoIgnoreFile bool
+
+	// NoGlob disables expansion of glob patterns (eg 'config/**/*.yml')
+	NoGlob bool
+	// AllowEmptyGlob permits glob patterns that do not match any files
+	AllowEmptyGlob bool
+
+	// ReadConcurrency is number of workers used to read file contents
+	// upfront; zero keeps files to be read lazily (once used)
+	ReadConcurrency int
+
+	// AllowDuplicatePaths keeps last of files that have the same
+	// relative path (instead of failing)
+	AllowDuplicatePaths bool
+
+	// NaturalSort sorts files within a directory (or archive)
+	// comparing numbers numerically (eg '2-x.yml' before '10-x.yml')
+	// instead of lexicographically
+	NaturalSort bool
+}
+
+func isIgnoredPath(rootPath, walkedPath string, fi os.FileInfo, rules *IgnoreRules, opts SourceOpts) (bool, error) {
+	// Output manifest (eg left in output directory used as input) describes output
+	if walkedPath != rootPath && !fi.IsDir() && fi.Name() == DefaultManifestFileName {
+		return true, nil
+	}
+
+	if opts.NoIgnoreFile || walkedPath == rootPath {
+		return false, nil
+	}
+
+	relPath, err := filepath.Rel(rootPath, walkedPath)
+	if err != nil {
+		return false, err
+	}
+
+	relPath = filepath.ToSlash(relPath)
+
+	// Ignore file configures input, hence is not an input itself
+	if relPath == IgnoreFileName {
+		return true, nil
+	}
+
+	return rules.Matches(relPath, fi.IsDir()), nil
+}
+
+func (o SourceOpts) archiveOpts() ArchiveOpts {
+	return ArchiveOpts{SymlinkAllowOpts: o.SymlinkAllowOpts,
+		MaxSize: o.ArchiveMaxSize, MaxFileSize: o.MaxFileSize}
+}
+
+func (o SourceOpts) httpSourceOpts() HTTPSourceOpts {
+	httpOpts := o.HTTPSourceOpts
+	httpOpts.MaxSize = o.MaxFileSize
+	return httpOpts
+}
+
+func NewSortedFilesFromPaths(paths []string, opts SourceOpts) ([]*File, error) {
 	var groupedFiles [][]*File
 
+	gitCheckouts := newGitCheckouts(opts.GitSourceOpts)
+	defer gitCheckouts.Cleanup()
+
+	err := checkStdinPaths(paths, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, path := range paths {
 		var files []*File
 
@@ -56,18 +196,100 @@ func NewSortedFilesFromPaths(paths []string, opts SymlinkAllowOpts) ([]*File, er
 		}
 
 		switch {
+		case path == "-" && opts.StdinFormat == StdinFormatZip:
+			if len(relativePath) > 0 || len(opts.StdinPath) > 0 {
+				return nil, fmt.Errorf("Expected stdin zip archive to not have relative path assigned")
+			}
+			stdinSource := NewStdinSource()
+			stdinBs, err := stdinSource.Bytes()
+			if err != nil {
+				return nil, fmt.Errorf("Reading stdin: %s", err)
+			}
+			archiveFiles, err := NewFilesFromZipArchive(NewBytesSource("stdin", stdinBs), opts.archiveOpts())
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, archiveFiles...)
+
+		case path == "-" && (opts.StdinFormat == "" || opts.StdinFormat == StdinFormatFile):
+			stdinSource := NewStdinSource()
+			if stdinBs, err := stdinSource.Bytes(); err == nil {
+				err := checkFileSize("stdin", int64(len(stdinBs)), opts.MaxFileSize)
+				if err != nil {
+					return nil, err
+				}
+			}
+			file, err := NewFileFromSource(NewCachedSource(stdinSource))
+			if err != nil {
+				return nil, err
+			}
+			if len(relativePath) == 0 && len(opts.StdinPath) > 0 {
+				// Stdin path acts as a regular file path (extension determines type)
+				file.MarkRelativePath(opts.StdinPath)
+			} else {
+				// Stdin does not have an extension of its own
+				if opts.DefaultType != nil {
+					file.MarkType(*opts.DefaultType)
+				}
+				if len(relativePath) > 0 {
+					file.MarkRelativePath(relativePath)
+				}
+			}
+			files = append(files, file)
+
 		case path == "-":
-			file, err := NewFileFromSource(NewCachedSource(NewStdinSource()))
+			return nil, fmt.Errorf("Unknown stdin format '%s' (expected %s or %s)", opts.StdinFormat, StdinFormatFile, StdinFormatZip)
+
+		case IsGitPath(path):
+			if len(relativePath) > 0 {
+				return nil, fmt.Errorf("Expected git path '%s' to not have relative path assigned", path)
+			}
+			gitFiles, err := newFilesFromGit(path, gitCheckouts, opts)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, gitFiles...)
+
+		case IsOCIPath(path):
+			if len(relativePath) > 0 {
+				return nil, fmt.Errorf("Expected OCI path '%s' to not have relative path assigned", path)
+			}
+			ociFiles, err := newFilesFromOCI(path, opts)
 			if err != nil {
 				return nil, err
 			}
+			files = append(files, ociFiles...)
+
+		case IsK8sPath(path):
 			if len(relativePath) > 0 {
-				file.MarkRelativePath(relativePath)
+				return nil, fmt.Errorf("Expected Kubernetes path '%s' to not have relative path assigned", path)
+			}
+			k8sFiles, err := newFilesFromK8s(path, opts)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, k8sFiles...)
+
+		case IsEnvPath(path):
+			if len(relativePath) == 0 {
+				return nil, fmt.Errorf("Expected file '%s' to have relative path assigned (e.g. 'values.yml=%s')", path, path)
+			}
+			envSource, err := NewEnvSource(path, relativePath)
+			if err != nil {
+				return nil, err
+			}
+			err = checkFileSize(envSource.Description(), int64(len(envSource.data)), opts.MaxFileSize)
+			if err != nil {
+				return nil, err
+			}
+			file, err := NewFileFromSource(envSource)
+			if err != nil {
+				return nil, err
 			}
 			files = append(files, file)
 
 		case strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://"):
-			file, err := NewFileFromSource(NewCachedSource(NewHTTPSource(path)))
+			file, err := NewFileFromSource(NewCachedSource(NewHTTPSource(path, opts.httpSourceOpts())))
 			if err != nil {
 				return nil, err
 			}
@@ -76,6 +298,16 @@ func NewSortedFilesFromPaths(paths []string, opts SymlinkAllowOpts) ([]*File, er
 			}
 			files = append(files, file)
 
+		case !opts.NoGlob && isGlobPath(path):
+			if len(relativePath) > 0 {
+				return nil, fmt.Errorf("Expected glob pattern '%s' to not have relative path assigned", path)
+			}
+			globFiles, err := newFilesFromGlob(path, opts)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, globFiles...)
+
 		default:
 			fileInfo, err := os.Lstat(path)
 			if err != nil {
@@ -83,15 +315,34 @@ func NewSortedFilesFromPaths(paths []string, opts SymlinkAllowOpts) ([]*File, er
 			}
 
 			if fileInfo.IsDir() {
+				ignoreRules := &IgnoreRules{}
+				if !opts.NoIgnoreFile {
+					ignoreRules, err = newIgnoreRulesFromDir(path)
+					if err != nil {
+						return nil, err
+					}
+				}
+
 				err := filepath.Walk(path, func(walkedPath string, fi os.FileInfo, err error) error {
-					if err != nil || fi.IsDir() {
+					if err != nil {
 						return err
 					}
-					regLocalSource, err := NewRegularFileLocalSource(walkedPath, path, fi, opts)
+
+					ignored, err := isIgnoredPath(path, walkedPath, fi, ignoreRules, opts)
 					if err != nil {
 						return err
 					}
-					file, err := NewFileFromSource(NewCachedSource(regLocalSource))
+					if ignored {
+						if fi.IsDir() {
+							return filepath.SkipDir
+						}
+						return nil
+					}
+
+					if fi.IsDir() {
+						return nil
+					}
+					file, err := newLocalFile(walkedPath, path, fi, opts)
 					if err != nil {
 						return err
 					}
@@ -102,12 +353,18 @@ func NewSortedFilesFromPaths(paths []string, opts SymlinkAllowOpts) ([]*File, er
 				if err != nil {
 					return nil, fmt.Errorf("Listing files '%s': %s", path, err)
 				}
-			} else {
-				regLocalSource, err := NewRegularFileLocalSource(path, "", fileInfo, opts)
+			} else if IsTarArchivePath(path) {
+				regLocalSource, err := NewRegularFileLocalSource(path, "", fileInfo, opts.SymlinkAllowOpts)
 				if err != nil {
 					return nil, err
 				}
-				file, err := NewFileFromSource(NewCachedSource(regLocalSource))
+				archiveFiles, err := NewFilesFromTarArchive(regLocalSource, opts.archiveOpts())
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, archiveFiles...)
+			} else {
+				file, err := newLocalFile(path, "", fileInfo, opts)
 				if err != nil {
 					return nil, err
 				}
@@ -118,6 +375,21 @@ func NewSortedFilesFromPaths(paths []string, opts SymlinkAllowOpts) ([]*File, er
 			}
 		}
 
+		if len(opts.Encoding) > 0 {
+			for _, file := range files {
+				file.MarkEncoding(opts.Encoding)
+			}
+		}
+
+		if opts.DefaultType != nil {
+			for _, file := range files {
+				// Detected shebang type is more specific
+				if file.defaultType == nil {
+					file.MarkDefaultType(*opts.DefaultType)
+				}
+			}
+		}
+
 		groupedFiles = append(groupedFiles, files)
 	}
 
@@ -126,9 +398,7 @@ func NewSortedFilesFromPaths(paths []string, opts SymlinkAllowOpts) ([]*File, er
 
 	for _, files := range groupedFiles {
 		// Only sort files alphanum within a group
-		sort.Slice(files, func(i, j int) bool {
-			return files[i].RelativePath() < files[j].RelativePath()
-		})
+		sortFilesByPath(files, opts.NaturalSort)
 
 		for _, file := range files {
 			file.order = currOrder
@@ -138,9 +408,74 @@ func NewSortedFilesFromPaths(paths []string, opts SymlinkAllowOpts) ([]*File, er
 		allFiles = append(allFiles, files...)
 	}
 
+	allFiles, err = dedupFilePaths(allFiles, opts.AllowDuplicatePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ReadConcurrency > 0 {
+		prefetchFiles(allFiles, opts.ReadConcurrency)
+	}
+
 	return allFiles, nil
 }
 
+// dedupFilePaths fails if multiple files have the same relative path,
+// or keeps last of such files (in place of the first one) if allowed
+func dedupFilePaths(files []*File, allowDuplicates bool) ([]*File, error) {
+	filesByPath := map[string]*File{}
+
+	for _, file := range files {
+		path := file.RelativePath()
+		if prevFile, found := filesByPath[path]; found && !allowDuplicates {
+			return nil, fmt.Errorf("Expected input files to have unique relative paths, but '%s' is provided "+
+				"by %s and %s (use --allow-duplicate-paths to keep the last one)",
+				path, sourceLocation(prevFile.src), sourceLocation(file.src))
+		}
+		filesByPath[path] = file
+	}
+
+	if len(filesByPath) == len(files) {
+		return files, nil
+	}
+
+	var result []*File
+	seenPaths := map[string]struct{}{}
+
+	for _, file := range files {
+		path := file.RelativePath()
+		if _, found := seenPaths[path]; found {
+			continue
+		}
+		seenPaths[path] = struct{}{}
+
+		lastFile := filesByPath[path]
+		lastFile.order = file.order
+		result = append(result, lastFile)
+	}
+
+	return result, nil
+}
+
+// sourceLocation describes where file comes from;
+// local files are described by their absolute paths
+func sourceLocation(src Source) string {
+	switch typedSrc := src.(type) {
+	case *CachedSource:
+		return sourceLocation(typedSrc.src)
+	case SizeLimitedSource:
+		return sourceLocation(typedSrc.src)
+	case LocalSource:
+		absPath, err := filepath.Abs(typedSrc.path)
+		if err != nil {
+			return typedSrc.Description()
+		}
+		return fmt.Sprintf("file '%s'", absPath)
+	default:
+		return src.Description()
+	}
+}
+
 func NewSortedFiles(files []*File) []*File {
 	currOrder := 1
 	for _, file := range files {
@@ -150,6 +485,106 @@ func NewSortedFiles(files []*File) []*File {
 	return files
 }
 
+// NewSortedFilesWithOrder places files matching given relative paths first
+// (in specified order) followed by remaining files in their current order
+func NewSortedFilesWithOrder(files []*File, relPaths []string) ([]*File, error) {
+	var result []*File
+	seenPaths := map[string]struct{}{}
+	placedFiles := map[*File]struct{}{}
+
+	for _, relPath := range relPaths {
+		if _, found := seenPaths[relPath]; found {
+			return nil, fmt.Errorf("Expected file order path '%s' to be specified only once", relPath)
+		}
+		seenPaths[relPath] = struct{}{}
+
+		var matched bool
+
+		for _, file := range files {
+			if file.RelativePath() == relPath {
+				result = append(result, file)
+				placedFiles[file] = struct{}{}
+				matched = true
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("Expected file order path '%s' to match at least one file by path, but did not", relPath)
+		}
+	}
+
+	for _, file := range files {
+		if _, found := placedFiles[file]; !found {
+			result = append(result, file)
+		}
+	}
+
+	return NewSortedFiles(result), nil
+}
+
+// checkStdinPaths ensures that stdin is read at most once (subsequent
+// reads would be empty) and that stdin path is only used with stdin
+func checkStdinPaths(paths []string, opts SourceOpts) error {
+	var stdinCount int
+
+	for _, path := range paths {
+		pathPieces := strings.Split(path, "=")
+		if pathPieces[len(pathPieces)-1] == "-" {
+			stdinCount++
+		}
+	}
+
+	if stdinCount > 1 {
+		return fmt.Errorf("Expected stdin ('-') to be specified only once, but was specified %d times", stdinCount)
+	}
+
+	if len(opts.StdinPath) > 0 {
+		if stdinCount == 0 {
+			return fmt.Errorf("Expected stdin path '%s' to be used together with stdin ('-'), but stdin was not specified", opts.StdinPath)
+		}
+
+		cleanPath := filepath.ToSlash(filepath.Clean(opts.StdinPath))
+		if filepath.IsAbs(opts.StdinPath) || cleanPath == "." || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+			return fmt.Errorf("Expected stdin path '%s' to be a relative file path", opts.StdinPath)
+		}
+	}
+
+	return nil
+}
+
+// newLocalFile creates file from local path; dir is used
+// for relative path calculation (empty means file's base name)
+func newLocalFile(path, dir string, fi os.FileInfo, opts SourceOpts) (*File, error) {
+	regLocalSource, err := NewRegularFileLocalSource(path, dir, fi, opts.SymlinkAllowOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkLocalFileSize(path, opts.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := NewFileFromSource(NewCachedSource(NewSizeLimitedSource(regLocalSource, opts.MaxFileSize)))
+	if err != nil {
+		return nil, err
+	}
+
+	err = file.setSrcModeFromLocal(path, fi)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DetectShebang {
+		err = file.detectShebangType(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
 func NewFileFromSource(fileSrc Source) (*File, error) {
 	relPath, err := fileSrc.RelativePath()
 	if err != nil {
@@ -180,10 +615,60 @@ func (r *File) RelativePath() string {
 	return r.relPath
 }
 
-func (r *File) Bytes() ([]byte, error) { return r.src.Bytes() }
+// MarkOutputSubdir places output file into given subdirectory of output
+// directory without affecting its relative path (eg used for loading)
+func (r *File) MarkOutputSubdir(dir string) { r.markedOutputDir = &dir }
+
+func (r *File) OutputSubdir() string {
+	if r.markedOutputDir != nil {
+		return *r.markedOutputDir
+	}
+	return ""
+}
+
+// MarkOutputPathTemplate sets output path to be computed from data
+// values once they are available (eg 'service-{{env}}.yml'), without
+// affecting its relative path (eg used for loading)
+func (r *File) MarkOutputPathTemplate(tpl string) { r.markedPathTpl = &tpl }
+
+func (r *File) OutputPathTemplate() (string, bool) {
+	if r.markedPathTpl != nil {
+		return *r.markedPathTpl, true
+	}
+	return "", false
+}
+
+// Bytes returns file contents, decoded into UTF-8 if encoding
+// is marked (binary files are always returned as is)
+func (r *File) Bytes() ([]byte, error) {
+	bs, err := r.src.Bytes()
+	if err != nil || r.markedEncoding == nil || r.Type() == TypeBinary {
+		return bs, err
+	}
+
+	decodedBs, err := r.markedEncoding.Decode(bs)
+	if err != nil {
+		return nil, fmt.Errorf("Decoding file '%s' as %s: %s", r.RelativePath(), *r.markedEncoding, err)
+	}
+
+	return decodedBs, nil
+}
+
+// MarkEncoding sets encoding used to decode file contents into UTF-8
+func (r *File) MarkEncoding(enc Encoding) { r.markedEncoding = &enc }
+
+func (r *File) Encoding() (Encoding, bool) {
+	if r.markedEncoding != nil {
+		return *r.markedEncoding, true
+	}
+	return "", false
+}
 
 func (r *File) MarkType(t Type) { r.markedType = &t }
 
+// MarkDefaultType sets type used when file extension is not recognized
+func (r *File) MarkDefaultType(t Type) { r.defaultType = &t }
+
 func (r *File) Type() Type {
 	if r.markedType != nil {
 		return *r.markedType
@@ -192,10 +677,14 @@ func (r *File) Type() Type {
 	switch {
 	case r.matchesExt(yamlExts):
 		return TypeYAML
+	case r.matchesExt(jsonExts):
+		return TypeJSON
 	case r.matchesExt(starlarkExts):
 		return TypeStarlark
 	case r.matchesExt(textExts):
 		return TypeText
+	case r.defaultType != nil:
+		return *r.defaultType
 	default:
 		return TypeUnknown
 	}
@@ -216,6 +705,50 @@ func (r *File) IsForOutput() bool {
 	return r.isTemplate()
 }
 
+func (r *File) MarkMode(mode os.FileMode) { r.markedMode = &mode }
+
+// Mode returns permissions that should be used for output file (nil if unknown).
+// Marked mode takes precedence over mode of the source file.
+func (r *File) Mode() *os.FileMode {
+	if r.markedMode != nil {
+		return r.markedMode
+	}
+	return r.srcMode
+}
+
+func (r *File) setSrcModeFromLocal(path string, fi os.FileInfo) error {
+	if (fi.Mode() & os.ModeSymlink) != 0 {
+		// use permissions of symlink destination
+		var err error
+		fi, err = os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("Checking file '%s': %s", path, err)
+		}
+	}
+	if !fi.Mode().IsRegular() {
+		return nil // eg named pipes do not carry meaningful permissions
+	}
+	mode := fi.Mode().Perm()
+	r.srcMode = &mode
+	return nil
+}
+
+// MarkAnnotation sets annotation value; later marks win for the same key
+func (r *File) MarkAnnotation(key, value string) {
+	if r.annotations == nil {
+		r.annotations = map[string]string{}
+	}
+	r.annotations[key] = value
+}
+
+func (r *File) Annotations() map[string]string {
+	result := map[string]string{}
+	for key, value := range r.annotations {
+		result[key] = value
+	}
+	return result
+}
+
 func (r *File) MarkTemplate(template bool) { r.markedTemplate = &template }
 
 func (r *File) IsTemplate() bool {
@@ -227,7 +760,7 @@ func (r *File) IsTemplate() bool {
 
 func (r *File) isTemplate() bool {
 	t := r.Type()
-	return !r.IsLibrary() && (t == TypeYAML || t == TypeText)
+	return !r.IsLibrary() && (t == TypeYAML || t == TypeText || t == TypeYAMLFrontMatter || t == TypeEnvTemplate)
 }
 
 func (r *File) IsLibrary() bool {
@@ -242,13 +775,7 @@ func (r *File) IsLibrary() bool {
 }
 
 func (r *File) matchesExt(exts []string) bool {
-	filename := filepath.Base(r.RelativePath())
-	for _, ext := range exts {
-		if strings.HasSuffix(filename, ext) {
-			return true
-		}
-	}
-	return false
+	return pathMatchesExt(filepath.Base(r.RelativePath()), exts)
 }
 
 func (r *File) OrderLess(otherFile *File) bool {
@@ -0,0 +1,74 @@
+package files
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	DefaultMaxFileSize = 10 * 1024 * 1024
+)
+
+// checkFileSize ensures that a single input file does not exceed
+// maximum size; zero (or negative) max means no limit
+func checkFileSize(desc string, size, max int64) error {
+	if max > 0 && size > max {
+		return newFileSizeErr(desc, max, fmt.Sprintf("%d bytes", size))
+	}
+	return nil
+}
+
+func newFileSizeErr(desc string, max int64, actual string) error {
+	return fmt.Errorf("Expected %s to not exceed max file size of %d bytes, but was %s "+
+		"(use --max-file-size to change limit)", desc, max, actual)
+}
+
+// checkLocalFileSize checks size of local file (following symlinks)
+// without reading its contents; sizes of non-regular files (eg pipes)
+// are not known upfront, hence they are checked once read
+func checkLocalFileSize(path string, max int64) error {
+	if max <= 0 {
+		return nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("Checking file '%s': %s", path, err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		return nil
+	}
+
+	return checkFileSize(fmt.Sprintf("file '%s'", path), fi.Size(), max)
+}
+
+// SizeLimitedSource fails to return contents of underlying
+// source if they exceed maximum size
+type SizeLimitedSource struct {
+	src Source
+	max int64
+}
+
+var _ Source = SizeLimitedSource{}
+
+func NewSizeLimitedSource(src Source, max int64) SizeLimitedSource {
+	return SizeLimitedSource{src, max}
+}
+
+func (s SizeLimitedSource) Description() string           { return s.src.Description() }
+func (s SizeLimitedSource) RelativePath() (string, error) { return s.src.RelativePath() }
+
+func (s SizeLimitedSource) Bytes() ([]byte, error) {
+	bs, err := s.src.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkFileSize(s.src.Description(), int64(len(bs)), s.max)
+	if err != nil {
+		return nil, err
+	}
+
+	return bs, nil
+}
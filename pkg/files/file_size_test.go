@@ -0,0 +1,102 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestMaxFileSizeLocal(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	smallPath := filepath.Join(dirPath, "small.yml")
+	largePath := filepath.Join(dirPath, "large.log")
+
+	for path, size := range map[string]int{smallPath: 100, largePath: 101} {
+		err := ioutil.WriteFile(path, []byte(strings.Repeat("a", size)), 0600)
+		if err != nil {
+			t.Fatalf("Writing file: %s", err)
+		}
+	}
+
+	opts := files.SourceOpts{MaxFileSize: 100}
+
+	_, err := files.NewSortedFilesFromPaths([]string{smallPath}, opts)
+	if err != nil {
+		t.Fatalf("Expected file within limit to succeed: %s", err)
+	}
+
+	expectedErr := "Expected file '" + largePath + "' to not exceed max file size of 100 bytes, but was 101 bytes"
+
+	for _, path := range []string{largePath, dirPath} {
+		_, err = files.NewSortedFilesFromPaths([]string{path}, opts)
+		if err == nil || !strings.Contains(err.Error(), expectedErr) {
+			t.Fatalf("Expected size err for '%s', but was: %v", path, err)
+		}
+	}
+
+	_, err = files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{})
+	if err != nil {
+		t.Fatalf("Expected no limit by default to succeed: %s", err)
+	}
+}
+
+func TestMaxFileSizeArchive(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	archivePath := filepath.Join(dirPath, "in.tgz")
+	writeTarArchive(t, archivePath, []tarEntry{
+		{Name: "a.yml", Data: strings.Repeat("a", 60)},
+		{Name: "b.yml", Data: strings.Repeat("b", 60)},
+	})
+
+	// Archive itself is limited via ArchiveMaxSize instead
+	_, err := files.NewSortedFilesFromPaths([]string{archivePath}, files.SourceOpts{MaxFileSize: 60})
+	if err != nil {
+		t.Fatalf("Expected entries within limit to succeed: %s", err)
+	}
+
+	_, err = files.NewSortedFilesFromPaths([]string{archivePath}, files.SourceOpts{MaxFileSize: 59})
+	if err == nil || !strings.Contains(err.Error(), "Expected file 'a.yml' in file '"+archivePath+
+		"' to not exceed max file size of 59 bytes, but was 60 bytes") {
+		t.Fatalf("Expected entry size err, but was: %v", err)
+	}
+}
+
+func TestMaxFileSizeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+
+	url := server.URL + "/large.yml"
+
+	result, err := files.NewSortedFilesFromPaths([]string{url}, files.SourceOpts{MaxFileSize: 100})
+	if err != nil {
+		t.Fatalf("Expected creating file to succeed: %s", err)
+	}
+
+	_, err = result[0].Bytes()
+	if err == nil || !strings.Contains(err.Error(), "Expected HTTP URL '"+url+
+		"' to not exceed max file size of 100 bytes, but was over 100 bytes") {
+		t.Fatalf("Expected HTTP size err, but was: %v", err)
+	}
+
+	result, err = files.NewSortedFilesFromPaths([]string{url}, files.SourceOpts{MaxFileSize: 1000})
+	if err != nil {
+		t.Fatalf("Expected creating file to succeed: %s", err)
+	}
+
+	bs, err := result[0].Bytes()
+	if err != nil || len(bs) != 1000 {
+		t.Fatalf("Expected HTTP file within limit to be read, but was %d bytes (err: %v)", len(bs), err)
+	}
+}
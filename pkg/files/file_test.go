@@ -0,0 +1,298 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestNewSortedFilesWithOrder(t *testing.T) {
+	var filesToSort []*files.File
+
+	for _, path := range []string{"a.yml", "b.yml", "c.yml", "d.yml"} {
+		filesToSort = append(filesToSort, files.MustNewFileFromSource(files.NewBytesSource(path, nil)))
+	}
+
+	result, err := files.NewSortedFilesWithOrder(files.NewSortedFiles(filesToSort), []string{"c.yml", "a.yml"})
+	if err != nil {
+		t.Fatalf("Expected sorting to succeed: %s", err)
+	}
+
+	var paths []string
+	for _, file := range result {
+		paths = append(paths, file.RelativePath())
+	}
+
+	if strings.Join(paths, ",") != "c.yml,a.yml,b.yml,d.yml" {
+		t.Fatalf("Expected files to be ordered, but was: %#v", paths)
+	}
+
+	if !result[0].OrderLess(result[1]) || !result[1].OrderLess(result[2]) {
+		t.Fatalf("Expected file order to be reassigned")
+	}
+
+	_, err = files.NewSortedFilesWithOrder(filesToSort, []string{"missing.yml"})
+	if err == nil || err.Error() != "Expected file order path 'missing.yml' to match at least one file by path, but did not" {
+		t.Fatalf("Expected unmatched path to fail, but was: %v", err)
+	}
+
+	_, err = files.NewSortedFilesWithOrder(filesToSort, []string{"a.yml", "a.yml"})
+	if err == nil || err.Error() != "Expected file order path 'a.yml' to be specified only once" {
+		t.Fatalf("Expected duplicate path to fail, but was: %v", err)
+	}
+}
+
+func TestNewSortedFilesFromPathsWithDefaultType(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "ytt-default-type")
+	if err != nil {
+		t.Fatalf("Expected creating temp dir to succeed: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	for _, path := range []string{"config", "values.yml", "notes.txt"} {
+		err := ioutil.WriteFile(filepath.Join(dirPath, path), []byte("a: 1"), 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	defaultType := files.TypeYAML
+
+	result, err := files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{DefaultType: &defaultType})
+	if err != nil {
+		t.Fatalf("Expected reading files to succeed: %s", err)
+	}
+
+	expectedTypes := map[string]files.Type{
+		"config":     files.TypeYAML,
+		"notes.txt":  files.TypeText,
+		"values.yml": files.TypeYAML,
+	}
+
+	for _, file := range result {
+		if file.Type() != expectedTypes[file.RelativePath()] {
+			t.Fatalf("Expected file '%s' type to be %d, but was %d", file.RelativePath(), expectedTypes[file.RelativePath()], file.Type())
+		}
+		if !file.IsTemplate() {
+			t.Fatalf("Expected file '%s' to be a template", file.RelativePath())
+		}
+	}
+
+	result[0].MarkType(files.TypeText)
+
+	if result[0].Type() != files.TypeText {
+		t.Fatalf("Expected marked type to take precedence over default type")
+	}
+}
+
+func TestNewSortedFilesFromPathsWithDuplicatePaths(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "ytt-duplicate-paths")
+	if err != nil {
+		t.Fatalf("Expected creating temp dir to succeed: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	err = os.Mkdir(filepath.Join(dirPath, "other"), 0700)
+	if err != nil {
+		t.Fatalf("Expected creating dir to succeed: %s", err)
+	}
+
+	for _, path := range []string{"a.yml", "b.yml", "other/a.yml"} {
+		err := ioutil.WriteFile(filepath.Join(dirPath, path), []byte(path), 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	paths := []string{filepath.Join(dirPath, "a.yml"), filepath.Join(dirPath, "b.yml"), filepath.Join(dirPath, "other")}
+
+	_, err = files.NewSortedFilesFromPaths(paths, files.SourceOpts{})
+	if err == nil || err.Error() != "Expected input files to have unique relative paths, but 'a.yml' is provided by "+
+		"file '"+filepath.Join(dirPath, "a.yml")+"' and file '"+filepath.Join(dirPath, "other", "a.yml")+"' "+
+		"(use --allow-duplicate-paths to keep the last one)" {
+		t.Fatalf("Expected duplicate paths err, but was: %v", err)
+	}
+
+	result, err := files.NewSortedFilesFromPaths(paths, files.SourceOpts{AllowDuplicatePaths: true})
+	if err != nil {
+		t.Fatalf("Expected reading files to succeed: %s", err)
+	}
+
+	var contents []string
+	for _, file := range result {
+		data, err := file.Bytes()
+		if err != nil {
+			t.Fatalf("Expected reading file to succeed: %s", err)
+		}
+		contents = append(contents, file.RelativePath()+"="+string(data))
+	}
+
+	if strings.Join(contents, ",") != "a.yml=other/a.yml,b.yml=b.yml" {
+		t.Fatalf("Expected last file to win, but was: %s", strings.Join(contents, ","))
+	}
+}
+
+func TestNewSortedFilesFromPathsWithStdin(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	for _, path := range []string{"b.yml", "a.yml"} {
+		err := ioutil.WriteFile(filepath.Join(dirPath, path), []byte("a: 1"), 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	examples := []struct {
+		Paths         []string
+		Opts          files.SourceOpts
+		ExpectedPaths string
+		ExpectedType  files.Type
+	}{
+		// Stdin keeps position of its flag in relation to other paths
+		{Paths: []string{"-", dirPath}, ExpectedPaths: "stdin.yml,a.yml,b.yml", ExpectedType: files.TypeYAML},
+		{Paths: []string{dirPath, "-"}, ExpectedPaths: "a.yml,b.yml,stdin.yml", ExpectedType: files.TypeYAML},
+		{
+			Paths:         []string{dirPath, "-"},
+			Opts:          files.SourceOpts{StdinPath: "values/prod.star"},
+			ExpectedPaths: "a.yml,b.yml,values/prod.star",
+			ExpectedType:  files.TypeStarlark,
+		},
+		{
+			Paths:         []string{"custom.txt=-"},
+			Opts:          files.SourceOpts{StdinPath: "ignored.yml"},
+			ExpectedPaths: "custom.txt",
+			ExpectedType:  files.TypeText,
+		},
+	}
+
+	for _, ex := range examples {
+		var result []*files.File
+
+		withStdin(t, "a: 1", func() {
+			var err error
+			result, err = files.NewSortedFilesFromPaths(ex.Paths, ex.Opts)
+			if err != nil {
+				t.Fatalf("Expected reading files to succeed: %s", err)
+			}
+		})
+
+		var paths []string
+		var stdinType files.Type
+
+		for _, file := range result {
+			paths = append(paths, file.RelativePath())
+			if !strings.HasPrefix(file.Description(), "file") {
+				stdinType = file.Type()
+			}
+		}
+
+		if strings.Join(paths, ",") != ex.ExpectedPaths {
+			t.Fatalf("Expected paths for %#v to match, but was: %s", ex.Paths, strings.Join(paths, ","))
+		}
+		if stdinType != ex.ExpectedType {
+			t.Fatalf("Expected stdin type for %#v to be %s, but was %s", ex.Paths, ex.ExpectedType, stdinType)
+		}
+	}
+
+	errExamples := []struct {
+		Paths []string
+		Opts  files.SourceOpts
+		Err   string
+	}{
+		{Paths: []string{"-", "-"}, Err: "Expected stdin ('-') to be specified only once, but was specified 2 times"},
+		{Paths: []string{dirPath}, Opts: files.SourceOpts{StdinPath: "a.yml"}, Err: "Expected stdin path 'a.yml' to be used together with stdin ('-'), but stdin was not specified"},
+		{Paths: []string{"-"}, Opts: files.SourceOpts{StdinPath: "../a.yml"}, Err: "Expected stdin path '../a.yml' to be a relative file path"},
+	}
+
+	for _, ex := range errExamples {
+		_, err := files.NewSortedFilesFromPaths(ex.Paths, ex.Opts)
+		if err == nil || err.Error() != ex.Err {
+			t.Fatalf("Expected reading %#v to fail with specific error, but was: %v", ex.Paths, err)
+		}
+	}
+}
+
+func withStdin(t *testing.T, data string, f func()) {
+	stdinFile, err := ioutil.TempFile("", "ytt-stdin")
+	if err != nil {
+		t.Fatalf("Expected creating temp file to succeed: %s", err)
+	}
+	defer os.Remove(stdinFile.Name())
+	defer stdinFile.Close()
+
+	_, err = stdinFile.WriteString(data)
+	if err == nil {
+		_, err = stdinFile.Seek(0, 0)
+	}
+	if err != nil {
+		t.Fatalf("Expected writing temp file to succeed: %s", err)
+	}
+
+	origStdin := os.Stdin
+	os.Stdin = stdinFile
+	defer func() { os.Stdin = origStdin }()
+
+	f()
+}
+
+func TestNewSortedFilesFromPathsWithEnv(t *testing.T) {
+	os.Setenv("YTT_TEST_ENV_FILE", "#@ load(\"@ytt:data\", \"data\")\na: 1\n")
+	os.Setenv("YTT_TEST_ENV_FILE_EMPTY", "")
+	defer os.Unsetenv("YTT_TEST_ENV_FILE")
+	defer os.Unsetenv("YTT_TEST_ENV_FILE_EMPTY")
+
+	result, err := files.NewSortedFilesFromPaths([]string{
+		"config/values.yml=env:YTT_TEST_ENV_FILE",
+		"lib/helpers.star=env:YTT_TEST_ENV_FILE_EMPTY",
+	}, files.SourceOpts{})
+	if err != nil {
+		t.Fatalf("Expected reading files to succeed: %s", err)
+	}
+
+	if len(result) != 2 {
+		t.Fatalf("Expected two files, but was %d", len(result))
+	}
+
+	if result[0].RelativePath() != "config/values.yml" || result[0].Type() != files.TypeYAML {
+		t.Fatalf("Expected relative path to determine type, but was: %s (%s)", result[0].RelativePath(), result[0].Type())
+	}
+	if result[1].Type() != files.TypeStarlark {
+		t.Fatalf("Expected starlark file, but was: %s", result[1].Type())
+	}
+
+	bs, err := result[0].Bytes()
+	if err != nil || string(bs) != "#@ load(\"@ytt:data\", \"data\")\na: 1\n" {
+		t.Fatalf("Expected file contents to match variable, but was: %s (err: %v)", bs, err)
+	}
+
+	if result[0].Description() != "environment variable 'YTT_TEST_ENV_FILE'" {
+		t.Fatalf("Expected description to name variable, but was: %s", result[0].Description())
+	}
+
+	errExamples := []struct {
+		Path string
+		Opts files.SourceOpts
+		Err  string
+	}{
+		{Path: "env:YTT_TEST_ENV_FILE", Err: "Expected file 'env:YTT_TEST_ENV_FILE' to have relative path assigned (e.g. 'values.yml=env:YTT_TEST_ENV_FILE')"},
+		{Path: "a.yml=env:YTT_TEST_ENV_FILE_MISSING", Err: "Expected environment variable 'YTT_TEST_ENV_FILE_MISSING' to be set (for file 'env:YTT_TEST_ENV_FILE_MISSING')"},
+		{Path: "a.yml=env:", Err: "Expected file 'env:' to specify environment variable name (format: env:NAME)"},
+		{
+			Path: "a.yml=env:YTT_TEST_ENV_FILE",
+			Opts: files.SourceOpts{MaxFileSize: 10},
+			Err:  "Expected environment variable 'YTT_TEST_ENV_FILE' to not exceed max file size of 10 bytes, but was 34 bytes (use --max-file-size to change limit)",
+		},
+	}
+
+	for _, ex := range errExamples {
+		_, err := files.NewSortedFilesFromPaths([]string{ex.Path}, ex.Opts)
+		if err == nil || err.Error() != ex.Err {
+			t.Fatalf("Expected reading '%s' to fail with specific error, but was: %v", ex.Path, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package files
+
+import (
+	"bytes"
+)
+
+const (
+	frontMatterFence = "---"
+)
+
+// FrontMatter represents YAML header of a text file (eg Markdown)
+// delimited by '---' lines at the very beginning of the file
+type FrontMatter struct {
+	Header []byte
+	Body   []byte
+
+	openingFence []byte
+	closingFence []byte
+}
+
+// SplitFrontMatter returns front matter of given data. False is returned
+// if data does not start with a fence, or if closing fence is not found.
+func SplitFrontMatter(data []byte) (FrontMatter, bool) {
+	openingFence, rest, found := cutFenceLine(data)
+	if !found {
+		return FrontMatter{}, false
+	}
+
+	var offset int
+
+	for offset < len(rest) {
+		closingFence, body, found := cutFenceLine(rest[offset:])
+		if found {
+			return FrontMatter{
+				Header:       rest[:offset],
+				Body:         body,
+				openingFence: openingFence,
+				closingFence: closingFence,
+			}, true
+		}
+
+		lineEnd := bytes.IndexByte(rest[offset:], '\n')
+		if lineEnd == -1 {
+			break
+		}
+		offset += lineEnd + 1
+	}
+
+	return FrontMatter{}, false
+}
+
+// Join returns file contents with given header in place
+// of original header; fences and body are kept as is
+func (fm FrontMatter) Join(header []byte) []byte {
+	var result []byte
+	result = append(result, fm.openingFence...)
+	result = append(result, header...)
+	if len(header) > 0 && header[len(header)-1] != '\n' {
+		result = append(result, '\n')
+	}
+	result = append(result, fm.closingFence...)
+	result = append(result, fm.Body...)
+	return result
+}
+
+// cutFenceLine checks if data starts with a fence line
+// and returns that line (including line ending) and remaining data
+func cutFenceLine(data []byte) ([]byte, []byte, bool) {
+	line := data
+	lineEnd := bytes.IndexByte(data, '\n')
+	if lineEnd != -1 {
+		line = data[:lineEnd+1]
+	}
+
+	if string(bytes.TrimRight(line, "\r\n")) != frontMatterFence {
+		return nil, nil, false
+	}
+
+	return line, data[len(line):], true
+}
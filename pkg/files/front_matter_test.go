@@ -0,0 +1,45 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	examples := []struct {
+		Data   string
+		Header string
+		Body   string
+		Found  bool
+	}{
+		{Data: "---\na: 1\n---\nbody\n", Header: "a: 1\n", Body: "body\n", Found: true},
+		{Data: "---\r\na: 1\r\n---\r\nbody", Header: "a: 1\r\n", Body: "body", Found: true},
+		{Data: "---\n---\n", Header: "", Body: "", Found: true},
+		{Data: "---\na: 1\n---", Header: "a: 1\n", Body: "", Found: true},
+		{Data: "---\na: 1\n---\nb\n---\nc\n", Header: "a: 1\n", Body: "b\n---\nc\n", Found: true},
+		{Data: "body\n---\na: 1\n---\n", Found: false},
+		{Data: "---\na: 1\n", Found: false},
+		{Data: "--- a: 1\n---\n", Found: false},
+		{Data: "", Found: false},
+	}
+
+	for _, ex := range examples {
+		frontMatter, found := files.SplitFrontMatter([]byte(ex.Data))
+		if found != ex.Found {
+			t.Fatalf("Expected front matter in %q to be found (%t), but was %t", ex.Data, ex.Found, found)
+		}
+		if !found {
+			continue
+		}
+		if string(frontMatter.Header) != ex.Header {
+			t.Fatalf("Expected header of %q to match, but was %q", ex.Data, frontMatter.Header)
+		}
+		if string(frontMatter.Body) != ex.Body {
+			t.Fatalf("Expected body of %q to match, but was %q", ex.Data, frontMatter.Body)
+		}
+		if string(frontMatter.Join(frontMatter.Header)) != ex.Data {
+			t.Fatalf("Expected joining original header to result in %q, but was %q", ex.Data, frontMatter.Join(frontMatter.Header))
+		}
+	}
+}
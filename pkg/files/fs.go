@@ -0,0 +1,212 @@
+//go:build go1.16
+// +build go1.16
+
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FSSource represents a file within a filesystem
+// abstraction (eg embed.FS used via go:embed)
+type FSSource struct {
+	fsys    fs.FS
+	path    string
+	relPath string
+}
+
+var _ Source = FSSource{}
+
+func NewFSSource(fsys fs.FS, path, relPath string) FSSource {
+	return FSSource{fsys, path, relPath}
+}
+
+func (s FSSource) Description() string {
+	return fmt.Sprintf("file '%s' in filesystem", s.path)
+}
+
+func (s FSSource) RelativePath() (string, error) { return s.relPath, nil }
+func (s FSSource) Bytes() ([]byte, error)        { return fs.ReadFile(s.fsys, s.path) }
+
+// NewSortedFilesFromFS returns files found at root within given
+// filesystem (eg embed.FS) sorted by their relative paths. Root may be
+// a directory (relative paths are calculated from it, "." is the root
+// of filesystem) or a single file (relative path is its base name).
+// Files are treated the same way as local files given via
+// NewSortedFilesFromPaths (eg types are based on extensions and ignore
+// file at the root of directory is honored). To use them alongside
+// other files, combine both (in desired order) via NewSortedFiles.
+func NewSortedFilesFromFS(fsys fs.FS, root string, opts SourceOpts) ([]*File, error) {
+	if !fs.ValidPath(root) {
+		return nil, fmt.Errorf("Expected filesystem root '%s' to be a valid slash separated "+
+			"path without leading or trailing slashes (eg 'config' or '.')", root)
+	}
+
+	rootInfo, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, fmt.Errorf("Checking file '%s' in filesystem: %s", root, err)
+	}
+
+	var files []*File
+
+	if rootInfo.IsDir() {
+		ignoreRules := &IgnoreRules{}
+		if !opts.NoIgnoreFile {
+			ignoreRules, err = newIgnoreRulesFromFS(fsys, root)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err := fs.WalkDir(fsys, root, func(walkedPath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			fi, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			relPath := walkedPath
+			if root != "." {
+				relPath = strings.TrimPrefix(strings.TrimPrefix(walkedPath, root), "/")
+			}
+
+			ignored, err := isIgnoredFSPath(relPath, fi, ignoreRules, opts)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				if fi.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+
+			if fi.IsDir() {
+				return nil
+			}
+			file, err := newFSFile(fsys, walkedPath, relPath, fi, opts)
+			if err != nil {
+				return err
+			}
+			files = append(files, file)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Listing files '%s' in filesystem: %s", root, err)
+		}
+	} else {
+		file, err := newFSFile(fsys, root, path.Base(root), rootInfo, opts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	for _, file := range files {
+		if len(opts.Encoding) > 0 {
+			file.MarkEncoding(opts.Encoding)
+		}
+		// Detected shebang type is more specific
+		if opts.DefaultType != nil && file.defaultType == nil {
+			file.MarkDefaultType(*opts.DefaultType)
+		}
+	}
+
+	sortFilesByPath(files, opts.NaturalSort)
+
+	if opts.ReadConcurrency > 0 {
+		prefetchFiles(files, opts.ReadConcurrency)
+	}
+
+	return NewSortedFiles(files), nil
+}
+
+// isIgnoredFSPath is same as isIgnoredPath, but
+// works with paths relative to filesystem root
+func isIgnoredFSPath(relPath string, fi fs.FileInfo, rules *IgnoreRules, opts SourceOpts) (bool, error) {
+	if relPath == "" {
+		return false, nil
+	}
+
+	// Output manifest (eg left in output directory used as input) describes output
+	if !fi.IsDir() && fi.Name() == DefaultManifestFileName {
+		return true, nil
+	}
+
+	if opts.NoIgnoreFile {
+		return false, nil
+	}
+
+	// Ignore file configures input, hence is not an input itself
+	if relPath == IgnoreFileName {
+		return true, nil
+	}
+
+	return rules.Matches(relPath, fi.IsDir()), nil
+}
+
+// newIgnoreRulesFromFS reads ignore file at the root of directory if it exists
+func newIgnoreRulesFromFS(fsys fs.FS, dirPath string) (*IgnoreRules, error) {
+	ignorePath := path.Join(dirPath, IgnoreFileName)
+
+	data, err := fs.ReadFile(fsys, ignorePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &IgnoreRules{}, nil
+		}
+		return nil, fmt.Errorf("Reading ignore file '%s' in filesystem: %s", ignorePath, err)
+	}
+
+	rules, err := NewIgnoreRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("Reading ignore file '%s' in filesystem: %s", ignorePath, err)
+	}
+
+	return rules, nil
+}
+
+func newFSFile(fsys fs.FS, filePath, relPath string, fi fs.FileInfo, opts SourceOpts) (*File, error) {
+	// Symlinks are not followed when walking filesystem
+	// hence they are not supported (same as by default for local files)
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("Expected file '%s' in filesystem to be a regular file, but was '%s'", filePath, fi.Mode().Type())
+	}
+
+	src := NewFSSource(fsys, filePath, relPath)
+
+	err := checkFileSize(src.Description(), fi.Size(), opts.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := NewFileFromSource(NewCachedSource(NewSizeLimitedSource(src, opts.MaxFileSize)))
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DetectShebang && file.Type() == TypeUnknown {
+		fd, err := fsys.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("Opening file '%s' in filesystem: %s", filePath, err)
+		}
+		defer fd.Close()
+
+		prefix, err := readShebangPrefix(fd)
+		if err != nil {
+			return nil, fmt.Errorf("Reading file '%s' in filesystem: %s", filePath, err)
+		}
+
+		if t, found := ShebangType(prefix); found {
+			file.MarkDefaultType(t)
+		}
+	}
+
+	return file, nil
+}
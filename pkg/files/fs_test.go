@@ -0,0 +1,89 @@
+//go:build go1.16
+// +build go1.16
+
+package files_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestNewSortedFilesFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/b.yml":                   {Data: []byte("b: 1")},
+		"config/a.star":                  {Data: []byte("a = 1")},
+		"config/sub/c.txt":               {Data: []byte("c")},
+		"config/run":                     {Data: []byte("#!/usr/bin/env starlark\n")},
+		"config/" + files.IgnoreFileName: {Data: []byte("ignored/\n")},
+		"config/ignored/d.yml":           {Data: []byte("d: 1")},
+		"config/.ytt-manifest.json":      {Data: []byte("{}")},
+		"other.yml":                      {Data: []byte("other: 1")},
+	}
+
+	result, err := files.NewSortedFilesFromFS(fsys, "config", files.SourceOpts{DetectShebang: true})
+	if err != nil {
+		t.Fatalf("Expected reading files to succeed: %s", err)
+	}
+
+	var descs []string
+	for _, file := range result {
+		descs = append(descs, fmt.Sprintf("%s=%s", file.RelativePath(), file.Type()))
+	}
+
+	expectedDescs := "a.star=starlark,b.yml=yaml,run=starlark,sub/c.txt=text"
+	if strings.Join(descs, ",") != expectedDescs {
+		t.Fatalf("Expected files to match, but was: %#v", descs)
+	}
+
+	bs, err := result[1].Bytes()
+	if err != nil || string(bs) != "b: 1" {
+		t.Fatalf("Expected file contents to match, but was: %s (%v)", bs, err)
+	}
+
+	if !result[0].OrderLess(result[1]) {
+		t.Fatalf("Expected file order to be assigned")
+	}
+
+	result, err = files.NewSortedFilesFromFS(fsys, "other.yml", files.SourceOpts{})
+	if err != nil {
+		t.Fatalf("Expected reading file to succeed: %s", err)
+	}
+	if len(result) != 1 || result[0].RelativePath() != "other.yml" || result[0].Description() != "file 'other.yml' in filesystem" {
+		t.Fatalf("Expected single file, but was: %#v", result)
+	}
+
+	result, err = files.NewSortedFilesFromFS(fsys, ".", files.SourceOpts{NoIgnoreFile: true})
+	if err != nil {
+		t.Fatalf("Expected reading files to succeed: %s", err)
+	}
+	if len(result) != 7 || result[0].RelativePath() != "config/"+files.IgnoreFileName {
+		t.Fatalf("Expected all files (except manifest), but was: %d", len(result))
+	}
+}
+
+func TestNewSortedFilesFromFSWithInvalidRoot(t *testing.T) {
+	fsys := fstest.MapFS{"config/a.yml": {Data: []byte("a: 1")}}
+
+	_, err := files.NewSortedFilesFromFS(fsys, "/config", files.SourceOpts{})
+	if err == nil || !strings.Contains(err.Error(), "Expected filesystem root '/config' to be a valid slash separated path") {
+		t.Fatalf("Expected absolute root to fail, but was: %v", err)
+	}
+
+	_, err = files.NewSortedFilesFromFS(fsys, "missing", files.SourceOpts{})
+	if err == nil || !strings.Contains(err.Error(), "Checking file 'missing' in filesystem") {
+		t.Fatalf("Expected missing root to fail, but was: %v", err)
+	}
+}
+
+func TestNewSortedFilesFromFSWithMaxFileSize(t *testing.T) {
+	fsys := fstest.MapFS{"a.yml": {Data: []byte("a: 12345")}}
+
+	_, err := files.NewSortedFilesFromFS(fsys, ".", files.SourceOpts{MaxFileSize: 4})
+	if err == nil || !strings.Contains(err.Error(), "Expected file 'a.yml' in filesystem to not exceed max file size of 4 bytes, but was 8 bytes") {
+		t.Fatalf("Expected too large file to fail, but was: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package files
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	gitPathPrefix = "git+"
+	gitSubpathSep = "//"
+)
+
+type GitSourceOpts struct {
+	// Token is sent to git servers via HTTP Authorization header;
+	// empty means standard git credential mechanisms are used
+	Token string
+}
+
+// GitPath represents 'git+<url>@<ref>//<subpath>' file path
+type GitPath struct {
+	URL     string
+	Ref     string // empty means remote HEAD
+	Subpath string // empty means repository root
+}
+
+func IsGitPath(path string) bool { return strings.HasPrefix(path, gitPathPrefix) }
+
+// ParseGitPath parses path such as 'git+https://github.com/org/repo@v1.0.0//config'.
+// Ref (branch, tag or commit) and subpath are optional.
+func ParseGitPath(path string) (GitPath, error) {
+	rest := strings.TrimPrefix(path, gitPathPrefix)
+
+	schemeIdx := strings.Index(rest, "://")
+	if schemeIdx <= 0 {
+		return GitPath{}, fmt.Errorf("Expected git path '%s' to be in format git+<url>[@<ref>][//<subpath>] (eg git+https://host/repo@v1//config)", path)
+	}
+
+	hostStart := schemeIdx + len("://")
+
+	var result GitPath
+
+	if subpathIdx := strings.Index(rest[hostStart:], gitSubpathSep); subpathIdx >= 0 {
+		result.Subpath = rest[hostStart+subpathIdx+len(gitSubpathSep):]
+		rest = rest[:hostStart+subpathIdx]
+	}
+
+	// Ref follows repository path (host portion may contain user info eg 'git@')
+	if pathIdx := strings.Index(rest[hostStart:], "/"); pathIdx >= 0 {
+		if refIdx := strings.LastIndex(rest, "@"); refIdx > hostStart+pathIdx {
+			result.Ref = rest[refIdx+1:]
+			rest = rest[:refIdx]
+			if len(result.Ref) == 0 {
+				return GitPath{}, fmt.Errorf("Expected git path '%s' to have non-empty ref after '@'", path)
+			}
+		}
+	}
+
+	result.URL = rest
+
+	cleanSubpath := filepath.Clean(filepath.FromSlash(result.Subpath))
+	if filepath.IsAbs(result.Subpath) || cleanSubpath == ".." || strings.HasPrefix(cleanSubpath, ".."+string(filepath.Separator)) {
+		return GitPath{}, fmt.Errorf("Expected git path '%s' subpath to be within repository", path)
+	}
+
+	return result, nil
+}
+
+func (p GitPath) Description() string {
+	desc := p.URL
+	if len(p.Ref) > 0 {
+		desc += "@" + p.Ref
+	}
+	return fmt.Sprintf("git repository '%s'", desc)
+}
+
+// gitCheckouts fetches each repository ref only once and
+// keeps checkouts until Cleanup (ie while files are collected)
+type gitCheckouts struct {
+	opts GitSourceOpts
+	dirs map[string]string
+}
+
+func newGitCheckouts(opts GitSourceOpts) *gitCheckouts {
+	return &gitCheckouts{opts, map[string]string{}}
+}
+
+func (c *gitCheckouts) Cleanup() {
+	for _, dir := range c.dirs {
+		os.RemoveAll(dir)
+	}
+	c.dirs = map[string]string{}
+}
+
+func (c *gitCheckouts) Checkout(gitPath GitPath) (string, error) {
+	key := gitPath.URL + "@" + gitPath.Ref
+
+	if dir, found := c.dirs[key]; found {
+		return dir, nil
+	}
+
+	dir, err := ioutil.TempDir("", "ytt-git")
+	if err != nil {
+		return "", fmt.Errorf("Creating temp directory: %s", err)
+	}
+
+	ref := gitPath.Ref
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+
+	// Fetching a single ref (unlike clone --branch) works for commits as well
+	cmds := [][]string{
+		{"init", "-q", dir},
+		{"-C", dir, "fetch", "-q", "--depth", "1", gitPath.URL, ref},
+		{"-C", dir, "checkout", "-q", "FETCH_HEAD"},
+	}
+
+	for _, args := range cmds {
+		err := c.run(args)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("Fetching %s: %s", gitPath.Description(), err)
+		}
+	}
+
+	// Repository metadata is not an input
+	err = os.RemoveAll(filepath.Join(dir, ".git"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("Removing git metadata: %s", err)
+	}
+
+	c.dirs[key] = dir
+
+	return dir, nil
+}
+
+func (c *gitCheckouts) run(args []string) error {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if len(c.opts.Token) > 0 {
+		// Pass token via environment so that it's not visible in process list
+		creds := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + c.opts.Token))
+		cmd.Env = append(cmd.Env, "GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0=Authorization: Basic "+creds)
+	}
+
+	err := cmd.Run()
+	if err != nil {
+		subcmd := args[0]
+		if subcmd == "-C" {
+			subcmd = args[2]
+		}
+		return fmt.Errorf("Running git %s: %s (stderr: %s)", subcmd, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// newFilesFromGit collects files from repository subpath; file contents
+// are read eagerly since checkout is removed once files are collected
+func newFilesFromGit(path string, checkouts *gitCheckouts, opts SourceOpts) ([]*File, error) {
+	gitPath, err := ParseGitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := checkouts.Checkout(gitPath)
+	if err != nil {
+		return nil, err
+	}
+
+	localPath := filepath.Join(dir, filepath.FromSlash(gitPath.Subpath))
+
+	if _, err := os.Lstat(localPath); err != nil {
+		return nil, fmt.Errorf("Expected subpath '%s' to exist in %s", gitPath.Subpath, gitPath.Description())
+	}
+
+	// Paths within repository are local, hence other schemes do not apply
+	localOpts := opts
+	localOpts.NoGlob = true
+
+	localFiles, err := NewSortedFilesFromPaths([]string{localPath}, localOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*File
+
+	for _, localFile := range localFiles {
+		data, err := localFile.Bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep everything (eg mode, detected type) except for source
+		gitFile := *localFile
+		gitFile.src = NewArchiveFileSource(gitPath.Description(), localFile.RelativePath(), data)
+		gitFile.order = 0
+
+		result = append(result, &gitFile)
+	}
+
+	return result, nil
+}
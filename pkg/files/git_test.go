@@ -0,0 +1,134 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestParseGitPath(t *testing.T) {
+	examples := []struct {
+		Path     string
+		Expected files.GitPath
+		Err      string
+	}{
+		{
+			Path:     "git+https://github.com/org/repo@v1.0.0//config/base",
+			Expected: files.GitPath{URL: "https://github.com/org/repo", Ref: "v1.0.0", Subpath: "config/base"},
+		},
+		{
+			Path:     "git+ssh://git@github.com/org/repo",
+			Expected: files.GitPath{URL: "ssh://git@github.com/org/repo"},
+		},
+		{
+			Path:     "git+ssh://git@github.com/org/repo//config",
+			Expected: files.GitPath{URL: "ssh://git@github.com/org/repo", Subpath: "config"},
+		},
+		{
+			Path:     "git+file:///tmp/repo@main",
+			Expected: files.GitPath{URL: "file:///tmp/repo", Ref: "main"},
+		},
+		{
+			Path: "git+github.com/org/repo",
+			Err:  "Expected git path 'git+github.com/org/repo' to be in format git+<url>[@<ref>][//<subpath>] (eg git+https://host/repo@v1//config)",
+		},
+		{
+			Path: "git+https://host/repo@//config",
+			Err:  "Expected git path 'git+https://host/repo@//config' to have non-empty ref after '@'",
+		},
+		{
+			Path: "git+https://host/repo//../other",
+			Err:  "Expected git path 'git+https://host/repo//../other' subpath to be within repository",
+		},
+	}
+
+	for _, ex := range examples {
+		result, err := files.ParseGitPath(ex.Path)
+		if len(ex.Err) > 0 {
+			if err == nil || err.Error() != ex.Err {
+				t.Fatalf("Expected parsing '%s' to fail with '%s', but was: %v", ex.Path, ex.Err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected parsing '%s' to succeed: %s", ex.Path, err)
+		}
+		if result != ex.Expected {
+			t.Fatalf("Expected parsing '%s' to result in %#v, but was %#v", ex.Path, ex.Expected, result)
+		}
+	}
+}
+
+func TestNewSortedFilesFromPathsWithGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	repoPath := mustTempDir(t)
+	defer os.RemoveAll(repoPath)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repoPath, "-c", "user.name=ytt", "-c", "user.email=ytt@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Expected git %v to succeed: %s (output: %s)", args, err, out)
+		}
+	}
+
+	writeFile := func(path, content string) {
+		fullPath := filepath.Join(repoPath, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0700)
+		err := ioutil.WriteFile(fullPath, []byte(content), 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	git("init", "-q")
+	writeFile("config/a.yml", "a: 1\n")
+	writeFile("config/sub/b.yml", "b: 1\n")
+	writeFile("README.md", "readme")
+	git("add", "-A")
+	git("commit", "-q", "-m", "first")
+	git("tag", "v1")
+	writeFile("config/a.yml", "a: 2\n")
+	git("commit", "-q", "-a", "-m", "second")
+
+	repoURL := "file://" + filepath.ToSlash(repoPath)
+
+	result, err := files.NewSortedFilesFromPaths([]string{
+		"git+" + repoURL + "@v1//config",
+		"git+" + repoURL + "//config/a.yml",
+	}, files.SourceOpts{AllowDuplicatePaths: true})
+	if err != nil {
+		t.Fatalf("Expected reading files to succeed: %s", err)
+	}
+
+	var contents []string
+	for _, file := range result {
+		data, err := file.Bytes()
+		if err != nil {
+			t.Fatalf("Expected reading file to succeed: %s", err)
+		}
+		contents = append(contents, file.RelativePath()+"="+strings.TrimSpace(string(data)))
+	}
+
+	// Latest a.yml replaces one from tagged revision
+	if strings.Join(contents, ",") != "a.yml=a: 2,sub/b.yml=b: 1" {
+		t.Fatalf("Expected files to match, but was: %s", strings.Join(contents, ","))
+	}
+
+	if !strings.Contains(result[1].Description(), "in git repository '"+repoURL+"@v1'") {
+		t.Fatalf("Expected description to mention repository, but was: %s", result[1].Description())
+	}
+
+	_, err = files.NewSortedFilesFromPaths([]string{"git+" + repoURL + "@v1//missing"}, files.SourceOpts{})
+	if err == nil || err.Error() != "Expected subpath 'missing' to exist in git repository '"+repoURL+"@v1'" {
+		t.Fatalf("Expected missing subpath to fail, but was: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	globMetaChars = `*?[\`
+)
+
+// isGlobPath checks if path is a glob pattern. Paths that exist as is
+// (even if they contain glob characters) are not treated as patterns.
+func isGlobPath(path string) bool {
+	if !strings.ContainsAny(path, globMetaChars) {
+		return false
+	}
+	_, err := os.Lstat(path)
+	return err != nil
+}
+
+// newFilesFromGlob collects files matching glob pattern (eg 'config/**/*.yml').
+// Pattern pieces follow filepath.Match syntax; '**' matches any number of directories.
+// Relative paths of matched files are based on pattern's leading directory without glob characters.
+func newFilesFromGlob(pattern string, opts SourceOpts) ([]*File, error) {
+	baseDir, patternPieces := splitGlobPattern(pattern)
+
+	// Validate pattern upfront since matching errors are reported only for some paths
+	for _, piece := range patternPieces {
+		if _, err := filepath.Match(piece, ""); err != nil {
+			return nil, fmt.Errorf("Parsing glob pattern '%s': %s", pattern, err)
+		}
+	}
+
+	ignoreRules := &IgnoreRules{}
+
+	if !opts.NoIgnoreFile {
+		var err error
+		ignoreRules, err = newIgnoreRulesFromDir(baseDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var files []*File
+
+	err := filepath.Walk(baseDir, func(walkedPath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		ignored, err := isIgnoredPath(baseDir, walkedPath, fi, ignoreRules, opts)
+		if err != nil {
+			return err
+		}
+		if ignored {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Only files are matched (directories are walked)
+		if fi.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(baseDir, walkedPath)
+		if err != nil {
+			return err
+		}
+
+		if !globPiecesMatch(strings.Split(filepath.ToSlash(relPath), "/"), patternPieces) {
+			return nil
+		}
+
+		file, err := newLocalFile(walkedPath, baseDir, fi, opts)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) && opts.AllowEmptyGlob {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Listing files matching glob pattern '%s': %s", pattern, err)
+	}
+
+	if len(files) == 0 && !opts.AllowEmptyGlob {
+		return nil, fmt.Errorf("Expected glob pattern '%s' to match at least one file, but did not", pattern)
+	}
+
+	return files, nil
+}
+
+// splitGlobPattern splits pattern into leading directory
+// without glob characters and remaining pattern pieces
+func splitGlobPattern(pattern string) (string, []string) {
+	pieces := strings.Split(filepath.ToSlash(pattern), "/")
+
+	var dirPieces []string
+
+	for len(pieces) > 1 && !strings.ContainsAny(pieces[0], globMetaChars) {
+		dirPieces = append(dirPieces, pieces[0])
+		pieces = pieces[1:]
+	}
+
+	if len(dirPieces) == 0 {
+		return ".", pieces
+	}
+
+	dir := strings.Join(dirPieces, "/")
+	if len(dir) == 0 {
+		dir = "/" // absolute pattern (eg '/*.yml')
+	}
+
+	return filepath.FromSlash(dir), pieces
+}
+
+func globPiecesMatch(pathPieces, patternPieces []string) bool {
+	if len(patternPieces) == 0 {
+		return len(pathPieces) == 0
+	}
+
+	if patternPieces[0] == "**" {
+		for i := 0; i <= len(pathPieces); i++ {
+			if globPiecesMatch(pathPieces[i:], patternPieces[1:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(pathPieces) == 0 {
+		return false
+	}
+
+	matched, err := filepath.Match(patternPieces[0], pathPieces[0])
+	if !matched || err != nil {
+		return false
+	}
+
+	return globPiecesMatch(pathPieces[1:], patternPieces[1:])
+}
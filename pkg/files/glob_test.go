@@ -0,0 +1,75 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestNewSortedFilesFromPathsWithGlob(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	for _, path := range []string{"a.yml", "b.txt", "x/c.yml", "x/y/d.yml", "x/y/e.star", "lit[1].yml"} {
+		fullPath := filepath.Join(dirPath, path)
+		err := os.MkdirAll(filepath.Dir(fullPath), 0700)
+		if err != nil {
+			t.Fatalf("Expected creating dir to succeed: %s", err)
+		}
+		err = ioutil.WriteFile(fullPath, []byte("a: 1\n"), 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	examples := []struct {
+		Pattern  string
+		Expected string
+	}{
+		{Pattern: "*.yml", Expected: "a.yml,lit[1].yml"},
+		{Pattern: "**/*.yml", Expected: "a.yml,lit[1].yml,x/c.yml,x/y/d.yml"},
+		{Pattern: "x/**/*.yml", Expected: "c.yml,y/d.yml"},
+		{Pattern: "x/*/*", Expected: "y/d.yml,y/e.star"},
+		{Pattern: "?.txt", Expected: "b.txt"},
+		{Pattern: `lit\[1\].yml`, Expected: "lit[1].yml"},
+		// Existing paths are used as is
+		{Pattern: "lit[1].yml", Expected: "lit[1].yml"},
+	}
+
+	for _, ex := range examples {
+		result, err := files.NewSortedFilesFromPaths([]string{filepath.Join(dirPath, ex.Pattern)}, files.SourceOpts{})
+		if err != nil {
+			t.Fatalf("Expected pattern '%s' to succeed: %s", ex.Pattern, err)
+		}
+
+		var paths []string
+		for _, file := range result {
+			paths = append(paths, file.RelativePath())
+		}
+
+		if strings.Join(paths, ",") != ex.Expected {
+			t.Fatalf("Expected pattern '%s' to match '%s', but was '%s'", ex.Pattern, ex.Expected, strings.Join(paths, ","))
+		}
+	}
+
+	pattern := filepath.Join(dirPath, "**/*.json")
+
+	_, err := files.NewSortedFilesFromPaths([]string{pattern}, files.SourceOpts{})
+	if err == nil || err.Error() != "Expected glob pattern '"+pattern+"' to match at least one file, but did not" {
+		t.Fatalf("Expected empty glob to fail, but was: %v", err)
+	}
+
+	result, err := files.NewSortedFilesFromPaths([]string{pattern}, files.SourceOpts{AllowEmptyGlob: true})
+	if err != nil || len(result) != 0 {
+		t.Fatalf("Expected empty glob to be allowed, but was: %v (%d files)", err, len(result))
+	}
+
+	_, err = files.NewSortedFilesFromPaths([]string{filepath.Join(dirPath, "*.yml")}, files.SourceOpts{NoGlob: true})
+	if err == nil || !strings.Contains(err.Error(), "Checking file") {
+		t.Fatalf("Expected pattern to be treated literally, but was: %v", err)
+	}
+}
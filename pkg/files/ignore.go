@@ -0,0 +1,192 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// IgnoreFileName is a file at the root of input directory
+	// that lists files to skip (same syntax as .gitignore)
+	IgnoreFileName = ".ytthignore"
+)
+
+type ignoreRule struct {
+	regexp  *regexp.Regexp
+	negated bool
+	dirOnly bool
+}
+
+// IgnoreRules match relative slash separated paths
+// against patterns specified in gitignore syntax
+type IgnoreRules struct {
+	rules []ignoreRule
+}
+
+func NewIgnoreRules(data []byte) (*IgnoreRules, error) {
+	result := &IgnoreRules{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+
+		rule, ok, err := newIgnoreRule(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("Parsing line %d: %s", lineNum, err)
+		}
+		if ok {
+			result.rules = append(result.rules, rule)
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// newIgnoreRulesFromDir reads ignore file at the root of directory if it exists
+func newIgnoreRulesFromDir(dirPath string) (*IgnoreRules, error) {
+	ignorePath := filepath.Join(dirPath, IgnoreFileName)
+
+	data, err := ioutil.ReadFile(ignorePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &IgnoreRules{}, nil
+		}
+		return nil, fmt.Errorf("Reading ignore file '%s': %s", ignorePath, err)
+	}
+
+	rules, err := NewIgnoreRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("Reading ignore file '%s': %s", ignorePath, err)
+	}
+
+	return rules, nil
+}
+
+// Matches returns true if path should be ignored. Directories are expected
+// to be checked before their contents since ignoring directory does not
+// ignore paths within it (see filepath.SkipDir).
+func (r *IgnoreRules) Matches(path string, isDir bool) bool {
+	var ignored bool
+
+	for _, rule := range r.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.regexp.MatchString(path) {
+			ignored = !rule.negated
+		}
+	}
+
+	return ignored
+}
+
+func newIgnoreRule(line string) (ignoreRule, bool, error) {
+	line = strings.TrimRight(line, "\r")
+
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if len(line) == 0 || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false, nil
+	}
+
+	var rule ignoreRule
+
+	if strings.HasPrefix(line, "!") {
+		rule.negated = true
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// Patterns without slashes match at any level,
+	// otherwise they are relative to the ignore file location
+	prefix := "^(.*/)?"
+	if strings.Contains(line, "/") {
+		prefix = "^"
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if len(line) == 0 {
+		return ignoreRule{}, false, nil
+	}
+
+	re, err := regexp.Compile(prefix + ignorePatternToRegexp(line) + "$")
+	if err != nil {
+		return ignoreRule{}, false, fmt.Errorf("Compiling pattern '%s': %s", line, err)
+	}
+
+	rule.regexp = re
+
+	return rule, true, nil
+}
+
+func ignorePatternToRegexp(pattern string) string {
+	var result strings.Builder
+
+	for i := 0; i < len(pattern); i++ {
+		rest := pattern[i:]
+
+		switch {
+		case i == 0 && strings.HasPrefix(rest, "**/"):
+			result.WriteString("(.*/)?")
+			i += 2
+
+		case rest == "/**":
+			result.WriteString("/.*")
+			i += 2
+
+		case strings.HasPrefix(rest, "/**/"):
+			result.WriteString("/(.*/)?")
+			i += 3
+
+		case rest[0] == '*':
+			result.WriteString("[^/]*")
+			for i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+			}
+
+		case rest[0] == '?':
+			result.WriteString("[^/]")
+
+		case rest[0] == '[':
+			end := strings.Index(rest[1:], "]")
+			if end == -1 {
+				result.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := rest[1 : end+1]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			result.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += end + 1
+
+		case rest[0] == '\\' && len(rest) > 1:
+			result.WriteString(regexp.QuoteMeta(rest[1:2]))
+			i++
+
+		default:
+			result.WriteString(regexp.QuoteMeta(rest[0:1]))
+		}
+	}
+
+	return result.String()
+}
@@ -0,0 +1,114 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	rules, err := files.NewIgnoreRules([]byte(`
+# comment
+*.tmp
+build/
+/root-only.yml
+docs/*.md
+!docs/keep.md
+**/cache
+logs/**
+a/**/z.yml
+\#hash.yml
+file[0-9].yml
+`))
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	examples := []struct {
+		Path    string
+		IsDir   bool
+		Ignored bool
+	}{
+		{Path: "x.tmp", Ignored: true},
+		{Path: "nested/x.tmp", Ignored: true},
+		{Path: "x.tmp.yml", Ignored: false},
+		{Path: "build", IsDir: true, Ignored: true},
+		{Path: "nested/build", IsDir: true, Ignored: true},
+		{Path: "build", IsDir: false, Ignored: false},
+		{Path: "root-only.yml", Ignored: true},
+		{Path: "nested/root-only.yml", Ignored: false},
+		{Path: "docs/readme.md", Ignored: true},
+		{Path: "docs/keep.md", Ignored: false},
+		{Path: "docs/nested/readme.md", Ignored: false},
+		{Path: "cache", IsDir: true, Ignored: true},
+		{Path: "x/y/cache", Ignored: true},
+		{Path: "logs/a/b.log", Ignored: true},
+		{Path: "logs", IsDir: true, Ignored: false},
+		{Path: "a/z.yml", Ignored: true},
+		{Path: "a/b/c/z.yml", Ignored: true},
+		{Path: "#hash.yml", Ignored: true},
+		{Path: "comment", Ignored: false},
+		{Path: "file1.yml", Ignored: true},
+		{Path: "filex.yml", Ignored: false},
+	}
+
+	for _, ex := range examples {
+		if rules.Matches(ex.Path, ex.IsDir) != ex.Ignored {
+			t.Fatalf("Expected path '%s' (dir %t) ignored to be %t, but was not", ex.Path, ex.IsDir, ex.Ignored)
+		}
+	}
+}
+
+func TestNewSortedFilesFromPathsWithIgnoreFile(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "ytt-ignore")
+	if err != nil {
+		t.Fatalf("Expected creating temp dir to succeed: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	for path, content := range map[string]string{
+		files.IgnoreFileName: "build/\n*.tmp\n",
+		"config.yml":         "a: 1",
+		"build/out.yml":      "a: 2",
+		"nested/scratch.tmp": "tmp",
+		"nested/value.yml":   "a: 3",
+	} {
+		fullPath := filepath.Join(dirPath, path)
+		err := os.MkdirAll(filepath.Dir(fullPath), 0700)
+		if err != nil {
+			t.Fatalf("Expected creating dir to succeed: %s", err)
+		}
+		err = ioutil.WriteFile(fullPath, []byte(content), 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	examples := []struct {
+		Opts  files.SourceOpts
+		Paths string
+	}{
+		{files.SourceOpts{}, "config.yml,nested/value.yml"},
+		{files.SourceOpts{NoIgnoreFile: true}, files.IgnoreFileName + ",build/out.yml,config.yml,nested/scratch.tmp,nested/value.yml"},
+	}
+
+	for _, ex := range examples {
+		result, err := files.NewSortedFilesFromPaths([]string{dirPath}, ex.Opts)
+		if err != nil {
+			t.Fatalf("Expected reading files to succeed: %s", err)
+		}
+
+		var paths []string
+		for _, file := range result {
+			paths = append(paths, file.RelativePath())
+		}
+
+		if strings.Join(paths, ",") != ex.Paths {
+			t.Fatalf("Expected files to match, but was: %#v", paths)
+		}
+	}
+}
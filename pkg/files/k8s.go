@@ -0,0 +1,494 @@
+package files
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+const (
+	k8sPathPrefix = "k8s://"
+
+	k8sServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// ConfigMaps are limited to 1MiB by API server
+	k8sResponseMaxSize = 4 * 1024 * 1024
+)
+
+type K8sSourceOpts struct {
+	// Kubeconfig is path to kubeconfig file; empty means first file
+	// listed in $KUBECONFIG, in-cluster configuration (when running
+	// in a pod) or ~/.kube/config (in that order)
+	Kubeconfig string
+	Context    string // empty means current context of kubeconfig
+
+	Timeout time.Duration // zero means no timeout
+}
+
+// K8sConfigMapPath represents 'k8s://<namespace>/<name>' file path
+type K8sConfigMapPath struct {
+	Namespace string
+	Name      string
+}
+
+func IsK8sPath(path string) bool { return strings.HasPrefix(path, k8sPathPrefix) }
+
+// ParseK8sPath parses path such as 'k8s://default/app-templates'
+func ParseK8sPath(path string) (K8sConfigMapPath, error) {
+	pieces := strings.Split(strings.TrimPrefix(path, k8sPathPrefix), "/")
+
+	if len(pieces) != 2 || len(pieces[0]) == 0 || len(pieces[1]) == 0 {
+		return K8sConfigMapPath{}, fmt.Errorf("Expected Kubernetes path '%s' to be in format "+
+			"k8s://<namespace>/<configmap-name> (eg k8s://default/app-templates)", path)
+	}
+
+	return K8sConfigMapPath{Namespace: pieces[0], Name: pieces[1]}, nil
+}
+
+func (p K8sConfigMapPath) Description() string {
+	return fmt.Sprintf("ConfigMap '%s/%s'", p.Namespace, p.Name)
+}
+
+// k8sConfigMapKeySource holds contents of a single ConfigMap key
+type k8sConfigMapKeySource struct {
+	path K8sConfigMapPath
+	key  string
+	data []byte
+}
+
+var _ Source = k8sConfigMapKeySource{}
+
+func (s k8sConfigMapKeySource) Description() string {
+	return fmt.Sprintf("key '%s' of %s", s.key, s.path.Description())
+}
+
+func (s k8sConfigMapKeySource) RelativePath() (string, error) { return s.key, nil }
+func (s k8sConfigMapKeySource) Bytes() ([]byte, error)        { return s.data, nil }
+
+// newFilesFromK8s fetches ConfigMap and returns file per each of its
+// keys sorted by key (binaryData keys are included as decoded bytes)
+func newFilesFromK8s(path string, opts SourceOpts) ([]*File, error) {
+	configMapPath, err := ParseK8sPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newK8sClient(opts.K8sSourceOpts)
+	if err != nil {
+		return nil, fmt.Errorf("Configuring Kubernetes client for %s: %s", configMapPath.Description(), err)
+	}
+
+	configMap, err := client.ConfigMap(configMapPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyData := map[string][]byte{}
+
+	for key, val := range configMap.Data {
+		keyData[key] = []byte(val)
+	}
+	for key, val := range configMap.BinaryData {
+		keyData[key] = val
+	}
+
+	var keys []string
+
+	for key := range keyData {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var result []*File
+
+	for _, key := range keys {
+		src := k8sConfigMapKeySource{configMapPath, key, keyData[key]}
+
+		err := checkFileSize(src.Description(), int64(len(src.data)), opts.MaxFileSize)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := NewFileFromSource(src)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+type k8sConfigMap struct {
+	Data       map[string]string `json:"data"`
+	BinaryData map[string][]byte `json:"binaryData"` // base64 encoded in JSON
+}
+
+// k8sStatus is returned by API server for failed requests
+type k8sStatus struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+}
+
+// k8sClient is a minimal Kubernetes API client (only reading ConfigMaps
+// is needed) that avoids depending on Kubernetes client libraries
+type k8sClient struct {
+	server     string
+	httpClient *http.Client
+	authHeader string
+	user       string // used in error messages
+}
+
+func newK8sClient(opts K8sSourceOpts) (*k8sClient, error) {
+	kubeconfigPath := opts.Kubeconfig
+
+	if len(kubeconfigPath) == 0 {
+		kubeconfigPath = strings.Split(os.Getenv("KUBECONFIG"), string(filepath.ListSeparator))[0]
+	}
+
+	if len(kubeconfigPath) == 0 {
+		if len(os.Getenv("KUBERNETES_SERVICE_HOST")) > 0 {
+			if len(opts.Context) > 0 {
+				return nil, fmt.Errorf("Expected kubeconfig to be available when context '%s' is specified", opts.Context)
+			}
+			return newInClusterK8sClient(opts)
+		}
+
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("Expected kubeconfig or in-cluster configuration to be available: %s", err)
+		}
+		kubeconfigPath = filepath.Join(homeDir, ".kube", "config")
+	}
+
+	return newKubeconfigK8sClient(kubeconfigPath, opts)
+}
+
+// newInClusterK8sClient uses service account of the pod
+func newInClusterK8sClient(opts K8sSourceOpts) (*k8sClient, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if len(port) == 0 {
+		port = "443"
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+
+	tokenBs, err := ioutil.ReadFile(filepath.Join(k8sServiceAccountDir, "token"))
+	if err != nil {
+		return nil, fmt.Errorf("Reading service account token: %s", err)
+	}
+
+	caBs, err := ioutil.ReadFile(filepath.Join(k8sServiceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("Reading service account CA certificate: %s", err)
+	}
+
+	tlsConfig, err := newK8sTLSConfig(caBs, nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k8sClient{
+		server:     fmt.Sprintf("https://%s:%s", host, port),
+		httpClient: newK8sHTTPClient(tlsConfig, opts.Timeout),
+		authHeader: "Bearer " + strings.TrimSpace(string(tokenBs)),
+		user:       "pod service account",
+	}, nil
+}
+
+type kubeconfig struct {
+	CurrentContext string `json:"current-context"`
+	Contexts       []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster string `json:"cluster"`
+			User    string `json:"user"`
+		} `json:"context"`
+	} `json:"contexts"`
+	Clusters []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			CertificateAuthorityData string `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string               `json:"name"`
+		User kubeconfigCredential `json:"user"`
+	} `json:"users"`
+}
+
+type kubeconfigCredential struct {
+	Token                 string      `json:"token"`
+	TokenFile             string      `json:"tokenFile"`
+	Username              string      `json:"username"`
+	Password              string      `json:"password"`
+	ClientCertificate     string      `json:"client-certificate"`
+	ClientCertificateData string      `json:"client-certificate-data"`
+	ClientKey             string      `json:"client-key"`
+	ClientKeyData         string      `json:"client-key-data"`
+	Exec                  interface{} `json:"exec"`
+	AuthProvider          interface{} `json:"auth-provider"`
+}
+
+func newKubeconfigK8sClient(path string, opts K8sSourceOpts) (*k8sClient, error) {
+	config, err := readKubeconfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	contextName := opts.Context
+	if len(contextName) == 0 {
+		contextName = config.CurrentContext
+	}
+	if len(contextName) == 0 {
+		return nil, fmt.Errorf("Expected kubeconfig '%s' to have current context (or context to be specified)", path)
+	}
+
+	var clusterName, userName string
+	var contextFound bool
+
+	for _, ctx := range config.Contexts {
+		if ctx.Name == contextName {
+			clusterName, userName, contextFound = ctx.Context.Cluster, ctx.Context.User, true
+		}
+	}
+	if !contextFound {
+		return nil, fmt.Errorf("Expected kubeconfig '%s' to have context '%s'", path, contextName)
+	}
+
+	client := &k8sClient{user: fmt.Sprintf("user '%s' of context '%s'", userName, contextName)}
+	var caBs []byte
+	var insecure, clusterFound bool
+
+	for _, cluster := range config.Clusters {
+		if cluster.Name != clusterName {
+			continue
+		}
+
+		clusterFound = true
+		client.server = strings.TrimSuffix(cluster.Cluster.Server, "/")
+		insecure = cluster.Cluster.InsecureSkipTLSVerify
+
+		caBs, err = kubeconfigData(path, cluster.Cluster.CertificateAuthorityData, cluster.Cluster.CertificateAuthority)
+		if err != nil {
+			return nil, fmt.Errorf("Reading certificate authority of cluster '%s': %s", clusterName, err)
+		}
+	}
+	if !clusterFound || len(client.server) == 0 {
+		return nil, fmt.Errorf("Expected kubeconfig '%s' to have cluster '%s' with server", path, clusterName)
+	}
+
+	var cred kubeconfigCredential
+
+	for _, user := range config.Users {
+		if user.Name == userName {
+			cred = user.User
+		}
+	}
+
+	if cred.Exec != nil || cred.AuthProvider != nil {
+		return nil, fmt.Errorf("Expected user '%s' to use token, basic or client certificate authentication "+
+			"(exec and auth provider plugins are not supported)", userName)
+	}
+
+	certBs, err := kubeconfigData(path, cred.ClientCertificateData, cred.ClientCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("Reading client certificate of user '%s': %s", userName, err)
+	}
+
+	keyBs, err := kubeconfigData(path, cred.ClientKeyData, cred.ClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("Reading client key of user '%s': %s", userName, err)
+	}
+
+	switch {
+	case len(cred.Token) > 0:
+		client.authHeader = "Bearer " + cred.Token
+	case len(cred.TokenFile) > 0:
+		tokenBs, err := ioutil.ReadFile(kubeconfigRelPath(path, cred.TokenFile))
+		if err != nil {
+			return nil, fmt.Errorf("Reading token file of user '%s': %s", userName, err)
+		}
+		client.authHeader = "Bearer " + strings.TrimSpace(string(tokenBs))
+	case len(cred.Username) > 0:
+		client.authHeader = "Basic " + base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password))
+	}
+
+	tlsConfig, err := newK8sTLSConfig(caBs, certBs, keyBs, insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	client.httpClient = newK8sHTTPClient(tlsConfig, opts.Timeout)
+
+	return client, nil
+}
+
+func readKubeconfig(path string) (kubeconfig, error) {
+	configBs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return kubeconfig{}, fmt.Errorf("Reading kubeconfig: %s", err)
+	}
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes(configBs, yamlmeta.DocSetOpts{AssociatedName: path})
+	if err != nil {
+		return kubeconfig{}, fmt.Errorf("Parsing kubeconfig '%s': %s", path, err)
+	}
+
+	if len(docSet.Items) != 1 {
+		return kubeconfig{}, fmt.Errorf("Expected kubeconfig '%s' to have exactly one document", path)
+	}
+
+	var jsonBuf bytes.Buffer
+
+	err = yamlmeta.NewJSONPrinter(&jsonBuf).Print(docSet.Items[0])
+	if err != nil {
+		return kubeconfig{}, fmt.Errorf("Converting kubeconfig '%s': %s", path, err)
+	}
+
+	var config kubeconfig
+
+	err = json.Unmarshal(jsonBuf.Bytes(), &config)
+	if err != nil {
+		return kubeconfig{}, fmt.Errorf("Unmarshaling kubeconfig '%s': %s", path, err)
+	}
+
+	return config, nil
+}
+
+// kubeconfigData returns base64 decoded inline data, or contents of
+// given file (relative to kubeconfig location) if data is not set
+func kubeconfigData(kubeconfigPath, data, path string) ([]byte, error) {
+	if len(data) > 0 {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if len(path) > 0 {
+		return ioutil.ReadFile(kubeconfigRelPath(kubeconfigPath, path))
+	}
+	return nil, nil
+}
+
+func kubeconfigRelPath(kubeconfigPath, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(kubeconfigPath), path)
+}
+
+func newK8sTLSConfig(caBs, certBs, keyBs []byte, insecure bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+
+	if len(caBs) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBs) {
+			return nil, fmt.Errorf("Expected certificate authority to contain PEM encoded certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(certBs) > 0 || len(keyBs) > 0 {
+		cert, err := tls.X509KeyPair(certBs, keyBs)
+		if err != nil {
+			return nil, fmt.Errorf("Loading client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func newK8sHTTPClient(tlsConfig *tls.Config, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+		Timeout:   timeout,
+	}
+}
+
+func (c *k8sClient) ConfigMap(path K8sConfigMapPath) (k8sConfigMap, error) {
+	reqURL := fmt.Sprintf("%s/api/v1/namespaces/%s/configmaps/%s",
+		c.server, url.PathEscape(path.Namespace), url.PathEscape(path.Name))
+
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return k8sConfigMap{}, fmt.Errorf("Building request for %s: %s", path.Description(), err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if len(c.authHeader) > 0 {
+		req.Header.Set("Authorization", c.authHeader)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return k8sConfigMap{}, fmt.Errorf("Fetching %s: %s", path.Description(), err)
+	}
+	defer resp.Body.Close()
+
+	// Read one extra byte to detect that limit was exceeded
+	bodyBs, err := ioutil.ReadAll(io.LimitReader(resp.Body, k8sResponseMaxSize+1))
+	if err != nil {
+		return k8sConfigMap{}, fmt.Errorf("Reading %s: %s", path.Description(), err)
+	}
+	if len(bodyBs) > k8sResponseMaxSize {
+		return k8sConfigMap{}, fmt.Errorf("Expected %s response to not exceed %d bytes", path.Description(), k8sResponseMaxSize)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return k8sConfigMap{}, c.statusErr(path, resp.StatusCode, bodyBs)
+	}
+
+	var configMap k8sConfigMap
+
+	err = json.Unmarshal(bodyBs, &configMap)
+	if err != nil {
+		return k8sConfigMap{}, fmt.Errorf("Unmarshaling %s: %s", path.Description(), err)
+	}
+
+	return configMap, nil
+}
+
+func (c *k8sClient) statusErr(path K8sConfigMapPath, statusCode int, bodyBs []byte) error {
+	var status k8sStatus
+
+	details := string(bodyBs)
+	if len(details) > httpErrBodyMaxLen {
+		details = details[:httpErrBodyMaxLen]
+	}
+	if json.Unmarshal(bodyBs, &status) == nil && len(status.Message) > 0 {
+		details = status.Message
+	}
+
+	switch statusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("Expected %s to exist, but it was not found (%s)", path.Description(), details)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("Fetching %s: Expected credentials of %s to be accepted by API server (%s)",
+			path.Description(), c.user, details)
+	case http.StatusForbidden:
+		return fmt.Errorf("Fetching %s: Expected %s to be allowed to get configmaps in namespace '%s' "+
+			"(check RBAC permissions) (%s)", path.Description(), c.user, path.Namespace, details)
+	default:
+		return fmt.Errorf("Fetching %s: Expected response status code to be 2xx, but was %d (%s)",
+			path.Description(), statusCode, details)
+	}
+}
@@ -0,0 +1,133 @@
+package files_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestParseK8sPath(t *testing.T) {
+	result, err := files.ParseK8sPath("k8s://default/app-templates")
+	if err != nil || result != (files.K8sConfigMapPath{Namespace: "default", Name: "app-templates"}) {
+		t.Fatalf("Expected parsing to succeed, but was: %#v (err: %v)", result, err)
+	}
+
+	for _, path := range []string{"k8s://default", "k8s:///name", "k8s://default/", "k8s://a/b/c"} {
+		_, err := files.ParseK8sPath(path)
+		if err == nil || !strings.Contains(err.Error(), "to be in format k8s://<namespace>/<configmap-name>") {
+			t.Fatalf("Expected parsing '%s' to fail, but was: %v", path, err)
+		}
+	}
+}
+
+func TestK8sFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"kind":"Status","message":"Unauthorized"}`)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/configmaps/templates":
+			// 'AQI=' is base64 encoded 0x01 0x02
+			fmt.Fprintf(w, `{"data":{"values.yml":"a: 1","tpl.yml":"b: 2"},"binaryData":{"data.bin":"AQI="}}`)
+		case "/api/v1/namespaces/other/configmaps/templates":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `{"kind":"Status","message":"configmaps \"templates\" is forbidden"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"kind":"Status","message":"configmaps \"missing\" not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	kubeconfigPath := filepath.Join(dirPath, "kubeconfig")
+	writeKubeconfig(t, kubeconfigPath, server.URL, "secret-token")
+
+	opts := files.SourceOpts{K8sSourceOpts: files.K8sSourceOpts{Kubeconfig: kubeconfigPath}}
+
+	result, err := files.NewSortedFilesFromPaths([]string{"k8s://default/templates"}, opts)
+	if err != nil {
+		t.Fatalf("Expected reading ConfigMap to succeed: %s", err)
+	}
+
+	var descs []string
+	for _, file := range result {
+		bs, err := file.Bytes()
+		if err != nil {
+			t.Fatalf("Reading file: %s", err)
+		}
+		descs = append(descs, fmt.Sprintf("%s=%q", file.RelativePath(), bs))
+	}
+
+	expectedDescs := `data.bin="\x01\x02",tpl.yml="b: 2",values.yml="a: 1"`
+	if strings.Join(descs, ",") != expectedDescs {
+		t.Fatalf("Expected ConfigMap files to match, but was: %s", strings.Join(descs, ","))
+	}
+
+	if result[2].Description() != "key 'values.yml' of ConfigMap 'default/templates'" {
+		t.Fatalf("Expected file description to match, but was: %s", result[2].Description())
+	}
+
+	examples := []struct {
+		Path        string
+		Token       string
+		ExpectedErr string
+	}{
+		{"k8s://default/missing", "secret-token", "Expected ConfigMap 'default/missing' to exist, but it was not found (configmaps \"missing\" not found)"},
+		{"k8s://other/templates", "secret-token", "Fetching ConfigMap 'other/templates': Expected user 'test-user' of context 'test' to be allowed to get configmaps in namespace 'other' (check RBAC permissions)"},
+		{"k8s://default/templates", "wrong-token", "Fetching ConfigMap 'default/templates': Expected credentials of user 'test-user' of context 'test' to be accepted by API server (Unauthorized)"},
+	}
+
+	for _, ex := range examples {
+		writeKubeconfig(t, kubeconfigPath, server.URL, ex.Token)
+
+		_, err := files.NewSortedFilesFromPaths([]string{ex.Path}, opts)
+		if err == nil || !strings.Contains(err.Error(), ex.ExpectedErr) {
+			t.Fatalf("Expected err to contain '%s', but was: %v", ex.ExpectedErr, err)
+		}
+	}
+
+	_, err = files.NewSortedFilesFromPaths([]string{"k8s://default/templates"},
+		files.SourceOpts{K8sSourceOpts: files.K8sSourceOpts{Kubeconfig: kubeconfigPath, Context: "missing"}})
+	if err == nil || !strings.Contains(err.Error(), "to have context 'missing'") {
+		t.Fatalf("Expected missing context to fail, but was: %v", err)
+	}
+}
+
+func writeKubeconfig(t *testing.T, path, server, token string) {
+	kubeconfig := fmt.Sprintf(`
+apiVersion: v1
+kind: Config
+current-context: test
+contexts:
+- name: test
+  context:
+    cluster: test-cluster
+    user: test-user
+clusters:
+- name: test-cluster
+  cluster:
+    server: %s
+users:
+- name: test-user
+  user:
+    token: %s
+`, server, token)
+
+	err := ioutil.WriteFile(path, []byte(kubeconfig), 0600)
+	if err != nil {
+		t.Fatalf("Writing kubeconfig: %s", err)
+	}
+}
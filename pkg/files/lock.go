@@ -0,0 +1,155 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// Lock records input files (in processing order) that contributed
+// to a render so that later runs can verify that inputs did not change
+type Lock struct {
+	Files []LockFile `json:"files"`
+}
+
+// LockFile describes single input file after file marks are applied;
+// SHA256 is calculated over contents as read (eg response body for
+// HTTP files), hence remote files are locked to their resolved contents
+type LockFile struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Template bool   `json:"template"`
+	SHA256   string `json:"sha256"`
+}
+
+func NewLock(files []*File) (Lock, error) {
+	result := Lock{Files: []LockFile{}}
+
+	for _, file := range files {
+		contents, err := file.Bytes()
+		if err != nil {
+			return Lock{}, fmt.Errorf("Reading file '%s': %s", file.RelativePath(), err)
+		}
+
+		result.Files = append(result.Files, LockFile{
+			Path:     file.RelativePath(),
+			Type:     file.Type().String(),
+			Template: file.IsTemplate(),
+			SHA256:   sha256Hex(contents),
+		})
+	}
+
+	return result, nil
+}
+
+// ReadLock reads lock written by Lock.AsBytes (any YAML or JSON
+// with the same structure is accepted)
+func ReadLock(path string) (Lock, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Lock{}, fmt.Errorf("Reading lock: %s", err)
+	}
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes(data, yamlmeta.DocSetOpts{AssociatedName: path})
+	if err != nil {
+		return Lock{}, fmt.Errorf("Parsing lock '%s': %s", path, err)
+	}
+
+	if len(docSet.Items) != 1 {
+		return Lock{}, fmt.Errorf("Expected lock '%s' to have exactly one document", path)
+	}
+
+	var jsonBuf bytes.Buffer
+
+	err = yamlmeta.NewJSONPrinter(&jsonBuf).Print(docSet.Items[0])
+	if err != nil {
+		return Lock{}, fmt.Errorf("Converting lock '%s': %s", path, err)
+	}
+
+	var result Lock
+
+	err = json.Unmarshal(jsonBuf.Bytes(), &result)
+	if err != nil {
+		return Lock{}, fmt.Errorf("Unmarshaling lock '%s': %s", path, err)
+	}
+
+	return result, nil
+}
+
+// AsBytes returns lock as YAML
+func (l Lock) AsBytes() ([]byte, error) {
+	jsonBs, err := json.Marshal(l)
+	if err != nil {
+		return nil, fmt.Errorf("Marshaling lock: %s", err)
+	}
+
+	// JSON is valid YAML; converting keeps key order of fields
+	docSet, err := yamlmeta.NewDocumentSetFromBytes(jsonBs, yamlmeta.DocSetOpts{AssociatedName: "lock"})
+	if err != nil {
+		return nil, fmt.Errorf("Converting lock: %s", err)
+	}
+
+	return docSet.AsBytes()
+}
+
+// Verify returns error describing all differences between
+// expected lock and this lock (added, removed, changed or reordered files)
+func (l Lock) Verify(expected Lock) error {
+	var diffs []string
+
+	currFiles := map[string]LockFile{}
+	for _, file := range l.Files {
+		currFiles[file.Path] = file
+	}
+
+	expectedFiles := map[string]LockFile{}
+	for _, file := range expected.Files {
+		expectedFiles[file.Path] = file
+
+		currFile, found := currFiles[file.Path]
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("file '%s' was removed", file.Path))
+			continue
+		}
+		if currFile.SHA256 != file.SHA256 {
+			diffs = append(diffs, fmt.Sprintf("file '%s' has different contents (sha256 %s, expected %s)",
+				file.Path, currFile.SHA256, file.SHA256))
+		}
+		if currFile.Type != file.Type {
+			diffs = append(diffs, fmt.Sprintf("file '%s' has different type (%s, expected %s)",
+				file.Path, currFile.Type, file.Type))
+		}
+		if currFile.Template != file.Template {
+			diffs = append(diffs, fmt.Sprintf("file '%s' has different template flag (%t, expected %t)",
+				file.Path, currFile.Template, file.Template))
+		}
+	}
+
+	for _, file := range l.Files {
+		if _, found := expectedFiles[file.Path]; !found {
+			diffs = append(diffs, fmt.Sprintf("file '%s' was added", file.Path))
+		}
+	}
+
+	// Order determines precedence (eg of data values), hence it matters
+	// as well; it's only compared if the same files are present
+	if len(diffs) == 0 && len(l.Files) == len(expected.Files) {
+		for i, file := range l.Files {
+			if expected.Files[i].Path != file.Path {
+				diffs = append(diffs, fmt.Sprintf("order of files is different (file '%s' is at position %d, expected '%s')",
+					file.Path, i+1, expected.Files[i].Path))
+				break
+			}
+		}
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("Expected input files to match lock, but:\n- %s", strings.Join(diffs, "\n- "))
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestLock(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	filesToLock := []*files.File{
+		files.MustNewFileFromSource(files.NewBytesSource("a.yml", []byte("a: 1\n"))),
+		files.MustNewFileFromSource(files.NewBytesSource("b.txt", []byte("b"))),
+	}
+
+	lock, err := files.NewLock(files.NewSortedFiles(filesToLock))
+	if err != nil {
+		t.Fatalf("Expected lock to succeed: %s", err)
+	}
+
+	lockBs, err := lock.AsBytes()
+	if err != nil {
+		t.Fatalf("Expected marshaling lock to succeed: %s", err)
+	}
+
+	expectedLock := `files:
+- path: a.yml
+  type: yaml
+  template: true
+  sha256: 37b128c59f1f5097f73f82691cb519f1f568667faab5ced1b4ab979d36837eae
+- path: b.txt
+  type: text
+  template: true
+  sha256: 3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d
+`
+	if string(lockBs) != expectedLock {
+		t.Fatalf("Expected lock to match, but was: >>>%s<<<", lockBs)
+	}
+
+	lockPath := filepath.Join(dirPath, "lock.yml")
+
+	err = ioutil.WriteFile(lockPath, lockBs, 0600)
+	if err != nil {
+		t.Fatalf("Writing lock: %s", err)
+	}
+
+	readLock, err := files.ReadLock(lockPath)
+	if err != nil {
+		t.Fatalf("Expected reading lock to succeed: %s", err)
+	}
+
+	err = lock.Verify(readLock)
+	if err != nil {
+		t.Fatalf("Expected lock to match itself: %s", err)
+	}
+}
+
+func TestLockVerify(t *testing.T) {
+	newLock := func(contents map[string]string, paths ...string) files.Lock {
+		var filesToLock []*files.File
+		for _, path := range paths {
+			filesToLock = append(filesToLock, files.MustNewFileFromSource(files.NewBytesSource(path, []byte(contents[path]))))
+		}
+		lock, err := files.NewLock(files.NewSortedFiles(filesToLock))
+		if err != nil {
+			t.Fatalf("Expected lock to succeed: %s", err)
+		}
+		return lock
+	}
+
+	expected := newLock(map[string]string{"a.yml": "a: 1", "b.yml": "b: 1"}, "a.yml", "b.yml")
+
+	examples := []struct {
+		Lock        files.Lock
+		ExpectedErr string
+	}{
+		{newLock(map[string]string{"a.yml": "a: 2", "b.yml": "b: 1"}, "a.yml", "b.yml"), "- file 'a.yml' has different contents"},
+		{newLock(map[string]string{"a.yml": "a: 1"}, "a.yml"), "- file 'b.yml' was removed"},
+		{newLock(map[string]string{"a.yml": "a: 1", "b.yml": "b: 1"}, "a.yml", "b.yml", "c.yml"), "- file 'c.yml' was added"},
+		{newLock(map[string]string{"a.yml": "a: 1", "b.yml": "b: 1"}, "b.yml", "a.yml"),
+			"- order of files is different (file 'b.yml' is at position 1, expected 'a.yml')"},
+	}
+
+	for _, ex := range examples {
+		err := ex.Lock.Verify(expected)
+		if err == nil || !strings.Contains(err.Error(), ex.ExpectedErr) {
+			t.Fatalf("Expected verify err to contain '%s', but was: %v", ex.ExpectedErr, err)
+		}
+	}
+
+	changedType := newLock(map[string]string{"a.yml": "a: 1", "b.yml": "b: 1"}, "a.yml", "b.yml")
+	changedType.Files[1].Template = false
+
+	err := changedType.Verify(expected)
+	if err == nil || !strings.Contains(err.Error(), "- file 'b.yml' has different template flag (false, expected true)") {
+		t.Fatalf("Expected template flag difference, but was: %v", err)
+	}
+}
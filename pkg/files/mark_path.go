@@ -0,0 +1,55 @@
+package files
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NewMarkPathRegexp converts file mark path pattern into regexp that
+// matches whole relative paths. Every occurrence of wildcards is translated:
+//   - '**/' (as a whole segment) matches zero or more directories
+//   - trailing '**' (as a whole segment) matches any characters (at least one)
+//   - '**' within a segment (eg 'example**/') matches any characters across segments
+//   - '*' matches any characters (at least one) within a single segment
+//
+// For example 'a/*/b/**/c.yml' matches 'a/x/b/c.yml' and 'a/x/b/y/z/c.yml'.
+func NewMarkPathRegexp(pattern string) *regexp.Regexp {
+	var result strings.Builder
+
+	result.WriteString("^")
+
+	for i := 0; i < len(pattern); {
+		rest := pattern[i:]
+		atSegmentStart := i == 0 || pattern[i-1] == '/'
+
+		switch {
+		case strings.HasPrefix(rest, "**/") && atSegmentStart:
+			result.WriteString("(?:.*/)?")
+			i += len("**/")
+
+		case rest == "**" && atSegmentStart:
+			result.WriteString(".+")
+			i += len("**")
+
+		case strings.HasPrefix(rest, "**"):
+			result.WriteString(".*")
+			i += len("**")
+
+		case rest[0] == '*':
+			result.WriteString("[^/]+")
+			i++
+
+		default:
+			nextIdx := strings.Index(rest, "*")
+			if nextIdx == -1 {
+				nextIdx = len(rest)
+			}
+			result.WriteString(regexp.QuoteMeta(rest[:nextIdx]))
+			i += nextIdx
+		}
+	}
+
+	result.WriteString("$")
+
+	return regexp.MustCompile(result.String())
+}
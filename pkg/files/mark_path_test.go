@@ -0,0 +1,76 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestNewMarkPathRegexp(t *testing.T) {
+	examples := []struct {
+		Pattern    string
+		Matches    []string
+		NonMatches []string
+	}{
+		{
+			Pattern:    "config/*.yml",
+			Matches:    []string{"config/a.yml"},
+			NonMatches: []string{"config/sub/a.yml", "config/.yml", "other/a.yml"},
+		},
+		{
+			Pattern:    "config/**/*",
+			Matches:    []string{"config/a.yml", "config/sub/deep/a.yml"},
+			NonMatches: []string{"config", "other/a.yml"},
+		},
+		{
+			Pattern:    "**/*.tpl",
+			Matches:    []string{"a.tpl", "sub/deep/a.tpl"},
+			NonMatches: []string{"a.tpl.yml"},
+		},
+		{
+			Pattern:    "a/**/b/*.yml",
+			Matches:    []string{"a/b/x.yml", "a/x/b/y.yml", "a/x/y/b/z.yml"},
+			NonMatches: []string{"a/x/b/y/z.yml", "a/xb/y.yml", "b/x.yml"},
+		},
+		{
+			Pattern:    "a/*/b/**/c.yml",
+			Matches:    []string{"a/x/b/c.yml", "a/x/b/y/z/c.yml"},
+			NonMatches: []string{"a/b/c.yml", "a/x/y/b/c.yml", "a/x/b/y/d.yml"},
+		},
+		{
+			Pattern:    "**/envs/**/*-*.yml",
+			Matches:    []string{"envs/prod-a.yml", "x/envs/y/prod-a.yml"},
+			NonMatches: []string{"x/envs/prod.yml", "x/envs-y/prod-a.yml"},
+		},
+		{
+			Pattern:    "config/**",
+			Matches:    []string{"config/a.yml", "config/sub/a.yml"},
+			NonMatches: []string{"config"},
+		},
+		{
+			Pattern:    "example**/*",
+			Matches:    []string{"example/a.yml", "examples/sub/a.yml"},
+			NonMatches: []string{"alt-example/a.yml"},
+		},
+		{
+			Pattern:    "app.(v1)+.yml",
+			Matches:    []string{"app.(v1)+.yml"},
+			NonMatches: []string{"app.v1.yml", "appx(v1)+.yml"},
+		},
+	}
+
+	for _, ex := range examples {
+		re := files.NewMarkPathRegexp(ex.Pattern)
+
+		for _, path := range ex.Matches {
+			if !re.MatchString(path) {
+				t.Fatalf("Expected pattern '%s' to match '%s' (regexp: %s)", ex.Pattern, path, re)
+			}
+		}
+		for _, path := range ex.NonMatches {
+			if re.MatchString(path) {
+				t.Fatalf("Expected pattern '%s' to not match '%s' (regexp: %s)", ex.Pattern, path, re)
+			}
+		}
+	}
+}
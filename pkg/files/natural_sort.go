@@ -0,0 +1,63 @@
+package files
+
+import (
+	"sort"
+	"strings"
+)
+
+// NaturalLess compares paths so that runs of digits are compared
+// numerically (eg '2-x.yml' comes before '10-x.yml'); paths that only
+// differ in zero padding of numbers (eg '01' and '1') are compared
+// lexicographically so that order stays deterministic
+func NaturalLess(a, b string) bool {
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			aEnd, bEnd := digitsEnd(a, i), digitsEnd(b, j)
+			aNum := strings.TrimLeft(a[i:aEnd], "0")
+			bNum := strings.TrimLeft(b[j:bEnd], "0")
+
+			if len(aNum) != len(bNum) {
+				return len(aNum) < len(bNum)
+			}
+			if aNum != bNum {
+				return aNum < bNum
+			}
+
+			i, j = aEnd, bEnd
+			continue
+		}
+
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+
+	// Shorter path (prefix of the other) comes first
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
+
+func sortFilesByPath(files []*File, natural bool) {
+	sort.Slice(files, func(i, j int) bool {
+		if natural {
+			return NaturalLess(files[i].RelativePath(), files[j].RelativePath())
+		}
+		return files[i].RelativePath() < files[j].RelativePath()
+	})
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+
+func digitsEnd(str string, start int) int {
+	end := start
+	for end < len(str) && isDigit(str[end]) {
+		end++
+	}
+	return end
+}
@@ -0,0 +1,77 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestNaturalLess(t *testing.T) {
+	examples := []struct {
+		A, B     string
+		Expected bool
+	}{
+		{"2-x.yml", "10-x.yml", true},
+		{"10-x.yml", "2-x.yml", false},
+		{"02-x.yml", "10-x.yml", true},
+		{"001-a.yml", "1-b.yml", true},
+		{"01-x.yml", "1-x.yml", true}, // zero padding only difference
+		{"1-x.yml", "01-x.yml", false},
+		{"a/9.yml", "a/10.yml", true},
+		{"a9/z.yml", "a10/a.yml", true},
+		{"x.yml", "x.yml", false},
+		{"x", "x-1", true},
+		{"b.yml", "a.yml", false},
+		{"v1.2.yml", "v1.10.yml", true},
+	}
+
+	for _, ex := range examples {
+		if result := files.NaturalLess(ex.A, ex.B); result != ex.Expected {
+			t.Fatalf("Expected NaturalLess('%s', '%s') to be %t, but was %t", ex.A, ex.B, ex.Expected, result)
+		}
+	}
+}
+
+func TestNewSortedFilesFromPathsWithNaturalSort(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "ytt-natural-sort")
+	if err != nil {
+		t.Fatalf("Expected creating temp dir to succeed: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	for _, path := range []string{"10-config.yml", "2-values.yml", "00-namespace.yml", "100-last.yml", "9-overlay.yml"} {
+		err := ioutil.WriteFile(filepath.Join(dirPath, path), nil, 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	examples := []struct {
+		NaturalSort bool
+		Expected    string
+	}{
+		{false, "00-namespace.yml,10-config.yml,100-last.yml,2-values.yml,9-overlay.yml"},
+		{true, "00-namespace.yml,2-values.yml,9-overlay.yml,10-config.yml,100-last.yml"},
+	}
+
+	for _, ex := range examples {
+		result, err := files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{NaturalSort: ex.NaturalSort})
+		if err != nil {
+			t.Fatalf("Expected reading files to succeed: %s", err)
+		}
+
+		var paths []string
+		for _, file := range result {
+			paths = append(paths, file.RelativePath())
+		}
+
+		if strings.Join(paths, ",") != ex.Expected {
+			t.Fatalf("Expected files to be sorted (natural: %t) as %s, but was: %s",
+				ex.NaturalSort, ex.Expected, strings.Join(paths, ","))
+		}
+	}
+}
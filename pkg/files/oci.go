@@ -0,0 +1,666 @@
+package files
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	ociPathPrefix = "oci://"
+
+	ociManifestMediaType        = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexMediaType           = "application/vnd.oci.image.index.v1+json"
+	dockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	// Manifests are small JSON documents
+	ociManifestMaxSize = 4 * 1024 * 1024
+
+	// Layers pushed by oras hold single file named by title annotation;
+	// directories are pushed as tar.gz layers marked with unpack annotation
+	ociTitleAnnotation  = "org.opencontainers.image.title"
+	ociUnpackAnnotation = "io.deis.oras.content.unpack"
+)
+
+var (
+	ociAuthParamRegexp = regexp.MustCompile(`([a-zA-Z]+)="([^"]*)"`)
+)
+
+type OCISourceOpts struct {
+	// PlainHTTP uses HTTP instead of HTTPS to talk to registries (eg local ones)
+	PlainHTTP bool
+	Timeout   time.Duration // zero means no timeout
+
+	// DockerConfigDir holds config.json with registry credentials
+	// (auths, credHelpers or credsStore); empty means $DOCKER_CONFIG
+	// or ~/.docker. Registries without credentials are accessed anonymously.
+	DockerConfigDir string
+}
+
+// OCIPath represents 'oci://<registry>/<repository>[:<tag>|@<digest>]' file path
+type OCIPath struct {
+	Registry   string
+	Repository string
+	Tag        string // 'latest' if neither tag nor digest is specified
+	Digest     string
+}
+
+func IsOCIPath(path string) bool { return strings.HasPrefix(path, ociPathPrefix) }
+
+// ParseOCIPath parses path such as 'oci://registry.example.com/org/config:v1.0.0'
+// or 'oci://registry.example.com/org/config@sha256:...'
+func ParseOCIPath(path string) (OCIPath, error) {
+	rest := strings.TrimPrefix(path, ociPathPrefix)
+
+	formatErr := fmt.Errorf("Expected OCI path '%s' to be in format oci://<registry>/<repository>[:<tag>|@<digest>] "+
+		"(eg oci://registry.example.com/org/config:v1)", path)
+
+	slashIdx := strings.Index(rest, "/")
+	if slashIdx <= 0 {
+		return OCIPath{}, formatErr
+	}
+
+	result := OCIPath{Registry: rest[:slashIdx]}
+	rest = rest[slashIdx+1:]
+
+	if digestIdx := strings.Index(rest, "@"); digestIdx >= 0 {
+		result.Digest = rest[digestIdx+1:]
+		rest = rest[:digestIdx]
+		if !strings.Contains(result.Digest, ":") {
+			return OCIPath{}, formatErr
+		}
+	} else if tagIdx := strings.LastIndex(rest, ":"); tagIdx > strings.LastIndex(rest, "/") {
+		result.Tag = rest[tagIdx+1:]
+		rest = rest[:tagIdx]
+		if len(result.Tag) == 0 {
+			return OCIPath{}, formatErr
+		}
+	} else {
+		result.Tag = "latest"
+	}
+
+	result.Repository = rest
+
+	if len(result.Repository) == 0 || strings.HasPrefix(result.Repository, "/") ||
+		strings.HasSuffix(result.Repository, "/") || strings.Contains(result.Repository, "//") {
+		return OCIPath{}, formatErr
+	}
+
+	return result, nil
+}
+
+// Reference returns digest if specified, otherwise tag
+func (p OCIPath) Reference() string {
+	if len(p.Digest) > 0 {
+		return p.Digest
+	}
+	return p.Tag
+}
+
+func (p OCIPath) Description() string {
+	sep := ":"
+	if len(p.Digest) > 0 {
+		sep = "@"
+	}
+	return fmt.Sprintf("OCI artifact '%s/%s%s%s'", p.Registry, p.Repository, sep, p.Reference())
+}
+
+// apiHost returns host that serves registry API
+// (Docker Hub is referred to by different name)
+func (p OCIPath) apiHost() string {
+	if p.Registry == "docker.io" || p.Registry == "index.docker.io" {
+		return "registry-1.docker.io"
+	}
+	return p.Registry
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+func (d ociDescriptor) Title() string { return d.Annotations[ociTitleAnnotation] }
+
+// newFilesFromOCI pulls artifact and returns files found within its single
+// tar layer (relative paths are paths within the layer), or, if layers have
+// title annotations (as pushed by oras), a file per layer named by its title.
+// Layer entries are subject to the same checks as entries of local tar archives.
+func newFilesFromOCI(path string, opts SourceOpts) ([]*File, error) {
+	ociPath, err := ParseOCIPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newOCIClient(ociPath, opts.OCISourceOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := client.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("Fetching manifest of %s: %s", ociPath.Description(), err)
+	}
+
+	var tarLayers []ociDescriptor
+	var titledLayersCount int
+
+	for _, layer := range manifest.Layers {
+		if strings.Contains(layer.MediaType, "tar") {
+			tarLayers = append(tarLayers, layer)
+		}
+		if len(layer.Title()) > 0 {
+			titledLayersCount++
+		}
+	}
+
+	if titledLayersCount > 0 {
+		if titledLayersCount != len(manifest.Layers) {
+			return nil, fmt.Errorf("Expected either all or none of layers of %s to have '%s' annotation, "+
+				"but %d of %d layers did", ociPath.Description(), ociTitleAnnotation, titledLayersCount, len(manifest.Layers))
+		}
+		return newFilesFromOCITitledLayers(client, ociPath, manifest.Layers, opts)
+	}
+
+	if len(tarLayers) != 1 {
+		return nil, fmt.Errorf("Expected %s to have exactly one tar layer or layers with '%s' annotation, but found %d "+
+			"tar layers (layers: %d)", ociPath.Description(), ociTitleAnnotation, len(tarLayers), len(manifest.Layers))
+	}
+
+	layer := tarLayers[0]
+
+	if opts.ArchiveMaxSize > 0 && layer.Size > opts.ArchiveMaxSize {
+		return nil, fmt.Errorf("Expected layer of %s to not exceed max archive size of %d bytes, but was %d bytes",
+			ociPath.Description(), opts.ArchiveMaxSize, layer.Size)
+	}
+
+	layerBs, err := client.Blob(layer)
+	if err != nil {
+		return nil, fmt.Errorf("Fetching layer '%s' of %s: %s", layer.Digest, ociPath.Description(), err)
+	}
+
+	layerPath := "layer.tar"
+	if strings.HasSuffix(layer.MediaType, "gzip") {
+		layerPath = "layer.tar.gz"
+	}
+
+	return NewFilesFromTarArchive(ociLayerSource{ociPath, layerPath, layerBs}, opts.archiveOpts())
+}
+
+// newFilesFromOCITitledLayers returns file per layer with relative path set to layer's
+// title; layers marked for unpacking are extracted as tar.gz archives instead
+// (their entries already include directory name). Layers are sorted by path.
+func newFilesFromOCITitledLayers(client *ociClient, ociPath OCIPath, layers []ociDescriptor, opts SourceOpts) ([]*File, error) {
+	entries := map[string][]byte{}
+	var totalSize int64
+
+	addEntry := func(entryPath string, data []byte) error {
+		if _, found := entries[entryPath]; found {
+			return fmt.Errorf("Expected %s to have single file '%s', but found multiple", ociPath.Description(), entryPath)
+		}
+		entries[entryPath] = data
+		return nil
+	}
+
+	for _, layer := range layers {
+		totalSize += layer.Size
+
+		if opts.ArchiveMaxSize > 0 && totalSize > opts.ArchiveMaxSize {
+			return nil, fmt.Errorf("Expected layers of %s to not exceed max archive size of %d bytes in total, but they did",
+				ociPath.Description(), opts.ArchiveMaxSize)
+		}
+
+		entryPath, err := cleanArchiveEntryPath(layer.Title())
+		if err != nil {
+			return nil, fmt.Errorf("Checking title of layer '%s' of %s: %s", layer.Digest, ociPath.Description(), err)
+		}
+
+		layerBs, err := client.Blob(layer)
+		if err != nil {
+			return nil, fmt.Errorf("Fetching layer '%s' of %s: %s", layer.Digest, ociPath.Description(), err)
+		}
+
+		if layer.Annotations[ociUnpackAnnotation] != "true" {
+			err := addEntry(entryPath, layerBs)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		dirFiles, err := NewFilesFromTarArchive(ociLayerSource{ociPath, entryPath + ".tar.gz", layerBs}, opts.archiveOpts())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range dirFiles {
+			fileBs, err := file.Bytes()
+			if err != nil {
+				return nil, err
+			}
+			err = addEntry(file.RelativePath(), fileBs)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return newArchiveFiles(ociLayerSource{path: ociPath}, entries, opts.archiveOpts())
+}
+
+// ociLayerSource holds contents of an artifact layer
+type ociLayerSource struct {
+	path      OCIPath
+	layerPath string // determines whether layer is decompressed
+	data      []byte
+}
+
+var _ Source = ociLayerSource{}
+
+func (s ociLayerSource) Description() string           { return s.path.Description() }
+func (s ociLayerSource) RelativePath() (string, error) { return s.layerPath, nil }
+func (s ociLayerSource) Bytes() ([]byte, error)        { return s.data, nil }
+
+// ociClient fetches manifests and blobs via OCI distribution API
+// authenticating via bearer tokens or basic auth as requested by registry
+type ociClient struct {
+	path       OCIPath
+	baseURL    string
+	httpClient *http.Client
+	creds      *ociCredentials
+	authHeader string
+}
+
+type ociCredentials struct {
+	Username string
+	Password string
+}
+
+func newOCIClient(path OCIPath, opts OCISourceOpts) (*ociClient, error) {
+	scheme := "https"
+	if opts.PlainHTTP {
+		scheme = "http"
+	}
+
+	creds, err := ociRegistryCredentials(path.Registry, opts.DockerConfigDir)
+	if err != nil {
+		return nil, fmt.Errorf("Looking up credentials for registry '%s': %s", path.Registry, err)
+	}
+
+	return &ociClient{
+		path:       path,
+		baseURL:    fmt.Sprintf("%s://%s/v2/%s", scheme, path.apiHost(), path.Repository),
+		httpClient: &http.Client{Timeout: opts.Timeout},
+		creds:      creds,
+	}, nil
+}
+
+func (c *ociClient) Manifest() (ociManifest, error) {
+	accept := []string{ociManifestMediaType, dockerManifestMediaType, ociIndexMediaType, dockerManifestListMediaType}
+
+	manifestBs, _, err := c.get("/manifests/"+c.path.Reference(), accept, ociManifestMaxSize)
+	if err != nil {
+		return ociManifest{}, err
+	}
+
+	if len(c.path.Digest) > 0 {
+		err := checkOCIDigest(manifestBs, c.path.Digest)
+		if err != nil {
+			return ociManifest{}, err
+		}
+	}
+
+	var manifest ociManifest
+
+	err = json.Unmarshal(manifestBs, &manifest)
+	if err != nil {
+		return ociManifest{}, fmt.Errorf("Unmarshaling manifest: %s", err)
+	}
+
+	if manifest.MediaType == ociIndexMediaType || manifest.MediaType == dockerManifestListMediaType {
+		return ociManifest{}, fmt.Errorf("Expected manifest to describe a single artifact, but was an index " +
+			"(refer to artifact within index by its digest)")
+	}
+
+	return manifest, nil
+}
+
+func (c *ociClient) Blob(desc ociDescriptor) ([]byte, error) {
+	blobBs, _, err := c.get("/blobs/"+desc.Digest, nil, desc.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(blobBs)) != desc.Size {
+		return nil, fmt.Errorf("Expected blob size to be %d bytes, but was %d bytes", desc.Size, len(blobBs))
+	}
+
+	err = checkOCIDigest(blobBs, desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return blobBs, nil
+}
+
+// get requests given API path; if registry requests authentication,
+// request is retried once with obtained authorization
+func (c *ociClient) get(apiPath string, accept []string, maxSize int64) ([]byte, http.Header, error) {
+	resp, err := c.do(apiPath, accept)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized && len(c.authHeader) == 0 {
+		err := c.authorize(resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resp.Body.Close()
+
+		resp, err = c.do(apiPath, accept)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer resp.Body.Close()
+	}
+
+	bodyBs, err := readOCIResponse(resp, maxSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Requesting '%s': %s", resp.Request.URL.String(), err)
+	}
+
+	return bodyBs, resp.Header, nil
+}
+
+func (c *ociClient) do(apiPath string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", c.baseURL+apiPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Building request: %s", err)
+	}
+
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if len(c.authHeader) > 0 {
+		req.Header.Set("Authorization", c.authHeader)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Requesting '%s': %s", req.URL.String(), err)
+	}
+
+	return resp, nil
+}
+
+// authorize obtains authorization based on registry's challenge
+// (bearer token from token service or basic auth credentials)
+func (c *ociClient) authorize(challenge string) error {
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+
+	switch scheme {
+	case "basic":
+		if c.creds == nil {
+			return fmt.Errorf("Expected credentials for registry '%s' to be configured in docker config, but were not", c.path.Registry)
+		}
+		c.authHeader = "Basic " + base64.StdEncoding.EncodeToString([]byte(c.creds.Username+":"+c.creds.Password))
+		return nil
+
+	case "bearer":
+		params := map[string]string{}
+		for _, match := range ociAuthParamRegexp.FindAllStringSubmatch(challenge, -1) {
+			params[strings.ToLower(match[1])] = match[2]
+		}
+
+		token, err := c.fetchToken(params)
+		if err != nil {
+			return fmt.Errorf("Fetching token for registry '%s': %s", c.path.Registry, err)
+		}
+		c.authHeader = "Bearer " + token
+		return nil
+
+	default:
+		return fmt.Errorf("Expected registry '%s' to request basic or bearer authentication, but was '%s'", c.path.Registry, challenge)
+	}
+}
+
+func (c *ociClient) fetchToken(params map[string]string) (string, error) {
+	realmURL, err := url.Parse(params["realm"])
+	if err != nil || len(params["realm"]) == 0 {
+		return "", fmt.Errorf("Expected authentication challenge to include valid realm, but was '%s'", params["realm"])
+	}
+
+	query := realmURL.Query()
+	if len(params["service"]) > 0 {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if len(scope) == 0 {
+		scope = fmt.Sprintf("repository:%s:pull", c.path.Repository)
+	}
+	query.Set("scope", scope)
+	realmURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", realmURL.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("Building request: %s", err)
+	}
+
+	if c.creds != nil {
+		req.SetBasicAuth(c.creds.Username, c.creds.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Requesting '%s': %s", realmURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	bodyBs, err := readOCIResponse(resp, ociManifestMaxSize)
+	if err != nil {
+		return "", fmt.Errorf("Requesting '%s': %s", realmURL.String(), err)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	err = json.Unmarshal(bodyBs, &tokenResp)
+	if err != nil {
+		return "", fmt.Errorf("Unmarshaling token response: %s", err)
+	}
+
+	if len(tokenResp.Token) > 0 {
+		return tokenResp.Token, nil
+	}
+	if len(tokenResp.AccessToken) > 0 {
+		return tokenResp.AccessToken, nil
+	}
+	return "", fmt.Errorf("Expected token response to include token, but did not")
+}
+
+func readOCIResponse(resp *http.Response, maxSize int64) ([]byte, error) {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBs, _ := ioutil.ReadAll(io.LimitReader(resp.Body, httpErrBodyMaxLen))
+		return nil, fmt.Errorf("Expected response status code to be 2xx, but was %d (body: %s)", resp.StatusCode, bodyBs)
+	}
+
+	// Read one extra byte to detect that limit was exceeded
+	bodyBs, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Reading response: %s", err)
+	}
+
+	if int64(len(bodyBs)) > maxSize {
+		return nil, fmt.Errorf("Expected response to not exceed %d bytes, but it did", maxSize)
+	}
+
+	return bodyBs, nil
+}
+
+func checkOCIDigest(data []byte, digest string) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("Expected digest '%s' to use sha256 algorithm", digest)
+	}
+
+	sum := sha256.Sum256(data)
+	actualDigest := "sha256:" + hex.EncodeToString(sum[:])
+
+	if actualDigest != digest {
+		return fmt.Errorf("Expected content to match digest '%s', but was '%s'", digest, actualDigest)
+	}
+	return nil
+}
+
+type dockerConfig struct {
+	Auths       map[string]dockerConfigAuth `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+type dockerConfigAuth struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ociRegistryCredentials looks up credentials for registry in docker
+// config: registry specific credential helper takes precedence over
+// inline auths, which take precedence over default credentials store.
+// Nil is returned if there are no credentials for registry.
+func ociRegistryCredentials(registry, configDir string) (*ociCredentials, error) {
+	if len(configDir) == 0 {
+		configDir = os.Getenv("DOCKER_CONFIG")
+	}
+	if len(configDir) == 0 {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		configDir = filepath.Join(homeDir, ".docker")
+	}
+
+	configPath := filepath.Join(configDir, "config.json")
+
+	configBs, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Reading docker config '%s': %s", configPath, err)
+	}
+
+	var config dockerConfig
+
+	err = json.Unmarshal(configBs, &config)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling docker config '%s': %s", configPath, err)
+	}
+
+	if helper, found := config.CredHelpers[registry]; found {
+		return dockerCredentialHelper(helper, registry)
+	}
+
+	for authKey, auth := range config.Auths {
+		if dockerConfigAuthHost(authKey) != registry {
+			continue
+		}
+		if len(auth.Auth) > 0 {
+			authBs, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("Decoding auth for '%s' in docker config '%s': %s", authKey, configPath, err)
+			}
+			pieces := strings.SplitN(string(authBs), ":", 2)
+			if len(pieces) != 2 {
+				return nil, fmt.Errorf("Expected auth for '%s' in docker config '%s' to be in format user:password", authKey, configPath)
+			}
+			return &ociCredentials{Username: pieces[0], Password: pieces[1]}, nil
+		}
+		if len(auth.Username) > 0 {
+			return &ociCredentials{Username: auth.Username, Password: auth.Password}, nil
+		}
+	}
+
+	if len(config.CredsStore) > 0 {
+		return dockerCredentialHelper(config.CredsStore, registry)
+	}
+
+	return nil, nil
+}
+
+// dockerConfigAuthHost returns registry host of auths key
+// (eg 'https://index.docker.io/v1/' refers to Docker Hub)
+func dockerConfigAuthHost(key string) string {
+	host := key
+	if idx := strings.Index(host, "://"); idx >= 0 {
+		host = host[idx+len("://"):]
+	}
+	host = strings.SplitN(host, "/", 2)[0]
+
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		return "docker.io"
+	}
+	return host
+}
+
+// dockerCredentialHelper runs 'docker-credential-<helper> get'
+// as specified by docker credential helper protocol
+func dockerCredentialHelper(helper, registry string) (*ociCredentials, error) {
+	var stdout, stderr bytes.Buffer
+
+	serverURL := registry
+	if registry == "docker.io" {
+		serverURL = "https://index.docker.io/v1/"
+	}
+
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		// Helpers report missing credentials via output
+		if strings.Contains(stdout.String()+stderr.String(), "credentials not found") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Running credential helper 'docker-credential-%s': %s (stderr: %s)",
+			helper, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+
+	err = json.Unmarshal(stdout.Bytes(), &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling output of credential helper 'docker-credential-%s': %s", helper, err)
+	}
+
+	return &ociCredentials{Username: resp.Username, Password: resp.Secret}, nil
+}
@@ -0,0 +1,354 @@
+package files_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestParseOCIPath(t *testing.T) {
+	examples := []struct {
+		Path     string
+		Expected files.OCIPath
+	}{
+		{"oci://registry.example.com/org/config:v1", files.OCIPath{Registry: "registry.example.com", Repository: "org/config", Tag: "v1"}},
+		{"oci://localhost:5000/config", files.OCIPath{Registry: "localhost:5000", Repository: "config", Tag: "latest"}},
+		{"oci://docker.io/org/config@sha256:abc", files.OCIPath{Registry: "docker.io", Repository: "org/config", Digest: "sha256:abc"}},
+	}
+
+	for _, ex := range examples {
+		result, err := files.ParseOCIPath(ex.Path)
+		if err != nil {
+			t.Fatalf("Expected parsing '%s' to succeed: %s", ex.Path, err)
+		}
+		if result != ex.Expected {
+			t.Fatalf("Expected '%s' to be parsed as %#v, but was %#v", ex.Path, ex.Expected, result)
+		}
+	}
+
+	for _, path := range []string{"oci://registry", "oci:///config", "oci://registry/config:", "oci://registry/config@abc", "oci://registry/config/"} {
+		_, err := files.ParseOCIPath(path)
+		if err == nil || !strings.Contains(err.Error(), "to be in format oci://<registry>/<repository>") {
+			t.Fatalf("Expected parsing '%s' to fail, but was: %v", path, err)
+		}
+	}
+}
+
+func TestOCIFiles(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	layerPath := filepath.Join(dirPath, "layer.tar.gz")
+	writeTarArchive(t, layerPath, []tarEntry{
+		{Name: "./config/"},
+		{Name: "./config/b.yml", Data: "b: 1"},
+		{Name: "values.star", Data: "x = 1"},
+	})
+
+	registry := newTestOCIRegistry(t, layerPath, "application/vnd.oci.image.layer.v1.tar+gzip")
+	defer registry.Close()
+
+	configDir := filepath.Join(dirPath, "docker")
+	writeDockerConfig(t, configDir, registry.Host(), "user", "pass")
+
+	opts := files.SourceOpts{OCISourceOpts: files.OCISourceOpts{PlainHTTP: true, DockerConfigDir: configDir}}
+
+	for _, ref := range []string{":v1", "@" + registry.manifestDigest} {
+		result, err := files.NewSortedFilesFromPaths([]string{"oci://" + registry.Host() + "/org/config" + ref}, opts)
+		if err != nil {
+			t.Fatalf("Expected reading OCI artifact to succeed: %s", err)
+		}
+
+		var paths []string
+		for _, file := range result {
+			paths = append(paths, file.RelativePath())
+		}
+
+		if strings.Join(paths, ",") != "config/b.yml,values.star" {
+			t.Fatalf("Expected OCI artifact files to match, but was: %#v", paths)
+		}
+
+		bs, err := result[0].Bytes()
+		if err != nil || string(bs) != "b: 1" {
+			t.Fatalf("Expected OCI artifact file contents to match, but was: %s (err: %v)", bs, err)
+		}
+	}
+
+	// Without credentials token service refuses to issue token
+	_, err := files.NewSortedFilesFromPaths([]string{"oci://" + registry.Host() + "/org/config:v1"},
+		files.SourceOpts{OCISourceOpts: files.OCISourceOpts{PlainHTTP: true, DockerConfigDir: filepath.Join(dirPath, "missing")}})
+	if err == nil || !strings.Contains(err.Error(), "Fetching token for registry") {
+		t.Fatalf("Expected reading without credentials to fail, but was: %v", err)
+	}
+
+	_, err = files.NewSortedFilesFromPaths([]string{"oci://" + registry.Host() + "/org/config:v1"},
+		files.SourceOpts{OCISourceOpts: opts.OCISourceOpts, ArchiveMaxSize: 10})
+	if err == nil || !strings.Contains(err.Error(), "to not exceed max archive size of 10 bytes") {
+		t.Fatalf("Expected too large layer to fail, but was: %v", err)
+	}
+}
+
+func TestOCIFilesDisallowEscaping(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	examples := []struct {
+		Entry       tarEntry
+		ExpectedErr string
+	}{
+		{tarEntry{Name: "../a.yml", Data: "a: 1"}, "Expected archive entry '../a.yml' to not refer to parent directory"},
+		{tarEntry{Name: "a.yml", Linkname: "b.yml"}, "Expected symlink entry 'a.yml' -> 'b.yml'"},
+	}
+
+	for _, ex := range examples {
+		layerPath := filepath.Join(dirPath, "layer.tar")
+		writeTarArchive(t, layerPath, []tarEntry{ex.Entry, {Name: "b.yml", Data: "b: 1"}})
+
+		registry := newTestOCIRegistry(t, layerPath, "application/vnd.oci.image.layer.v1.tar")
+		writeDockerConfig(t, dirPath, registry.Host(), "user", "pass")
+
+		_, err := files.NewSortedFilesFromPaths([]string{"oci://" + registry.Host() + "/org/config:v1"},
+			files.SourceOpts{OCISourceOpts: files.OCISourceOpts{PlainHTTP: true, DockerConfigDir: dirPath}})
+		registry.Close()
+
+		if err == nil || !strings.Contains(err.Error(), ex.ExpectedErr) {
+			t.Fatalf("Expected err to contain '%s', but was: %v", ex.ExpectedErr, err)
+		}
+	}
+}
+
+func TestOCIFilesTitledLayers(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	dirLayerPath := filepath.Join(dirPath, "config.tar.gz")
+	writeTarArchive(t, dirLayerPath, []tarEntry{
+		{Name: "config/"},
+		{Name: "config/b.yml", Data: "b: 1"},
+	})
+
+	dirLayerBs, err := ioutil.ReadFile(dirLayerPath)
+	if err != nil {
+		t.Fatalf("Reading layer: %s", err)
+	}
+
+	// Media types are arbitrary for layers pushed by oras
+	registry := newTestOCIRegistryWithLayers(t, []testOCILayer{
+		{MediaType: "application/vnd.oci.image.layer.v1.tar", Data: []byte("x = 1"),
+			Annotations: map[string]string{"org.opencontainers.image.title": "values.star"}},
+		{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Data: dirLayerBs,
+			Annotations: map[string]string{"org.opencontainers.image.title": "config", "io.deis.oras.content.unpack": "true"}},
+		{MediaType: "application/yaml", Data: []byte("a: 1"),
+			Annotations: map[string]string{"org.opencontainers.image.title": "./a.yml"}},
+	})
+	defer registry.Close()
+
+	writeDockerConfig(t, dirPath, registry.Host(), "user", "pass")
+
+	opts := files.SourceOpts{OCISourceOpts: files.OCISourceOpts{PlainHTTP: true, DockerConfigDir: dirPath}}
+
+	result, err := files.NewSortedFilesFromPaths([]string{"oci://" + registry.Host() + "/org/config:v1"}, opts)
+	if err != nil {
+		t.Fatalf("Expected reading OCI artifact to succeed: %s", err)
+	}
+
+	var contents []string
+	for _, file := range result {
+		bs, err := file.Bytes()
+		if err != nil {
+			t.Fatalf("Reading file: %s", err)
+		}
+		contents = append(contents, file.RelativePath()+"="+string(bs))
+	}
+
+	if strings.Join(contents, ",") != "a.yml=a: 1,config/b.yml=b: 1,values.star=x = 1" {
+		t.Fatalf("Expected OCI artifact files to match, but was: %#v", contents)
+	}
+
+	_, err = files.NewSortedFilesFromPaths([]string{"oci://" + registry.Host() + "/org/config:v1"},
+		files.SourceOpts{OCISourceOpts: opts.OCISourceOpts, ArchiveMaxSize: 10})
+	if err == nil || !strings.Contains(err.Error(), "to not exceed max archive size of 10 bytes in total") {
+		t.Fatalf("Expected too large layers to fail, but was: %v", err)
+	}
+}
+
+func TestOCIFilesUnsupportedLayers(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	titled := func(title string) map[string]string {
+		return map[string]string{"org.opencontainers.image.title": title}
+	}
+
+	examples := []struct {
+		Layers      []testOCILayer
+		ExpectedErr string
+	}{
+		{
+			[]testOCILayer{{MediaType: "application/yaml", Data: []byte("a: 1")}},
+			"to have exactly one tar layer or layers with 'org.opencontainers.image.title' annotation, but found 0 tar layers (layers: 1)",
+		},
+		{
+			[]testOCILayer{
+				{MediaType: "application/vnd.oci.image.layer.v1.tar", Data: []byte("a")},
+				{MediaType: "application/vnd.oci.image.layer.v1.tar", Data: []byte("b")},
+			},
+			"to have exactly one tar layer or layers with 'org.opencontainers.image.title' annotation, but found 2 tar layers (layers: 2)",
+		},
+		{
+			[]testOCILayer{
+				{MediaType: "application/yaml", Data: []byte("a: 1"), Annotations: titled("a.yml")},
+				{MediaType: "application/yaml", Data: []byte("b: 1")},
+			},
+			"Expected either all or none of layers of OCI artifact",
+		},
+		{
+			[]testOCILayer{{MediaType: "application/yaml", Data: []byte("a: 1"), Annotations: titled("../a.yml")}},
+			"Expected archive entry '../a.yml' to not refer to parent directory",
+		},
+		{
+			[]testOCILayer{
+				{MediaType: "application/yaml", Data: []byte("a: 1"), Annotations: titled("a.yml")},
+				{MediaType: "application/yaml", Data: []byte("a: 2"), Annotations: titled("./a.yml")},
+			},
+			"to have single file 'a.yml', but found multiple",
+		},
+	}
+
+	for _, ex := range examples {
+		registry := newTestOCIRegistryWithLayers(t, ex.Layers)
+		writeDockerConfig(t, dirPath, registry.Host(), "user", "pass")
+
+		_, err := files.NewSortedFilesFromPaths([]string{"oci://" + registry.Host() + "/org/config:v1"},
+			files.SourceOpts{OCISourceOpts: files.OCISourceOpts{PlainHTTP: true, DockerConfigDir: dirPath}})
+		registry.Close()
+
+		if err == nil || !strings.Contains(err.Error(), ex.ExpectedErr) {
+			t.Fatalf("Expected err to contain '%s', but was: %v", ex.ExpectedErr, err)
+		}
+	}
+}
+
+type testOCIRegistry struct {
+	*httptest.Server
+	manifestDigest string
+}
+
+func (r testOCIRegistry) Host() string { return strings.TrimPrefix(r.URL, "http://") }
+
+type testOCILayer struct {
+	MediaType   string
+	Data        []byte
+	Annotations map[string]string
+}
+
+// newTestOCIRegistry serves artifact with single layer under 'org/config:v1';
+// if any credentials are sent to token service, they must be 'user:pass'
+func newTestOCIRegistry(t *testing.T, layerPath, layerMediaType string) testOCIRegistry {
+	layerBs, err := ioutil.ReadFile(layerPath)
+	if err != nil {
+		t.Fatalf("Reading layer: %s", err)
+	}
+
+	return newTestOCIRegistryWithLayers(t, []testOCILayer{{MediaType: layerMediaType, Data: layerBs}})
+}
+
+// newTestOCIRegistryWithLayers is same as newTestOCIRegistry but serves artifact with given layers
+func newTestOCIRegistryWithLayers(t *testing.T, layers []testOCILayer) testOCIRegistry {
+	blobs := map[string][]byte{}
+	var layerDescs []interface{}
+
+	for _, layer := range layers {
+		layerDigest := testSHA256Digest(layer.Data)
+		blobs["/v2/org/config/blobs/"+layerDigest] = layer.Data
+
+		layerDesc := map[string]interface{}{"mediaType": layer.MediaType, "digest": layerDigest, "size": len(layer.Data)}
+		if len(layer.Annotations) > 0 {
+			layerDesc["annotations"] = layer.Annotations
+		}
+		layerDescs = append(layerDescs, layerDesc)
+	}
+
+	manifestBs, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]interface{}{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": testSHA256Digest([]byte("{}")), "size": 2},
+		"layers":        layerDescs,
+	})
+	if err != nil {
+		t.Fatalf("Marshaling manifest: %s", err)
+	}
+
+	manifestDigest := testSHA256Digest(manifestBs)
+
+	var serverURL string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			user, pass, ok := r.BasicAuth()
+			if ok && (user != "user" || pass != "pass") {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !ok || r.URL.Query().Get("scope") != "repository:org/config:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, `{"token":"secret-token"}`)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:org/config:pull"`, serverURL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/org/config/manifests/v1", "/v2/org/config/manifests/" + manifestDigest:
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			w.Write(manifestBs)
+		default:
+			if blobBs, found := blobs[r.URL.Path]; found {
+				w.Write(blobBs)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	serverURL = server.URL
+
+	return testOCIRegistry{server, manifestDigest}
+}
+
+func writeDockerConfig(t *testing.T, configDir, registry, user, pass string) {
+	err := os.MkdirAll(configDir, 0700)
+	if err != nil {
+		t.Fatalf("Creating docker config dir: %s", err)
+	}
+
+	auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + pass))
+	configBs := fmt.Sprintf(`{"auths":{"%s":{"auth":"%s"}}}`, registry, auth)
+
+	err = ioutil.WriteFile(filepath.Join(configDir, "config.json"), []byte(configBs), 0600)
+	if err != nil {
+		t.Fatalf("Writing docker config: %s", err)
+	}
+}
+
+func testSHA256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -1,24 +1,376 @@
 package files
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+var (
+	splitNamePlaceholderRegexp = regexp.MustCompile(`\{([^}]+)\}`)
+	splitNameUnsafeCharsRegexp = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+const (
+	DefaultSplitNameTemplate = "{kind}-{metadata.name}"
+
+	gzipExt = ".gz"
+)
+
+type OutputDirectoryMode string
+
+const (
+	// OutputDirectoryModeClean removes previously written files before writing
+	OutputDirectoryModeClean OutputDirectoryMode = "clean"
+	// OutputDirectoryModeMerge overwrites matching files and leaves others
+	OutputDirectoryModeMerge OutputDirectoryMode = "merge"
+	// OutputDirectoryModeError fails if directory is not empty
+	OutputDirectoryModeError OutputDirectoryMode = "error"
 )
 
 type OutputDirectory struct {
 	path  string
 	files []OutputFile
 	ui    UI
+	opts  OutputDirectoryOpts
+}
+
+type OutputDirectoryOpts struct {
+	// SplitDocuments writes each YAML document into its own file
+	// named according to SplitNameTemplate (eg '{kind}-{metadata.name}')
+	SplitDocuments    bool
+	SplitNameTemplate string
+
+	// FileExtension replaces extension of all files except binary ones
+	// (eg '.yaml'), before Formats are applied; empty keeps extensions
+	FileExtension string
+
+	// Formats are used to write out files that contain YAML documents
+	// (each format produces separate file); empty means files are written as is
+	Formats []OutputFormat
+
+	// PostProcess transforms contents of each file except binary ones
+	// (after Formats are applied, before compression); optional
+	PostProcess func(relativePath string, data []byte) ([]byte, error)
+
+	// Mode controls how existing directory contents are treated; empty means clean
+	Mode OutputDirectoryMode
+
+	// Gzip compresses each written file and appends .gz to its name
+	Gzip bool
+
+	// ManifestPath is a relative path of manifest file listing written files
+	// (eg DefaultManifestFileName); files listed in previous manifest, but not
+	// written this time, are removed if they were not modified. Empty disables manifest.
+	ManifestPath string
+
+	// FollowSymlinks writes files through existing symlinks (to their
+	// targets) instead of replacing symlinks with regular files (default)
+	FollowSymlinks bool
+
+	// Progress is called after each file is written (before files are moved
+	// into place) with number of written files and total number; optional
+	Progress func(written, total int)
+}
+
+type OutputFormat struct {
+	// Ext replaces extension of the file (eg '.json'); empty keeps original extension
+	Ext     string
+	Printer func(io.Writer) yamlmeta.DocumentPrinter
+	// Encode transforms marshaled contents (eg base64); optional
+	Encode func([]byte) []byte
 }
 
 func NewOutputDirectory(path string, files []OutputFile, ui UI) *OutputDirectory {
-	return &OutputDirectory{path, files, ui}
+	return &OutputDirectory{path, files, ui, OutputDirectoryOpts{}}
+}
+
+func NewOutputDirectoryWithOpts(path string, files []OutputFile, ui UI, opts OutputDirectoryOpts) *OutputDirectory {
+	return &OutputDirectory{path, files, ui, opts}
 }
 
 func (d *OutputDirectory) Files() []OutputFile { return d.files }
 
+// Paths returns relative paths of files (including manifest) that
+// Write would write, after output options (eg SplitDocuments) are applied
+func (d *OutputDirectory) Paths() ([]string, error) {
+	err := d.prepareFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, file := range d.files {
+		result = append(result, file.RelativePath())
+	}
+
+	if len(d.opts.ManifestPath) > 0 {
+		err := checkManifestPath(d.opts.ManifestPath)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, filepath.ToSlash(filepath.Clean(d.opts.ManifestPath)))
+	}
+
+	return result, nil
+}
+
 func (d *OutputDirectory) Write() error {
+	err := d.prepareFiles()
+	if err != nil {
+		return err
+	}
+
+	switch d.opts.Mode {
+	case OutputDirectoryModeClean, "":
+		err := d.checkCleanablePath()
+		if err != nil {
+			return err
+		}
+
+	case OutputDirectoryModeError:
+		err := d.checkEmpty()
+		if err != nil {
+			return err
+		}
+
+	case OutputDirectoryModeMerge:
+		// do nothing
+
+	default:
+		return fmt.Errorf("Unknown output directory mode '%s'", d.opts.Mode)
+	}
+
+	err = os.MkdirAll(d.path, 0700)
+	if err != nil {
+		return err
+	}
+
+	var oldManifest OutputManifest
+	var manifestFile *OutputFile
+
+	if len(d.opts.ManifestPath) > 0 {
+		oldManifest, manifestFile, err = d.manifest()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Write all files before touching existing ones so that
+	// failure does not leave partially written output behind
+	stagedFiles, err := d.stageFiles()
+	if err != nil {
+		return err
+	}
+
+	var stagedManifestFile *StagedOutputFile
+
+	if manifestFile != nil {
+		staged, err := manifestFile.stage(d.path, false)
+		if err != nil {
+			d.discardStagedFiles(stagedFiles)
+			return err
+		}
+		stagedManifestFile = &staged
+	}
+
+	discardAll := func(stagedFiles []StagedOutputFile) {
+		d.discardStagedFiles(stagedFiles)
+		if stagedManifestFile != nil {
+			stagedManifestFile.Discard()
+		}
+	}
+
+	if d.opts.Mode == OutputDirectoryModeClean || d.opts.Mode == "" {
+		replacedFiles := stagedFiles
+		if stagedManifestFile != nil {
+			replacedFiles = append(append([]StagedOutputFile{}, stagedFiles...), *stagedManifestFile)
+		}
+
+		err = d.removeOldFiles(replacedFiles)
+		if err != nil {
+			discardAll(stagedFiles)
+			return err
+		}
+	}
+
+	for i, file := range stagedFiles {
+		d.ui.Infof("creating: %s\n", file.Path())
+
+		err := file.Commit()
+		if err != nil {
+			discardAll(stagedFiles[i+1:])
+			return err
+		}
+	}
+
+	if stagedManifestFile != nil {
+		err := d.pruneManifestFiles(oldManifest)
+		if err != nil {
+			stagedManifestFile.Discard()
+			return err
+		}
+
+		d.ui.Infof("creating: %s\n", stagedManifestFile.Path())
+
+		return stagedManifestFile.Commit()
+	}
+
+	return nil
+}
+
+// manifest returns previously written manifest and file for the new one
+func (d *OutputDirectory) manifest() (OutputManifest, *OutputFile, error) {
+	err := checkManifestPath(d.opts.ManifestPath)
+	if err != nil {
+		return OutputManifest{}, nil, err
+	}
+
+	manifestRelPath := filepath.ToSlash(filepath.Clean(d.opts.ManifestPath))
+
+	for _, file := range d.files {
+		if filepath.ToSlash(filepath.Clean(file.RelativePath())) == manifestRelPath {
+			return OutputManifest{}, nil, fmt.Errorf("Expected manifest path '%s' to not be used by output file", d.opts.ManifestPath)
+		}
+	}
+
+	oldManifest, err := readOutputManifest(filepath.Join(d.path, d.opts.ManifestPath))
+	if err != nil {
+		return OutputManifest{}, nil, err
+	}
+
+	newManifest, err := newOutputManifest(d.files)
+	if err != nil {
+		return OutputManifest{}, nil, err
+	}
+
+	manifestBs, err := newManifest.AsBytes()
+	if err != nil {
+		return OutputManifest{}, nil, err
+	}
+
+	manifestFile := NewOutputFile(d.opts.ManifestPath, manifestBs)
+
+	return oldManifest, &manifestFile, nil
+}
+
+// pruneManifestFiles removes files listed in old manifest that were not written
+// this time; files that were modified since they were written are kept
+func (d *OutputDirectory) pruneManifestFiles(oldManifest OutputManifest) error {
+	newManifest, err := newOutputManifest(d.files)
+	if err != nil {
+		return err
+	}
+
+	for _, oldFile := range oldManifest.Files {
+		if newManifest.hasPath(oldFile.Path) {
+			continue
+		}
+
+		// Manifest may have been edited, hence do not trust its paths
+		if checkManifestPath(oldFile.Path) != nil {
+			d.ui.Infof("skipping: %s (path is not within output directory)\n", oldFile.Path)
+			continue
+		}
+
+		path := filepath.Join(d.path, filepath.FromSlash(oldFile.Path))
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("Reading file '%s': %s", path, err)
+		}
+
+		if sha256Hex(contents) != oldFile.SHA256 {
+			d.ui.Infof("skipping: %s (modified since it was written)\n", path)
+			continue
+		}
+
+		d.ui.Infof("deleting: %s\n", path)
+
+		err = os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("Deleting file '%s': %s", path, err)
+		}
+	}
+
+	return nil
+}
+
+func (d *OutputDirectory) stageFiles() ([]StagedOutputFile, error) {
+	var result []StagedOutputFile
+
+	for _, file := range d.files {
+		stagedFile, err := file.stage(d.path, d.opts.FollowSymlinks)
+		if err != nil {
+			d.discardStagedFiles(result)
+			return nil, err
+		}
+		result = append(result, stagedFile)
+
+		if d.opts.Progress != nil {
+			d.opts.Progress(len(result), len(d.files))
+		}
+	}
+
+	return result, nil
+}
+
+func (d *OutputDirectory) discardStagedFiles(stagedFiles []StagedOutputFile) {
+	for _, file := range stagedFiles {
+		file.Discard()
+	}
+}
+
+// prepareFiles produces final set of files to be written
+func (d *OutputDirectory) prepareFiles() error {
+	if d.opts.SplitDocuments {
+		splitFiles, err := d.splitFiles()
+		if err != nil {
+			return err
+		}
+		d.files = splitFiles
+	}
+
+	if len(d.opts.FileExtension) > 0 {
+		extFiles, err := d.extensionFiles()
+		if err != nil {
+			return err
+		}
+		d.files = extFiles
+	}
+
+	if len(d.opts.Formats) > 0 {
+		formattedFiles, err := d.formattedFiles()
+		if err != nil {
+			return err
+		}
+		d.files = formattedFiles
+	}
+
+	if d.opts.PostProcess != nil {
+		err := d.postProcessFiles()
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.opts.Gzip {
+		d.files = d.gzipFiles()
+	}
+
 	filePaths := map[string]struct{}{}
 
 	for _, file := range d.files {
@@ -29,67 +381,428 @@ func (d *OutputDirectory) Write() error {
 		filePaths[path] = struct{}{}
 	}
 
-	err := os.MkdirAll(d.path, 0700)
+	return nil
+}
+
+func (d *OutputDirectory) splitFiles() ([]OutputFile, error) {
+	nameTpl := d.opts.SplitNameTemplate
+	if len(nameTpl) == 0 {
+		nameTpl = DefaultSplitNameTemplate
+	}
+
+	var result []OutputFile
+
+	for _, file := range d.files {
+		if file.DocSet() == nil {
+			result = append(result, file)
+			continue
+		}
+
+		ext := filepath.Ext(file.RelativePath())
+		dirPath := filepath.Dir(file.RelativePath())
+		baseName := strings.TrimSuffix(filepath.Base(file.RelativePath()), ext)
+
+		var docIdx int
+
+		for _, doc := range file.DocSet().Items {
+			if doc.IsEmpty() {
+				continue
+			}
+
+			name, found := d.splitName(nameTpl, doc.AsInterface())
+			if !found {
+				name = fmt.Sprintf("%s-%d", baseName, docIdx)
+				d.ui.Warnf("warning: document %d in '%s' does not have all keys "+
+					"referenced in '%s', using name '%s'\n", docIdx, file.RelativePath(), nameTpl, name+ext)
+			}
+
+			docBytes, err := doc.AsYAMLBytes()
+			if err != nil {
+				return nil, fmt.Errorf("Marshaling document %d in '%s': %s", docIdx, file.RelativePath(), err)
+			}
+
+			docSet := &yamlmeta.DocumentSet{Items: []*yamlmeta.Document{doc}}
+			splitFile := NewOutputFileWithDocSet(filepath.Join(dirPath, name+ext), docBytes, docSet)
+
+			result = append(result, splitFile.WithMode(file.mode))
+			docIdx++
+		}
+	}
+
+	return result, nil
+}
+
+func (d *OutputDirectory) extensionFiles() ([]OutputFile, error) {
+	var result []OutputFile
+
+	origPaths := map[string]string{}
+
+	for _, file := range d.files {
+		origPath := file.RelativePath()
+
+		if !file.IsBinary() {
+			file.relativePath = strings.TrimSuffix(origPath, filepath.Ext(origPath)) + d.opts.FileExtension
+		}
+
+		if prevOrigPath, found := origPaths[file.RelativePath()]; found {
+			return nil, fmt.Errorf("Expected files '%s' and '%s' to have different paths after changing "+
+				"extension to '%s', but both are written to '%s'", prevOrigPath, origPath, d.opts.FileExtension, file.RelativePath())
+		}
+
+		origPaths[file.RelativePath()] = origPath
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+func (d *OutputDirectory) formattedFiles() ([]OutputFile, error) {
+	var result []OutputFile
+
+	for _, file := range d.files {
+		if file.DocSet() == nil {
+			result = append(result, file)
+			continue
+		}
+
+		for _, format := range d.opts.Formats {
+			path := file.RelativePath()
+			if len(format.Ext) > 0 {
+				path = strings.TrimSuffix(path, filepath.Ext(path)) + format.Ext
+			}
+
+			docBytes, err := file.DocSet().AsBytesWithPrinter(format.Printer)
+			if err != nil {
+				return nil, fmt.Errorf("Marshaling '%s': %s", path, err)
+			}
+
+			if format.Encode != nil {
+				docBytes = format.Encode(docBytes)
+			}
+
+			result = append(result, NewOutputFileWithDocSet(path, docBytes, file.DocSet()).WithMode(file.mode))
+		}
+	}
+
+	return result, nil
+}
+
+func (d *OutputDirectory) postProcessFiles() error {
+	for i, file := range d.files {
+		if file.IsBinary() {
+			continue
+		}
+		data, err := d.opts.PostProcess(file.RelativePath(), file.Bytes())
+		if err != nil {
+			return fmt.Errorf("Post-processing '%s': %s", file.RelativePath(), err)
+		}
+		d.files[i].data = data
+	}
+	return nil
+}
+
+func (d *OutputDirectory) gzipFiles() []OutputFile {
+	var result []OutputFile
+
+	for _, file := range d.files {
+		if file.IsBinary() {
+			result = append(result, file)
+			continue
+		}
+		gzipFile := file
+		gzipFile.relativePath += gzipExt
+		result = append(result, gzipFile.WithGzip(true))
+	}
+
+	return result
+}
+
+func (d *OutputDirectory) splitName(nameTpl string, val interface{}) (string, bool) {
+	found := true
+
+	name := splitNamePlaceholderRegexp.ReplaceAllStringFunc(nameTpl, func(placeholder string) string {
+		currVal := val
+
+		for _, key := range strings.Split(placeholder[1:len(placeholder)-1], ".") {
+			typedMap, ok := currVal.(*orderedmap.Map)
+			if !ok {
+				found = false
+				return ""
+			}
+			currVal, ok = typedMap.Get(key)
+			if !ok {
+				found = false
+				return ""
+			}
+		}
+
+		switch currVal.(type) {
+		case *orderedmap.Map, []interface{}, nil:
+			found = false
+			return ""
+		default:
+			return fmt.Sprintf("%v", currVal)
+		}
+	})
+
+	name = strings.Trim(splitNameUnsafeCharsRegexp.ReplaceAllString(name, "-"), "-")
+
+	// Names such as '..' would point outside of file's directory
+	// (eg for files without extension)
+	if name == "." || name == ".." {
+		return "", false
+	}
+
+	return strings.ToLower(name), found && len(name) > 0
+}
+
+// checkCleanablePath refuses to clean directories that are
+// very likely to be specified by mistake (eg root or current directory)
+func (d *OutputDirectory) checkCleanablePath() error {
+	absPath, err := filepath.Abs(d.path)
 	if err != nil {
-		return err
+		return fmt.Errorf("Abs path '%s': %s", d.path, err)
+	}
+
+	if absPath == string(filepath.Separator) {
+		return fmt.Errorf("Expected output directory '%s' to not be root directory in clean mode", d.path)
+	}
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("Getting working directory: %s", err)
+	}
+
+	if absPath == filepath.Clean(workingDir) {
+		return fmt.Errorf("Expected output directory '%s' to not be current directory in clean mode", d.path)
+	}
+
+	return nil
+}
+
+func (d *OutputDirectory) formatExts() []string {
+	var result []string
+	if len(d.opts.FileExtension) > 0 {
+		result = append(result, d.opts.FileExtension)
+	}
+	for _, format := range d.opts.Formats {
+		if len(format.Ext) > 0 {
+			result = append(result, format.Ext)
+		}
+	}
+	if d.opts.Gzip {
+		result = append(result, gzipExt)
+	}
+	return result
+}
+
+func (d *OutputDirectory) checkEmpty() error {
+	entries, err := ioutil.ReadDir(d.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("Listing directory '%s': %s", d.path, err)
 	}
 
-	err = d.removeOldFiles()
+	if len(entries) > 0 {
+		return fmt.Errorf("Expected output directory '%s' to be empty, but found '%s'", d.path, entries[0].Name())
+	}
+
+	return nil
+}
+
+// removeOldFiles removes files that ytt could have previously written,
+// except for those that are about to be replaced by staged files
+func (d *OutputDirectory) removeOldFiles(stagedFiles []StagedOutputFile) error {
+	selectedPaths, err := d.oldFilePaths()
 	if err != nil {
 		return err
 	}
 
-	for _, file := range d.files {
-		d.ui.Printf("creating: %s\n", file.Path(d.path))
+	replacedPaths := map[string]struct{}{}
+	for _, file := range stagedFiles {
+		replacedPaths[filepath.Clean(file.Path())] = struct{}{}
+		// Keep targets of followed symlinks within output directory
+		replacedPaths[filepath.Clean(file.writePath)] = struct{}{}
+	}
+
+	for _, selectedPath := range selectedPaths {
+		if _, found := replacedPaths[filepath.Clean(selectedPath)]; found {
+			continue
+		}
+
+		d.ui.Infof("deleting: %s\n", selectedPath)
 
-		err := file.Create(d.path)
+		err := os.Remove(selectedPath)
 		if err != nil {
-			return err
+			return fmt.Errorf("Deleting file '%s'", selectedPath)
 		}
 	}
 
 	return nil
 }
 
-// clean removes all files that may conflict with output files
-// we don's just use os.RemoveAll to avoid accidently deleting
-// files like .git if incorrect directory is specified.
-func (d *OutputDirectory) removeOldFiles() error {
+// oldFilePaths returns paths of existing files that ytt could have previously written
+func (d *OutputDirectory) oldFilePaths() ([]string, error) {
 	fileInfo, err := os.Stat(d.path)
 	if err != nil {
-		return fmt.Errorf("Checking directory '%s'", d.path)
+		return nil, fmt.Errorf("Checking directory '%s'", d.path)
 	}
 
 	if !fileInfo.IsDir() {
-		return fmt.Errorf("Expected file '%s' to be a directory", d.path)
+		return nil, fmt.Errorf("Expected file '%s' to be a directory", d.path)
 	}
 
 	var selectedPaths []string
 
 	err = filepath.Walk(d.path, func(walkedPath string, fi os.FileInfo, err error) error {
-		if err != nil || fi.IsDir() {
+		if err != nil {
 			return err
 		}
 
+		// Skip hidden files and directories (eg .git) since ytt never writes them
+		if walkedPath != d.path && strings.HasPrefix(fi.Name(), ".") {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if fi.IsDir() {
+			return nil
+		}
+
+		// Walk does not follow symlinked directories, but be defensive
+		relPath, err := filepath.Rel(d.path, walkedPath)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("Expected file '%s' to be within output directory", walkedPath)
+		}
+
 		// TODO does not work with filtering of template files
-		if (&File{nil, walkedPath, nil, nil, nil, nil, 0}).IsForOutput() {
+		if (&File{src: nil, relPath: walkedPath}).IsForOutput() || pathMatchesExt(walkedPath, d.formatExts()) {
 			selectedPaths = append(selectedPaths, walkedPath)
 		}
 
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("Listing files '%s'", d.path)
+		return nil, fmt.Errorf("Listing files '%s': %s", d.path, err)
 	}
 
-	for _, selectedPath := range selectedPaths {
-		d.ui.Printf("deleting: %s\n", selectedPath)
+	return selectedPaths, nil
+}
 
-		err := os.Remove(selectedPath)
-		if err != nil {
-			return fmt.Errorf("Deleting file '%s'", selectedPath)
+type OutputDirectoryDiff struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+func (d OutputDirectoryDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Modified) > 0 || len(d.Deleted) > 0
+}
+
+// Diff prints unified diff between files already present in the output
+// directory and files that would be written, without changing anything.
+// Existing files are treated as deleted only in clean mode.
+func (d *OutputDirectory) Diff() (OutputDirectoryDiff, error) {
+	var result OutputDirectoryDiff
+
+	err := d.prepareFiles()
+	if err != nil {
+		return result, err
+	}
+
+	oldPaths := map[string]struct{}{}
+
+	if d.opts.Mode == OutputDirectoryModeClean || d.opts.Mode == "" {
+		_, err := os.Stat(d.path)
+		if err == nil {
+			selectedPaths, err := d.oldFilePaths()
+			if err != nil {
+				return result, err
+			}
+			for _, selectedPath := range selectedPaths {
+				relPath, err := filepath.Rel(d.path, selectedPath)
+				if err != nil {
+					return result, err
+				}
+				oldPaths[filepath.ToSlash(relPath)] = struct{}{}
+			}
+		} else if !os.IsNotExist(err) {
+			return result, fmt.Errorf("Checking directory '%s': %s", d.path, err)
 		}
 	}
 
-	return nil
+	newFiles := map[string]OutputFile{}
+	var allPaths []string
+
+	for _, file := range d.files {
+		path := filepath.ToSlash(filepath.Clean(file.RelativePath()))
+		newFiles[path] = file
+		allPaths = append(allPaths, path)
+	}
+	for path := range oldPaths {
+		if _, found := newFiles[path]; !found {
+			allPaths = append(allPaths, path)
+		}
+	}
+
+	sort.Strings(allPaths)
+
+	for _, path := range allPaths {
+		oldBs, err := ioutil.ReadFile(filepath.Join(d.path, path))
+		oldExists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return result, fmt.Errorf("Reading file '%s': %s", path, err)
+		}
+
+		file, newExists := newFiles[path]
+
+		var newBs []byte
+		if newExists {
+			newBs, err = file.contents()
+			if err != nil {
+				return result, err
+			}
+		}
+
+		switch {
+		case newExists && file.IsBinary() && !bytes.Equal(oldBs, newBs):
+			if oldExists {
+				result.Modified = append(result.Modified, path)
+				d.ui.Printf("Binary files a/%s and b/%s differ\n", path, path)
+			} else {
+				result.Added = append(result.Added, path)
+				d.ui.Printf("Binary files /dev/null and b/%s differ\n", path)
+			}
+
+		case !oldExists:
+			result.Added = append(result.Added, path)
+			d.ui.Printf("%s", unifiedDiff("/dev/null", "b/"+path, nil, newBs))
+
+		case !newExists:
+			result.Deleted = append(result.Deleted, path)
+			d.ui.Printf("%s", unifiedDiff("a/"+path, "/dev/null", oldBs, nil))
+
+		case !bytes.Equal(oldBs, newBs):
+			result.Modified = append(result.Modified, path)
+			d.ui.Printf("%s", unifiedDiff("a/"+path, "b/"+path, oldBs, newBs))
+		}
+	}
+
+	for _, group := range []struct {
+		Name  string
+		Paths []string
+	}{{"added", result.Added}, {"modified", result.Modified}, {"deleted", result.Deleted}} {
+		for _, path := range group.Paths {
+			d.ui.Printf("%s: %s\n", group.Name, path)
+		}
+	}
+
+	d.ui.Printf("Summary: %d added, %d modified, %d deleted\n",
+		len(result.Added), len(result.Modified), len(result.Deleted))
+
+	return result, nil
 }
@@ -0,0 +1,864 @@
+package files_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+type recordingUI struct {
+	out []string
+}
+
+func (ui *recordingUI) Printf(str string, args ...interface{}) {
+	ui.out = append(ui.out, fmt.Sprintf(str, args...))
+}
+func (ui *recordingUI) Infof(str string, args ...interface{}) {
+	ui.out = append(ui.out, fmt.Sprintf(str, args...))
+}
+func (ui *recordingUI) Warnf(str string, args ...interface{}) {
+	ui.out = append(ui.out, fmt.Sprintf(str, args...))
+}
+func (ui *recordingUI) Debugf(str string, args ...interface{}) {}
+func (ui *recordingUI) DebugWriter() io.Writer                 { return ioutil.Discard }
+
+type bufferUI struct {
+	buf bytes.Buffer
+}
+
+func (ui *bufferUI) Printf(str string, args ...interface{}) { fmt.Fprintf(&ui.buf, str, args...) }
+func (ui *bufferUI) Infof(str string, args ...interface{})  { fmt.Fprintf(&ui.buf, str, args...) }
+func (ui *bufferUI) Warnf(str string, args ...interface{})  { fmt.Fprintf(&ui.buf, str, args...) }
+func (ui *bufferUI) Debugf(str string, args ...interface{}) {}
+func (ui *bufferUI) DebugWriter() io.Writer                 { return ioutil.Discard }
+
+func TestOutputDirectorySplitDocuments(t *testing.T) {
+	docSet := mustParseDocSet(t, `
+kind: Deployment
+metadata:
+  name: web
+---
+kind: Service
+metadata:
+  name: web
+---
+---
+kind: ConfigMap
+`)
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFileWithDocSet("app/resources.yml", nil, docSet),
+		files.NewOutputFile("notes.txt", []byte("notes")),
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	ui := &recordingUI{}
+	opts := files.OutputDirectoryOpts{SplitDocuments: true}
+
+	err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, ui, opts).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	expectedFiles := map[string]string{
+		"app/deployment-web.yml": "kind: Deployment\nmetadata:\n  name: web\n",
+		"app/service-web.yml":    "kind: Service\nmetadata:\n  name: web\n",
+		"app/resources-2.yml":    "kind: ConfigMap\n",
+		"notes.txt":              "notes",
+	}
+
+	for path, expectedContent := range expectedFiles {
+		content, err := ioutil.ReadFile(filepath.Join(dirPath, path))
+		if err != nil {
+			t.Fatalf("Expected file '%s' to exist: %s", path, err)
+		}
+		if string(content) != expectedContent {
+			t.Fatalf("Expected file '%s' content to match, but was: >>>%s<<<", path, content)
+		}
+	}
+
+	var foundWarning bool
+	for _, line := range ui.out {
+		if line == "warning: document 2 in 'app/resources.yml' does not have all keys "+
+			"referenced in '{kind}-{metadata.name}', using name 'resources-2.yml'\n" {
+			foundWarning = true
+		}
+	}
+	if !foundWarning {
+		t.Fatalf("Expected warning about index-based name, but was: %#v", ui.out)
+	}
+}
+
+func TestOutputDirectorySplitDocumentsCollision(t *testing.T) {
+	docSet := mustParseDocSet(t, `
+kind: Service
+metadata:
+  name: web
+---
+kind: service
+metadata:
+  name: WEB
+`)
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFileWithDocSet("resources.yml", nil, docSet),
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	opts := files.OutputDirectoryOpts{SplitDocuments: true}
+
+	err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err == nil {
+		t.Fatalf("Expected write to fail")
+	}
+	if err.Error() != "Multiple files have same output destination paths: service-web.yml" {
+		t.Fatalf("Expected write to fail with collision err, but was: %s", err)
+	}
+}
+
+func TestOutputDirectorySplitDocumentsRelativeNames(t *testing.T) {
+	docSet := mustParseDocSet(t, `
+metadata:
+  name: ..
+---
+metadata:
+  name: .
+`)
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFileWithDocSet("app/resources", nil, docSet),
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	ui := &recordingUI{}
+	opts := files.OutputDirectoryOpts{SplitDocuments: true, SplitNameTemplate: "{metadata.name}"}
+
+	err := files.NewOutputDirectoryWithOpts(filepath.Join(dirPath, "out"), outputFiles, ui, opts).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	var paths []string
+
+	err = filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			relPath, _ := filepath.Rel(dirPath, path)
+			paths = append(paths, filepath.ToSlash(relPath))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Walking dir: %s", err)
+	}
+
+	if strings.Join(paths, ",") != "out/app/resources-0,out/app/resources-1" {
+		t.Fatalf("Expected documents to fall back to index-based names, but was: %#v", paths)
+	}
+	if ui.out[0] != "warning: document 0 in 'app/resources' does not have all keys "+
+		"referenced in '{metadata.name}', using name 'resources-0'\n" {
+		t.Fatalf("Expected warning about index-based name, but was: %#v", ui.out)
+	}
+}
+
+func TestOutputDirectoryProgress(t *testing.T) {
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("a.txt", []byte("a")),
+		files.NewOutputFile("b.txt", []byte("b")),
+		files.NewOutputFile("c.txt", []byte("c")),
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	var progress []string
+
+	opts := files.OutputDirectoryOpts{
+		Progress: func(written, total int) {
+			progress = append(progress, fmt.Sprintf("%d/%d", written, total))
+		},
+	}
+
+	err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	if strings.Join(progress, ",") != "1/3,2/3,3/3" {
+		t.Fatalf("Expected progress for each file, but was: %#v", progress)
+	}
+}
+
+func TestOutputDirectoryFormats(t *testing.T) {
+	docSet := mustParseDocSet(t, `
+kind: Service
+metadata:
+  name: web
+---
+kind: Deployment
+`)
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFileWithDocSet("app/resources.yml", []byte("original"), docSet),
+		files.NewOutputFile("notes.txt", []byte("notes")),
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	opts := files.OutputDirectoryOpts{
+		SplitDocuments: true,
+		Formats: []files.OutputFormat{
+			{},
+			{Ext: ".json", Printer: func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONPrinter(w) }},
+		},
+	}
+
+	err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	expectedFiles := map[string]string{
+		"app/service-web.yml":  "kind: Service\nmetadata:\n  name: web\n",
+		"app/service-web.json": `{"kind":"Service","metadata":{"name":"web"}}`,
+		"app/resources-1.yml":  "kind: Deployment\n",
+		"app/resources-1.json": `{"kind":"Deployment"}`,
+		"notes.txt":            "notes",
+	}
+
+	for path, expectedContent := range expectedFiles {
+		content, err := ioutil.ReadFile(filepath.Join(dirPath, path))
+		if err != nil {
+			t.Fatalf("Expected file '%s' to exist: %s", path, err)
+		}
+		if string(content) != expectedContent {
+			t.Fatalf("Expected file '%s' content to match, but was: >>>%s<<<", path, content)
+		}
+	}
+}
+
+func TestOutputDirectoryFileExtension(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("a.yml", []byte("a: 1")),
+		files.NewOutputFile("dir/b.tpl", []byte("b: 1")),
+		files.NewOutputFile("c", []byte("c: 1")),
+		files.NewBinaryOutputFile("logo.png", []byte("png")),
+	}
+
+	opts := files.OutputDirectoryOpts{FileExtension: ".yaml"}
+
+	err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	for _, path := range []string{"a.yaml", "dir/b.yaml", "c.yaml", "logo.png"} {
+		_, err := os.Stat(filepath.Join(dirPath, path))
+		if err != nil {
+			t.Fatalf("Expected file '%s' to be written: %s", path, err)
+		}
+	}
+
+	outputFiles = []files.OutputFile{
+		files.NewOutputFile("a.yml", []byte("a: 1")),
+		files.NewOutputFile("a.yaml", []byte("a: 2")),
+	}
+
+	err = files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err == nil || err.Error() != "Expected files 'a.yml' and 'a.yaml' to have different paths after changing extension to '.yaml', but both are written to 'a.yaml'" {
+		t.Fatalf("Expected collision err, but was: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dirPath, "a.yaml"))
+	if err != nil || string(content) != "a: 1" {
+		t.Fatalf("Expected file to not be overwritten, but was: >>>%s<<< (err: %v)", content, err)
+	}
+}
+
+func TestOutputDirectoryPostProcess(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("a.yml", []byte("a: 1")),
+		files.NewBinaryOutputFile("logo.png", []byte("png")),
+	}
+
+	opts := files.OutputDirectoryOpts{
+		PostProcess: func(relativePath string, data []byte) ([]byte, error) {
+			return []byte(relativePath + ": " + strings.ToUpper(string(data))), nil
+		},
+	}
+
+	err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	expectedContents := map[string]string{"a.yml": "a.yml: A: 1", "logo.png": "png"}
+
+	for path, expectedContent := range expectedContents {
+		content, err := ioutil.ReadFile(filepath.Join(dirPath, path))
+		if err != nil || string(content) != expectedContent {
+			t.Fatalf("Expected file '%s' to have contents '%s', but was: >>>%s<<< (err: %v)", path, expectedContent, content, err)
+		}
+	}
+
+	opts.PostProcess = func(string, []byte) ([]byte, error) { return nil, fmt.Errorf("failed") }
+
+	err = files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err == nil || err.Error() != "Post-processing 'a.yml': failed" {
+		t.Fatalf("Expected post-process err, but was: %v", err)
+	}
+}
+
+func TestOutputDirectoryGzip(t *testing.T) {
+	outputFiles := []files.OutputFile{
+		files.NewOutputFileWithDocSet("app/resources.yml", []byte("kind: Service\n"), mustParseDocSet(t, "kind: Service")),
+		files.NewOutputFileWithDocSet("empty.yml", nil, &yamlmeta.DocumentSet{}),
+		files.NewOutputFile("notes.txt", []byte("notes")),
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	err := ioutil.WriteFile(filepath.Join(dirPath, "old.yml.gz"), []byte("old"), 0600)
+	if err != nil {
+		t.Fatalf("Expected writing file to succeed: %s", err)
+	}
+
+	err = files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, files.OutputDirectoryOpts{Gzip: true}).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	expectedFiles := map[string]string{
+		"app/resources.yml.gz": "kind: Service\n",
+		"empty.yml.gz":         "",
+		"notes.txt.gz":         "notes",
+	}
+
+	for path, expectedContent := range expectedFiles {
+		fd, err := os.Open(filepath.Join(dirPath, path))
+		if err != nil {
+			t.Fatalf("Expected file '%s' to exist: %s", path, err)
+		}
+
+		gzipReader, err := gzip.NewReader(fd)
+		if err != nil {
+			fd.Close()
+			t.Fatalf("Expected file '%s' to be gzip compressed: %s", path, err)
+		}
+
+		content, err := ioutil.ReadAll(gzipReader)
+		fd.Close()
+		if err != nil {
+			t.Fatalf("Expected decompressing file '%s' to succeed: %s", path, err)
+		}
+		if string(content) != expectedContent {
+			t.Fatalf("Expected file '%s' content to match, but was: >>>%s<<<", path, content)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dirPath, "old.yml.gz")); !os.IsNotExist(err) {
+		t.Fatalf("Expected previously written gzip file to be removed: %v", err)
+	}
+}
+
+func TestOutputDirectoryBinaryFiles(t *testing.T) {
+	binaryBs := []byte("\x89PNG\x00\xff")
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("notes.txt", []byte("notes")),
+		files.NewBinaryOutputFile("img/logo.png", binaryBs),
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	// Binary files are written as is, even when other files are compressed
+	err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, files.OutputDirectoryOpts{Gzip: true}).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	bs, err := ioutil.ReadFile(filepath.Join(dirPath, "img/logo.png"))
+	if err != nil || !bytes.Equal(bs, binaryBs) {
+		t.Fatalf("Expected binary file to be written as is, but was: %q (err: %v)", bs, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dirPath, "notes.txt.gz")); err != nil {
+		t.Fatalf("Expected text file to be compressed: %s", err)
+	}
+
+	ui := &bufferUI{}
+	newOutputFiles := []files.OutputFile{files.NewBinaryOutputFile("img/logo.png", []byte("new"))}
+
+	_, err = files.NewOutputDirectoryWithOpts(dirPath, newOutputFiles, ui, files.OutputDirectoryOpts{Mode: files.OutputDirectoryModeMerge}).Diff()
+	if err != nil {
+		t.Fatalf("Expected diff to succeed: %s", err)
+	}
+
+	if !strings.Contains(ui.buf.String(), "Binary files a/img/logo.png and b/img/logo.png differ\nmodified: img/logo.png\n") {
+		t.Fatalf("Expected binary diff to not include contents, but was: >>>%s<<<", ui.buf.String())
+	}
+}
+
+func TestOutputDirectoryDiff(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	for path, content := range map[string]string{
+		"same.yml":     "a: 1\n",
+		"changed.yml":  "a: 1\nb: 2\nc: 3\n",
+		"deleted.yml":  "d: 4\n",
+		".git/config":  "hidden",
+		"notes.custom": "not written by ytt",
+	} {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dirPath, path)), 0700)
+		if err != nil {
+			t.Fatalf("Expected creating dir to succeed: %s", err)
+		}
+		err = ioutil.WriteFile(filepath.Join(dirPath, path), []byte(content), 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("same.yml", []byte("a: 1\n")),
+		files.NewOutputFile("changed.yml", []byte("a: 1\nb: 20\nc: 3")),
+		files.NewOutputFile("added/new.yml", []byte("n: 1\n")),
+	}
+
+	ui := &bufferUI{}
+
+	diff, err := files.NewOutputDirectory(dirPath, outputFiles, ui).Diff()
+	if err != nil {
+		t.Fatalf("Expected diff to succeed: %s", err)
+	}
+
+	if !diff.HasChanges() {
+		t.Fatalf("Expected diff to have changes")
+	}
+
+	expectedOutput := `--- /dev/null
++++ b/added/new.yml
+@@ -0,0 +1 @@
++n: 1
+--- a/changed.yml
++++ b/changed.yml
+@@ -1,3 +1,3 @@
+ a: 1
+-b: 2
+-c: 3
++b: 20
++c: 3
+\ No newline at end of file
+--- a/deleted.yml
++++ /dev/null
+@@ -1 +0,0 @@
+-d: 4
+added: added/new.yml
+modified: changed.yml
+deleted: deleted.yml
+Summary: 1 added, 1 modified, 1 deleted
+`
+
+	if ui.buf.String() != expectedOutput {
+		t.Fatalf("Expected diff output to match, but was: >>>%s<<<", ui.buf.String())
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dirPath, "changed.yml"))
+	if err != nil || string(content) != "a: 1\nb: 2\nc: 3\n" {
+		t.Fatalf("Expected diff to not change files, but was: %s (%v)", content, err)
+	}
+
+	// Merge mode does not delete files hence they are not reported
+	diff, err = files.NewOutputDirectoryWithOpts(dirPath, outputFiles[:1], &bufferUI{},
+		files.OutputDirectoryOpts{Mode: files.OutputDirectoryModeMerge}).Diff()
+	if err != nil {
+		t.Fatalf("Expected diff to succeed: %s", err)
+	}
+	if diff.HasChanges() {
+		t.Fatalf("Expected diff to not have changes, but was: %#v", diff)
+	}
+}
+
+func TestOutputDirectoryFileModes(t *testing.T) {
+	execMode := os.FileMode(0755)
+	readOnlyMode := os.FileMode(0444)
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("run.sh", []byte("#!/bin/sh")).WithMode(&execMode),
+		files.NewOutputFile("config/ro.txt", []byte("ro")).WithMode(&readOnlyMode),
+		files.NewOutputFile("default.txt", []byte("default")),
+	}
+
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	err := files.NewOutputDirectory(dirPath, outputFiles, &recordingUI{}).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	expectedModes := map[string]os.FileMode{
+		"run.sh":        0755,
+		"config/ro.txt": 0444,
+	}
+
+	for path, expectedMode := range expectedModes {
+		fi, err := os.Stat(filepath.Join(dirPath, path))
+		if err != nil {
+			t.Fatalf("Expected file '%s' to exist: %s", path, err)
+		}
+		if fi.Mode().Perm() != expectedMode {
+			t.Fatalf("Expected file '%s' mode to be %o, but was: %o", path, expectedMode, fi.Mode().Perm())
+		}
+	}
+
+	fi, err := os.Stat(filepath.Join(dirPath, "default.txt"))
+	if err != nil {
+		t.Fatalf("Expected file to exist: %s", err)
+	}
+	if fi.Mode().Perm()&0111 == 0 {
+		t.Fatalf("Expected file without mode to keep default permissions, but was: %o", fi.Mode().Perm())
+	}
+}
+
+func TestOutputDirectoryModes(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	existingFiles := map[string]string{
+		"old.yml":     "old",
+		"keep.json":   "keep",
+		"a.yml":       "previous longer content",
+		".git/config": "git",
+		".hidden.yml": "hidden",
+	}
+
+	writeExisting := func() {
+		for path, content := range existingFiles {
+			fullPath := filepath.Join(dirPath, path)
+			os.MkdirAll(filepath.Dir(fullPath), 0700)
+			err := ioutil.WriteFile(fullPath, []byte(content), 0600)
+			if err != nil {
+				t.Fatalf("Expected write to succeed: %s", err)
+			}
+		}
+	}
+
+	outputFiles := []files.OutputFile{files.NewOutputFile("a.yml", []byte("a: 1"))}
+
+	examples := []struct {
+		Mode            files.OutputDirectoryMode
+		ExpectedErr     string
+		ExpectedMissing []string
+	}{
+		{files.OutputDirectoryModeClean, "", []string{"old.yml"}},
+		{files.OutputDirectoryModeMerge, "", nil},
+		{files.OutputDirectoryModeError, "Expected output directory '" + dirPath + "' to be empty, but found '.git'", nil},
+		{files.OutputDirectoryMode("other"), "Unknown output directory mode 'other'", nil},
+	}
+
+	for _, ex := range examples {
+		writeExisting()
+
+		opts := files.OutputDirectoryOpts{Mode: ex.Mode}
+
+		err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected mode '%s' to fail with '%s', but was: %v", ex.Mode, ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected mode '%s' write to succeed: %s", ex.Mode, err)
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dirPath, "a.yml"))
+		if err != nil || string(content) != "a: 1" {
+			t.Fatalf("Expected mode '%s' to overwrite file, but was: >>>%s<<< (err: %v)", ex.Mode, content, err)
+		}
+
+		for path := range existingFiles {
+			if path == "a.yml" {
+				continue
+			}
+			var expectedMissing bool
+			for _, missingPath := range ex.ExpectedMissing {
+				if missingPath == path {
+					expectedMissing = true
+				}
+			}
+			_, err := os.Stat(filepath.Join(dirPath, path))
+			if expectedMissing != os.IsNotExist(err) {
+				t.Fatalf("Expected mode '%s' file '%s' to be missing=%t, but was: %v", ex.Mode, path, expectedMissing, err)
+			}
+		}
+	}
+}
+
+func TestOutputDirectoryAtomicWrite(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	for path, content := range map[string]string{"a.yml": "old", "old.yml": "old", "blocker": "file"} {
+		err := ioutil.WriteFile(filepath.Join(dirPath, path), []byte(content), 0600)
+		if err != nil {
+			t.Fatalf("Expected write to succeed: %s", err)
+		}
+	}
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("a.yml", []byte("a: 1")),
+		// Cannot be written since parent directory is an existing file
+		files.NewOutputFile("blocker/b.yml", []byte("b: 1")),
+	}
+
+	err := files.NewOutputDirectory(dirPath, outputFiles, &recordingUI{}).Write()
+	if err == nil || !strings.Contains(err.Error(), "blocker") {
+		t.Fatalf("Expected write to fail, but was: %v", err)
+	}
+
+	entries, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		t.Fatalf("Expected listing to succeed: %s", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	// Existing files are kept as is and temporary files are removed
+	if strings.Join(names, ",") != "a.yml,blocker,old.yml" {
+		t.Fatalf("Expected directory contents to be unchanged, but was: %s", strings.Join(names, ","))
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dirPath, "a.yml"))
+	if err != nil || string(content) != "old" {
+		t.Fatalf("Expected file to not be overwritten, but was: >>>%s<<< (err: %v)", content, err)
+	}
+
+	ui := &bufferUI{}
+
+	err = files.NewOutputDirectory(dirPath, outputFiles[:1], ui).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	expectedOutput := "deleting: " + filepath.Join(dirPath, "old.yml") + "\ncreating: " + filepath.Join(dirPath, "a.yml") + "\n"
+
+	if ui.buf.String() != expectedOutput {
+		t.Fatalf("Expected replaced file to not be deleted beforehand, but output was: >>>%s<<<", ui.buf.String())
+	}
+}
+
+func TestOutputDirectoryFollowSymlinks(t *testing.T) {
+	for _, follow := range []bool{false, true} {
+		dirPath := mustTempDir(t)
+		defer os.RemoveAll(dirPath)
+
+		targetPath := filepath.Join(dirPath, "target")
+
+		err := ioutil.WriteFile(targetPath, []byte("old"), 0600)
+		if err != nil {
+			t.Fatalf("Expected write to succeed: %s", err)
+		}
+
+		err = os.Symlink(targetPath, filepath.Join(dirPath, "a.yml"))
+		if err != nil {
+			t.Fatalf("Expected symlink to succeed: %s", err)
+		}
+
+		outputFiles := []files.OutputFile{
+			files.NewOutputFile("a.yml", []byte("a: 1")),
+		}
+		opts := files.OutputDirectoryOpts{Mode: files.OutputDirectoryModeMerge, FollowSymlinks: follow}
+
+		err = files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+		if err != nil {
+			t.Fatalf("Expected write to succeed: %s", err)
+		}
+
+		fi, err := os.Lstat(filepath.Join(dirPath, "a.yml"))
+		if err != nil {
+			t.Fatalf("Expected lstat to succeed: %s", err)
+		}
+
+		isSymlink := fi.Mode()&os.ModeSymlink != 0
+		if isSymlink != follow {
+			t.Fatalf("Expected symlink to be kept only when following (follow=%t), but was symlink=%t", follow, isSymlink)
+		}
+
+		expectedTarget := "old"
+		if follow {
+			expectedTarget = "a: 1"
+		}
+
+		content, err := ioutil.ReadFile(targetPath)
+		if err != nil || string(content) != expectedTarget {
+			t.Fatalf("Expected target to contain '%s' (follow=%t), but was: >>>%s<<< (err: %v)", expectedTarget, follow, content, err)
+		}
+
+		content, err = ioutil.ReadFile(filepath.Join(dirPath, "a.yml"))
+		if err != nil || string(content) != "a: 1" {
+			t.Fatalf("Expected file to be written, but was: >>>%s<<< (err: %v)", content, err)
+		}
+	}
+}
+
+func TestOutputDirectoryFollowSymlinksBroken(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	err := os.Symlink(filepath.Join(dirPath, "missing"), filepath.Join(dirPath, "a.yml"))
+	if err != nil {
+		t.Fatalf("Expected symlink to succeed: %s", err)
+	}
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("a.yml", []byte("a: 1")),
+	}
+	opts := files.OutputDirectoryOpts{Mode: files.OutputDirectoryModeMerge, FollowSymlinks: true}
+
+	err = files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err == nil || !strings.Contains(err.Error(), "Following symlink '"+filepath.Join(dirPath, "a.yml")+"'") {
+		t.Fatalf("Expected broken symlink err, but was: %v", err)
+	}
+}
+
+func TestOutputDirectoryModeCleanDisallowedPaths(t *testing.T) {
+	for _, path := range []string{"/", ".", "./"} {
+		err := files.NewOutputDirectory(path, nil, &recordingUI{}).Write()
+		if err == nil || !strings.Contains(err.Error(), "in clean mode") {
+			t.Fatalf("Expected cleaning '%s' to fail, but was: %v", path, err)
+		}
+	}
+}
+
+func mustParseDocSet(t *testing.T, data string) *yamlmeta.DocumentSet {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+	return docSet
+}
+
+func mustTempDir(t *testing.T) string {
+	dirPath, err := ioutil.TempDir("", "ytt-output-dir")
+	if err != nil {
+		t.Fatalf("Expected temp dir creation to succeed: %s", err)
+	}
+	return dirPath
+}
+
+func TestOutputDirectoryManifest(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	opts := files.OutputDirectoryOpts{Mode: files.OutputDirectoryModeMerge, ManifestPath: files.DefaultManifestFileName}
+
+	outputFiles := []files.OutputFile{
+		files.NewOutputFile("a.yml", []byte("a: 1\n")),
+		files.NewOutputFile("sub/b.yml", []byte("b: 1\n")),
+		files.NewOutputFile("c.yml", []byte("c: 1\n")),
+	}
+
+	err := files.NewOutputDirectoryWithOpts(dirPath, outputFiles, &recordingUI{}, opts).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	manifestBs, err := ioutil.ReadFile(filepath.Join(dirPath, files.DefaultManifestFileName))
+	if err != nil {
+		t.Fatalf("Expected manifest to be written: %s", err)
+	}
+
+	expectedManifest := `{
+  "files": [
+    {
+      "path": "a.yml",
+      "size": 5,
+      "sha256": "37b128c59f1f5097f73f82691cb519f1f568667faab5ced1b4ab979d36837eae"
+    },
+    {
+      "path": "sub/b.yml",
+      "size": 5,
+      "sha256": "08e60701d32af867a9df2a88cc0a72b634187039d243c4dd3afe1b87b957c97d"
+    },
+    {
+      "path": "c.yml",
+      "size": 5,
+      "sha256": "13ac93244e4816a1872b164e575e5e147dca6645bfc1a1ed0b26627094d72d74"
+    }
+  ]
+}
+`
+
+	if string(manifestBs) != expectedManifest {
+		t.Fatalf("Expected manifest to match, but was: >>>%s<<<", manifestBs)
+	}
+
+	// Modified file should not be pruned
+	err = ioutil.WriteFile(filepath.Join(dirPath, "c.yml"), []byte("c: 2\n"), 0600)
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	ui := &bufferUI{}
+
+	err = files.NewOutputDirectoryWithOpts(dirPath, outputFiles[:1], ui, opts).Write()
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	for path, expectedExists := range map[string]bool{"a.yml": true, "sub/b.yml": false, "c.yml": true} {
+		_, err := os.Stat(filepath.Join(dirPath, path))
+		if expectedExists == os.IsNotExist(err) {
+			t.Fatalf("Expected file '%s' to exist=%t, but was: %v", path, expectedExists, err)
+		}
+	}
+
+	expectedOutput := "creating: " + filepath.Join(dirPath, "a.yml") + "\n" +
+		"deleting: " + filepath.Join(dirPath, "sub/b.yml") + "\n" +
+		"skipping: " + filepath.Join(dirPath, "c.yml") + " (modified since it was written)\n" +
+		"creating: " + filepath.Join(dirPath, files.DefaultManifestFileName) + "\n"
+
+	if ui.buf.String() != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", ui.buf.String())
+	}
+
+	// Manifest is not treated as input
+	inputFiles, err := files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{})
+	if err != nil {
+		t.Fatalf("Expected reading files to succeed: %s", err)
+	}
+	for _, file := range inputFiles {
+		if file.RelativePath() == files.DefaultManifestFileName {
+			t.Fatalf("Expected manifest to not be included as input")
+		}
+	}
+}
@@ -1,41 +1,338 @@
 package files
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
 )
 
 type OutputFile struct {
 	relativePath string
 	data         []byte
+	docSet       *yamlmeta.DocumentSet // only available for YAML files
+	mode         *os.FileMode          // nil means default permissions
+	gzip         bool
+	binary       bool // written byte for byte (eg never compressed)
 }
 
 func NewOutputFile(relativePath string, data []byte) OutputFile {
-	return OutputFile{relativePath, data}
+	return OutputFile{relativePath, data, nil, nil, false, false}
+}
+
+func NewOutputFileWithDocSet(relativePath string, data []byte, docSet *yamlmeta.DocumentSet) OutputFile {
+	return OutputFile{relativePath, data, docSet, nil, false, false}
+}
+
+// NewBinaryOutputFile returns output file whose contents
+// are written as is, regardless of output options (eg gzip)
+func NewBinaryOutputFile(relativePath string, data []byte) OutputFile {
+	return OutputFile{relativePath, data, nil, nil, false, true}
+}
+
+// WithMode returns copy of output file that will be created with given permissions
+func (f OutputFile) WithMode(mode *os.FileMode) OutputFile {
+	f.mode = mode
+	return f
 }
 
-func (f OutputFile) RelativePath() string { return f.relativePath }
-func (f OutputFile) Bytes() []byte        { return f.data }
+// WithGzip returns copy of output file that will be gzip compressed
+// when created; relative path is expected to already include .gz suffix
+func (f OutputFile) WithGzip(gzip bool) OutputFile {
+	f.gzip = gzip
+	return f
+}
+
+// WithContents returns copy of output file with given
+// contents (eg modified documents) and same options
+func (f OutputFile) WithContents(data []byte, docSet *yamlmeta.DocumentSet) OutputFile {
+	f.data = data
+	f.docSet = docSet
+	return f
+}
+
+func (f OutputFile) RelativePath() string          { return f.relativePath }
+func (f OutputFile) Bytes() []byte                 { return f.data }
+func (f OutputFile) DocSet() *yamlmeta.DocumentSet { return f.docSet }
+func (f OutputFile) Mode() *os.FileMode            { return f.mode }
+func (f OutputFile) IsBinary() bool                { return f.binary }
 
 func (f OutputFile) Path(dirPath string) string {
 	return filepath.Join(dirPath, f.relativePath)
 }
 
+// contents returns bytes that would be written by Create
+func (f OutputFile) contents() ([]byte, error) {
+	if !f.gzip {
+		return f.data, nil
+	}
+
+	buf := new(bytes.Buffer)
+
+	err := WriteGzip(buf, f.data)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Create atomically writes file within given directory, ie file
+// contents are written into a temporary file that is renamed into place
 func (f OutputFile) Create(dirPath string) error {
+	stagedFile, err := f.stage(dirPath, false)
+	if err != nil {
+		return err
+	}
+
+	return stagedFile.Commit()
+}
+
+// StagedOutputFile is output file written into a temporary file
+// (next to its destination) that is not yet renamed into place
+type StagedOutputFile struct {
+	tmpPath string
+	path    string
+	mode    *os.FileMode
+
+	// writePath differs from path when existing symlink is followed
+	writePath string
+}
+
+const (
+	stagedTmpFileMaxAttempts = 10000
+)
+
+// stage writes temporary file next to file's destination. Existing
+// symlink at destination is replaced by a regular file, unless
+// followSymlinks is true, in which case file is written to symlink's target.
+func (f OutputFile) stage(dirPath string, followSymlinks bool) (StagedOutputFile, error) {
 	resultPath := f.Path(dirPath)
 
 	err := os.MkdirAll(filepath.Dir(resultPath), 0700)
+	if err != nil {
+		return StagedOutputFile{}, err
+	}
+
+	writePath := resultPath
+
+	if followSymlinks {
+		writePath, err = f.symlinkTargetPath(resultPath)
+		if err != nil {
+			return StagedOutputFile{}, err
+		}
+	}
+
+	fd, tmpPath, err := f.createTmpFile(writePath)
+	if err != nil {
+		return StagedOutputFile{}, err
+	}
+
+	staged := StagedOutputFile{tmpPath: tmpPath, path: resultPath, mode: f.mode, writePath: writePath}
+
+	if f.gzip {
+		err = WriteGzip(fd, f.data)
+	} else {
+		_, err = fd.Write(f.data)
+	}
+
+	closeErr := fd.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil && f.mode != nil {
+		err = os.Chmod(tmpPath, *f.mode)
+		if err != nil {
+			err = fmt.Errorf("Setting mode on file '%s': %s", resultPath, err)
+		}
+	}
+
+	if err != nil {
+		staged.Discard()
+		return StagedOutputFile{}, fmt.Errorf("Writing file '%s': %s", resultPath, err)
+	}
+
+	return staged, nil
+}
+
+// symlinkTargetPath returns final target of symlink at given path
+// (or path itself if it's not a symlink). Symlinks with missing
+// targets are rejected since it's unclear what should be created.
+func (f OutputFile) symlinkTargetPath(path string) (string, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		return "", fmt.Errorf("Checking file '%s': %s", path, err)
+	}
+
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return path, nil
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("Following symlink '%s': %s", path, err)
+	}
+
+	targetFi, err := os.Stat(target)
+	if err != nil {
+		return "", fmt.Errorf("Following symlink '%s': %s", path, err)
+	}
+	if !targetFi.Mode().IsRegular() {
+		return "", fmt.Errorf("Expected symlink '%s' to point to a regular file, but pointed to '%s'", path, target)
+	}
+
+	return target, nil
+}
+
+// createTmpFile creates hidden temporary file in the same directory as given path
+// so that it can be renamed within the same filesystem. Default permissions
+// (subject to umask) are used, same as when creating file directly.
+func (f OutputFile) createTmpFile(path string) (*os.File, string, error) {
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".ytt-tmp-")
+
+	for i := 0; i < stagedTmpFileMaxAttempts; i++ {
+		tmpPath := prefix + strconv.Itoa(os.Getpid()) + "-" + strconv.FormatUint(uint64(rand.Uint32()), 36)
+
+		fd, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0700)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("Creating temporary file for '%s': %s", path, err)
+		}
+		return fd, tmpPath, nil
+	}
+
+	return nil, "", fmt.Errorf("Creating temporary file for '%s': too many attempts", path)
+}
+
+func (f StagedOutputFile) Path() string { return f.path }
+
+// Commit renames temporary file into place. If rename is not possible
+// across filesystems, contents are copied instead (non atomically).
+func (f StagedOutputFile) Commit() error {
+	err := os.Rename(f.tmpPath, f.writePath)
+	if err == nil {
+		return nil
+	}
+
+	if linkErr, ok := err.(*os.LinkError); ok && linkErr.Err == syscall.EXDEV {
+		// Rename replaces symlinks, hence do the same before copying
+		if f.writePath == f.path {
+			if fi, lstatErr := os.Lstat(f.path); lstatErr == nil && fi.Mode()&os.ModeSymlink != 0 {
+				os.Remove(f.path)
+			}
+		}
+		err = f.copyIntoPlace()
+		if err == nil {
+			return os.Remove(f.tmpPath)
+		}
+	}
+
+	f.Discard()
+	return fmt.Errorf("Moving file into '%s': %s", f.path, err)
+}
+
+func (f StagedOutputFile) copyIntoPlace() error {
+	data, err := ioutil.ReadFile(f.tmpPath)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.OpenFile(f.writePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0700)
 	if err != nil {
 		return err
 	}
 
-	fd, err := os.OpenFile(resultPath, os.O_WRONLY|os.O_CREATE, 0700)
+	_, err = fd.Write(data)
+
+	closeErr := fd.Close()
+	if err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return err
 	}
 
-	defer fd.Close()
+	if f.mode != nil {
+		return os.Chmod(f.writePath, *f.mode)
+	}
+	return nil
+}
+
+// Discard removes temporary file (errors are ignored since there is nothing else to do)
+func (f StagedOutputFile) Discard() {
+	os.Remove(f.tmpPath)
+}
+
+// WriteGzip streams gzip compressed data into given writer.
+// Empty data still results in a valid gzip stream.
+func WriteGzip(w io.Writer, data []byte) error {
+	gzipWriter := gzip.NewWriter(w)
+
+	_, err := gzipWriter.Write(data)
+	if err != nil {
+		gzipWriter.Close()
+		return fmt.Errorf("Compressing: %s", err)
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return fmt.Errorf("Compressing: %s", err)
+	}
+
+	return nil
+}
+
+const (
+	// Base64MIMEWrapColumns is line length used by MIME (RFC 2045)
+	Base64MIMEWrapColumns = 76
+)
+
+// EncodeBase64 returns standard base64 encoding of data. Encoded string
+// is split into lines of wrapColumns length if wrapColumns is positive.
+func EncodeBase64(data []byte, wrapColumns int) []byte {
+	encoded := []byte(base64.StdEncoding.EncodeToString(data))
+	if wrapColumns <= 0 {
+		return encoded
+	}
+
+	var result []byte
+
+	for len(encoded) > wrapColumns {
+		result = append(result, encoded[:wrapColumns]...)
+		result = append(result, '\n')
+		encoded = encoded[wrapColumns:]
+	}
+
+	return append(result, encoded...)
+}
+
+// EncodeChecksum returns hex digest of data (newline terminated). Digest
+// is followed by name if it's not empty, matching sha256sum tool format.
+func EncodeChecksum(data []byte, newHash func() hash.Hash, name string) []byte {
+	h := newHash()
+	h.Write(data)
+
+	result := hex.EncodeToString(h.Sum(nil))
+	if len(name) > 0 {
+		result += "  " + name
+	}
 
-	_, err = fd.Write(f.data)
-	return err
+	return []byte(result + "\n")
 }
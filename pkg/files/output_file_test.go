@@ -0,0 +1,57 @@
+package files_test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestEncodeBase64(t *testing.T) {
+	data := []byte(strings.Repeat("kind: ConfigMap\n", 10))
+
+	encoded := string(files.EncodeBase64(data, 0))
+	if strings.Contains(encoded, "\n") || !strings.HasPrefix(encoded, "a2luZDogQ29uZmlnTWFwCm") {
+		t.Fatalf("Expected encoding to not be wrapped, but was: >>>%s<<<", encoded)
+	}
+
+	wrapped := string(files.EncodeBase64(data, files.Base64MIMEWrapColumns))
+	lines := strings.Split(wrapped, "\n")
+
+	if len(lines) != 3 {
+		t.Fatalf("Expected encoding to be wrapped into 3 lines, but was: >>>%s<<<", wrapped)
+	}
+	for _, line := range lines[:2] {
+		if len(line) != 76 {
+			t.Fatalf("Expected wrapped line to be 76 characters long, but was: >>>%s<<<", line)
+		}
+	}
+	if strings.Join(lines, "") != encoded {
+		t.Fatalf("Expected wrapped encoding to match unwrapped encoding")
+	}
+
+	if string(files.EncodeBase64(nil, files.Base64MIMEWrapColumns)) != "" {
+		t.Fatalf("Expected empty data to result in empty encoding")
+	}
+}
+
+func TestEncodeChecksum(t *testing.T) {
+	data := []byte("kind: ConfigMap\n")
+
+	result := string(files.EncodeChecksum(data, sha256.New, ""))
+	if result != "bb6c7fb1ce4b8ac8baa8f6344623dd4602cf3d6ce859f9ff859a5a43787c5987\n" {
+		t.Fatalf("Expected sha256 digest, but was: >>>%s<<<", result)
+	}
+
+	result = string(files.EncodeChecksum(data, sha256.New, "out.yml"))
+	if result != "bb6c7fb1ce4b8ac8baa8f6344623dd4602cf3d6ce859f9ff859a5a43787c5987  out.yml\n" {
+		t.Fatalf("Expected sha256 digest with name, but was: >>>%s<<<", result)
+	}
+
+	result = string(files.EncodeChecksum(data, sha512.New, ""))
+	if len(result) != 129 || !strings.HasSuffix(result, "\n") {
+		t.Fatalf("Expected sha512 digest, but was: >>>%s<<<", result)
+	}
+}
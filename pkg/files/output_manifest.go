@@ -0,0 +1,101 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultManifestFileName is a hidden file hence it is neither
+	// removed in clean mode nor picked up as input from directories
+	DefaultManifestFileName = ".ytt-manifest.json"
+)
+
+// OutputManifest records files written into output directory
+type OutputManifest struct {
+	Files []OutputManifestFile `json:"files"`
+}
+
+type OutputManifestFile struct {
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func newOutputManifest(outputFiles []OutputFile) (OutputManifest, error) {
+	result := OutputManifest{Files: []OutputManifestFile{}}
+
+	for _, file := range outputFiles {
+		contents, err := file.contents()
+		if err != nil {
+			return OutputManifest{}, err
+		}
+
+		result.Files = append(result.Files, OutputManifestFile{
+			Path:   filepath.ToSlash(filepath.Clean(file.RelativePath())),
+			Size:   len(contents),
+			SHA256: sha256Hex(contents),
+		})
+	}
+
+	return result, nil
+}
+
+// readOutputManifest returns empty manifest if it does not exist
+func readOutputManifest(path string) (OutputManifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return OutputManifest{}, nil
+		}
+		return OutputManifest{}, fmt.Errorf("Reading manifest '%s': %s", path, err)
+	}
+
+	var result OutputManifest
+
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return OutputManifest{}, fmt.Errorf("Unmarshaling manifest '%s': %s", path, err)
+	}
+
+	return result, nil
+}
+
+func (m OutputManifest) AsBytes() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Marshaling manifest: %s", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func (m OutputManifest) hasPath(path string) bool {
+	for _, file := range m.Files {
+		if file.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func checkManifestPath(path string) error {
+	cleanPath := filepath.Clean(path)
+
+	if filepath.IsAbs(cleanPath) || cleanPath == "." || cleanPath == ".." ||
+		strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("Expected manifest path '%s' to be a relative file path within output directory", path)
+	}
+
+	return nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,115 @@
+package files
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+var (
+	outputPathPlaceholderRegexp = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+	outputPathKeyRegexp         = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+)
+
+// CheckOutputPathTemplate validates output path template (eg
+// 'service-{{env}}.yml') before data values are available
+func CheckOutputPathTemplate(tpl string) error {
+	if len(tpl) == 0 {
+		return fmt.Errorf("Expected output path template to be non-empty")
+	}
+
+	for _, match := range outputPathPlaceholderRegexp.FindAllStringSubmatch(tpl, -1) {
+		if !outputPathKeyRegexp.MatchString(match[1]) {
+			return fmt.Errorf("Expected placeholder '%s' in output path template '%s' "+
+				"to be a dotted data values key (eg '{{app.env}}')", match[0], tpl)
+		}
+	}
+
+	rest := outputPathPlaceholderRegexp.ReplaceAllString(tpl, "")
+	if strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+		return fmt.Errorf("Expected output path template '%s' to have balanced '{{' and '}}'", tpl)
+	}
+
+	// Check static parts of the path (values cannot introduce new segments)
+	if !isOutputPathWithinDir(outputPathPlaceholderRegexp.ReplaceAllString(tpl, "x")) {
+		return fmt.Errorf("Expected output path template '%s' to be a relative file path within output directory", tpl)
+	}
+
+	return nil
+}
+
+// ResolveOutputPathTemplate replaces placeholders in output path template
+// with scalar data values; resulting path must stay within output directory
+func ResolveOutputPathTemplate(tpl string, values interface{}) (string, error) {
+	err := CheckOutputPathTemplate(tpl)
+	if err != nil {
+		return "", err
+	}
+
+	var resolveErr error
+
+	result := outputPathPlaceholderRegexp.ReplaceAllStringFunc(tpl, func(placeholder string) string {
+		key := outputPathPlaceholderRegexp.FindStringSubmatch(placeholder)[1]
+
+		val, err := outputPathValue(key, values)
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = fmt.Errorf("Resolving output path template '%s': %s", tpl, err)
+			}
+			return ""
+		}
+		return val
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+
+	if !isOutputPathWithinDir(result) {
+		return "", fmt.Errorf("Expected output path template '%s' to produce a relative "+
+			"file path within output directory, but was '%s'", tpl, result)
+	}
+
+	return path.Clean(result), nil
+}
+
+func outputPathValue(key string, values interface{}) (string, error) {
+	currVal := values
+
+	for _, piece := range strings.Split(key, ".") {
+		typedMap, ok := currVal.(*orderedmap.Map)
+		if !ok {
+			return "", fmt.Errorf("Expected data value '%s' to be found, but parent was not a map", key)
+		}
+		currVal, ok = typedMap.Get(piece)
+		if !ok {
+			return "", fmt.Errorf("Expected data value '%s' to be found, but was not", key)
+		}
+	}
+
+	var str string
+
+	switch typedVal := currVal.(type) {
+	case *orderedmap.Map, []interface{}, nil:
+		return "", fmt.Errorf("Expected data value '%s' to be a scalar, but was %T", key, currVal)
+	case string:
+		str = typedVal
+	default:
+		str = fmt.Sprintf("%v", typedVal)
+	}
+
+	// Values are not allowed to introduce new path segments
+	if len(str) == 0 || str == "." || str == ".." || strings.ContainsAny(str, "/\\\x00") {
+		return "", fmt.Errorf("Expected data value '%s' to be a valid file name segment, but was '%s'", key, str)
+	}
+
+	return str, nil
+}
+
+func isOutputPathWithinDir(resultPath string) bool {
+	cleanPath := path.Clean(resultPath)
+	return !strings.HasPrefix(resultPath, "/") && !strings.Contains(resultPath, "\\") &&
+		cleanPath != "." && cleanPath != ".." && !strings.HasPrefix(cleanPath, "../")
+}
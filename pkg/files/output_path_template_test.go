@@ -0,0 +1,47 @@
+package files_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+func TestResolveOutputPathTemplate(t *testing.T) {
+	values := orderedmap.NewMapWithItems([]orderedmap.MapItem{
+		{Key: "env", Value: "prod"},
+		{Key: "app", Value: orderedmap.NewMapWithItems([]orderedmap.MapItem{
+			{Key: "replicas", Value: 3},
+			{Key: "name", Value: "../etc"},
+			{Key: "tags", Value: []interface{}{"a"}},
+		})},
+	})
+
+	path, err := files.ResolveOutputPathTemplate("envs/service-{{env}}-{{ app.replicas }}.yml", values)
+	if err != nil {
+		t.Fatalf("Expected resolving to succeed: %s", err)
+	}
+	if path != "envs/service-prod-3.yml" {
+		t.Fatalf("Expected path to be resolved, but was: %s", path)
+	}
+
+	errs := map[string]string{
+		"{{missing}}.yml":     "Expected data value 'missing' to be found, but was not",
+		"{{app.tags}}.yml":    "Expected data value 'app.tags' to be a scalar, but was []interface {}",
+		"{{app.name}}.yml":    "Expected data value 'app.name' to be a valid file name segment, but was '../etc'",
+		"../{{env}}.yml":      "Expected output path template '../{{env}}.yml' to be a relative file path within output directory",
+		"/{{env}}.yml":        "Expected output path template '/{{env}}.yml' to be a relative file path within output directory",
+		"{{env.x y}}.yml":     "to be a dotted data values key",
+		"{{env}.yml":          "to have balanced '{{' and '}}'",
+		"dir/{{env}}/../..":   "to be a relative file path within output directory",
+		"{{env}}/{{app}}.yml": "Expected data value 'app' to be a scalar",
+	}
+
+	for tpl, expectedErr := range errs {
+		_, err := files.ResolveOutputPathTemplate(tpl, values)
+		if err == nil || !strings.Contains(err.Error(), expectedErr) {
+			t.Fatalf("Expected template '%s' to fail with '%s', but was: %v", tpl, expectedErr, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package files
+
+import (
+	"sync"
+)
+
+// prefetchFiles reads contents of files backed by cached sources
+// using up to concurrency workers. Results (including errors) are kept
+// by cached sources, hence errors surface only once file is used,
+// same as when files are read lazily. Each file is read by a single
+// worker and all workers finish before files are returned, so cached
+// sources do not need to be synchronized. Path, symlink and size checks
+// happen beforehand (when files are collected), hence are not affected.
+func prefetchFiles(files []*File, concurrency int) {
+	var srcs []*CachedSource
+
+	for _, file := range files {
+		if cachedSrc, ok := file.src.(*CachedSource); ok {
+			srcs = append(srcs, cachedSrc)
+		}
+	}
+
+	if concurrency > len(srcs) {
+		concurrency = len(srcs)
+	}
+
+	srcsCh := make(chan *CachedSource)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := range srcsCh {
+				src.Bytes()
+			}
+		}()
+	}
+
+	for _, src := range srcs {
+		srcsCh <- src
+	}
+	close(srcsCh)
+
+	wg.Wait()
+}
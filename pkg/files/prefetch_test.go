@@ -0,0 +1,110 @@
+package files_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestReadConcurrency(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	mustWriteFileTree(t, dirPath, 50)
+
+	err := os.Symlink(filepath.Join(dirPath, "dir0", "file0.yml"), filepath.Join(dirPath, "link.yml"))
+	if err != nil {
+		t.Fatalf("Creating symlink: %s", err)
+	}
+
+	// Symlinks are still checked when files are read upfront
+	_, err = files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{ReadConcurrency: 4})
+	if err == nil || !strings.Contains(err.Error(), "Checking symlink file") {
+		t.Fatalf("Expected symlink err, but was: %v", err)
+	}
+
+	err = os.Remove(filepath.Join(dirPath, "link.yml"))
+	if err != nil {
+		t.Fatalf("Removing symlink: %s", err)
+	}
+
+	lazyFiles, err := files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{})
+	if err != nil {
+		t.Fatalf("Expected reading files to succeed: %s", err)
+	}
+
+	for _, concurrency := range []int{1, 4, 100} {
+		result, err := files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{ReadConcurrency: concurrency})
+		if err != nil {
+			t.Fatalf("Expected reading files to succeed: %s", err)
+		}
+
+		if len(result) != len(lazyFiles) {
+			t.Fatalf("Expected same number of files, but was %d vs %d", len(result), len(lazyFiles))
+		}
+
+		for i, file := range result {
+			if file.RelativePath() != lazyFiles[i].RelativePath() {
+				t.Fatalf("Expected file order to match, but was '%s' vs '%s'",
+					file.RelativePath(), lazyFiles[i].RelativePath())
+			}
+
+			bs, err := file.Bytes()
+			if err != nil || string(bs) != "path: "+file.RelativePath()+"\n" {
+				t.Fatalf("Expected file '%s' contents to match, but was: %s (err: %v)", file.RelativePath(), bs, err)
+			}
+		}
+	}
+}
+
+func BenchmarkReadConcurrency(b *testing.B) {
+	dirPath, err := ioutil.TempDir("", "ytt-bench")
+	if err != nil {
+		b.Fatalf("Creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	mustWriteFileTree(b, dirPath, 2000)
+
+	for _, concurrency := range []int{0, 1, 8} {
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				result, err := files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{ReadConcurrency: concurrency})
+				if err != nil {
+					b.Fatalf("Expected reading files to succeed: %s", err)
+				}
+				// Contents are used afterwards (read lazily with zero concurrency)
+				for _, file := range result {
+					_, err := file.Bytes()
+					if err != nil {
+						b.Fatalf("Expected reading file to succeed: %s", err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// mustWriteFileTree writes given number of small YAML files
+// spread across directories of 100 files each
+func mustWriteFileTree(t testing.TB, dirPath string, num int) {
+	for i := 0; i < num; i++ {
+		relPath := filepath.Join(fmt.Sprintf("dir%d", i/100), fmt.Sprintf("file%d.yml", i))
+		path := filepath.Join(dirPath, relPath)
+
+		err := os.MkdirAll(filepath.Dir(path), 0700)
+		if err != nil {
+			t.Fatalf("Creating dir: %s", err)
+		}
+
+		err = ioutil.WriteFile(path, []byte("path: "+filepath.ToSlash(relPath)+"\n"), 0600)
+		if err != nil {
+			t.Fatalf("Writing file: %s", err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	// Shebang line is expected to be short hence
+	// avoid reading large (possibly binary) files
+	shebangMaxPrefixLen = 256
+)
+
+var (
+	shebangInterpreterTypes = map[string]Type{
+		"starlark": TypeStarlark,
+	}
+)
+
+// detectShebangType marks default type of a file that does
+// not have recognized extension based on its shebang line
+func (r *File) detectShebangType(localPath string) error {
+	if r.Type() != TypeUnknown {
+		return nil
+	}
+
+	fd, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("Opening file '%s': %s", localPath, err)
+	}
+	defer fd.Close()
+
+	prefix, err := readShebangPrefix(fd)
+	if err != nil {
+		return fmt.Errorf("Reading file '%s': %s", localPath, err)
+	}
+
+	if t, found := ShebangType(prefix); found {
+		r.MarkDefaultType(t)
+	}
+
+	return nil
+}
+
+// readShebangPrefix reads beginning of a file that may contain shebang line
+func readShebangPrefix(reader io.Reader) ([]byte, error) {
+	prefix := make([]byte, shebangMaxPrefixLen)
+
+	n, err := io.ReadFull(reader, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	return prefix[:n], nil
+}
+
+// ShebangType returns file type based on interpreter specified in the
+// shebang line (eg '#!/usr/bin/env starlark' or '#!/usr/local/bin/starlark')
+func ShebangType(data []byte) (Type, bool) {
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		return TypeUnknown, false
+	}
+
+	lineEnd := bytes.IndexByte(data, '\n')
+	if lineEnd == -1 {
+		// Prefix may be cut off in the middle of the line
+		lineEnd = len(data)
+	}
+
+	line := data[2:lineEnd]
+
+	// Binary files may accidentally start with '#!'
+	if bytes.IndexByte(line, 0) != -1 {
+		return TypeUnknown, false
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return TypeUnknown, false
+	}
+
+	interpreter := path.Base(fields[0])
+
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			// Skip env options (eg -S) and variable assignments
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = path.Base(field)
+			break
+		}
+	}
+
+	t, found := shebangInterpreterTypes[interpreter]
+	return t, found
+}
@@ -0,0 +1,83 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestShebangType(t *testing.T) {
+	examples := []struct {
+		Data  string
+		Found bool
+	}{
+		{Data: "#!/usr/bin/env starlark\ndef f():\n", Found: true},
+		{Data: "#!/usr/bin/env -S starlark -x\n", Found: true},
+		{Data: "#! /usr/local/bin/starlark", Found: true},
+		{Data: "#!/bin/bash\n", Found: false},
+		{Data: "#!/usr/bin/env\n", Found: false},
+		{Data: "#!\n", Found: false},
+		{Data: "def f():\n", Found: false},
+		{Data: "#!/usr/bin/env star\x00lark\n", Found: false},
+		{Data: "", Found: false},
+	}
+
+	for _, ex := range examples {
+		typ, found := files.ShebangType([]byte(ex.Data))
+		if found != ex.Found {
+			t.Fatalf("Expected shebang in %q found to be %t", ex.Data, ex.Found)
+		}
+		if found && typ != files.TypeStarlark {
+			t.Fatalf("Expected type to be starlark, but was %d", typ)
+		}
+	}
+}
+
+func TestNewSortedFilesFromPathsWithDetectShebang(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "ytt-shebang")
+	if err != nil {
+		t.Fatalf("Expected creating temp dir to succeed: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	for path, content := range map[string]string{
+		"helpers":      "#!/usr/bin/env starlark\ndef f():\n  return 1\nend\n",
+		"script":       "#!/bin/sh\necho\n",
+		"binary":       "\x00\x01\x02",
+		"config.yml":   "#!/usr/bin/env starlark\n",
+		"empty-helper": "",
+	} {
+		err := ioutil.WriteFile(filepath.Join(dirPath, path), []byte(content), 0600)
+		if err != nil {
+			t.Fatalf("Expected writing file to succeed: %s", err)
+		}
+	}
+
+	expectedTypes := map[string]files.Type{
+		"binary":       files.TypeUnknown,
+		"config.yml":   files.TypeYAML,
+		"empty-helper": files.TypeUnknown,
+		"helpers":      files.TypeStarlark,
+		"script":       files.TypeUnknown,
+	}
+
+	for _, detect := range []bool{true, false} {
+		result, err := files.NewSortedFilesFromPaths([]string{dirPath}, files.SourceOpts{DetectShebang: detect})
+		if err != nil {
+			t.Fatalf("Expected reading files to succeed: %s", err)
+		}
+
+		for _, file := range result {
+			expectedType := expectedTypes[file.RelativePath()]
+			if !detect && file.RelativePath() == "helpers" {
+				expectedType = files.TypeUnknown
+			}
+			if file.Type() != expectedType {
+				t.Fatalf("Expected file '%s' type to be %d, but was %d", file.RelativePath(), expectedType, file.Type())
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package files_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestHTTPHeaderMatchesURL(t *testing.T) {
+	examples := []struct {
+		Prefix   string
+		URL      string
+		Expected bool
+	}{
+		{"https://example.com", "https://example.com/a.yml", true},
+		{"https://example.com/", "https://example.com/a.yml", true},
+		{"https://example.com/api", "https://example.com/api", true},
+		{"https://example.com/api", "https://example.com/api/v1/a.yml", true},
+		{"https://example.com/api/", "https://example.com/api/v1/a.yml", true},
+		{"https://EXAMPLE.com/api", "https://example.com/api/a.yml", true},
+		{"https://example.com/api", "https://example.com/apis/a.yml", false},
+		{"https://example.com", "https://example.com.evil.io/a.yml", false},
+		{"https://example.com", "https://evil.io/https://example.com/a.yml", false},
+		{"https://example.com", "http://example.com/a.yml", false},
+		{"https://example.com", "https://example.com:8443/a.yml", false},
+		{"https://example.com:8443", "https://example.com:8443/a.yml", true},
+		{"example.com", "https://example.com/a.yml", false},
+	}
+
+	for _, ex := range examples {
+		u, err := url.Parse(ex.URL)
+		if err != nil {
+			t.Fatalf("Parsing URL: %s", err)
+		}
+
+		header := files.HTTPHeader{URLPrefix: ex.Prefix, Name: "Authorization", Value: "secret"}
+		if result := header.MatchesURL(u); result != ex.Expected {
+			t.Fatalf("Expected prefix '%s' matching URL '%s' to be %t, but was %t", ex.Prefix, ex.URL, ex.Expected, result)
+		}
+	}
+}
+
+func TestHTTPSourceHeaders(t *testing.T) {
+	otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "other: %q", r.Header.Get("X-Token"))
+	}))
+	defer otherServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/redirect.yml" {
+			http.Redirect(w, r, otherServer.URL+"/a.yml", http.StatusFound)
+			return
+		}
+		fmt.Fprintf(w, "token: %q", r.Header.Get("X-Token"))
+	}))
+	defer server.Close()
+
+	opts := files.HTTPSourceOpts{Headers: []files.HTTPHeader{
+		{URLPrefix: server.URL + "/api", Name: "X-Token", Value: "secret"},
+		{URLPrefix: server.URL + "/api/v2", Name: "X-Token", Value: "secret-v2"},
+	}}
+
+	examples := []struct {
+		Path     string
+		Expected string
+	}{
+		{"/api/a.yml", `token: "secret"`},
+		{"/api/v2/a.yml", `token: "secret-v2"`}, // later header wins
+		{"/apis/a.yml", `token: ""`},
+		{"/a.yml", `token: ""`},
+		{"/api/redirect.yml", `other: ""`}, // not forwarded to other host
+	}
+
+	for _, ex := range examples {
+		result, err := files.NewHTTPSource(server.URL+ex.Path, opts).Bytes()
+		if err != nil {
+			t.Fatalf("Expected request to succeed: %s", err)
+		}
+		if string(result) != ex.Expected {
+			t.Fatalf("Expected response for '%s' to be '%s', but was '%s'", ex.Path, ex.Expected, result)
+		}
+	}
+}
+
+func TestHTTPSourceNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "not found%s", strings.Repeat("x", 1000))
+	}))
+	defer server.Close()
+
+	_, err := files.NewHTTPSource(server.URL+"/a.yml", files.HTTPSourceOpts{}).Bytes()
+	if err == nil {
+		t.Fatalf("Expected request to fail")
+	}
+
+	// Body is included only up to 512 bytes
+	expectedErr := fmt.Sprintf("Requesting URL '%s/a.yml': Expected response status code to be 2xx, "+
+		"but was 404 (body: not found%s)", server.URL, strings.Repeat("x", 512-len("not found")))
+
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected err to match, but was: %s", err)
+	}
+}
+
+func TestHTTPSourceTimeout(t *testing.T) {
+	doneCh := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-doneCh:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(doneCh)
+
+	_, err := files.NewHTTPSource(server.URL+"/a.yml", files.HTTPSourceOpts{Timeout: 50 * time.Millisecond}).Bytes()
+	if err == nil || !strings.Contains(err.Error(), "Requesting URL '"+server.URL+"/a.yml'") ||
+		!strings.Contains(err.Error(), "Client.Timeout exceeded") {
+		t.Fatalf("Expected request to time out, but was: %v", err)
+	}
+}
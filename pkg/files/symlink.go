@@ -51,20 +51,41 @@ func (s Symlink) isIn(path, allowedPath string) (bool, error) {
 		return false, fmt.Errorf("Abs path '%s': %s", allowedPath, err)
 	}
 
-	pathPieces := s.pathPieces(path)
-	allowedPathPieces := s.pathPieces(allowedPath)
+	matched, err := s.piecesIn(s.pathPieces(path), s.pathPieces(allowedPath))
+	if err != nil {
+		return false, fmt.Errorf("Matching allowed symlink destination '%s': %s", allowedPath, err)
+	}
 
-	if len(allowedPathPieces) > len(pathPieces) {
-		return false, nil
+	return matched, nil
+}
+
+// piecesIn checks whether path is within allowed path. Each piece of
+// allowed path may be a glob pattern (eg '*'); '**' matches any number of pieces.
+func (s Symlink) piecesIn(pathPieces, allowedPathPieces []string) (bool, error) {
+	if len(allowedPathPieces) == 0 {
+		return true, nil
 	}
 
-	for i, _ := range allowedPathPieces {
-		if allowedPathPieces[i] != pathPieces[i] {
-			return false, nil
+	if allowedPathPieces[0] == "**" {
+		for i := 0; i <= len(pathPieces); i++ {
+			matched, err := s.piecesIn(pathPieces[i:], allowedPathPieces[1:])
+			if matched || err != nil {
+				return matched, err
+			}
 		}
+		return false, nil
+	}
+
+	if len(pathPieces) == 0 {
+		return false, nil
+	}
+
+	matched, err := filepath.Match(allowedPathPieces[0], pathPieces[0])
+	if !matched || err != nil {
+		return false, err
 	}
 
-	return true, nil
+	return s.piecesIn(pathPieces[1:], allowedPathPieces[1:])
 }
 
 func (s Symlink) pathPieces(path string) []string {
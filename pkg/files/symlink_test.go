@@ -0,0 +1,87 @@
+package files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+func TestSymlinkAllowedDstPathGlobs(t *testing.T) {
+	dirPath := mustTempDir(t)
+	defer os.RemoveAll(dirPath)
+
+	// Destination is matched against fully resolved path
+	dirPath, err := filepath.EvalSymlinks(dirPath)
+	if err != nil {
+		t.Fatalf("Expected resolving temp dir to succeed: %s", err)
+	}
+
+	dstPath := filepath.Join(dirPath, "store", "abc123-pkg", "config", "dst.yml")
+
+	err = os.MkdirAll(filepath.Dir(dstPath), 0700)
+	if err != nil {
+		t.Fatalf("Expected mkdir to succeed: %s", err)
+	}
+	err = ioutil.WriteFile(dstPath, []byte("a: 1"), 0600)
+	if err != nil {
+		t.Fatalf("Expected write to succeed: %s", err)
+	}
+
+	linkPath := filepath.Join(dirPath, "link.yml")
+
+	err = os.Symlink(dstPath, linkPath)
+	if err != nil {
+		t.Fatalf("Expected symlink to succeed: %s", err)
+	}
+
+	examples := []struct {
+		AllowedDstPath string
+		Allowed        bool
+	}{
+		{filepath.Join(dirPath, "store"), true},
+		{filepath.Join(dirPath, "store", "*"), true},
+		{filepath.Join(dirPath, "store", "*-pkg", "config"), true},
+		{filepath.Join(dirPath, "**", "config"), true},
+		{filepath.Join(dirPath, "**", "dst.yml"), true},
+		{filepath.Join(dirPath, "*", "config"), false},
+		{filepath.Join(dirPath, "store", "*-other"), false},
+		{filepath.Join(dirPath, "**", "other"), false},
+		// symlink name itself must not be used for matching
+		{filepath.Join(dirPath, "link.yml"), false},
+	}
+
+	for _, ex := range examples {
+		opts := files.SourceOpts{
+			SymlinkAllowOpts: files.SymlinkAllowOpts{AllowedDstPaths: []string{ex.AllowedDstPath}},
+		}
+
+		_, err := files.NewSortedFilesFromPaths([]string{linkPath}, opts)
+
+		if ex.Allowed && err != nil {
+			t.Fatalf("Expected symlink to be allowed by '%s': %s", ex.AllowedDstPath, err)
+		}
+		if !ex.Allowed {
+			if err == nil {
+				t.Fatalf("Expected symlink to not be allowed by '%s'", ex.AllowedDstPath)
+			}
+			if !strings.Contains(err.Error(), "to be allowed, but was not") {
+				t.Fatalf("Expected err to indicate symlink is not allowed, but was: %s", err)
+			}
+		}
+	}
+
+	invalidPattern := filepath.Join(dirPath, "[abc")
+
+	opts := files.SourceOpts{
+		SymlinkAllowOpts: files.SymlinkAllowOpts{AllowedDstPaths: []string{invalidPattern}},
+	}
+
+	_, err = files.NewSortedFilesFromPaths([]string{linkPath}, opts)
+	if err == nil || !strings.Contains(err.Error(), "Matching allowed symlink destination '"+invalidPattern+"': syntax error in pattern") {
+		t.Fatalf("Expected invalid pattern to fail, but was: %v", err)
+	}
+}
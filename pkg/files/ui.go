@@ -7,5 +7,11 @@ import (
 type UI interface {
 	Printf(string, ...interface{})
 	Debugf(string, ...interface{})
+	// Infof prints informational message that is not part of
+	// regular output (eg files written to output directory)
+	Infof(string, ...interface{})
+	// Warnf prints diagnostic message that does not fail processing
+	// (eg results of Starlark print()) regardless of debug flag
+	Warnf(string, ...interface{})
 	DebugWriter() io.Writer
 }
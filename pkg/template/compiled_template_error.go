@@ -15,7 +15,7 @@ type CompiledTemplateMultiError struct {
 	loader CompiledTemplateLoader
 }
 
-var _ error = CompiledTemplateMultiError{}
+var _ filepos.DetailedError = CompiledTemplateMultiError{}
 
 type CompiledTemplateError struct {
 	Positions []CompiledTemplateErrorPosition
@@ -128,6 +128,35 @@ func (e CompiledTemplateMultiError) Error() string {
 	return strings.Join(result, "\n")
 }
 
+func (e CompiledTemplateMultiError) ErrorDetails() []filepos.ErrorDetail {
+	var result []filepos.ErrorDetail
+
+	for _, err := range e.errs {
+		detail := filepos.ErrorDetail{Kind: "template", Message: err.Msg}
+
+		// Innermost position that refers to a source line is most specific
+		for _, pos := range err.Positions {
+			if pos.TemplateLine == nil || pos.TemplateLine.SourceLine == nil {
+				continue
+			}
+			srcPos := pos.TemplateLine.SourceLine.Position
+			if !srcPos.IsKnown() {
+				continue
+			}
+			detail.File = srcPos.File()
+			if len(detail.File) == 0 {
+				detail.File = pos.Filename
+			}
+			detail.Line = srcPos.Line()
+			break
+		}
+
+		result = append(result, detail)
+	}
+
+	return result
+}
+
 func (e CompiledTemplateMultiError) posPrefixStr(srcLine *SourceLine) string {
 	// TODO show column information
 	return fmt.Sprintf("%s | ", srcLine.Position.AsCompactString())
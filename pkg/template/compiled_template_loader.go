@@ -11,6 +11,7 @@ type CompiledTemplateLoader interface {
 	Load(*starlark.Thread, string) (starlark.StringDict, error)
 	LoadData(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error)
 	ListData(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error)
+	FileAnnotations(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error)
 }
 
 type NoopCompiledTemplateLoader struct{}
@@ -40,3 +41,10 @@ func (l NoopCompiledTemplateLoader) ListData(
 
 	return nil, fmt.Errorf("ListData is not supported")
 }
+
+func (l NoopCompiledTemplateLoader) FileAnnotations(
+	thread *starlark.Thread, f *starlark.Builtin,
+	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	return nil, fmt.Errorf("FileAnnotations is not supported")
+}
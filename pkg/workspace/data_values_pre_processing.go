@@ -83,7 +83,7 @@ func (p DataValuesPreProcessing) overlay(valuesDoc, newValuesDoc *yamlmeta.Docum
 	op := yttoverlay.OverlayOp{
 		Left:   &yamlmeta.DocumentSet{Items: []*yamlmeta.Document{valuesDoc}},
 		Right:  &yamlmeta.DocumentSet{Items: []*yamlmeta.Document{newValuesDoc}},
-		Thread: &starlark.Thread{Name: "data-values-pre-processing"},
+		Thread: &starlark.Thread{Name: "data-values-pre-processing", Print: starlarkPrintFunc(p.loader.ui)},
 
 		ExactMatch: true,
 	}
@@ -0,0 +1,98 @@
+package workspace
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+var (
+	// Matches '$$' (escaped '$'), '${dotted.name}' and '$name'
+	envTemplateVarRegexp = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_-]*(?:\.[A-Za-z_][A-Za-z0-9_-]*)*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// EnvTemplate substitutes shell style variables ('${name}' and '$name')
+// with data values, keeping the rest of the contents as is.
+// Nested data values are referenced with dots (eg '${app.name}').
+type EnvTemplate struct {
+	values       interface{}
+	allowMissing bool
+}
+
+func NewEnvTemplate(values interface{}, allowMissing bool) EnvTemplate {
+	return EnvTemplate{values, allowMissing}
+}
+
+func (t EnvTemplate) Substitute(data []byte) ([]byte, error) {
+	var result bytes.Buffer
+	var lastIdx int
+
+	for _, match := range envTemplateVarRegexp.FindAllSubmatchIndex(data, -1) {
+		result.Write(data[lastIdx:match[0]])
+		lastIdx = match[1]
+
+		var name string
+
+		switch {
+		case match[2] >= 0:
+			name = string(data[match[2]:match[3]])
+		case match[4] >= 0:
+			name = string(data[match[4]:match[5]])
+		default:
+			result.WriteString("$")
+			continue
+		}
+
+		val, found, err := t.value(name)
+		if err != nil {
+			return nil, fmt.Errorf("Substituting variable '%s' on line %d: %s", name, t.lineNum(data, match[0]), err)
+		}
+		if !found {
+			if !t.allowMissing {
+				return nil, fmt.Errorf("Expected variable '%s' on line %d to be a data value, but was not found "+
+					"(use --env-template-allow-missing to keep unresolved variables as is)", name, t.lineNum(data, match[0]))
+			}
+			result.Write(data[match[0]:match[1]])
+			continue
+		}
+
+		result.WriteString(val)
+	}
+
+	result.Write(data[lastIdx:])
+
+	return result.Bytes(), nil
+}
+
+func (t EnvTemplate) value(name string) (string, bool, error) {
+	currVal := t.values
+
+	for _, key := range strings.Split(name, ".") {
+		typedMap, ok := currVal.(*orderedmap.Map)
+		if !ok {
+			return "", false, nil
+		}
+		currVal, ok = typedMap.Get(key)
+		if !ok {
+			return "", false, nil
+		}
+	}
+
+	switch typedVal := currVal.(type) {
+	case nil:
+		return "", true, nil
+	case string:
+		return typedVal, true, nil
+	case *orderedmap.Map, []interface{}:
+		return "", false, fmt.Errorf("Expected data value to be a scalar, but was %T", currVal)
+	default:
+		return fmt.Sprintf("%v", typedVal), true, nil
+	}
+}
+
+func (EnvTemplate) lineNum(data []byte, idx int) int {
+	return bytes.Count(data[:idx], []byte("\n")) + 1
+}
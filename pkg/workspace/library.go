@@ -136,6 +136,14 @@ func (fileInLib *FileInLibrary) RelativePath() string {
 	return strings.Join(components, pathSeparator)
 }
 
+// OutputRelativePath is a path of output file within output directory
+func (fileInLib *FileInLibrary) OutputRelativePath() string {
+	if subdir := fileInLib.File.OutputSubdir(); len(subdir) > 0 {
+		return subdir + pathSeparator + fileInLib.RelativePath()
+	}
+	return fileInLib.RelativePath()
+}
+
 func (l *Library) ListAccessibleFiles() []*FileInLibrary {
 	return l.listAccessibleFiles(nil)
 }
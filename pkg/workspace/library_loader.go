@@ -2,11 +2,14 @@ package workspace
 
 import (
 	"fmt"
+	"io"
 
+	"github.com/k14s/ytt/pkg/filepos"
 	"github.com/k14s/ytt/pkg/files"
 	"github.com/k14s/ytt/pkg/yamlmeta"
 	"github.com/k14s/ytt/pkg/yamltemplate"
 	"github.com/k14s/ytt/pkg/yttlibrary"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
 )
 
 type LibraryLoader struct {
@@ -18,6 +21,12 @@ type LibraryLoader struct {
 type EvalResult struct {
 	Files  []files.OutputFile
 	DocSet *yamlmeta.DocumentSet
+
+	// EmptyDocs are documents left out of DocSet since they were empty
+	EmptyDocs []*yamlmeta.Document
+
+	// OverlayTrace is only available if requested (TemplateLoaderOpts.OverlayTrace)
+	OverlayTrace *yttoverlay.Trace
 }
 
 type EvalValuesAst interface{}
@@ -47,7 +56,7 @@ func (ll *LibraryLoader) Values(valuesFlagsAst EvalValuesAst) (EvalValuesAst, er
 
 	vals, err := dvpp.Apply()
 	if err != nil {
-		return nil, fmt.Errorf("Processing data values: %s", err)
+		return nil, filepos.NewFileError("Processing data values", err, "data-values", "")
 	}
 
 	return vals, nil
@@ -81,38 +90,71 @@ func (ll *LibraryLoader) valuesFiles(loader *TemplateLoader) ([]*FileInLibrary,
 }
 
 func (ll *LibraryLoader) Eval(values EvalValuesAst) (*EvalResult, error) {
-	docSets, outputFiles, err := ll.eval(values)
+	outputPaths := newOutputPaths(values)
+
+	docSets, outputFiles, err := ll.eval(values, outputPaths)
 	if err != nil {
 		return nil, err
 	}
 
-	docSets, err = (&OverlayPostProcessing{docSets: docSets}).Apply()
+	overlayProcessing := &OverlayPostProcessing{docSets: docSets, ui: ll.ui}
+
+	if ll.templateLoaderOpts.OverlayTrace {
+		overlayProcessing.trace = yttoverlay.NewTrace()
+	}
+
+	docSets, err = overlayProcessing.Apply()
 	if err != nil {
 		return nil, err
 	}
 
 	result := &EvalResult{
-		Files:  outputFiles,
-		DocSet: &yamlmeta.DocumentSet{},
+		Files:     outputFiles,
+		DocSet:    &yamlmeta.DocumentSet{},
+		EmptyDocs: overlayProcessing.emptyDocs,
+
+		OverlayTrace: overlayProcessing.trace,
 	}
 
 	for _, fileInLib := range ll.sortedOutputDocSets(docSets) {
 		docSet := docSets[fileInLib]
 		result.DocSet.Items = append(result.DocSet.Items, docSet.Items...)
 
-		resultDocBytes, err := docSet.AsBytes()
+		var printerFunc func(io.Writer) yamlmeta.DocumentPrinter
+
+		// Keep JSON files in JSON when writing them out
+		if fileInLib.File.Type() == files.TypeJSON {
+			printerFunc = func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONPrinter(w) }
+		}
+
+		resultDocBytes, err := docSet.AsBytesWithPrinter(printerFunc)
 		if err != nil {
 			return nil, fmt.Errorf("Marshaling template result: %s", err)
 		}
 
 		ll.ui.Debugf("### %s result\n%s", fileInLib.RelativePath(), resultDocBytes)
-		result.Files = append(result.Files, files.NewOutputFile(fileInLib.RelativePath(), resultDocBytes))
+
+		outputPath, err := outputPaths.Path(fileInLib)
+		if err != nil {
+			return nil, err
+		}
+
+		if fileInLib.File.Type() == files.TypeJSON {
+			result.Files = append(result.Files, files.NewOutputFile(outputPath, resultDocBytes).WithMode(fileInLib.File.Mode()))
+		} else {
+			result.Files = append(result.Files, files.NewOutputFileWithDocSet(outputPath, resultDocBytes, docSet).WithMode(fileInLib.File.Mode()))
+		}
+	}
+
+	err = outputPaths.CheckUnique(result.Files)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-func (ll *LibraryLoader) eval(values EvalValuesAst) (map[*FileInLibrary]*yamlmeta.DocumentSet, []files.OutputFile, error) {
+func (ll *LibraryLoader) eval(values EvalValuesAst, outputPaths *outputPaths) (map[*FileInLibrary]*yamlmeta.DocumentSet, []files.OutputFile, error) {
 	loader := NewTemplateLoader(values, ll.ui, ll.templateLoaderOpts)
 
 	docSets := map[*FileInLibrary]*yamlmeta.DocumentSet{}
@@ -124,7 +166,7 @@ func (ll *LibraryLoader) eval(values EvalValuesAst) (map[*FileInLibrary]*yamlmet
 		}
 
 		switch fileInLib.File.Type() {
-		case files.TypeYAML:
+		case files.TypeYAML, files.TypeJSON:
 			_, resultDocSet, err := loader.EvalYAML(fileInLib.Library, fileInLib.File)
 			if err != nil {
 				return nil, nil, err
@@ -141,7 +183,56 @@ func (ll *LibraryLoader) eval(values EvalValuesAst) (map[*FileInLibrary]*yamlmet
 			resultStr := resultVal.AsString()
 
 			ll.ui.Debugf("### %s result\n%s", fileInLib.RelativePath(), resultStr)
-			outputFiles = append(outputFiles, files.NewOutputFile(fileInLib.RelativePath(), []byte(resultStr)))
+
+			outputPath, err := outputPaths.Path(fileInLib)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			outputFiles = append(outputFiles, files.NewOutputFile(outputPath, []byte(resultStr)).WithMode(fileInLib.File.Mode()))
+
+		case files.TypeYAMLFrontMatter:
+			resultBs, err := loader.EvalYAMLFrontMatter(fileInLib.Library, fileInLib.File)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			ll.ui.Debugf("### %s result\n%s", fileInLib.RelativePath(), resultBs)
+
+			outputPath, err := outputPaths.Path(fileInLib)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			outputFiles = append(outputFiles, files.NewOutputFile(outputPath, resultBs).WithMode(fileInLib.File.Mode()))
+
+		case files.TypeEnvTemplate:
+			resultBs, err := loader.EvalEnvTemplate(fileInLib.File)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			ll.ui.Debugf("### %s result\n%s", fileInLib.RelativePath(), resultBs)
+
+			outputPath, err := outputPaths.Path(fileInLib)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			outputFiles = append(outputFiles, files.NewOutputFile(outputPath, resultBs).WithMode(fileInLib.File.Mode()))
+
+		case files.TypeBinary:
+			resultBs, err := fileInLib.File.Bytes()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			outputPath, err := outputPaths.Path(fileInLib)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			outputFiles = append(outputFiles, files.NewBinaryOutputFile(outputPath, resultBs).WithMode(fileInLib.File.Mode()))
 
 		default:
 			return nil, nil, fmt.Errorf("Unknown file type")
@@ -0,0 +1,69 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+// outputPaths computes output paths of files, resolving
+// output path templates (file mark 'path-template') with data values
+type outputPaths struct {
+	values    interface{}
+	templated map[string]*FileInLibrary
+}
+
+func newOutputPaths(values interface{}) *outputPaths {
+	return &outputPaths{values: values, templated: map[string]*FileInLibrary{}}
+}
+
+func (p *outputPaths) Path(fileInLib *FileInLibrary) (string, error) {
+	tpl, found := fileInLib.File.OutputPathTemplate()
+	if !found {
+		return fileInLib.OutputRelativePath(), nil
+	}
+
+	path, err := files.ResolveOutputPathTemplate(tpl, p.values)
+	if err != nil {
+		return "", fmt.Errorf("Computing output path of file '%s': %s", fileInLib.RelativePath(), err)
+	}
+
+	if subdir := fileInLib.File.OutputSubdir(); len(subdir) > 0 {
+		path = subdir + pathSeparator + path
+	}
+
+	if prevFileInLib, found := p.templated[path]; found {
+		return "", fmt.Errorf("Expected output path templates to produce unique paths, "+
+			"but files '%s' and '%s' are both written to '%s'",
+			prevFileInLib.RelativePath(), fileInLib.RelativePath(), path)
+	}
+
+	p.templated[path] = fileInLib
+
+	return path, nil
+}
+
+// CheckUnique ensures that files with templated output paths
+// are not written to the same path as some other file
+func (p *outputPaths) CheckUnique(outputFiles []files.OutputFile) error {
+	if len(p.templated) == 0 {
+		return nil
+	}
+
+	seenPaths := map[string]struct{}{}
+
+	for _, file := range outputFiles {
+		path := file.RelativePath()
+
+		if _, found := seenPaths[path]; found {
+			if fileInLib, found := p.templated[path]; found {
+				return fmt.Errorf("Expected output path template of file '%s' to produce unique path, "+
+					"but '%s' is also written by another file", fileInLib.RelativePath(), path)
+			}
+		}
+
+		seenPaths[path] = struct{}{}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package workspace
 import (
 	"fmt"
 
+	"github.com/k14s/ytt/pkg/files"
 	"github.com/k14s/ytt/pkg/template"
 	"github.com/k14s/ytt/pkg/yamlmeta"
 	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
@@ -11,9 +12,14 @@ import (
 
 type OverlayPostProcessing struct {
 	docSets map[*FileInLibrary]*yamlmeta.DocumentSet
+	ui      files.UI
+	trace   *yttoverlay.Trace // optional
+
+	// emptyDocs collects documents that were left out since they were empty
+	emptyDocs []*yamlmeta.Document
 }
 
-func (o OverlayPostProcessing) Apply() (map[*FileInLibrary]*yamlmeta.DocumentSet, error) {
+func (o *OverlayPostProcessing) Apply() (map[*FileInLibrary]*yamlmeta.DocumentSet, error) {
 	overlayDocSets := map[*FileInLibrary][]*yamlmeta.Document{}
 	docSetsWithoutOverlays := []*yamlmeta.DocumentSet{}
 	docSetToFilesMapping := map[*yamlmeta.DocumentSet]*FileInLibrary{}
@@ -26,6 +32,7 @@ func (o OverlayPostProcessing) Apply() (map[*FileInLibrary]*yamlmeta.DocumentSet
 			} else {
 				// TODO avoid filtering out docs?
 				if doc.IsEmpty() {
+					o.emptyDocs = append(o.emptyDocs, doc)
 					continue
 				}
 				newItems = append(newItems, doc)
@@ -54,7 +61,8 @@ func (o OverlayPostProcessing) Apply() (map[*FileInLibrary]*yamlmeta.DocumentSet
 				Right: &yamlmeta.DocumentSet{
 					Items: []*yamlmeta.Document{overlay},
 				},
-				Thread: &starlark.Thread{Name: "overlay-post-processing"},
+				Thread: &starlark.Thread{Name: "overlay-post-processing", Print: starlarkPrintFunc(o.ui)},
+				Trace:  o.trace,
 			}
 			newLeft, err := op.Apply()
 			if err != nil {
@@ -1,9 +1,13 @@
 package workspace
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/k14s/ytt/pkg/filepos"
 	"github.com/k14s/ytt/pkg/files"
 	"github.com/k14s/ytt/pkg/template"
 	"github.com/k14s/ytt/pkg/template/core"
@@ -24,6 +28,15 @@ type TemplateLoader struct {
 type TemplateLoaderOpts struct {
 	IgnoreUnknownComments bool
 	StrictYAML            bool
+	// PreserveComments keeps comments and anchors of
+	// unchanged documents from plain (non-template) YAML files
+	PreserveComments bool
+	// EnvTemplateAllowMissing keeps unresolved variables
+	// of env-template files as is instead of failing
+	EnvTemplateAllowMissing bool
+	// OverlayTrace records overlays that modified output
+	// documents (available via EvalResult.OverlayTrace)
+	OverlayTrace bool
 }
 
 func NewTemplateLoader(values interface{}, ui files.UI, opts TemplateLoaderOpts) *TemplateLoader {
@@ -133,33 +146,108 @@ func (l *TemplateLoader) LoadData(thread *starlark.Thread, f *starlark.Builtin,
 	return starlark.String(string(fileBs)), nil
 }
 
+func (l *TemplateLoader) FileAnnotations(thread *starlark.Thread, f *starlark.Builtin,
+	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	var file *files.File
+
+	switch args.Len() {
+	case 0:
+		file = l.getFile(thread)
+
+	case 1:
+		path, err := core.NewStarlarkValue(args.Index(0)).AsString()
+		if err != nil {
+			return starlark.None, err
+		}
+
+		file, err = l.getLibrary(thread).FindFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return starlark.None, fmt.Errorf("expected zero or one argument")
+	}
+
+	annotations := file.Annotations()
+
+	var keys []string
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := starlark.NewDict(len(keys))
+	for _, key := range keys {
+		err := result.SetKey(starlark.String(key), starlark.String(annotations[key]))
+		if err != nil {
+			return starlark.None, err
+		}
+	}
+	return result, nil
+}
+
 func (l *TemplateLoader) ParseYAML(file *files.File) (*yamlmeta.DocumentSet, error) {
 	fileBs, err := file.Bytes()
 	if err != nil {
 		return nil, err
 	}
 
+	return l.parseYAMLBytes(file, fileBs)
+}
+
+func (l *TemplateLoader) parseYAMLBytes(file *files.File, fileBs []byte) (*yamlmeta.DocumentSet, error) {
+	if file.Type() == files.TypeJSON {
+		// YAML parser accepts more than JSON hence validate strictly first
+		err := l.validateJSON(fileBs)
+		if err != nil {
+			return nil, filepos.NewFileError(fmt.Sprintf("Unmarshaling JSON file '%s'", file.RelativePath()), err, "parse", file.RelativePath())
+		}
+	}
+
 	docSetOpts := yamlmeta.DocSetOpts{
 		AssociatedName: file.RelativePath(),
 		WithoutMeta:    !file.IsTemplate() && !file.IsLibrary(),
 		Strict:         l.opts.StrictYAML,
+		// JSON files are printed as YAML hence their text is not kept
+		PreserveComments: l.opts.PreserveComments && file.Type() == files.TypeYAML,
 	}
 	l.ui.Debugf("## file %s (opts %#v)\n", file.RelativePath(), docSetOpts)
 
 	docSet, err := yamlmeta.NewDocumentSetFromBytes(fileBs, docSetOpts)
 	if err != nil {
-		return nil, fmt.Errorf("Unmarshaling YAML template '%s': %s", file.RelativePath(), err)
+		return nil, filepos.NewFileError(fmt.Sprintf("Unmarshaling YAML template '%s'", file.RelativePath()), err, "parse", file.RelativePath())
 	}
 
 	return docSet, nil
 }
 
+func (l *TemplateLoader) validateJSON(data []byte) error {
+	var val interface{}
+
+	err := json.Unmarshal(data, &val)
+	if err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+			return fmt.Errorf("line %d: %s", line, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (l *TemplateLoader) EvalYAML(library *Library, file *files.File) (starlark.StringDict, *yamlmeta.DocumentSet, error) {
 	docSet, err := l.ParseYAML(file)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	return l.evalYAMLDocSet(library, file, docSet)
+}
+
+func (l *TemplateLoader) evalYAMLDocSet(library *Library, file *files.File, docSet *yamlmeta.DocumentSet) (starlark.StringDict, *yamlmeta.DocumentSet, error) {
 	l.ui.Debugf("### ast\n")
 	docSet.Print(l.ui.DebugWriter())
 
@@ -171,7 +259,7 @@ func (l *TemplateLoader) EvalYAML(library *Library, file *files.File) (starlark.
 
 	compiledTemplate, err := yamltemplate.NewTemplate(file.RelativePath(), tplOpts).Compile(docSet)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Compiling YAML template '%s': %s", file.RelativePath(), err)
+		return nil, nil, filepos.NewFileError(fmt.Sprintf("Compiling YAML template '%s'", file.RelativePath()), err, "compile", file.RelativePath())
 	}
 
 	l.addCompiledTemplate(file.RelativePath(), compiledTemplate)
@@ -205,12 +293,12 @@ func (l *TemplateLoader) EvalText(library *Library, file *files.File) (starlark.
 
 	textRoot, err := texttemplate.NewParser().Parse(fileBs, file.RelativePath())
 	if err != nil {
-		return nil, nil, fmt.Errorf("Parsing text template '%s': %s", file.RelativePath(), err)
+		return nil, nil, filepos.NewFileError(fmt.Sprintf("Parsing text template '%s'", file.RelativePath()), err, "parse", file.RelativePath())
 	}
 
 	compiledTemplate, err := texttemplate.NewTemplate(file.RelativePath()).Compile(textRoot)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Compiling text template '%s': %s", file.RelativePath(), err)
+		return nil, nil, filepos.NewFileError(fmt.Sprintf("Compiling text template '%s'", file.RelativePath()), err, "compile", file.RelativePath())
 	}
 
 	l.addCompiledTemplate(file.RelativePath(), compiledTemplate)
@@ -221,12 +309,62 @@ func (l *TemplateLoader) EvalText(library *Library, file *files.File) (starlark.
 
 	globals, resultVal, err := compiledTemplate.Eval(thread, l)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Evaluating text template: %s", err)
+		return nil, nil, filepos.NewFileError("Evaluating text template", err, "template", file.RelativePath())
 	}
 
 	return globals, resultVal.(*texttemplate.NodeRoot), nil
 }
 
+// EvalYAMLFrontMatter templates YAML front matter of the file and returns
+// file contents with resulting header; rest of the file is kept as is.
+// Files without front matter are returned as is.
+func (l *TemplateLoader) EvalYAMLFrontMatter(library *Library, file *files.File) ([]byte, error) {
+	fileBs, err := file.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	frontMatter, found := files.SplitFrontMatter(fileBs)
+	if !found {
+		l.ui.Debugf("## file %s (no front matter)\n", file.RelativePath())
+		return fileBs, nil
+	}
+
+	docSet, err := l.parseYAMLBytes(file, frontMatter.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	_, resultDocSet, err := l.evalYAMLDocSet(library, file, docSet)
+	if err != nil {
+		return nil, err
+	}
+
+	headerBs, err := resultDocSet.AsBytes()
+	if err != nil {
+		return nil, fmt.Errorf("Marshaling front matter of '%s': %s", file.RelativePath(), err)
+	}
+
+	return frontMatter.Join(headerBs), nil
+}
+
+// EvalEnvTemplate substitutes shell style variables of the file with data values
+func (l *TemplateLoader) EvalEnvTemplate(file *files.File) ([]byte, error) {
+	fileBs, err := file.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	l.ui.Debugf("## file %s\n", file.RelativePath())
+
+	resultBs, err := NewEnvTemplate(l.values, l.opts.EnvTemplateAllowMissing).Substitute(fileBs)
+	if err != nil {
+		return nil, filepos.NewFileError("Evaluating env template", err, "template", file.RelativePath())
+	}
+
+	return resultBs, nil
+}
+
 func (l *TemplateLoader) EvalStarlark(library *Library, file *files.File) (starlark.StringDict, error) {
 	fileBs, err := file.Bytes()
 	if err != nil {
@@ -248,7 +386,7 @@ func (l *TemplateLoader) EvalStarlark(library *Library, file *files.File) (starl
 
 	globals, _, err := compiledTemplate.Eval(thread, l)
 	if err != nil {
-		return nil, fmt.Errorf("Evaluating starlark template: %s", err)
+		return nil, filepos.NewFileError("Evaluating starlark template", err, "template", file.RelativePath())
 	}
 
 	return globals, nil
@@ -257,6 +395,7 @@ func (l *TemplateLoader) EvalStarlark(library *Library, file *files.File) (starl
 const (
 	threadLibraryKey    = "ytt.library_key"
 	threadYTTLibraryKey = "ytt.ytt_library_key"
+	threadFileKey       = "ytt.file_key"
 )
 
 func (l *TemplateLoader) getLibrary(thread *starlark.Thread) *Library {
@@ -283,10 +422,28 @@ func (l *TemplateLoader) setYTTLibrary(thread *starlark.Thread, yttLibrary yttli
 	thread.SetLocal(threadYTTLibraryKey, yttLibrary)
 }
 
+func (l *TemplateLoader) getFile(thread *starlark.Thread) *files.File {
+	file, ok := thread.Local(threadFileKey).(*files.File)
+	if !ok {
+		panic("Expected to find file associated with thread")
+	}
+	return file
+}
+
+func (l *TemplateLoader) setFile(thread *starlark.Thread, file *files.File) {
+	thread.SetLocal(threadFileKey, file)
+}
+
+// starlarkPrintFunc reports results of Starlark print() as warnings
+func starlarkPrintFunc(ui files.UI) func(*starlark.Thread, string) {
+	return func(_ *starlark.Thread, msg string) { ui.Warnf("%s\n", msg) }
+}
+
 func (l *TemplateLoader) newThread(library *Library, yttLibrary yttlibrary.API, file *files.File) *starlark.Thread {
-	thread := &starlark.Thread{Name: "template=" + file.RelativePath(), Load: l.Load}
+	thread := &starlark.Thread{Name: "template=" + file.RelativePath(), Load: l.Load, Print: starlarkPrintFunc(l.ui)}
 	l.setLibrary(thread, library)
 	l.setYTTLibrary(thread, yttLibrary)
+	l.setFile(thread, file)
 	return thread
 }
 
@@ -44,6 +44,7 @@ type Document struct {
 
 	annotations interface{}
 	injected    bool // indicates that Document was not present in the parsed content
+	source      *documentSource
 }
 
 type Map struct {
@@ -0,0 +1,139 @@
+package yamlmeta
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+// CSVPrinter prints documents that are arrays of maps with the same keys
+// (first map's keys become header row). Multiple documents are separated by an empty line.
+type CSVPrinter struct {
+	buf         io.Writer
+	writtenOnce bool
+}
+
+var _ DocumentPrinter = &CSVPrinter{}
+
+func NewCSVPrinter(writer io.Writer) *CSVPrinter {
+	return &CSVPrinter{writer, false}
+}
+
+func (p *CSVPrinter) Print(item *Document) error {
+	rows, ok := item.AsInterface().([]interface{})
+	if !ok {
+		return fmt.Errorf("Expected document to be an array of maps for CSV output, but was %T", item.AsInterface())
+	}
+
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+
+	var header []string
+
+	for i, row := range rows {
+		typedRow, ok := row.(*orderedmap.Map)
+		if !ok {
+			return fmt.Errorf("Expected array item %d to be a map for CSV output, but was %T", i, row)
+		}
+
+		if i == 0 {
+			typedRow.Iterate(func(k, _ interface{}) {
+				header = append(header, p.keyStr(k))
+			})
+			writer.Write(header)
+		}
+
+		record, err := p.record(i, header, typedRow)
+		if err != nil {
+			return err
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+
+	err := writer.Error()
+	if err != nil {
+		return fmt.Errorf("marshaling doc: %s", err)
+	}
+
+	if p.writtenOnce && buf.Len() > 0 {
+		p.buf.Write([]byte("\n"))
+	}
+	if buf.Len() > 0 {
+		p.writtenOnce = true
+	}
+
+	p.buf.Write(buf.Bytes())
+	return nil
+}
+
+func (p *CSVPrinter) record(idx int, header []string, row *orderedmap.Map) ([]string, error) {
+	valsByKey := map[string]interface{}{}
+
+	row.Iterate(func(k, v interface{}) {
+		valsByKey[p.keyStr(k)] = v
+	})
+
+	if len(valsByKey) != len(header) {
+		return nil, fmt.Errorf("Expected array item %d to have same keys as first item (%d keys) "+
+			"for CSV output, but had %d keys", idx, len(header), len(valsByKey))
+	}
+
+	var result []string
+
+	for _, key := range header {
+		val, found := valsByKey[key]
+		if !found {
+			return nil, fmt.Errorf("Expected array item %d to have key '%s' "+
+				"(same keys as first item) for CSV output, but did not", idx, key)
+		}
+
+		cell, err := p.cellStr(val)
+		if err != nil {
+			return nil, fmt.Errorf("Marshaling array item %d key '%s': %s", idx, key, err)
+		}
+		result = append(result, cell)
+	}
+
+	return result, nil
+}
+
+func (p *CSVPrinter) cellStr(val interface{}) (string, error) {
+	switch typedVal := val.(type) {
+	case nil:
+		return "", nil
+
+	case string:
+		return typedVal, nil
+
+	case bool:
+		return strconv.FormatBool(typedVal), nil
+
+	case int, int64, uint64:
+		return fmt.Sprintf("%d", typedVal), nil
+
+	case float64:
+		return strconv.FormatFloat(typedVal, 'g', -1, 64), nil
+
+	default:
+		// Nested values are kept as JSON within a cell
+		bs, err := json.Marshal(orderedmap.Conversion{Object: val}.AsUnorderedStringMaps())
+		if err != nil {
+			return "", err
+		}
+		return string(bs), nil
+	}
+}
+
+func (p *CSVPrinter) keyStr(key interface{}) string {
+	if typedKey, ok := key.(string); ok {
+		return typedKey
+	}
+	return fmt.Sprintf("%v", key)
+}
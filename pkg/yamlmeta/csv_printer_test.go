@@ -0,0 +1,68 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestCSVPrinter(t *testing.T) {
+	data := `
+- name: web
+  replicas: 2
+  ratio: 0.5
+  enabled: true
+  owner: null
+- name: "db, primary"
+  replicas: 1
+  ratio: 1.5
+  enabled: false
+  owner: {team: data, tags: [a, b]}
+---
+- kind: "say \"hi\""
+`
+
+	expectedOutput := `name,replicas,ratio,enabled,owner
+web,2,0.5,true,
+"db, primary",1,1.5,false,"{""tags"":[""a"",""b""],""team"":""data""}"
+
+kind
+"say ""hi"""
+`
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewCSVPrinter(w)
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestCSVPrinterErrs(t *testing.T) {
+	examples := []struct {
+		Data string
+		Err  string
+	}{
+		{Data: "a: 1", Err: "Expected document to be an array of maps for CSV output, but was *orderedmap.Map"},
+		{Data: "- a: 1\n- 2", Err: "Expected array item 1 to be a map for CSV output, but was int"},
+		{Data: "- a: 1\n- b: 2", Err: "Expected array item 1 to have key 'a' (same keys as first item) for CSV output, but did not"},
+		{Data: "- a: 1\n- a: 1\n  b: 2", Err: "Expected array item 1 to have same keys as first item (1 keys) for CSV output, but had 2 keys"},
+	}
+
+	for _, ex := range examples {
+		_, err := printDocSet(ex.Data, func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.NewCSVPrinter(w)
+		})
+		if err == nil {
+			t.Fatalf("Expected printing of %q to fail", ex.Data)
+		}
+		if !strings.Contains(err.Error(), ex.Err) {
+			t.Fatalf("Expected error to match, but was: %s", err)
+		}
+	}
+}
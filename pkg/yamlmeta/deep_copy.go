@@ -39,6 +39,7 @@ func (n *Document) DeepCopy() *Document {
 
 		annotations: annotationsDeepCopy(n.annotations),
 		injected:    n.injected,
+		source:      n.source,
 	}
 }
 
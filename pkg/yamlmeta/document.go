@@ -1,6 +1,9 @@
 package yamlmeta
 
 import (
+	"bytes"
+	"strings"
+
 	"github.com/k14s/ytt/pkg/yamlmeta/internal/yaml.v2"
 )
 
@@ -18,8 +21,67 @@ func (d *Document) IsEmpty() bool {
 	return false
 }
 
+// EmptyKind describes document if it is null, empty map or array,
+// or whitespace-only string. Documents that were not present in
+// the parsed content (eg holding trailing comments) are never empty.
+func (d *Document) EmptyKind() (string, bool) {
+	if d.injected {
+		return "", false
+	}
+
+	switch typedVal := d.Value.(type) {
+	case nil:
+		return "null", true
+	case *Map:
+		if len(typedVal.Items) == 0 {
+			return "empty map", true
+		}
+	case *Array:
+		if len(typedVal.Items) == 0 {
+			return "empty array", true
+		}
+	case string:
+		if len(strings.TrimSpace(typedVal)) == 0 {
+			return "whitespace-only string", true
+		}
+	}
+
+	return "", false
+}
+
+// AsYAMLBytes returns original text of the document if it was
+// preserved during parsing (see DocSetOpts.PreserveComments) and
+// document's value has not changed since then
 func (d *Document) AsYAMLBytes() ([]byte, error) {
-	return yaml.Marshal(convertToLowYAML(convertToGo(d.Value)))
+	return d.AsYAMLBytesWithOpts(YAMLPrinterOpts{})
+}
+
+// AsYAMLBytesWithOpts is same as AsYAMLBytes, but formats
+// output based on given options (eg folds long scalars at given
+// line width). Preserved original text is never changed.
+func (d *Document) AsYAMLBytesWithOpts(opts YAMLPrinterOpts) ([]byte, error) {
+	if d.source != nil {
+		bs, err := d.asMarshaledYAMLBytes(YAMLPrinterOpts{})
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(bs, d.source.valueBytes) {
+			return d.source.bytes, nil
+		}
+		if opts.isDefault() {
+			return bs, nil
+		}
+	}
+
+	return d.asMarshaledYAMLBytes(opts)
+}
+
+func (d *Document) asMarshaledYAMLBytes(opts YAMLPrinterOpts) ([]byte, error) {
+	val := convertToLowYAML(convertToGo(d.Value))
+	if opts.isDefault() {
+		return yaml.Marshal(val)
+	}
+	return yaml.MarshalWithOpts(val, opts.asMarshalOpts())
 }
 
 func (d *Document) AsInterface() interface{} {
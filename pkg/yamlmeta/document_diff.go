@@ -0,0 +1,66 @@
+package yamlmeta
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type DocumentChange string
+
+const (
+	DocumentUnchanged DocumentChange = "unchanged"
+	DocumentAdded     DocumentChange = "added"
+	DocumentModified  DocumentChange = "modified"
+)
+
+// DocumentIdentity identifies document across renders: Kubernetes
+// resources (maps with kind and metadata.name) are identified by kind,
+// namespace (if any) and name (eg 'Deployment/default/web'), other documents
+// by path of containing file and their index within it (eg 'app.yml#1')
+func DocumentIdentity(doc *Document, path string, idx int) string {
+	val := doc.AsInterface()
+
+	kind, kindFound := lookupScalarAtPath(val, []string{"kind"})
+	name, nameFound := lookupScalarAtPath(val, []string{"metadata", "name"})
+
+	if kindFound && nameFound {
+		pieces := []string{kind, name}
+		if ns, found := lookupScalarAtPath(val, []string{"metadata", "namespace"}); found {
+			pieces = []string{kind, ns, name}
+		}
+		return strings.Join(pieces, "/")
+	}
+
+	return fmt.Sprintf("%s#%d", path, idx)
+}
+
+// CompareDocuments returns whether document was added (baseline
+// document is nil) or modified compared to baseline document.
+// Order of map keys is not taken into account.
+func CompareDocuments(doc, baselineDoc *Document) (DocumentChange, error) {
+	if baselineDoc == nil {
+		return DocumentAdded, nil
+	}
+
+	bs, err := doc.asComparableBytes()
+	if err != nil {
+		return "", err
+	}
+
+	baselineBs, err := baselineDoc.asComparableBytes()
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.Equal(bs, baselineBs) {
+		return DocumentUnchanged, nil
+	}
+	return DocumentModified, nil
+}
+
+func (d *Document) asComparableBytes() ([]byte, error) {
+	doc := d.DeepCopy()
+	SortKeys(doc)
+	return doc.asMarshaledYAMLBytes(YAMLPrinterOpts{})
+}
@@ -0,0 +1,81 @@
+package yamlmeta_test
+
+import (
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestDocumentIdentity(t *testing.T) {
+	data := `
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+---
+kind: Service
+metadata:
+  name: web
+---
+kind: ConfigMap
+---
+plain: true
+`
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	expectedIDs := []string{"Deployment/prod/web", "Service/web", "app.yml#2", "app.yml#3"}
+
+	for i, doc := range docSet.Items {
+		id := yamlmeta.DocumentIdentity(doc, "app.yml", i)
+		if id != expectedIDs[i] {
+			t.Fatalf("Expected document %d identity to be '%s', but was '%s'", i, expectedIDs[i], id)
+		}
+	}
+}
+
+func TestCompareDocuments(t *testing.T) {
+	data := `
+a: 1
+b: [1, 2]
+---
+b: [1, 2]
+a: 1
+---
+a: 1
+b: [2, 1]
+`
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	examples := []struct {
+		Doc      *yamlmeta.Document
+		Baseline *yamlmeta.Document
+		Expected yamlmeta.DocumentChange
+	}{
+		{docSet.Items[0], nil, yamlmeta.DocumentAdded},
+		{docSet.Items[0], docSet.Items[1], yamlmeta.DocumentUnchanged},
+		{docSet.Items[0], docSet.Items[2], yamlmeta.DocumentModified},
+	}
+
+	for i, ex := range examples {
+		change, err := yamlmeta.CompareDocuments(ex.Doc, ex.Baseline)
+		if err != nil {
+			t.Fatalf("Expected comparing to succeed: %s", err)
+		}
+		if change != ex.Expected {
+			t.Fatalf("Expected example %d to be '%s', but was '%s'", i, ex.Expected, change)
+		}
+	}
+
+	// Key order of compared (baseline) document is left as is
+	if docSet.Items[1].Value.(*yamlmeta.Map).Items[0].Key != "b" {
+		t.Fatalf("Expected baseline document to not be modified")
+	}
+}
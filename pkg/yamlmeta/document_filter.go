@@ -0,0 +1,111 @@
+package yamlmeta
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+// DocumentFilter matches documents whose value at dotted key path
+// equals (or does not equal, if negated) given string (eg 'kind=Deployment')
+type DocumentFilter struct {
+	path    []string
+	value   string
+	negated bool
+}
+
+// ParseDocumentFilter parses 'path=value' or 'path!=value' filter;
+// array items are referenced by their index (eg 'spec.ports.0.port=80')
+func ParseDocumentFilter(str string) (DocumentFilter, error) {
+	idx := strings.Index(str, "=")
+	if idx <= 0 {
+		return DocumentFilter{}, fmt.Errorf("Expected filter '%s' to be in format 'path=value' or 'path!=value'", str)
+	}
+
+	filter := DocumentFilter{value: str[idx+1:]}
+	pathStr := str[:idx]
+
+	if strings.HasSuffix(pathStr, "!") {
+		filter.negated = true
+		pathStr = strings.TrimSuffix(pathStr, "!")
+	}
+
+	filter.path = strings.Split(pathStr, ".")
+
+	for _, key := range filter.path {
+		if len(key) == 0 {
+			return DocumentFilter{}, fmt.Errorf("Expected filter '%s' path to not have empty keys", str)
+		}
+	}
+
+	return filter, nil
+}
+
+// Matches returns true if document's value at filter's path is a scalar
+// whose string form equals filter's value. Documents without such value
+// do not match (or match if filter is negated).
+func (f DocumentFilter) Matches(doc *Document) bool {
+	val, found := lookupScalarAtPath(doc.AsInterface(), f.path)
+	return (found && val == f.value) != f.negated
+}
+
+// lookupScalarAtPath returns string form of scalar found at key path;
+// array items are referenced by their index
+func lookupScalarAtPath(val interface{}, path []string) (string, bool) {
+	currVal := val
+
+	for _, key := range path {
+		switch typedVal := currVal.(type) {
+		case *orderedmap.Map:
+			var found bool
+			currVal, found = typedVal.Get(key)
+			if !found {
+				return "", false
+			}
+
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(typedVal) {
+				return "", false
+			}
+			currVal = typedVal[idx]
+
+		default:
+			return "", false
+		}
+	}
+
+	switch typedVal := currVal.(type) {
+	case *orderedmap.Map, []interface{}:
+		return "", false
+	case nil:
+		return "null", true
+	case string:
+		return typedVal, true
+	default:
+		return fmt.Sprintf("%v", typedVal), true
+	}
+}
+
+// FilterDocumentSet returns document set with documents matching
+// all filters (ordering is preserved)
+func FilterDocumentSet(docSet *DocumentSet, filters []DocumentFilter) *DocumentSet {
+	result := &DocumentSet{Position: docSet.Position}
+
+	for _, doc := range docSet.Items {
+		matched := true
+		for _, filter := range filters {
+			if !filter.Matches(doc) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result.Items = append(result.Items, doc)
+		}
+	}
+
+	return result
+}
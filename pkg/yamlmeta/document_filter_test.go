@@ -0,0 +1,82 @@
+package yamlmeta_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestFilterDocumentSet(t *testing.T) {
+	data := `
+kind: Deployment
+metadata:
+  name: a
+spec:
+  replicas: 2
+---
+kind: Service
+metadata:
+  name: a
+---
+kind: Deployment
+metadata:
+  name: b
+spec:
+  replicas: 1
+---
+- kind: Deployment
+`
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	examples := []struct {
+		Filters  []string
+		Expected []string
+	}{
+		{[]string{"kind=Deployment"}, []string{"a", "b"}},
+		{[]string{"kind=Deployment", "spec.replicas=2"}, []string{"a"}},
+		{[]string{"kind!=Deployment"}, []string{"a", ""}},
+		{[]string{"metadata.name=a", "kind!=Service"}, []string{"a"}},
+		{[]string{"0.kind=Deployment"}, []string{""}},
+		{[]string{"kind=deployment"}, nil},
+		{[]string{"metadata=a"}, nil},
+		{[]string{"kind="}, nil},
+	}
+
+	for _, ex := range examples {
+		var filters []yamlmeta.DocumentFilter
+
+		for _, filterStr := range ex.Filters {
+			filter, err := yamlmeta.ParseDocumentFilter(filterStr)
+			if err != nil {
+				t.Fatalf("Expected parsing filter to succeed: %s", err)
+			}
+			filters = append(filters, filter)
+		}
+
+		var names []string
+
+		for _, doc := range yamlmeta.FilterDocumentSet(docSet, filters).Items {
+			var name string
+			if resource, ok := doc.Value.(*yamlmeta.Map); ok {
+				name = resource.Items[1].Value.(*yamlmeta.Map).Items[0].Value.(string)
+			}
+			names = append(names, name)
+		}
+
+		if strings.Join(names, ",") != strings.Join(ex.Expected, ",") || len(names) != len(ex.Expected) {
+			t.Fatalf("Expected filters %v to match %v, but matched %v", ex.Filters, ex.Expected, names)
+		}
+	}
+
+	for _, filterStr := range []string{"kind", "=Deployment", "metadata..name=a"} {
+		_, err := yamlmeta.ParseDocumentFilter(filterStr)
+		if err == nil {
+			t.Fatalf("Expected parsing filter '%s' to fail", filterStr)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package yamlmeta
 
 import (
+	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 )
 
@@ -10,6 +12,10 @@ type DocSetOpts struct {
 	Strict      bool
 	// associatedName is typically a file name where data came from
 	AssociatedName string
+	// PreserveComments keeps original text of each document so that
+	// documents that are not changed are printed as YAML as is
+	// (including comments and anchors); only supported WithoutMeta
+	PreserveComments bool
 }
 
 func NewDocumentSetFromBytes(data []byte, opts DocSetOpts) (*DocumentSet, error) {
@@ -20,6 +26,14 @@ func NewDocumentSetFromBytes(data []byte, opts DocSetOpts) (*DocumentSet, error)
 		return nil, err
 	}
 	docSet.originalBytes = &data
+
+	if opts.PreserveComments && opts.WithoutMeta {
+		err := docSet.attachDocumentSources(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return docSet, nil
 }
 
@@ -41,19 +55,53 @@ func (d *DocumentSet) AsBytes() ([]byte, error) {
 }
 
 func (d *DocumentSet) AsBytesWithPrinter(printerFunc func(io.Writer) DocumentPrinter) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	err := d.WriteWithPrinter(buf, printerFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteWithPrinter prints documents into given writer one by one
+// (same output as AsBytesWithPrinter) flushing it after each document,
+// so that whole output does not need to be kept in memory. On error
+// preceding documents may have been already written.
+func (d *DocumentSet) WriteWithPrinter(writer io.Writer, printerFunc func(io.Writer) DocumentPrinter) error {
 	if printerFunc == nil {
 		printerFunc = func(w io.Writer) DocumentPrinter { return NewYAMLPrinter(w) }
 	}
 
-	buf := new(bytes.Buffer)
-	printer := printerFunc(buf)
+	bufWriter := bufio.NewWriter(writer)
+	printer := printerFunc(bufWriter)
+
+	return d.EachDocument(func(doc *Document) error {
+		err := printer.Print(doc)
+		if err != nil {
+			return err
+		}
+		err = bufWriter.Flush()
+		if err != nil {
+			return fmt.Errorf("Writing output: %s", err)
+		}
+		return nil
+	})
+}
 
+// EachDocument calls given function for each document that would be
+// printed (injected and empty documents are skipped) in order.
+// Iteration stops at the first error which is returned as is.
+func (d *DocumentSet) EachDocument(fn func(doc *Document) error) error {
 	for _, item := range d.Items {
 		if item.injected || item.IsEmpty() {
 			continue
 		}
-		printer.Print(item)
+		err := fn(item)
+		if err != nil {
+			return err
+		}
 	}
-
-	return buf.Bytes(), nil
+	return nil
 }
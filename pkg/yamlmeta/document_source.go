@@ -0,0 +1,69 @@
+package yamlmeta
+
+import (
+	"bytes"
+	"strings"
+)
+
+// documentSource keeps original text of a parsed document so that
+// it can be printed as is (including comments, anchors and formatting)
+// as long as document's value has not changed since it was parsed
+type documentSource struct {
+	bytes []byte
+	// valueBytes is document value marshaled right after parsing
+	valueBytes []byte
+}
+
+// attachDocumentSources splits original data into per document
+// segments based on document positions. Each segment spans from
+// document's start marker until next document's start marker, hence
+// comments preceding a start marker stay with preceding document.
+func (d *DocumentSet) attachDocumentSources(data []byte) error {
+	lines := strings.SplitAfter(string(data), "\n")
+
+	for i, doc := range d.Items {
+		if !doc.Position.IsKnown() {
+			return nil
+		}
+
+		startLine := doc.Position.Line()
+		endLine := len(lines) + 1
+
+		if i+1 < len(d.Items) {
+			nextPos := d.Items[i+1].Position
+			if !nextPos.IsKnown() {
+				return nil
+			}
+			endLine = nextPos.Line()
+		}
+
+		// Positions are 1 based and must not overlap
+		if startLine < 1 || endLine <= startLine || endLine > len(lines)+1 {
+			return nil
+		}
+
+		segment := lines[startLine-1 : endLine-1]
+
+		if strings.HasPrefix(segment[0], "---") {
+			// Content on the same line as start marker is not supported
+			if strings.TrimSpace(segment[0]) != "---" {
+				continue
+			}
+			segment = segment[1:]
+		}
+
+		srcBytes := []byte(strings.Join(segment, ""))
+		if len(srcBytes) > 0 && !bytes.HasSuffix(srcBytes, []byte("\n")) {
+			srcBytes = append(srcBytes, '\n')
+		}
+
+		valueBytes, err := doc.asMarshaledYAMLBytes(YAMLPrinterOpts{})
+		if err != nil {
+			return err
+		}
+
+		doc.source = &documentSource{bytes: srcBytes, valueBytes: valueBytes}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package yamlmeta_test
+
+import (
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestPreserveComments(t *testing.T) {
+	data := `# top
+a: &x 1 # inline
+b: *x
+# trailing
+---
+# leading
+c: 2
+--- {d: 3}
+`
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{WithoutMeta: true, PreserveComments: true})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	// Document with content on the start marker line is marshaled
+	expectedOutput := `# top
+a: &x 1 # inline
+b: *x
+# trailing
+---
+# leading
+c: 2
+---
+d: 3
+`
+
+	bs, err := docSet.AsBytes()
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if string(bs) != expectedOutput {
+		t.Fatalf("Expected output to preserve comments, but was: >>>%s<<<", bs)
+	}
+
+	// Changed documents fall back to regular printing
+	docSet.Items[1].Value.(*yamlmeta.Map).Items[0].Value = 3
+
+	bs, err = docSet.AsBytes()
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if string(bs) != "# top\na: &x 1 # inline\nb: *x\n# trailing\n---\nc: 3\n---\nd: 3\n" {
+		t.Fatalf("Expected changed document to be marshaled, but was: >>>%s<<<", bs)
+	}
+
+	docSet, err = yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{WithoutMeta: true})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	bs, err = docSet.AsBytes()
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if string(bs) != "a: 1\nb: 1\n---\nc: 2\n---\nd: 3\n" {
+		t.Fatalf("Expected comments to not be preserved by default, but was: >>>%s<<<", bs)
+	}
+}
@@ -0,0 +1,131 @@
+package yamlmeta
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+var (
+	dotenvKeyRegexp       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	dotenvBareValueRegexp = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+)
+
+// DotenvPrinter prints a single document that is a map of scalars as KEY=value lines
+type DotenvPrinter struct {
+	buf         io.Writer
+	opts        DotenvPrinterOpts
+	writtenOnce bool
+}
+
+type DotenvPrinterOpts struct {
+	// Flatten joins keys of nested maps with underscore
+	// (eg 'db: {host: x}' becomes 'db_host=x') instead of rejecting them
+	Flatten bool
+}
+
+var _ DocumentPrinter = &DotenvPrinter{}
+
+func NewDotenvPrinter(writer io.Writer) *DotenvPrinter {
+	return &DotenvPrinter{writer, DotenvPrinterOpts{}, false}
+}
+
+func NewDotenvPrinterWithOpts(writer io.Writer, opts DotenvPrinterOpts) *DotenvPrinter {
+	return &DotenvPrinter{writer, opts, false}
+}
+
+func (p *DotenvPrinter) Print(item *Document) error {
+	if p.writtenOnce {
+		return fmt.Errorf("dotenv output does not support multiple documents " +
+			"(use --output-directory to write documents from different files separately)")
+	}
+	p.writtenOnce = true
+
+	typedMap, ok := item.AsInterface().(*orderedmap.Map)
+	if !ok {
+		return fmt.Errorf("Expected document to be a map for dotenv output, but was %T", item.AsInterface())
+	}
+
+	buf := new(bytes.Buffer)
+
+	err := p.printMap(buf, nil, typedMap, map[string]struct{}{})
+	if err != nil {
+		return fmt.Errorf("marshaling doc: %s", err)
+	}
+
+	p.buf.Write(buf.Bytes())
+	return nil
+}
+
+func (p *DotenvPrinter) printMap(buf *bytes.Buffer, path []string, val *orderedmap.Map, seenKeys map[string]struct{}) error {
+	return val.IterateErr(func(k, v interface{}) error {
+		keyPath := append(append([]string{}, path...), fmt.Sprintf("%v", k))
+		key := strings.Join(keyPath, "_")
+
+		if nestedMap, isMap := v.(*orderedmap.Map); isMap {
+			if !p.opts.Flatten {
+				return fmt.Errorf("Expected value of key '%s' to be a scalar, but was a map "+
+					"(use --dotenv-flatten to join nested keys with underscore)", key)
+			}
+			return p.printMap(buf, keyPath, nestedMap, seenKeys)
+		}
+
+		if !dotenvKeyRegexp.MatchString(key) {
+			return fmt.Errorf("Expected key '%s' to be a valid environment variable name "+
+				"(letters, digits and underscores, not starting with a digit)", key)
+		}
+		if _, found := seenKeys[key]; found {
+			return fmt.Errorf("Expected key '%s' to be specified only once (after flattening nested keys)", key)
+		}
+		seenKeys[key] = struct{}{}
+
+		valStr, err := p.valueStr(key, v)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(buf, "%s=%s\n", key, valStr)
+		return nil
+	})
+}
+
+func (p *DotenvPrinter) valueStr(key string, val interface{}) (string, error) {
+	switch typedVal := val.(type) {
+	case nil:
+		return "", nil
+
+	case string:
+		return p.quoteStr(typedVal), nil
+
+	case bool:
+		return strconv.FormatBool(typedVal), nil
+
+	case int, int64, uint64:
+		return fmt.Sprintf("%d", typedVal), nil
+
+	case float64:
+		return strconv.FormatFloat(typedVal, 'g', -1, 64), nil
+
+	default:
+		return "", fmt.Errorf("Expected value of key '%s' to be a scalar, but was %T", key, val)
+	}
+}
+
+func (p *DotenvPrinter) quoteStr(val string) string {
+	if dotenvBareValueRegexp.MatchString(val) {
+		return val
+	}
+
+	// Single quoted values are taken literally (no variable expansion)
+	if !strings.ContainsAny(val, "'\n\r") {
+		return "'" + val + "'"
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+	return `"` + replacer.Replace(val) + `"`
+}
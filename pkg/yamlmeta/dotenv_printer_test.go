@@ -0,0 +1,88 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestDotenvPrinter(t *testing.T) {
+	data := `
+PORT: 8080
+DEBUG: false
+RATIO: 0.5
+EMPTY: null
+URL: "https://host:80/path?a=1"
+GREETING: hello world
+QUOTE: "it's $HOME"
+MULTI: "line1\nline2"
+db:
+  host: localhost
+`
+
+	expectedOutput := `PORT=8080
+DEBUG=false
+RATIO=0.5
+EMPTY=
+URL='https://host:80/path?a=1'
+GREETING='hello world'
+QUOTE="it's \$HOME"
+MULTI="line1\nline2"
+db_host=localhost
+`
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewDotenvPrinterWithOpts(w, yamlmeta.DotenvPrinterOpts{Flatten: true})
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestDotenvPrinterErrors(t *testing.T) {
+	examples := []struct {
+		Data string
+		Err  string
+	}{
+		{
+			Data: "db:\n  host: x",
+			Err:  "marshaling doc: Expected value of key 'db' to be a scalar, but was a map (use --dotenv-flatten to join nested keys with underscore)",
+		},
+		{
+			Data: "a: [1]",
+			Err:  "marshaling doc: Expected value of key 'a' to be a scalar, but was []interface {}",
+		},
+		{
+			Data: "1a: x",
+			Err:  "marshaling doc: Expected key '1a' to be a valid environment variable name (letters, digits and underscores, not starting with a digit)",
+		},
+		{
+			Data: "a: 1\n---\nb: 2",
+			Err:  "dotenv output does not support multiple documents (use --output-directory to write documents from different files separately)",
+		},
+		{
+			Data: "[1]",
+			Err:  "Expected document to be a map for dotenv output, but was []interface {}",
+		},
+	}
+
+	for _, ex := range examples {
+		_, err := printDocSet(ex.Data, func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.NewDotenvPrinter(w)
+		})
+		if err == nil || err.Error() != ex.Err {
+			t.Fatalf("Expected printing of %q to fail with specific error, but was: %v", ex.Data, err)
+		}
+	}
+
+	_, err := printDocSet("a_b: 1\na:\n  b: 2", func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewDotenvPrinterWithOpts(w, yamlmeta.DotenvPrinterOpts{Flatten: true})
+	})
+	if err == nil || err.Error() != "marshaling doc: Expected key 'a_b' to be specified only once (after flattening nested keys)" {
+		t.Fatalf("Expected duplicate flattened key to fail, but was: %v", err)
+	}
+}
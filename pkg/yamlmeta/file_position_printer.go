@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/k14s/ytt/pkg/filepos"
@@ -14,22 +15,120 @@ type FilePositionPrinter struct {
 	writer   io.Writer
 	opts     FilePositionPrinterOpts
 	locWidth int
+	matched  bool
 }
 
-type FilePositionPrinterOpts struct{}
+type FilePositionPrinterOpts struct {
+	// Query (JSON pointer tokens, see ParseJSONPointer) limits output
+	// to a single node within each document; nil means all nodes are printed
+	Query []string
+}
 
 func NewFilePositionPrinter(writer io.Writer) *FilePositionPrinter {
-	return &FilePositionPrinter{writer, FilePositionPrinterOpts{}, 0}
+	return &FilePositionPrinter{writer, FilePositionPrinterOpts{}, 0, false}
 }
 
 func NewFilePositionPrinterWithOpts(writer io.Writer, opts FilePositionPrinterOpts) *FilePositionPrinter {
-	return &FilePositionPrinter{writer, opts, 0}
+	return &FilePositionPrinter{writer, opts, 0, false}
 }
 
 func (p *FilePositionPrinter) Print(val interface{}) {
+	if p.opts.Query != nil {
+		if doc, ok := val.(*Document); ok {
+			p.printQuery(doc)
+			return
+		}
+	}
 	fmt.Fprintf(p.writer, "%s", p.PrintStr(val))
 }
 
+// Matched indicates if query matched node in at least one printed document
+func (p *FilePositionPrinter) Matched() bool { return p.matched }
+
+func (p *FilePositionPrinter) printQuery(doc *Document) {
+	pos, val, found := p.queryNode(doc)
+	if !found {
+		return
+	}
+
+	p.matched = true
+
+	label := "/" + strings.Join(escapeJSONPointerTokens(p.opts.Query), "/")
+	if len(p.opts.Query) == 0 {
+		label = "[doc]"
+	}
+
+	posStr := "?"
+	if pos.IsKnown() {
+		posStr = pos.AsCompactString()
+	}
+
+	valStr, isLeaf := p.leafValue(val)
+	if isLeaf && !strings.Contains(valStr, "\n") {
+		fmt.Fprintf(p.writer, "%s | %s: %s\n", posStr, label, valStr)
+	} else {
+		fmt.Fprintf(p.writer, "%s | %s\n", posStr, label)
+	}
+}
+
+func (p *FilePositionPrinter) queryNode(doc *Document) (*filepos.Position, interface{}, bool) {
+	pos := doc.Position
+	val := doc.Value
+
+	for _, token := range p.opts.Query {
+		switch typedVal := val.(type) {
+		case *Map:
+			var found bool
+			for _, item := range typedVal.Items {
+				if fmt.Sprintf("%v", item.Key) == token {
+					pos, val, found = item.Position, item.Value, true
+					break
+				}
+			}
+			if !found {
+				return nil, nil, false
+			}
+
+		case *Array:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(typedVal.Items) || token != strconv.Itoa(idx) {
+				return nil, nil, false
+			}
+			pos, val = typedVal.Items[idx].Position, typedVal.Items[idx].Value
+
+		default:
+			return nil, nil, false
+		}
+	}
+
+	return pos, val, true
+}
+
+// ParseJSONPointer parses JSON pointer (RFC 6901) into
+// unescaped reference tokens (eg '/spec/containers/0/image')
+func ParseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("Expected JSON pointer '%s' to start with '/'", pointer)
+	}
+
+	var result []string
+	for _, token := range strings.Split(pointer[1:], "/") {
+		result = append(result, strings.NewReplacer("~1", "/", "~0", "~").Replace(token))
+	}
+	return result, nil
+}
+
+func escapeJSONPointerTokens(tokens []string) []string {
+	var result []string
+	for _, token := range tokens {
+		result = append(result, strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return result
+}
+
 func (p *FilePositionPrinter) PrintStr(val interface{}) string {
 	buf := new(bytes.Buffer)
 	p.print(val, "", buf)
@@ -0,0 +1,111 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestFilePositionPrinterQuery(t *testing.T) {
+	data := `kind: Deployment
+spec:
+  template:
+    containers:
+    - name: app
+      image: nginx
+    - name: sidecar
+      a/b: 3
+---
+kind: Service
+`
+
+	examples := []struct {
+		Query   string
+		Output  string
+		Matched bool
+	}{
+		{
+			Query:   "/spec/template/containers/0/image",
+			Output:  "app.yml:6 | /spec/template/containers/0/image: nginx\n",
+			Matched: true,
+		},
+		{
+			Query:   "/spec/template/containers/1",
+			Output:  "app.yml:7 | /spec/template/containers/1\n",
+			Matched: true,
+		},
+		{
+			Query:   "/spec/template/containers/1/a~1b",
+			Output:  "app.yml:8 | /spec/template/containers/1/a~1b: 3\n",
+			Matched: true,
+		},
+		{
+			Query:   "/kind",
+			Output:  "app.yml:1 | /kind: Deployment\napp.yml:10 | /kind: Service\n",
+			Matched: true,
+		},
+		{Query: "/spec/template/containers/2", Output: "", Matched: false},
+		{Query: "/spec/template/containers/01", Output: "", Matched: false},
+		{Query: "/spec/missing", Output: "", Matched: false},
+	}
+
+	for _, ex := range examples {
+		docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{AssociatedName: "app.yml"})
+		if err != nil {
+			t.Fatalf("Expected parsing to succeed: %s", err)
+		}
+
+		query, err := yamlmeta.ParseJSONPointer(ex.Query)
+		if err != nil {
+			t.Fatalf("Expected parsing query to succeed: %s", err)
+		}
+
+		var printer *yamlmeta.FilePositionPrinter
+
+		out, err := printDocSetItems(docSet, func(w io.Writer) yamlmeta.DocumentPrinter {
+			printer = yamlmeta.NewFilePositionPrinterWithOpts(w, yamlmeta.FilePositionPrinterOpts{Query: query})
+			return yamlmeta.WrappedFilePositionPrinter{Printer: printer}
+		})
+		if err != nil {
+			t.Fatalf("Expected printing to succeed: %s", err)
+		}
+		if out != ex.Output {
+			t.Fatalf("Expected output for query '%s' to match, but was: >>>%s<<<", ex.Query, out)
+		}
+		if printer.Matched() != ex.Matched {
+			t.Fatalf("Expected query '%s' matched to be %t", ex.Query, ex.Matched)
+		}
+	}
+}
+
+func TestParseJSONPointerErr(t *testing.T) {
+	_, err := yamlmeta.ParseJSONPointer("spec/name")
+	if err == nil || err.Error() != "Expected JSON pointer 'spec/name' to start with '/'" {
+		t.Fatalf("Expected parsing to fail, but was: %v", err)
+	}
+}
+
+func TestFilePositionPrinterFirstDocument(t *testing.T) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte("a: 1\n---\nb: 2\n"), yamlmeta.DocSetOpts{AssociatedName: "p.yml"})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	out, err := printDocSetItems(docSet, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.WrappedFilePositionPrinter{Printer: yamlmeta.NewFilePositionPrinter(w)}
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+
+	// First document position is implicit hence does not include file name
+	expectedOutput := `          1 | [doc]
+    p.yml:1 |   a: 1
+    p.yml:2 | [doc]
+    p.yml:3 |   b: 2
+`
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
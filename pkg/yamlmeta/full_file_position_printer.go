@@ -0,0 +1,130 @@
+package yamlmeta
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/yamlmeta/internal/yaml.v2"
+)
+
+// FullFilePositionPrinter prints YAML document per each document
+// describing start and end positions of all map and array items
+// so that it can be consumed by tools (eg editors)
+type FullFilePositionPrinter struct {
+	writer      io.Writer
+	opts        FullFilePositionPrinterOpts
+	writtenOnce bool
+}
+
+type FullFilePositionPrinterOpts struct {
+	// LSP prints positions as Language Server Protocol ranges
+	// (0 based lines and columns counted in UTF-16 code units)
+	LSP bool
+}
+
+var _ DocumentPrinter = &FullFilePositionPrinter{}
+
+func NewFullFilePositionPrinter(writer io.Writer) *FullFilePositionPrinter {
+	return &FullFilePositionPrinter{writer, FullFilePositionPrinterOpts{}, false}
+}
+
+func NewFullFilePositionPrinterWithOpts(writer io.Writer, opts FullFilePositionPrinterOpts) *FullFilePositionPrinter {
+	return &FullFilePositionPrinter{writer, opts, false}
+}
+
+func (p *FullFilePositionPrinter) Print(item *Document) error {
+	result := yaml.MapSlice{}
+	result = append(result, p.positionItems(item.SourcePosition())...)
+
+	nodes := []interface{}{}
+	p.collectNodes(item.Value, []interface{}{}, &nodes)
+	result = append(result, yaml.MapItem{Key: "nodes", Value: nodes})
+
+	bs, err := yaml.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshaling doc positions: %s", err)
+	}
+
+	if p.writtenOnce {
+		p.writer.Write([]byte("---\n"))
+	} else {
+		p.writtenOnce = true
+	}
+
+	p.writer.Write(bs)
+	return nil
+}
+
+func (p *FullFilePositionPrinter) collectNodes(val interface{}, path []interface{}, result *[]interface{}) {
+	switch typedVal := val.(type) {
+	case *Map:
+		for _, item := range typedVal.Items {
+			itemPath := append(append([]interface{}{}, path...), item.Key)
+			*result = append(*result, p.nodeItem(itemPath, item.Position))
+			p.collectNodes(item.Value, itemPath, result)
+		}
+
+	case *Array:
+		for i, item := range typedVal.Items {
+			itemPath := append(append([]interface{}{}, path...), i)
+			*result = append(*result, p.nodeItem(itemPath, item.Position))
+			p.collectNodes(item.Value, itemPath, result)
+		}
+	}
+}
+
+func (p *FullFilePositionPrinter) nodeItem(path []interface{}, pos *filepos.Position) yaml.MapSlice {
+	return append(yaml.MapSlice{{Key: "path", Value: path}}, p.positionItems(pos)...)
+}
+
+func (p *FullFilePositionPrinter) positionItems(pos *filepos.Position) yaml.MapSlice {
+	if !pos.IsKnown() {
+		return nil
+	}
+
+	result := yaml.MapSlice{{Key: "file", Value: pos.File()}}
+
+	extent, found := pos.Extent()
+
+	if p.opts.LSP {
+		return append(result, yaml.MapItem{Key: "range", Value: p.lspRange(pos, extent, found)})
+	}
+
+	if !found {
+		return append(result, yaml.MapItem{Key: "start", Value: yaml.MapSlice{{Key: "line", Value: pos.Line()}}})
+	}
+
+	return append(result,
+		yaml.MapItem{Key: "start", Value: yaml.MapSlice{
+			{Key: "line", Value: pos.Line()},
+			{Key: "column", Value: extent.Column},
+			{Key: "offset", Value: extent.Offset},
+		}},
+		yaml.MapItem{Key: "end", Value: yaml.MapSlice{
+			{Key: "line", Value: extent.EndLine},
+			{Key: "column", Value: extent.EndColumn},
+			{Key: "offset", Value: extent.EndOffset},
+		}},
+	)
+}
+
+// lspRange returns LSP range of position; positions with
+// unknown extent cover whole line (up to the start of next line)
+func (p *FullFilePositionPrinter) lspRange(pos *filepos.Position, extent filepos.Extent, found bool) yaml.MapSlice {
+	lspPosition := func(line, character int) yaml.MapSlice {
+		return yaml.MapSlice{{Key: "line", Value: line}, {Key: "character", Value: character}}
+	}
+
+	if !found {
+		return yaml.MapSlice{
+			{Key: "start", Value: lspPosition(pos.Line()-1, 0)},
+			{Key: "end", Value: lspPosition(pos.Line(), 0)},
+		}
+	}
+
+	return yaml.MapSlice{
+		{Key: "start", Value: lspPosition(pos.Line()-1, extent.UTF16Column-1)},
+		{Key: "end", Value: lspPosition(extent.EndLine-1, extent.EndUTF16Column-1)},
+	}
+}
@@ -0,0 +1,184 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestParserExtents(t *testing.T) {
+	examples := []string{"a:\n  b: héllo\nc: [1]\n", "---\na:\n  b: héllo\nc: [1]\n"}
+
+	for i, data := range examples {
+		// Offsets and lines are shifted by document start marker
+		lineShift := i
+		offsetShift := i * len("---\n")
+
+		docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+		if err != nil {
+			t.Fatalf("Expected parsing to succeed: %s", err)
+		}
+
+		topMap := docSet.Items[0].Value.(*yamlmeta.Map)
+		nestedItem := topMap.Items[0].Value.(*yamlmeta.Map).Items[0]
+
+		expectedExtents := []struct {
+			Pos    *filepos.Position
+			Line   int
+			Extent filepos.Extent
+		}{
+			{topMap.Items[0].Position, 1, filepos.Extent{Column: 1, Offset: 0, EndLine: 2, EndColumn: 11, EndOffset: 14,
+				UTF16Column: 1, EndUTF16Column: 11}},
+			// len("héllo") is 6 bytes, but only 5 characters
+			{nestedItem.Position, 2, filepos.Extent{Column: 3, Offset: 5, EndLine: 2, EndColumn: 11, EndOffset: 14,
+				UTF16Column: 3, EndUTF16Column: 11}},
+			{topMap.Items[1].Position, 3, filepos.Extent{Column: 1, Offset: 15, EndLine: 3, EndColumn: 7, EndOffset: 21,
+				UTF16Column: 1, EndUTF16Column: 7}},
+		}
+
+		for j, ex := range expectedExtents {
+			extent, found := ex.Pos.Extent()
+			if !found {
+				t.Fatalf("Expected extent %d to be found in example %d", j, i)
+			}
+
+			ex.Extent.Offset += offsetShift
+			ex.Extent.EndOffset += offsetShift
+			ex.Extent.EndLine += lineShift
+
+			if ex.Pos.Line() != ex.Line+lineShift || extent != ex.Extent {
+				t.Fatalf("Expected extent %d in example %d to match, but was: line %d %#v", j, i, ex.Pos.Line(), extent)
+			}
+		}
+	}
+}
+
+func TestParserExtentsUTF16(t *testing.T) {
+	// Emoji is 4 bytes, 1 character and 2 UTF-16 code units
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte("a: 😀\nb: [😀, 1]\n"), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	topMap := docSet.Items[0].Value.(*yamlmeta.Map)
+	arrayItem := topMap.Items[1].Value.(*yamlmeta.Array).Items[1]
+
+	expectedExtents := []struct {
+		Pos    *filepos.Position
+		Extent filepos.Extent
+	}{
+		{topMap.Items[0].Position, filepos.Extent{Column: 1, Offset: 0, EndLine: 1, EndColumn: 5, EndOffset: 7,
+			UTF16Column: 1, EndUTF16Column: 6}},
+		{arrayItem.Position, filepos.Extent{Column: 6, Offset: 16, EndLine: 2, EndColumn: 9, EndOffset: 19,
+			UTF16Column: 7, EndUTF16Column: 10}},
+	}
+
+	for i, ex := range expectedExtents {
+		extent, found := ex.Pos.Extent()
+		if !found || extent != ex.Extent {
+			t.Fatalf("Expected extent %d to match, but was: %#v", i, extent)
+		}
+	}
+}
+
+func TestFullFilePositionPrinter(t *testing.T) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte("a:\n- 1\n---\nb: 2\n"), yamlmeta.DocSetOpts{AssociatedName: "app.yml"})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	expectedOutput := `file: app.yml
+start:
+  line: 1
+nodes:
+- path:
+  - a
+  file: app.yml
+  start:
+    line: 1
+    column: 1
+    offset: 0
+  end:
+    line: 2
+    column: 4
+    offset: 6
+- path:
+  - a
+  - 0
+  file: app.yml
+  start:
+    line: 2
+    column: 1
+    offset: 3
+  end:
+    line: 2
+    column: 4
+    offset: 6
+---
+file: app.yml
+start:
+  line: 3
+nodes:
+- path:
+  - b
+  file: app.yml
+  start:
+    line: 4
+    column: 1
+    offset: 11
+  end:
+    line: 4
+    column: 5
+    offset: 15
+`
+
+	out, err := printDocSetItems(docSet, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewFullFilePositionPrinter(w)
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestFullFilePositionPrinterLSP(t *testing.T) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte("a: 😀\n"), yamlmeta.DocSetOpts{AssociatedName: "app.yml"})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	expectedOutput := `file: app.yml
+range:
+  start:
+    line: 0
+    character: 0
+  end:
+    line: 1
+    character: 0
+nodes:
+- path:
+  - a
+  file: app.yml
+  range:
+    start:
+      line: 0
+      character: 0
+    end:
+      line: 0
+      character: 5
+`
+
+	out, err := printDocSetItems(docSet, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewFullFilePositionPrinterWithOpts(w, yamlmeta.FullFilePositionPrinterOpts{LSP: true})
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
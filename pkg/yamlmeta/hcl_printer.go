@@ -0,0 +1,240 @@
+package yamlmeta
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+var (
+	hclIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+const (
+	hclIndent = "  "
+)
+
+// HCLPrinter prints document (a map) as HCL body: maps become blocks
+// (eg 'owner { ... }'), other values become attributes (eg 'count = 3').
+// Arrays become tuples and maps within them become objects.
+// Maps with keys that are not identifiers are printed as objects as well
+// since such keys cannot be used as attribute names.
+type HCLPrinter struct {
+	buf         io.Writer
+	writtenOnce bool
+}
+
+var _ DocumentPrinter = &HCLPrinter{}
+
+func NewHCLPrinter(writer io.Writer) *HCLPrinter {
+	return &HCLPrinter{writer, false}
+}
+
+func (p *HCLPrinter) Print(item *Document) error {
+	if p.writtenOnce {
+		return fmt.Errorf("HCL output does not support multiple documents " +
+			"(use --output-directory to write documents from different files separately)")
+	}
+	p.writtenOnce = true
+
+	typedMap, ok := item.AsInterface().(*orderedmap.Map)
+	if !ok {
+		return fmt.Errorf("Expected document to be a map for HCL output, but was %T", item.AsInterface())
+	}
+
+	buf := new(bytes.Buffer)
+
+	err := p.printBody(buf, nil, typedMap)
+	if err != nil {
+		return fmt.Errorf("marshaling doc: %s", err)
+	}
+
+	p.buf.Write(buf.Bytes())
+	return nil
+}
+
+func (p *HCLPrinter) printBody(buf *bytes.Buffer, path []string, val *orderedmap.Map) error {
+	indent := strings.Repeat(hclIndent, len(path))
+
+	var written, prevBlock bool
+
+	return val.IterateErr(func(k, v interface{}) error {
+		key := p.keyStr(k)
+		subPath := append(append([]string{}, path...), key)
+
+		if !hclIdentifierRegexp.MatchString(key) {
+			return fmt.Errorf("HCL does not support attribute or block names that are not identifiers (key '%s')", p.pathStr(subPath))
+		}
+
+		block := p.isBlock(v)
+
+		// Separate blocks from surrounding items for readability
+		if written && (block || prevBlock) {
+			buf.WriteString("\n")
+		}
+		written, prevBlock = true, block
+
+		if block {
+			typedMap := v.(*orderedmap.Map)
+			if typedMap.Len() == 0 {
+				fmt.Fprintf(buf, "%s%s {}\n", indent, key)
+				return nil
+			}
+
+			fmt.Fprintf(buf, "%s%s {\n", indent, key)
+			err := p.printBody(buf, subPath, typedMap)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "%s}\n", indent)
+			return nil
+		}
+
+		valStr, err := p.valueStr(subPath, v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s%s = %s\n", indent, key, valStr)
+		return nil
+	})
+}
+
+func (p *HCLPrinter) isBlock(val interface{}) bool {
+	typedMap, ok := val.(*orderedmap.Map)
+	if !ok {
+		return false
+	}
+
+	isBlock := true
+
+	typedMap.Iterate(func(k, _ interface{}) {
+		if !hclIdentifierRegexp.MatchString(p.keyStr(k)) {
+			isBlock = false
+		}
+	})
+
+	return isBlock
+}
+
+func (p *HCLPrinter) valueStr(path []string, val interface{}) (string, error) {
+	switch typedVal := val.(type) {
+	case nil:
+		return "null", nil
+
+	case string:
+		return p.quotedStr(typedVal), nil
+
+	case bool:
+		return strconv.FormatBool(typedVal), nil
+
+	case int:
+		return strconv.FormatInt(int64(typedVal), 10), nil
+
+	case int64:
+		return strconv.FormatInt(typedVal, 10), nil
+
+	case uint64:
+		return strconv.FormatUint(typedVal, 10), nil
+
+	case float64:
+		if math.IsNaN(typedVal) || math.IsInf(typedVal, 0) {
+			return "", fmt.Errorf("HCL does not support NaN or infinite numbers (key '%s')", p.pathStr(path))
+		}
+		return strconv.FormatFloat(typedVal, 'g', -1, 64), nil
+
+	case *orderedmap.Map:
+		var result []string
+		err := typedVal.IterateErr(func(k, v interface{}) error {
+			key := p.keyStr(k)
+			valStr, err := p.valueStr(append(path, key), v)
+			if err != nil {
+				return err
+			}
+			result = append(result, p.objectKey(key)+" = "+valStr)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(result) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(result, ", ") + " }", nil
+
+	case []interface{}:
+		var result []string
+		for i, item := range typedVal {
+			valStr, err := p.valueStr(append(path, strconv.Itoa(i)), item)
+			if err != nil {
+				return "", err
+			}
+			result = append(result, valStr)
+		}
+		return "[" + strings.Join(result, ", ") + "]", nil
+
+	default:
+		return "", fmt.Errorf("Unsupported value type %T for HCL output (key '%s')", val, p.pathStr(path))
+	}
+}
+
+func (p *HCLPrinter) keyStr(key interface{}) string {
+	if typedKey, ok := key.(string); ok {
+		return typedKey
+	}
+	return fmt.Sprintf("%v", key)
+}
+
+func (p *HCLPrinter) objectKey(key string) string {
+	// Keywords are interpreted as values (not names) in object keys
+	if hclIdentifierRegexp.MatchString(key) && key != "null" && key != "true" && key != "false" {
+		return key
+	}
+	return p.quotedStr(key)
+}
+
+func (p *HCLPrinter) pathStr(path []string) string {
+	return strings.Join(path, ".")
+}
+
+func (p *HCLPrinter) quotedStr(val string) string {
+	var buf strings.Builder
+	buf.WriteString(`"`)
+
+	runes := []rune(val)
+
+	for i, r := range runes {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '$', '%':
+			// Escape template sequences ('${' and '%{') by doubling
+			buf.WriteRune(r)
+			if i+1 < len(runes) && runes[i+1] == '{' {
+				buf.WriteRune(r)
+			}
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteString(`"`)
+	return buf.String()
+}
@@ -0,0 +1,84 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestHCLPrinter(t *testing.T) {
+	data := `
+region: us-east-1
+count: 3
+ratio: 0.5
+enabled: true
+owner: null
+template: "${var} and %{if} $5"
+tags: [a, "b\"c\nd"]
+network:
+  cidr: 10.0.0.0/16
+  subnets:
+  - name: a
+    public: true
+  empty: {}
+labels:
+  "app.kubernetes.io/name": web
+  "true": 1
+after: 1
+`
+
+	expectedHCL := `region = "us-east-1"
+count = 3
+ratio = 0.5
+enabled = true
+owner = null
+template = "$${var} and %%{if} $5"
+tags = ["a", "b\"c\nd"]
+
+network {
+  cidr = "10.0.0.0/16"
+  subnets = [{ name = "a", public = true }]
+
+  empty {}
+}
+
+labels = { "app.kubernetes.io/name" = "web", "true" = 1 }
+after = 1
+`
+
+	out, err := printHCL(data)
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedHCL {
+		t.Fatalf("Expected HCL output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestHCLPrinterErrors(t *testing.T) {
+	examples := []struct {
+		Data        string
+		ExpectedErr string
+	}{
+		{"a:\n  b: .nan", "marshaling doc: HCL does not support NaN or infinite numbers (key 'a.b')"},
+		{"\"a b\": 1", "marshaling doc: HCL does not support attribute or block names that are not identifiers (key 'a b')"},
+		{"[1, 2]", "Expected document to be a map for HCL output, but was []interface {}"},
+		{"a: 1\n---\nb: 2", "HCL output does not support multiple documents " +
+			"(use --output-directory to write documents from different files separately)"},
+	}
+
+	for _, ex := range examples {
+		_, err := printHCL(ex.Data)
+		if err == nil {
+			t.Fatalf("Expected printing of '%s' to fail", ex.Data)
+		}
+		if err.Error() != ex.ExpectedErr {
+			t.Fatalf("Expected err for '%s', but was: %s", ex.Data, err)
+		}
+	}
+}
+
+func printHCL(data string) (string, error) {
+	return printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewHCLPrinter(w) })
+}
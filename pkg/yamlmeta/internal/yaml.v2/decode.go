@@ -31,6 +31,10 @@ type node struct {
 	implicit bool
 	children []*node
 	anchors  map[string]*node
+
+	// offset, endOffset are character (not byte) based indexes
+	offset                        int
+	endLine, endColumn, endOffset int
 }
 
 // ----------------------------------------------------------------------------
@@ -166,6 +170,27 @@ func (p *parser) node(kind int) *node {
 		kind:   kind,
 		line:   p.event.start_mark.line,
 		column: p.event.start_mark.column,
+		offset: p.event.start_mark.index,
+	}
+}
+
+func (p *parser) setEnd(n *node, mark yaml_mark_t) {
+	n.endLine = mark.line
+	n.endColumn = mark.column
+	n.endOffset = mark.index
+}
+
+// setEndFromChildren uses end of last child since end events
+// of block collections are positioned at the following token
+// (flow collections end at their closing bracket)
+func (p *parser) setEndFromChildren(n *node, flow bool) {
+	if len(n.children) > 0 && !flow {
+		lastChild := n.children[len(n.children)-1]
+		n.endLine = lastChild.endLine
+		n.endColumn = lastChild.endColumn
+		n.endOffset = lastChild.endOffset
+	} else {
+		p.setEnd(n, p.event.end_mark)
 	}
 }
 
@@ -186,6 +211,7 @@ func (p *parser) alias() *node {
 	if n.alias == nil {
 		failf("unknown anchor '%s' referenced", n.value)
 	}
+	p.setEnd(n, p.event.end_mark)
 	p.expect(yaml_ALIAS_EVENT)
 	return n
 }
@@ -196,6 +222,7 @@ func (p *parser) scalar() *node {
 	n.tag = string(p.event.tag)
 	n.implicit = p.event.implicit
 	p.anchor(n, p.event.anchor)
+	p.setEnd(n, p.event.end_mark)
 	p.expect(yaml_SCALAR_EVENT)
 	return n
 }
@@ -203,6 +230,7 @@ func (p *parser) scalar() *node {
 func (p *parser) sequence() *node {
 	n := p.node(sequenceNode)
 	p.anchor(n, p.event.anchor)
+	flow := p.event.sequence_style() == yaml_FLOW_SEQUENCE_STYLE
 	p.expect(yaml_SEQUENCE_START_EVENT)
 	for p.peek() != yaml_SEQUENCE_END_EVENT {
 		if p.parser.pendingSeqItemEvent != nil {
@@ -210,11 +238,13 @@ func (p *parser) sequence() *node {
 				kind:   sequenceItemNode,
 				line:   p.parser.pendingSeqItemEvent.start_mark.line,
 				column: p.parser.pendingSeqItemEvent.start_mark.column,
+				offset: p.parser.pendingSeqItemEvent.start_mark.index,
 			})
 			p.parser.pendingSeqItemEvent = nil
 		}
 		n.children = append(n.children, p.parse())
 	}
+	p.setEndFromChildren(n, flow)
 	p.expect(yaml_SEQUENCE_END_EVENT)
 	return n
 }
@@ -222,10 +252,12 @@ func (p *parser) sequence() *node {
 func (p *parser) mapping() *node {
 	n := p.node(mappingNode)
 	p.anchor(n, p.event.anchor)
+	flow := p.event.mapping_style() == yaml_FLOW_MAPPING_STYLE
 	p.expect(yaml_MAPPING_START_EVENT)
 	for p.peek() != yaml_MAPPING_END_EVENT {
 		n.children = append(n.children, p.parse())
 	}
+	p.setEndFromChildren(n, flow)
 	p.expect(yaml_MAPPING_END_EVENT)
 	return n
 }
@@ -544,6 +576,16 @@ func (d *decoder) scalar(n *node, out reflect.Value) bool {
 	return false
 }
 
+func newExtent(start, end *node) Extent {
+	return Extent{
+		Column:    start.column,
+		Offset:    start.offset,
+		EndLine:   end.endLine,
+		EndColumn: end.endColumn,
+		EndOffset: end.endOffset,
+	}
+}
+
 func settableValueOf(i interface{}) reflect.Value {
 	v := reflect.ValueOf(i)
 	sv := reflect.New(v.Type()).Elem()
@@ -554,16 +596,16 @@ func settableValueOf(i interface{}) reflect.Value {
 func (d *decoder) sequence(n *node, out reflect.Value) (good bool) {
 	// If aliased content contains sequence this function will be called multiple times
 	childrenWithoutPosNodes := []*node{}
-	lineNums := []int{}
+	posNodes := []*node{}
 	for _, child := range n.children {
 		if child.kind == sequenceItemNode {
-			lineNums = append(lineNums, child.line)
+			posNodes = append(posNodes, child)
 			continue
 		}
 		childrenWithoutPosNodes = append(childrenWithoutPosNodes, child)
 	}
-	if len(childrenWithoutPosNodes) != len(lineNums) {
-		panic(fmt.Sprintf("expected len of sequence children to match len of children line nums: %d != %d", len(childrenWithoutPosNodes), len(lineNums)))
+	if len(childrenWithoutPosNodes) != len(posNodes) {
+		panic(fmt.Sprintf("expected len of sequence children to match len of children line nums: %d != %d", len(childrenWithoutPosNodes), len(posNodes)))
 	}
 
 	l := len(childrenWithoutPosNodes)
@@ -589,7 +631,11 @@ func (d *decoder) sequence(n *node, out reflect.Value) (good bool) {
 	for i := 0; i < l; i++ {
 		e := reflect.New(et).Elem()
 		if ok := d.unmarshal(childrenWithoutPosNodes[i], e); ok {
-			eItem := reflect.ValueOf(ArrayItem{Value: e.Interface(), Line: lineNums[i]})
+			eItem := reflect.ValueOf(ArrayItem{
+				Value:  e.Interface(),
+				Line:   posNodes[i].line,
+				Extent: newExtent(posNodes[i], childrenWithoutPosNodes[i]),
+			})
 			out.Index(j).Set(eItem)
 			j++
 		}
@@ -690,7 +736,7 @@ func (d *decoder) mappingSlice(n *node, out reflect.Value) (good bool) {
 			d.merge(n.children[i+1], out)
 			continue
 		}
-		item := MapItem{Line: n.children[i].line}
+		item := MapItem{Line: n.children[i].line, Extent: newExtent(n.children[i], n.children[i+1])}
 		k := reflect.ValueOf(&item.Key).Elem()
 		if d.unmarshal(n.children[i], k) {
 			v := reflect.ValueOf(&item.Value).Elem()
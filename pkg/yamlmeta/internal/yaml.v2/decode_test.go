@@ -569,7 +569,7 @@ var unmarshalTests = []struct {
 	// Ordered maps.
 	{
 		"{b: 2, a: 1, d: 4, c: 3, sub: {e: 5}}",
-		&yaml.MapSlice{{"b", 2, 0}, {"a", 1, 0}, {"d", 4, 0}, {"c", 3, 0}, {"sub", yaml.MapSlice{{"e", 5, 0}}, 0}},
+		&yaml.MapSlice{{Key: "b", Value: 2}, {Key: "a", Value: 1}, {Key: "d", Value: 4}, {Key: "c", Value: 3}, {Key: "sub", Value: yaml.MapSlice{{Key: "e", Value: 5}}}},
 	},
 
 	// Issue #39.
@@ -878,7 +878,10 @@ var unmarshalerTests = []struct {
 	value     interface{}
 }{
 	{"_: {hi: there}", "!!map", map[interface{}]interface{}{"hi": "there"}},
-	{"_: [1,A]", "!!seq", []interface{}{yaml.ArrayItem{1, 0}, yaml.ArrayItem{"A", 0}}},
+	{"_: [1,A]", "!!seq", []interface{}{
+		yaml.ArrayItem{Value: 1, Extent: yaml.Extent{Column: 3, Offset: 3, EndColumn: 5, EndOffset: 5}},
+		yaml.ArrayItem{Value: "A", Extent: yaml.Extent{Column: 5, Offset: 5, EndColumn: 7, EndOffset: 7}},
+	}},
 	{"_: 10", "!!int", 10},
 	{"_: null", "!!null", nil},
 	{`_: BAR!`, "!!str", "BAR!"},
@@ -814,15 +814,19 @@ func yaml_emitter_select_scalar_style(emitter *yaml_emitter_t, event *yaml_event
 	}
 
 	if style == yaml_PLAIN_SCALAR_STYLE {
+		quoted_style := yaml_SINGLE_QUOTED_SCALAR_STYLE
+		if emitter.prefer_double_quoted {
+			quoted_style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+		}
 		if emitter.flow_level > 0 && !emitter.scalar_data.flow_plain_allowed ||
 			emitter.flow_level == 0 && !emitter.scalar_data.block_plain_allowed {
-			style = yaml_SINGLE_QUOTED_SCALAR_STYLE
+			style = quoted_style
 		}
 		if len(emitter.scalar_data.value) == 0 && (emitter.flow_level > 0 || emitter.simple_key_context) {
-			style = yaml_SINGLE_QUOTED_SCALAR_STYLE
+			style = quoted_style
 		}
 		if no_tag && !event.implicit {
-			style = yaml_SINGLE_QUOTED_SCALAR_STYLE
+			style = quoted_style
 		}
 	}
 	if style == yaml_SINGLE_QUOTED_SCALAR_STYLE {
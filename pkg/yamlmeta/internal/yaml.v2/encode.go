@@ -31,6 +31,9 @@ type encoder struct {
 	event   yaml_event_t
 	out     []byte
 	flow    bool
+	opts    MarshalOpts
+	// key holds whether map key is being marshaled
+	key bool
 	// doneInit holds whether the initial stream_start_event has been
 	// emitted.
 	doneInit bool
@@ -189,22 +192,47 @@ func (e *encoder) mapv(tag string, in reflect.Value) {
 		keys := keyList(in.MapKeys())
 		sort.Sort(keys)
 		for _, k := range keys {
-			e.marshal("", k)
+			e.marshalKey(k)
 			e.marshal("", in.MapIndex(k))
 		}
 	})
 }
 
 func (e *encoder) itemsv(tag string, in reflect.Value) {
+	slice := in.Convert(reflect.TypeOf([]MapItem{})).Interface().([]MapItem)
+	if e.opts.FlowStyle == FlowLeaf {
+		var values []interface{}
+		for _, item := range slice {
+			values = append(values, item.Value)
+		}
+		e.flow = e.flow || isLeafCollection(values)
+	}
 	e.mappingv(tag, func() {
-		slice := in.Convert(reflect.TypeOf([]MapItem{})).Interface().([]MapItem)
 		for _, item := range slice {
-			e.marshal("", reflect.ValueOf(item.Key))
+			e.marshalKey(reflect.ValueOf(item.Key))
 			e.marshal("", reflect.ValueOf(item.Value))
 		}
 	})
 }
 
+func (e *encoder) marshalKey(in reflect.Value) {
+	e.key = true
+	defer func() { e.key = false }()
+	e.marshal("", in)
+}
+
+// isLeafCollection returns whether values are all scalars
+// (empty collections are always printed in flow style)
+func isLeafCollection(values []interface{}) bool {
+	for _, val := range values {
+		switch reflect.ValueOf(val).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return false
+		}
+	}
+	return len(values) > 0
+}
+
 func (e *encoder) structv(tag string, in reflect.Value) {
 	sinfo, err := getStructInfo(in.Type())
 	if err != nil {
@@ -247,7 +275,7 @@ func (e *encoder) structv(tag string, in reflect.Value) {
 func (e *encoder) mappingv(tag string, f func()) {
 	implicit := tag == ""
 	style := yaml_BLOCK_MAPPING_STYLE
-	if e.flow {
+	if e.flow || e.opts.FlowStyle == FlowAlways {
 		e.flow = false
 		style = yaml_FLOW_MAPPING_STYLE
 	}
@@ -261,7 +289,14 @@ func (e *encoder) mappingv(tag string, f func()) {
 func (e *encoder) slicev(tag string, in reflect.Value) {
 	implicit := tag == ""
 	style := yaml_BLOCK_SEQUENCE_STYLE
-	if e.flow {
+	if e.opts.FlowStyle == FlowLeaf {
+		var values []interface{}
+		for i := 0; i < in.Len(); i++ {
+			values = append(values, in.Index(i).Interface())
+		}
+		e.flow = e.flow || isLeafCollection(values)
+	}
+	if e.flow || e.opts.FlowStyle == FlowAlways {
 		e.flow = false
 		style = yaml_FLOW_SEQUENCE_STYLE
 	}
@@ -326,8 +361,10 @@ func (e *encoder) stringv(tag string, in reflect.Value) {
 	switch {
 	case strings.Contains(s, "\n"):
 		style = yaml_LITERAL_SCALAR_STYLE
-	case canUsePlain:
+	case canUsePlain && (e.opts.QuoteStyle == QuoteMinimal || e.key):
 		style = yaml_PLAIN_SCALAR_STYLE
+	case e.opts.QuoteStyle == QuoteSingle:
+		style = yaml_SINGLE_QUOTED_SCALAR_STYLE
 	default:
 		style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
 	}
@@ -335,7 +335,7 @@ var marshalTests = []struct {
 
 	// Ordered maps.
 	{
-		&yaml.MapSlice{{"b", 2, 0}, {"a", 1, 0}, {"d", 4, 0}, {"c", 3, 0}, {"sub", yaml.MapSlice{{"e", 5, 0}}, 0}},
+		&yaml.MapSlice{{Key: "b", Value: 2}, {Key: "a", Value: 1}, {Key: "d", Value: 4}, {Key: "c", Value: 3}, {Key: "sub", Value: yaml.MapSlice{{Key: "e", Value: 5}}}},
 		"b: 2\na: 1\nd: 4\nc: 3\nsub:\n  e: 5\n",
 	},
 
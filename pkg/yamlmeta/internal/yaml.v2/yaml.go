@@ -23,11 +23,23 @@ type MapSlice []MapItem
 type MapItem struct {
 	Key, Value interface{}
 	Line       int
+	Extent     Extent
 }
 
 type ArrayItem struct {
-	Value interface{}
-	Line  int
+	Value  interface{}
+	Line   int
+	Extent Extent
+}
+
+// Extent describes where item starts and ends. Lines and columns are 0 based;
+// offsets are character based indexes from the beginning of the input.
+type Extent struct {
+	Column    int
+	Offset    int
+	EndLine   int
+	EndColumn int
+	EndOffset int
 }
 
 // The Unmarshaler interface may be implemented by types to customize their
@@ -262,6 +274,62 @@ func Marshal(in interface{}) (out []byte, err error) {
 	return
 }
 
+// QuoteStyle controls quoting of strings by MarshalWithOpts
+type QuoteStyle string
+
+const (
+	// QuoteMinimal only quotes strings that cannot be plain (with double quotes)
+	QuoteMinimal QuoteStyle = ""
+	// QuoteSingle quotes all string values with single quotes
+	// (double quotes are used if string contains non-printable characters)
+	QuoteSingle QuoteStyle = "single"
+	// QuoteDouble quotes all string values with double quotes
+	QuoteDouble QuoteStyle = "double"
+)
+
+// FlowStyle controls usage of flow style for maps and sequences by MarshalWithOpts
+type FlowStyle string
+
+const (
+	// FlowNever uses block style for all non-empty maps and sequences
+	FlowNever FlowStyle = ""
+	// FlowAlways uses flow style for all maps and sequences
+	FlowAlways FlowStyle = "always"
+	// FlowLeaf uses flow style for maps and sequences that only contain scalars
+	FlowLeaf FlowStyle = "leaf"
+)
+
+// MarshalOpts controls formatting of MarshalWithOpts;
+// zero value produces same output as Marshal.
+type MarshalOpts struct {
+	// Width is preferred line width for folding long scalars (negative
+	// disables folding; values not greater than twice the indentation
+	// use default of 80)
+	Width int
+	// Indent is number of spaces per indentation level
+	// (values outside of 2 to 9 use default of 2)
+	Indent int
+	// QuoteStyle applies to string values; map keys are
+	// only quoted when necessary (with preferred quotes)
+	QuoteStyle QuoteStyle
+	FlowStyle  FlowStyle
+}
+
+// MarshalWithOpts is same as Marshal, but formats output based on given options
+func MarshalWithOpts(in interface{}, opts MarshalOpts) (out []byte, err error) {
+	defer handleErr(&err)
+	e := newEncoder()
+	defer e.destroy()
+	e.opts = opts
+	yaml_emitter_set_width(&e.emitter, opts.Width)
+	yaml_emitter_set_indent(&e.emitter, opts.Indent)
+	e.emitter.prefer_double_quoted = opts.QuoteStyle == QuoteDouble
+	e.marshalDoc("", reflect.ValueOf(in))
+	e.finish()
+	out = e.out
+	return
+}
+
 // An Encoder writes YAML values to an output stream.
 type Encoder struct {
 	encoder *encoder
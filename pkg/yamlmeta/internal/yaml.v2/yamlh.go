@@ -682,6 +682,8 @@ type yaml_emitter_t struct {
 	unicode     bool         // Allow unescaped non-ASCII characters?
 	line_break  yaml_break_t // The preferred line break.
 
+	prefer_double_quoted bool // Use double quotes for scalars that cannot be plain?
+
 	state  yaml_emitter_state_t   // The current emitter state.
 	states []yaml_emitter_state_t // The stack of states.
 
@@ -0,0 +1,25 @@
+package yamlmeta
+
+import (
+	"github.com/k14s/ytt/pkg/filepos"
+)
+
+// NewArrayDocSet wraps values of all documents into a single document
+// holding an array (eg for APIs that accept JSON array of objects).
+// Null documents are skipped unless includeNulls is set.
+// Ordering of documents is preserved as ordering of array items.
+func NewArrayDocSet(docSet *DocumentSet, includeNulls bool) *DocumentSet {
+	items := &Array{Position: filepos.NewUnknownPosition()}
+
+	for _, doc := range docSet.Items {
+		if doc.Value == nil && !includeNulls {
+			continue
+		}
+		items.Items = append(items.Items, &ArrayItem{Value: doc.Value, Position: doc.Position})
+	}
+
+	return &DocumentSet{
+		Items:    []*Document{{Value: items, Position: filepos.NewUnknownPosition()}},
+		Position: docSet.Position,
+	}
+}
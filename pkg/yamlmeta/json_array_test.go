@@ -0,0 +1,49 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestNewArrayDocSet(t *testing.T) {
+	data := `
+a: 1
+---
+---
+- b
+`
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	examples := []struct {
+		IncludeNulls bool
+		Expected     string
+	}{
+		{false, `[{"a":1},["b"]]`},
+		{true, `[{"a":1},null,["b"]]`},
+	}
+
+	for _, ex := range examples {
+		out, err := printDocSetItems(yamlmeta.NewArrayDocSet(docSet, ex.IncludeNulls), func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.NewJSONPrinter(w)
+		})
+		if err != nil {
+			t.Fatalf("Expected printing to succeed: %s", err)
+		}
+		if out != ex.Expected {
+			t.Fatalf("Expected output with nulls %t to match, but was: >>>%s<<<", ex.IncludeNulls, out)
+		}
+	}
+
+	out, err := printDocSetItems(yamlmeta.NewArrayDocSet(&yamlmeta.DocumentSet{}, false), func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewJSONPrinter(w)
+	})
+	if err != nil || out != "[]" {
+		t.Fatalf("Expected empty array, but was: >>>%s<<< (%v)", out, err)
+	}
+}
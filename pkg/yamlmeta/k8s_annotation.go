@@ -0,0 +1,60 @@
+package yamlmeta
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/filepos"
+)
+
+// SetK8sAnnotation sets metadata.annotations key of a Kubernetes
+// resource document (annotations map is created if necessary).
+// Returns false without modifying document if its root is not a map
+// or it does not have metadata map.
+func SetK8sAnnotation(doc *Document, key, value string) (bool, error) {
+	rootMap, ok := doc.Value.(*Map)
+	if !ok {
+		return false, nil
+	}
+
+	metadataItem := findMapItem(rootMap, "metadata")
+	if metadataItem == nil {
+		return false, nil
+	}
+
+	metadataMap, ok := metadataItem.Value.(*Map)
+	if !ok {
+		return false, nil
+	}
+
+	annsItem := findMapItem(metadataMap, "annotations")
+	if annsItem == nil {
+		annsItem = &MapItem{Key: "annotations", Position: filepos.NewUnknownPosition()}
+		metadataMap.Items = append(metadataMap.Items, annsItem)
+	}
+
+	if annsItem.Value == nil {
+		annsItem.Value = &Map{Position: filepos.NewUnknownPosition()}
+	}
+
+	annsMap, ok := annsItem.Value.(*Map)
+	if !ok {
+		return false, fmt.Errorf("Expected metadata.annotations to be a map, but was %T", annsItem.Value)
+	}
+
+	if annItem := findMapItem(annsMap, key); annItem != nil {
+		annItem.Value = value
+	} else {
+		annsMap.Items = append(annsMap.Items, &MapItem{Key: key, Value: value, Position: filepos.NewUnknownPosition()})
+	}
+
+	return true, nil
+}
+
+func findMapItem(typedMap *Map, key string) *MapItem {
+	for _, item := range typedMap.Items {
+		if item.Key == key {
+			return item
+		}
+	}
+	return nil
+}
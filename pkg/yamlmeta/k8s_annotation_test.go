@@ -0,0 +1,91 @@
+package yamlmeta_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestSetK8sAnnotation(t *testing.T) {
+	data := `
+kind: ConfigMap
+metadata:
+  name: a
+---
+kind: ConfigMap
+metadata:
+  name: b
+  annotations:
+    other: x
+    ytt.io/test: old
+---
+kind: ConfigMap
+metadata:
+  annotations: null
+---
+kind: NoMetadata
+---
+- array
+`
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	expectedSet := []bool{true, true, true, false, false}
+
+	for i, doc := range docSet.Items {
+		set, err := yamlmeta.SetK8sAnnotation(doc, "ytt.io/test", "new")
+		if err != nil {
+			t.Fatalf("Expected setting annotation to succeed: %s", err)
+		}
+		if set != expectedSet[i] {
+			t.Fatalf("Expected document %d annotation to be set: %t", i, expectedSet[i])
+		}
+	}
+
+	out, err := docSet.AsBytes()
+	if err != nil {
+		t.Fatalf("Expected marshaling to succeed: %s", err)
+	}
+
+	expectedOut := `kind: ConfigMap
+metadata:
+  name: a
+  annotations:
+    ytt.io/test: new
+---
+kind: ConfigMap
+metadata:
+  name: b
+  annotations:
+    other: x
+    ytt.io/test: new
+---
+kind: ConfigMap
+metadata:
+  annotations:
+    ytt.io/test: new
+---
+kind: NoMetadata
+---
+- array
+`
+	if string(out) != expectedOut {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestSetK8sAnnotationInvalid(t *testing.T) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte("metadata:\n  annotations: [a]\n"), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	_, err = yamlmeta.SetK8sAnnotation(docSet.Items[0], "ytt.io/test", "new")
+	if err == nil || !strings.Contains(err.Error(), "Expected metadata.annotations to be a map") {
+		t.Fatalf("Expected non-map annotations to fail, but was: %v", err)
+	}
+}
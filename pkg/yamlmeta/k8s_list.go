@@ -0,0 +1,33 @@
+package yamlmeta
+
+import (
+	"github.com/k14s/ytt/pkg/filepos"
+)
+
+// NewK8sListDocSet wraps values of all documents into a single
+// Kubernetes List document (null documents are skipped).
+// Ordering of documents is preserved as ordering of list items.
+func NewK8sListDocSet(docSet *DocumentSet) *DocumentSet {
+	items := &Array{Position: filepos.NewUnknownPosition()}
+
+	for _, doc := range docSet.Items {
+		if doc.Value == nil {
+			continue
+		}
+		items.Items = append(items.Items, &ArrayItem{Value: doc.Value, Position: doc.Position})
+	}
+
+	listMap := &Map{
+		Items: []*MapItem{
+			{Key: "apiVersion", Value: "v1", Position: filepos.NewUnknownPosition()},
+			{Key: "kind", Value: "List", Position: filepos.NewUnknownPosition()},
+			{Key: "items", Value: items, Position: filepos.NewUnknownPosition()},
+		},
+		Position: filepos.NewUnknownPosition(),
+	}
+
+	return &DocumentSet{
+		Items:    []*Document{{Value: listMap, Position: filepos.NewUnknownPosition()}},
+		Position: docSet.Position,
+	}
+}
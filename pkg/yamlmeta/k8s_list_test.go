@@ -0,0 +1,57 @@
+package yamlmeta_test
+
+import (
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestNewK8sListDocSet(t *testing.T) {
+	data := `
+kind: ConfigMap
+metadata:
+  name: b
+---
+---
+kind: Service
+metadata:
+  name: a
+---
+- not-a-resource
+`
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	resultBs, err := yamlmeta.NewK8sListDocSet(docSet).AsBytes()
+	if err != nil {
+		t.Fatalf("Expected marshaling to succeed: %s", err)
+	}
+
+	expectedOutput := `apiVersion: v1
+kind: List
+items:
+- kind: ConfigMap
+  metadata:
+    name: b
+- kind: Service
+  metadata:
+    name: a
+- - not-a-resource
+`
+
+	if string(resultBs) != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", resultBs)
+	}
+
+	resultBs, err = yamlmeta.NewK8sListDocSet(&yamlmeta.DocumentSet{}).AsBytes()
+	if err != nil {
+		t.Fatalf("Expected marshaling to succeed: %s", err)
+	}
+
+	if string(resultBs) != "apiVersion: v1\nkind: List\nitems: []\n" {
+		t.Fatalf("Expected empty list, but was: >>>%s<<<", resultBs)
+	}
+}
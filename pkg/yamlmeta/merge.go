@@ -0,0 +1,82 @@
+package yamlmeta
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/filepos"
+)
+
+type ArrayMergeStrategy string
+
+const (
+	ArrayMergeReplace ArrayMergeStrategy = "replace"
+	ArrayMergeAppend  ArrayMergeStrategy = "append"
+)
+
+func ParseArrayMergeStrategy(name string) (ArrayMergeStrategy, error) {
+	switch ArrayMergeStrategy(name) {
+	case ArrayMergeReplace, ArrayMergeAppend:
+		return ArrayMergeStrategy(name), nil
+	default:
+		return "", fmt.Errorf("Unknown array merge strategy '%s' (expected replace or append)", name)
+	}
+}
+
+// MergeDocuments deep merges values of given documents into a single
+// document; later documents win on conflicts: maps are merged recursively,
+// arrays are replaced or appended (based on strategy) and other values
+// (including values of different types) are replaced. Null documents are skipped.
+// Given documents are modified in place.
+func MergeDocuments(docs []*Document, arrays ArrayMergeStrategy) *Document {
+	result := &Document{Position: filepos.NewUnknownPosition()}
+
+	for _, doc := range docs {
+		if doc.Value == nil {
+			continue
+		}
+		if result.Value == nil {
+			result.Value = doc.Value
+			result.Position = doc.Position
+			continue
+		}
+		result.Value = mergeValues(result.Value, doc.Value, arrays)
+	}
+
+	return result
+}
+
+func mergeValues(left, right interface{}, arrays ArrayMergeStrategy) interface{} {
+	switch typedRight := right.(type) {
+	case *Map:
+		typedLeft, ok := left.(*Map)
+		if !ok {
+			return right
+		}
+
+		for _, rightItem := range typedRight.Items {
+			var found bool
+			for _, leftItem := range typedLeft.Items {
+				if leftItem.Key == rightItem.Key {
+					leftItem.Value = mergeValues(leftItem.Value, rightItem.Value, arrays)
+					found = true
+					break
+				}
+			}
+			if !found {
+				typedLeft.Items = append(typedLeft.Items, rightItem)
+			}
+		}
+		return typedLeft
+
+	case *Array:
+		typedLeft, ok := left.(*Array)
+		if !ok || arrays != ArrayMergeAppend {
+			return right
+		}
+		typedLeft.Items = append(typedLeft.Items, typedRight.Items...)
+		return typedLeft
+
+	default:
+		return right
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+	"unicode/utf16"
 
 	"github.com/k14s/ytt/pkg/filepos"
 	"github.com/k14s/ytt/pkg/yamlmeta/internal/yaml.v2"
@@ -19,6 +21,9 @@ var (
 
 	// eg "yaml: line 2: found character that cannot start any token"
 	lineErrRegexp = regexp.MustCompile(`^(?P<prefix>yaml: line )(?P<num>\d+)(?P<suffix>: .+)$`)
+
+	// eg "key: |", "- >-" or "key: |2 # comment"
+	blockScalarIndicatorRegexp = regexp.MustCompile(`(^|\s)[|>]([1-9]?)[+-]?([1-9]?)\s*(#.*)?$`)
 )
 
 type ParserOpts struct {
@@ -29,14 +34,32 @@ type ParserOpts struct {
 type Parser struct {
 	opts           ParserOpts
 	associatedName string
+
+	// byteOffsets maps character offsets to byte offsets
+	// (YAML library counts characters, not bytes)
+	byteOffsets      []int
+	offsetCorrection int
+
+	// data is original (uncorrected) input used for UTF-16 columns
+	data []byte
 }
 
 func NewParser(opts ParserOpts) *Parser {
-	return &Parser{opts, ""}
+	return &Parser{opts: opts}
 }
 
 func (p *Parser) ParseBytes(data []byte, associatedName string) (*DocumentSet, error) {
 	p.associatedName = associatedName
+	p.byteOffsets = p.buildByteOffsets(data)
+	p.offsetCorrection = 0
+	p.data = data
+
+	if p.opts.Strict {
+		err := p.checkStrictIndentation(data)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// YAML library uses 0 based line numbers for nodes
 	// so by default move the numbering by one
@@ -53,6 +76,7 @@ func (p *Parser) ParseBytes(data []byte, associatedName string) (*DocumentSet, e
 		// For errors though, we do need to correct
 		errLineCorrection = -1
 		data = append([]byte("---\n"), data...)
+		p.offsetCorrection = -len("---\n")
 	}
 
 	docSet, err := p.parseBytes(data, nodeLineCorrection)
@@ -67,9 +91,115 @@ func (p *Parser) ParseBytes(data []byte, associatedName string) (*DocumentSet, e
 		docSet.Items[0].Position = filepos.NewPosition(1)
 	}
 
+	if p.opts.Strict {
+		for _, doc := range docSet.Items {
+			err := p.checkStrictNode(doc.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return docSet, nil
 }
 
+// checkStrictIndentation disallows tabs within indentation since
+// YAML only allows spaces (tabs end up being confusingly treated as content).
+// Lines within block scalars (eg '|') are content, hence may start with tabs
+// (eg embedded Makefile) as long as they are indented past block's indentation.
+func (p *Parser) checkStrictIndentation(data []byte) error {
+	// Indentation of line that starts block scalar (-1 if not within one)
+	blockIndent := -1
+	// Indentation of block scalar content (0 until first non-empty line)
+	contentIndent := 0
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		spaces := len(line) - len(strings.TrimLeft(line, " "))
+		content := strings.TrimLeft(line, " \t")
+
+		if len(content) == 0 {
+			continue // empty lines do not affect indentation
+		}
+
+		if blockIndent >= 0 {
+			if contentIndent == 0 && spaces > blockIndent {
+				contentIndent = spaces
+			}
+			if contentIndent > 0 && spaces >= contentIndent {
+				continue
+			}
+			blockIndent = -1
+			contentIndent = 0
+		}
+
+		indent := line[:len(line)-len(content)]
+		if strings.Contains(indent, "\t") {
+			return fmt.Errorf("Strict parsing: Found tab character in indentation at %s", p.strictPosStr(p.newPosition(i+1, 0)))
+		}
+
+		if strings.HasPrefix(content, "#") {
+			continue
+		}
+
+		if match := blockScalarIndicatorRegexp.FindStringSubmatch(line); match != nil {
+			blockIndent = spaces
+			// Explicit indentation indicator (eg '|2') may come before or after chomping indicator
+			if explicitIndent := match[2] + match[3]; len(explicitIndent) > 0 {
+				num, _ := strconv.Atoi(explicitIndent[:1])
+				contentIndent = spaces + num
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Parser) checkStrictNode(val interface{}) error {
+	switch typedVal := val.(type) {
+	case *Map:
+		seenItems := map[string]*MapItem{}
+
+		for _, item := range typedVal.Items {
+			key, ok := item.Key.(string)
+			if !ok {
+				return fmt.Errorf("Strict parsing: Expected map key to be a string, but was %T (key '%v' at %s)",
+					item.Key, item.Key, p.strictPosStr(item.Position))
+			}
+
+			// Templates may legitimately specify same key multiple times
+			// (eg within if/else branches), hence only consider plain items
+			if prevItem, found := seenItems[key]; found && len(prevItem.Metas) == 0 && len(item.Metas) == 0 {
+				return fmt.Errorf("Strict parsing: Found duplicate map key '%s' (at %s and %s)",
+					key, p.strictPosStr(prevItem.Position), p.strictPosStr(item.Position))
+			}
+			seenItems[key] = item
+
+			err := p.checkStrictNode(item.Value)
+			if err != nil {
+				return err
+			}
+		}
+
+	case *Array:
+		for _, item := range typedVal.Items {
+			err := p.checkStrictNode(item.Value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Parser) strictPosStr(pos *filepos.Position) string {
+	if len(p.associatedName) == 0 {
+		return pos.AsString()
+	}
+	return pos.AsCompactString()
+}
+
 func (p *Parser) parseBytes(data []byte, lineCorrection int) (*DocumentSet, error) {
 	docSet := &DocumentSet{Position: filepos.NewUnknownPosition()}
 
@@ -127,7 +257,7 @@ func (p *Parser) parse(val interface{}, lineCorrection int) interface{} {
 			result.Items = append(result.Items, &MapItem{
 				Key:      item.Key,
 				Value:    p.parse(item.Value, lineCorrection),
-				Position: p.newPosition(item.Line, lineCorrection),
+				Position: p.newPositionWithExtent(item.Line, lineCorrection, item.Extent),
 			})
 		}
 		return result
@@ -142,7 +272,7 @@ func (p *Parser) parse(val interface{}, lineCorrection int) interface{} {
 			if typedItem, ok := item.(yaml.ArrayItem); ok {
 				result.Items = append(result.Items, &ArrayItem{
 					Value:    p.parse(typedItem.Value, lineCorrection),
-					Position: p.newPosition(typedItem.Line, lineCorrection),
+					Position: p.newPositionWithExtent(typedItem.Line, lineCorrection, typedItem.Extent),
 				})
 			} else {
 				panic("unknown item")
@@ -252,6 +382,60 @@ func (p *Parser) newPosition(actualLine, correction int) *filepos.Position {
 	return pos
 }
 
+func (p *Parser) newPositionWithExtent(actualLine, correction int, extent yaml.Extent) *filepos.Position {
+	offset := p.byteOffset(extent.Offset)
+	endOffset := p.byteOffset(extent.EndOffset)
+
+	pos := p.newPosition(actualLine, correction)
+	pos.SetExtent(filepos.Extent{
+		Column:    extent.Column + 1,
+		Offset:    offset,
+		EndLine:   extent.EndLine + correction,
+		EndColumn: extent.EndColumn + 1,
+		EndOffset: endOffset,
+
+		UTF16Column:    p.utf16Column(offset),
+		EndUTF16Column: p.utf16Column(endOffset),
+	})
+	return pos
+}
+
+// utf16Column returns 1 based column (in UTF-16 code units) of byte offset
+func (p *Parser) utf16Column(byteOffset int) int {
+	if byteOffset > len(p.data) {
+		byteOffset = len(p.data)
+	}
+
+	lineStart := bytes.LastIndexByte(p.data[:byteOffset], '\n') + 1
+	column := 1
+
+	for _, r := range string(p.data[lineStart:byteOffset]) {
+		column += len(utf16.Encode([]rune{r}))
+	}
+
+	return column
+}
+
+func (p *Parser) byteOffset(charOffset int) int {
+	charOffset += p.offsetCorrection
+	switch {
+	case charOffset < 0:
+		return 0
+	case charOffset >= len(p.byteOffsets):
+		return p.byteOffsets[len(p.byteOffsets)-1]
+	default:
+		return p.byteOffsets[charOffset]
+	}
+}
+
+func (p *Parser) buildByteOffsets(data []byte) []int {
+	var result []int
+	for i := range string(data) {
+		result = append(result, i)
+	}
+	return append(result, len(data))
+}
+
 func (p *Parser) newUnknownPosition() *filepos.Position {
 	pos := filepos.NewUnknownPosition()
 	pos.SetFile(p.associatedName)
@@ -77,9 +77,61 @@ func TestParserStrict(t *testing.T) {
 			Data:        "prevval---key: value",
 			ExpectedErr: "yaml: Strict parsing: Strings with triple-dash must be explicitly quoted: 'prevval---key'",
 		},
+		{Description: "duplicate map keys",
+			Data:        "key: 1\nnested:\n  other: 2\n  other: 3",
+			ExpectedErr: "Strict parsing: Found duplicate map key 'other' (at line 3 and line 4)",
+		},
+		{Description: "duplicate map keys within templated branches",
+			Data:        "#@ if True:\nkey: 1\n#@ else:\nkey: 2\n#@ end\nother: 3",
+			ExpectedVal: map[string]interface{}{"key": 2, "other": 3},
+		},
+		{Description: "non-string map keys",
+			Data:        "key: 1\n123: 2",
+			ExpectedErr: "Strict parsing: Expected map key to be a string, but was int (key '123' at line 2)",
+		},
+		{Description: "tab indentation in block scalar",
+			Data:        "key: |\n  line1\n\tline2",
+			ExpectedErr: "Strict parsing: Found tab character in indentation at line 3",
+		},
+		{Description: "tab indentation in map",
+			Data:        "key: value\n\tother",
+			ExpectedErr: "Strict parsing: Found tab character in indentation at line 2",
+		},
+		{Description: "tab indentation after block scalar",
+			Data:        "key: |\n  line1\n \tother: 1",
+			ExpectedErr: "Strict parsing: Found tab character in indentation at line 3",
+		},
+		{Description: "tab within block scalar content",
+			Data: "data:\n  Makefile: |\n    all:\n    \techo hi\n\n    \t\techo bye\n  other: >-\n    a\tb\n    \tc",
+			ExpectedVal: map[string]interface{}{"data": map[string]interface{}{
+				"Makefile": "all:\n\techo hi\n\n\t\techo bye\n",
+				"other":    "a\tb\n\tc",
+			}},
+		},
+		{Description: "tab within block scalar content with indentation indicator",
+			Data:        "- |2 # comment\n   \tline1\n  \tline2",
+			ExpectedVal: []interface{}{" \tline1\n\tline2"},
+		},
 	}.Check(t)
 }
 
+func TestParserStrictDuplicateKeysWithFile(t *testing.T) {
+	_, err := yamlmeta.NewParser(yamlmeta.ParserOpts{Strict: true}).ParseBytes([]byte("---\na: 1\nb: 2\na: 3\n"), "config.yml")
+	if err == nil {
+		t.Fatalf("Expected parsing to fail")
+	}
+
+	expectedErr := "Strict parsing: Found duplicate map key 'a' (at config.yml:2 and config.yml:4)"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected err to match, but was: %s", err)
+	}
+
+	_, err = yamlmeta.NewParser(yamlmeta.ParserOpts{}).ParseBytes([]byte("a: 1\na: 3\n"), "config.yml")
+	if err != nil {
+		t.Fatalf("Expected non-strict parsing to succeed: %s", err)
+	}
+}
+
 type parserStrictExamples []parserStrictExample
 
 func (exs parserStrictExamples) Check(t *testing.T) {
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/k14s/ytt/pkg/filepos"
 	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/yamlmeta/internal/yaml.v2"
 )
 
 type DocumentPrinter interface {
@@ -14,13 +16,97 @@ type DocumentPrinter interface {
 
 type YAMLPrinter struct {
 	buf         io.Writer
+	opts        YAMLPrinterOpts
 	writtenOnce bool
 }
 
+type YAMLPrinterOpts struct {
+	// LineWidth is preferred line width after which long scalars
+	// are folded; zero uses default (80), negative disables folding
+	LineWidth int
+	// Indent is number of spaces per indentation level (2 to 9);
+	// zero uses default (2)
+	Indent     int
+	QuoteStyle YAMLQuoteStyle
+	FlowStyle  YAMLFlowStyle
+}
+
+// isDefault returns whether options produce same output as zero value
+func (o YAMLPrinterOpts) isDefault() bool {
+	return (o.LineWidth == 0 || o.LineWidth == 80) && (o.Indent == 0 || o.Indent == 2) &&
+		(o.QuoteStyle == "" || o.QuoteStyle == YAMLQuoteMinimal) &&
+		(o.FlowStyle == "" || o.FlowStyle == YAMLFlowBlock)
+}
+
+func (o YAMLPrinterOpts) asMarshalOpts() yaml.MarshalOpts {
+	opts := yaml.MarshalOpts{Width: o.LineWidth, Indent: o.Indent}
+
+	switch o.QuoteStyle {
+	case YAMLQuoteSingle:
+		opts.QuoteStyle = yaml.QuoteSingle
+	case YAMLQuoteDouble:
+		opts.QuoteStyle = yaml.QuoteDouble
+	}
+
+	switch o.FlowStyle {
+	case YAMLFlowAlways:
+		opts.FlowStyle = yaml.FlowAlways
+	case YAMLFlowLeaf:
+		opts.FlowStyle = yaml.FlowLeaf
+	}
+
+	return opts
+}
+
+type YAMLQuoteStyle string
+
+const (
+	// YAMLQuoteMinimal only quotes strings that would not be
+	// read back as strings otherwise (with double quotes)
+	YAMLQuoteMinimal YAMLQuoteStyle = "minimal"
+	// YAMLQuoteSingle and YAMLQuoteDouble quote all (single line) string
+	// values; map keys are only quoted when necessary with selected quotes
+	YAMLQuoteSingle YAMLQuoteStyle = "single"
+	YAMLQuoteDouble YAMLQuoteStyle = "double"
+)
+
+func ParseYAMLQuoteStyle(name string) (YAMLQuoteStyle, error) {
+	switch YAMLQuoteStyle(name) {
+	case YAMLQuoteMinimal, YAMLQuoteSingle, YAMLQuoteDouble:
+		return YAMLQuoteStyle(name), nil
+	default:
+		return "", fmt.Errorf("Unknown YAML quote style '%s' (expected minimal, single or double)", name)
+	}
+}
+
+type YAMLFlowStyle string
+
+const (
+	// YAMLFlowBlock prints non-empty maps and arrays in block style
+	YAMLFlowBlock YAMLFlowStyle = "block"
+	// YAMLFlowAlways prints whole documents in flow style (eg '{a: [1, 2]}')
+	YAMLFlowAlways YAMLFlowStyle = "always"
+	// YAMLFlowLeaf prints maps and arrays that only contain scalars in flow style
+	YAMLFlowLeaf YAMLFlowStyle = "leaf"
+)
+
+func ParseYAMLFlowStyle(name string) (YAMLFlowStyle, error) {
+	switch YAMLFlowStyle(name) {
+	case YAMLFlowBlock, YAMLFlowAlways, YAMLFlowLeaf:
+		return YAMLFlowStyle(name), nil
+	default:
+		return "", fmt.Errorf("Unknown YAML flow style '%s' (expected block, always or leaf)", name)
+	}
+}
+
 var _ DocumentPrinter = &YAMLPrinter{}
 
 func NewYAMLPrinter(writer io.Writer) *YAMLPrinter {
-	return &YAMLPrinter{writer, false}
+	return &YAMLPrinter{writer, YAMLPrinterOpts{}, false}
+}
+
+func NewYAMLPrinterWithOpts(writer io.Writer, opts YAMLPrinterOpts) *YAMLPrinter {
+	return &YAMLPrinter{writer, opts, false}
 }
 
 func (p *YAMLPrinter) Print(item *Document) error {
@@ -30,28 +116,148 @@ func (p *YAMLPrinter) Print(item *Document) error {
 		p.writtenOnce = true
 	}
 
+	bs, err := item.AsYAMLBytesWithOpts(p.opts)
+	if err != nil {
+		return fmt.Errorf("marshaling doc: %s", err)
+	}
+	p.buf.Write(bs)
+	return nil
+}
+
+// YAMLStreamPrinter precedes every document (including first one)
+// with document start marker so that stream is unambiguous
+type YAMLStreamPrinter struct {
+	buf  io.Writer
+	opts YAMLPrinterOpts
+}
+
+var _ DocumentPrinter = YAMLStreamPrinter{}
+
+func NewYAMLStreamPrinter(writer io.Writer) YAMLStreamPrinter {
+	return YAMLStreamPrinter{writer, YAMLPrinterOpts{}}
+}
+
+func NewYAMLStreamPrinterWithOpts(writer io.Writer, opts YAMLPrinterOpts) YAMLStreamPrinter {
+	return YAMLStreamPrinter{writer, opts}
+}
+
+func (p YAMLStreamPrinter) Print(item *Document) error {
+	bs, err := item.AsYAMLBytesWithOpts(p.opts)
+	if err != nil {
+		return fmt.Errorf("marshaling doc: %s", err)
+	}
+	if len(bs) == 0 || bs[len(bs)-1] != '\n' {
+		bs = append(bs, '\n')
+	}
+	p.buf.Write(append([]byte("---\n"), bs...))
+	return nil
+}
+
+// SourceMapPrinter prints documents as YAML, preceding each one
+// with a comment that indicates where document originated from
+type SourceMapPrinter struct {
+	buf         io.Writer
+	writtenOnce bool
+}
+
+var _ DocumentPrinter = &SourceMapPrinter{}
+
+func NewSourceMapPrinter(writer io.Writer) *SourceMapPrinter {
+	return &SourceMapPrinter{writer, false}
+}
+
+func (p *SourceMapPrinter) Print(item *Document) error {
+	if p.writtenOnce {
+		p.buf.Write([]byte("---\n"))
+	} else {
+		p.writtenOnce = true
+	}
+
 	bs, err := item.AsYAMLBytes()
 	if err != nil {
 		return fmt.Errorf("marshaling doc: %s", err)
 	}
+
+	source := "?"
+	if item.Position != nil {
+		source = item.SourcePosition().AsCompactString()
+	}
+
+	fmt.Fprintf(p.buf, "# from: %s\n", source)
 	p.buf.Write(bs)
 	return nil
 }
 
+// SourcePosition returns document's position including file name.
+// Position of the first document in a file does not include file name
+// (eg for pos output), hence file is taken from document's contents.
+func (d *Document) SourcePosition() *filepos.Position {
+	if d.Position == nil || len(d.Position.File()) > 0 {
+		return d.Position
+	}
+	if file := nodeSourceFile(d.Value); len(file) > 0 {
+		pos := d.Position.DeepCopy()
+		pos.SetFile(file)
+		return pos
+	}
+	return d.Position
+}
+
+func nodeSourceFile(val interface{}) string {
+	switch typedVal := val.(type) {
+	case *Map:
+		for _, item := range typedVal.Items {
+			if item.Position != nil && len(item.Position.File()) > 0 {
+				return item.Position.File()
+			}
+			if file := nodeSourceFile(item.Value); len(file) > 0 {
+				return file
+			}
+		}
+	case *Array:
+		for _, item := range typedVal.Items {
+			if item.Position != nil && len(item.Position.File()) > 0 {
+				return item.Position.File()
+			}
+			if file := nodeSourceFile(item.Value); len(file) > 0 {
+				return file
+			}
+		}
+	}
+	return ""
+}
+
 type JSONPrinter struct {
-	buf io.Writer
+	buf  io.Writer
+	opts JSONPrinterOpts
+}
+
+type JSONPrinterOpts struct {
+	// Indent is used for each indentation level; empty means compact output
+	Indent string
 }
 
 var _ DocumentPrinter = &JSONPrinter{}
 
 func NewJSONPrinter(writer io.Writer) JSONPrinter {
-	return JSONPrinter{writer}
+	return JSONPrinter{writer, JSONPrinterOpts{}}
+}
+
+func NewJSONPrinterWithOpts(writer io.Writer, opts JSONPrinterOpts) JSONPrinter {
+	return JSONPrinter{writer, opts}
 }
 
 func (p JSONPrinter) Print(item *Document) error {
-	val := item.AsInterface()
+	val := orderedmap.Conversion{Object: item.AsInterface()}.AsUnorderedStringMaps()
+
+	var bs []byte
+	var err error
 
-	bs, err := json.Marshal(orderedmap.Conversion{val}.AsUnorderedStringMaps())
+	if len(p.opts.Indent) > 0 {
+		bs, err = json.MarshalIndent(val, "", p.opts.Indent)
+	} else {
+		bs, err = json.Marshal(val)
+	}
 	if err != nil {
 		return fmt.Errorf("marshaling doc: %s", err)
 	}
@@ -59,6 +265,32 @@ func (p JSONPrinter) Print(item *Document) error {
 	return nil
 }
 
+type JSONLinesPrinter struct {
+	buf io.Writer
+}
+
+var _ DocumentPrinter = JSONLinesPrinter{}
+
+func NewJSONLinesPrinter(writer io.Writer) JSONLinesPrinter {
+	return JSONLinesPrinter{writer}
+}
+
+func (p JSONLinesPrinter) Print(item *Document) error {
+	// Avoid producing blank lines in the stream
+	if item.IsEmpty() {
+		return nil
+	}
+
+	val := item.AsInterface()
+
+	bs, err := json.Marshal(orderedmap.Conversion{Object: val}.AsUnorderedStringMaps())
+	if err != nil {
+		return fmt.Errorf("marshaling doc: %s", err)
+	}
+	p.buf.Write(append(bs, '\n'))
+	return nil
+}
+
 type WrappedFilePositionPrinter struct {
 	Printer *FilePositionPrinter
 }
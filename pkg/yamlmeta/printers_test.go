@@ -0,0 +1,395 @@
+package yamlmeta_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestJSONLinesPrinter(t *testing.T) {
+	data := `
+a: 1
+b: [x, z]
+---
+---
+c:
+  d: true
+`
+
+	expectedOutput := `{"a":1,"b":["x","z"]}
+{"c":{"d":true}}
+`
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewJSONLinesPrinter(w)
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func printDocSet(data string, printerFunc func(io.Writer) yamlmeta.DocumentPrinter) (string, error) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		return "", err
+	}
+
+	return printDocSetItems(docSet, printerFunc)
+}
+
+func printDocSetItems(docSet *yamlmeta.DocumentSet, printerFunc func(io.Writer) yamlmeta.DocumentPrinter) (string, error) {
+	buf := new(bytes.Buffer)
+	printer := printerFunc(buf)
+
+	for _, doc := range docSet.Items {
+		err := printer.Print(doc)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
+
+func TestJSONPrinterWithIndent(t *testing.T) {
+	data := `
+b:
+  d: [1, {e: f}]
+  c: true
+a: 1
+`
+
+	expectedOutput := `{
+  "a": 1,
+  "b": {
+    "c": true,
+    "d": [
+      1,
+      {
+        "e": "f"
+      }
+    ]
+  }
+}`
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewJSONPrinterWithOpts(w, yamlmeta.JSONPrinterOpts{Indent: "  "})
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	data := `
+b:
+  z: 1
+  a:
+  - q: 1
+    p: 2
+  - 3
+a: [c, b]
+---
+d: 1
+c: 2
+`
+
+	expectedOutput := `a:
+- c
+- b
+b:
+  a:
+  - p: 2
+    q: 1
+  - 3
+  z: 1
+---
+c: 2
+d: 1
+`
+
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	yamlmeta.SortKeys(docSet)
+
+	out, err := docSet.AsBytes()
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if string(out) != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestYAMLPrinterDocStart(t *testing.T) {
+	examples := []struct {
+		Data     string
+		Expected string
+	}{
+		// Single document is never preceded by document start marker
+		{Data: "---\na: 1\n", Expected: "a: 1\n"},
+		{Data: "a: 1\n", Expected: "a: 1\n"},
+		// Separators are required only between documents
+		{Data: "---\na: 1\n---\nb: 2\n", Expected: "a: 1\n---\nb: 2\n"},
+	}
+
+	for _, ex := range examples {
+		out, err := printDocSet(ex.Data, func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.NewYAMLPrinter(w)
+		})
+		if err != nil {
+			t.Fatalf("Expected printing to succeed: %s", err)
+		}
+		if out != ex.Expected {
+			t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+		}
+	}
+}
+
+func TestYAMLStreamPrinter(t *testing.T) {
+	data := `---
+a: 1
+---
+b: |
+  text
+`
+
+	expectedOutput := `---
+a: 1
+---
+b: |
+  text
+`
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewYAMLStreamPrinter(w)
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestYAMLPrinterLineWidth(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("word ", 20))
+	data := "a: " + long + "\nb: |\n  " + long + "\n  " + long + "\n"
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewYAMLPrinter(w)
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if !strings.HasPrefix(out, "a: word word word word word word word word word word word word word word word word\n  word word word word\nb: |\n") {
+		t.Fatalf("Expected long string to be folded by default, but was: >>>%s<<<", out)
+	}
+
+	// Block scalars are never folded
+	expectedOutput := "a: " + long + "\nb: |\n  " + long + "\n  " + long + "\n"
+
+	for _, lineWidth := range []int{-1, 1000} {
+		out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.NewYAMLPrinterWithOpts(w, yamlmeta.YAMLPrinterOpts{LineWidth: lineWidth})
+		})
+		if err != nil {
+			t.Fatalf("Expected printing to succeed: %s", err)
+		}
+		if out != expectedOutput {
+			t.Fatalf("Expected long string to not be folded with width %d, but was: >>>%s<<<", lineWidth, out)
+		}
+	}
+
+	out, err = printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewYAMLStreamPrinterWithOpts(w, yamlmeta.YAMLPrinterOpts{LineWidth: 40})
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if !strings.HasPrefix(out, "---\na: word word word word word word word word\n  word") {
+		t.Fatalf("Expected long string to be folded at width 40, but was: >>>%s<<<", out)
+	}
+}
+
+func TestYAMLPrinterFormatting(t *testing.T) {
+	data := `a:
+  b: "1"
+  "c: d": text
+  e: [1, two]
+  f:
+  - g: h
+`
+
+	exampleTests := []struct {
+		Opts           yamlmeta.YAMLPrinterOpts
+		ExpectedOutput string
+	}{
+		{
+			Opts: yamlmeta.YAMLPrinterOpts{Indent: 2, QuoteStyle: yamlmeta.YAMLQuoteMinimal, FlowStyle: yamlmeta.YAMLFlowBlock},
+			ExpectedOutput: `a:
+  b: "1"
+  'c: d': text
+  e:
+  - 1
+  - two
+  f:
+  - g: h
+`,
+		},
+		{
+			Opts: yamlmeta.YAMLPrinterOpts{Indent: 4},
+			ExpectedOutput: `a:
+    b: "1"
+    'c: d': text
+    e:
+    - 1
+    - two
+    f:
+    -   g: h
+`,
+		},
+		{
+			Opts: yamlmeta.YAMLPrinterOpts{QuoteStyle: yamlmeta.YAMLQuoteSingle},
+			ExpectedOutput: `a:
+  b: '1'
+  'c: d': 'text'
+  e:
+  - 1
+  - 'two'
+  f:
+  - g: 'h'
+`,
+		},
+		{
+			Opts: yamlmeta.YAMLPrinterOpts{QuoteStyle: yamlmeta.YAMLQuoteDouble, FlowStyle: yamlmeta.YAMLFlowLeaf},
+			ExpectedOutput: `a:
+  b: "1"
+  "c: d": "text"
+  e: [1, "two"]
+  f:
+  - {g: "h"}
+`,
+		},
+		{
+			Opts:           yamlmeta.YAMLPrinterOpts{FlowStyle: yamlmeta.YAMLFlowAlways},
+			ExpectedOutput: "{a: {b: \"1\", 'c: d': text, e: [1, two], f: [{g: h}]}}\n",
+		},
+	}
+
+	for _, tc := range exampleTests {
+		out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.NewYAMLPrinterWithOpts(w, tc.Opts)
+		})
+		if err != nil {
+			t.Fatalf("Expected printing to succeed: %s", err)
+		}
+		if out != tc.ExpectedOutput {
+			t.Fatalf("Expected output with opts %#v to match, but was: >>>%s<<<", tc.Opts, out)
+		}
+	}
+}
+
+func TestSourceMapPrinter(t *testing.T) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte("a: 1\n---\nb: 2\n"), yamlmeta.DocSetOpts{AssociatedName: "config/app.yml"})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	docSet.Items = append(docSet.Items, &yamlmeta.Document{Value: "c"})
+
+	expectedOutput := `# from: config/app.yml:1
+a: 1
+---
+# from: config/app.yml:2
+b: 2
+---
+# from: ?
+c
+`
+
+	buf := new(bytes.Buffer)
+	printer := yamlmeta.NewSourceMapPrinter(buf)
+
+	for _, doc := range docSet.Items {
+		err := printer.Print(doc)
+		if err != nil {
+			t.Fatalf("Expected printing to succeed: %s", err)
+		}
+	}
+
+	if buf.String() != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", buf.String())
+	}
+}
+
+// countingWriter records number of writes to verify output is streamed
+type countingWriter struct {
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	w.writes++
+	return w.buf.Write(data)
+}
+
+func TestDocumentSetWriteWithPrinter(t *testing.T) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte("a: 1\n---\n---\nb: 2\n---\nc: [3]\n"), yamlmeta.DocSetOpts{})
+	if err != nil {
+		t.Fatalf("Expected parsing to succeed: %s", err)
+	}
+
+	printerFuncs := []func(io.Writer) yamlmeta.DocumentPrinter{
+		nil,
+		func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewYAMLStreamPrinter(w) },
+		func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONPrinter(w) },
+		func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewJSONLinesPrinter(w) },
+	}
+
+	for i, printerFunc := range printerFuncs {
+		expectedBs, err := docSet.AsBytesWithPrinter(printerFunc)
+		if err != nil {
+			t.Fatalf("Expected marshaling to succeed: %s", err)
+		}
+
+		writer := &countingWriter{}
+
+		err = docSet.WriteWithPrinter(writer, printerFunc)
+		if err != nil {
+			t.Fatalf("Expected writing to succeed: %s", err)
+		}
+
+		if writer.buf.String() != string(expectedBs) {
+			t.Fatalf("Expected streamed output for printer %d to match, but was: >>>%s<<< vs >>>%s<<<", i, writer.buf.String(), expectedBs)
+		}
+
+		// Empty document is skipped
+		if writer.writes != 3 {
+			t.Fatalf("Expected output for printer %d to be written per document, but was %d writes", i, writer.writes)
+		}
+	}
+
+	writer := &countingWriter{}
+
+	err = docSet.WriteWithPrinter(writer, func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewTOMLPrinter(w) })
+	if err == nil {
+		t.Fatalf("Expected writing multiple documents as TOML to fail")
+	}
+
+	if writer.buf.String() != "a = 1\n" {
+		t.Fatalf("Expected preceding document to be written, but was: >>>%s<<<", writer.buf.String())
+	}
+}
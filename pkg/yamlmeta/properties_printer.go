@@ -0,0 +1,188 @@
+package yamlmeta
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+const (
+	PropertiesListFormatIndexed = "indexed"
+	PropertiesListFormatComma   = "comma"
+)
+
+// PropertiesPrinter prints a single document that is a map as Java
+// properties (key=value lines) with nested keys joined with dot
+type PropertiesPrinter struct {
+	buf         io.Writer
+	opts        PropertiesPrinterOpts
+	writtenOnce bool
+}
+
+type PropertiesPrinterOpts struct {
+	// ListFormat controls how arrays are printed: 'indexed' (default)
+	// uses index as key segment (eg 'items.0=a'), 'comma' joins
+	// scalar items with comma (eg 'items=a,b')
+	ListFormat string
+}
+
+var _ DocumentPrinter = &PropertiesPrinter{}
+
+func NewPropertiesPrinter(writer io.Writer) *PropertiesPrinter {
+	return &PropertiesPrinter{writer, PropertiesPrinterOpts{}, false}
+}
+
+func NewPropertiesPrinterWithOpts(writer io.Writer, opts PropertiesPrinterOpts) *PropertiesPrinter {
+	return &PropertiesPrinter{writer, opts, false}
+}
+
+func (p *PropertiesPrinter) Print(item *Document) error {
+	if p.writtenOnce {
+		return fmt.Errorf("properties output does not support multiple documents " +
+			"(use --output-directory to write documents from different files separately)")
+	}
+	p.writtenOnce = true
+
+	switch p.opts.ListFormat {
+	case "", PropertiesListFormatIndexed, PropertiesListFormatComma:
+		// valid
+	default:
+		return fmt.Errorf("Unknown properties list format '%s' (expected %s or %s)",
+			p.opts.ListFormat, PropertiesListFormatIndexed, PropertiesListFormatComma)
+	}
+
+	typedMap, ok := item.AsInterface().(*orderedmap.Map)
+	if !ok {
+		return fmt.Errorf("Expected document to be a map for properties output, but was %T", item.AsInterface())
+	}
+
+	buf := new(bytes.Buffer)
+
+	err := p.printVal(buf, nil, typedMap, map[string]struct{}{})
+	if err != nil {
+		return fmt.Errorf("marshaling doc: %s", err)
+	}
+
+	p.buf.Write(buf.Bytes())
+	return nil
+}
+
+func (p *PropertiesPrinter) printVal(buf *bytes.Buffer, path []string, val interface{}, seenKeys map[string]struct{}) error {
+	key := strings.Join(path, ".")
+
+	switch typedVal := val.(type) {
+	case *orderedmap.Map:
+		return typedVal.IterateErr(func(k, v interface{}) error {
+			return p.printVal(buf, append(append([]string{}, path...), fmt.Sprintf("%v", k)), v, seenKeys)
+		})
+
+	case []interface{}:
+		if p.opts.ListFormat == PropertiesListFormatComma {
+			var itemStrs []string
+			for _, item := range typedVal {
+				itemStr, err := p.scalarStr(key, item)
+				if err != nil {
+					return fmt.Errorf("%s (comma list format only supports arrays of scalars)", err)
+				}
+				itemStrs = append(itemStrs, itemStr)
+			}
+			return p.printLine(buf, key, strings.Join(itemStrs, ","), seenKeys)
+		}
+
+		for i, item := range typedVal {
+			err := p.printVal(buf, append(append([]string{}, path...), strconv.Itoa(i)), item, seenKeys)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		valStr, err := p.scalarStr(key, val)
+		if err != nil {
+			return err
+		}
+		return p.printLine(buf, key, valStr, seenKeys)
+	}
+}
+
+func (p *PropertiesPrinter) printLine(buf *bytes.Buffer, key, val string, seenKeys map[string]struct{}) error {
+	if _, found := seenKeys[key]; found {
+		return fmt.Errorf("Expected key '%s' to be specified only once (after flattening nested keys)", key)
+	}
+	seenKeys[key] = struct{}{}
+
+	fmt.Fprintf(buf, "%s=%s\n", p.escape(key, true), p.escape(val, false))
+	return nil
+}
+
+func (p *PropertiesPrinter) scalarStr(key string, val interface{}) (string, error) {
+	switch typedVal := val.(type) {
+	case nil:
+		return "", nil
+
+	case string:
+		return typedVal, nil
+
+	case bool:
+		return strconv.FormatBool(typedVal), nil
+
+	case int, int64, uint64:
+		return fmt.Sprintf("%d", typedVal), nil
+
+	case float64:
+		return strconv.FormatFloat(typedVal, 'g', -1, 64), nil
+
+	default:
+		return "", fmt.Errorf("Expected value of key '%s' to be a scalar, but was %T", key, val)
+	}
+}
+
+// escape follows java.util.Properties#store rules: whitespace and
+// separators are escaped with backslash (spaces only at the beginning
+// of values), and characters outside of printable ASCII are written
+// as \uXXXX (characters outside of BMP as UTF-16 surrogate pairs)
+func (p *PropertiesPrinter) escape(str string, isKey bool) string {
+	var result strings.Builder
+
+	for i, r := range str {
+		switch r {
+		case ' ':
+			if isKey || i == 0 {
+				result.WriteString(`\ `)
+			} else {
+				result.WriteRune(r)
+			}
+		case '\t':
+			result.WriteString(`\t`)
+		case '\n':
+			result.WriteString(`\n`)
+		case '\r':
+			result.WriteString(`\r`)
+		case '\f':
+			result.WriteString(`\f`)
+		case '\\', '=', ':', '#', '!':
+			result.WriteRune('\\')
+			result.WriteRune(r)
+		default:
+			switch {
+			case r < 0x20 || r > 0x7e:
+				if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+					fmt.Fprintf(&result, `\u%04X\u%04X`, r1, r2)
+				} else {
+					fmt.Fprintf(&result, `\u%04X`, r)
+				}
+			default:
+				result.WriteRune(r)
+			}
+		}
+	}
+
+	return result.String()
+}
@@ -0,0 +1,114 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestPropertiesPrinter(t *testing.T) {
+	data := `
+server:
+  port: 8080
+  host: localhost
+debug: false
+ratio: 0.5
+empty: null
+url: "https://host:80/path?a=1"
+greeting: " hello world"
+"key with spaces": "a#b!c"
+multi: "line1\nline2\ttab"
+unicode: "caf\u00e9 \U0001F600"
+path: 'C:\dir'
+items:
+- a
+- name: b
+`
+
+	expectedOutput := `server.port=8080
+server.host=localhost
+debug=false
+ratio=0.5
+empty=
+url=https\://host\:80/path?a\=1
+greeting=\ hello world
+key\ with\ spaces=a\#b\!c
+multi=line1\nline2\ttab
+unicode=caf\u00E9 \uD83D\uDE00
+path=C\:\\dir
+items.0=a
+items.1.name=b
+`
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewPropertiesPrinter(w)
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestPropertiesPrinterCommaLists(t *testing.T) {
+	data := `
+profiles: [dev, "local cluster", 1, true]
+none: []
+`
+
+	expectedOutput := `profiles=dev,local cluster,1,true
+none=
+`
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewPropertiesPrinterWithOpts(w, yamlmeta.PropertiesPrinterOpts{ListFormat: yamlmeta.PropertiesListFormatComma})
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestPropertiesPrinterErrors(t *testing.T) {
+	examples := []struct {
+		Data       string
+		ListFormat string
+		Err        string
+	}{
+		{
+			Data:       "a: [{b: 1}]",
+			ListFormat: yamlmeta.PropertiesListFormatComma,
+			Err:        "marshaling doc: Expected value of key 'a' to be a scalar, but was *orderedmap.Map (comma list format only supports arrays of scalars)",
+		},
+		{
+			Data: "a.b: 1\na:\n  b: 2",
+			Err:  "marshaling doc: Expected key 'a.b' to be specified only once (after flattening nested keys)",
+		},
+		{
+			Data: "a: 1\n---\nb: 2",
+			Err:  "properties output does not support multiple documents (use --output-directory to write documents from different files separately)",
+		},
+		{
+			Data: "[1]",
+			Err:  "Expected document to be a map for properties output, but was []interface {}",
+		},
+		{
+			Data:       "a: 1",
+			ListFormat: "json",
+			Err:        "Unknown properties list format 'json' (expected indexed or comma)",
+		},
+	}
+
+	for _, ex := range examples {
+		_, err := printDocSet(ex.Data, func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.NewPropertiesPrinterWithOpts(w, yamlmeta.PropertiesPrinterOpts{ListFormat: ex.ListFormat})
+		})
+		if err == nil || err.Error() != ex.Err {
+			t.Fatalf("Expected printing of %q to fail with specific error, but was: %v", ex.Data, err)
+		}
+	}
+}
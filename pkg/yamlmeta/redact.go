@@ -0,0 +1,67 @@
+package yamlmeta
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	RedactedValue = "<redacted>"
+
+	// redactWildcard matches any map key or array index
+	redactWildcard = "*"
+)
+
+// RedactPath is a parsed JSON pointer (eg '/data/password' or '/data/*')
+type RedactPath []string
+
+// ParseRedactPath parses JSON pointer whose '*' tokens match any map key
+// or array index; empty pointer is not allowed since it would redact
+// whole documents
+func ParseRedactPath(pointer string) (RedactPath, error) {
+	tokens, err := ParseJSONPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("Expected JSON pointer '%s' to refer to a value within document", pointer)
+	}
+	return RedactPath(tokens), nil
+}
+
+// Redact replaces values found at given paths within documents with
+// RedactedValue (maps and arrays are replaced as a whole).
+// Paths that are not found are ignored. Documents are modified in place.
+func Redact(docSet *DocumentSet, paths []RedactPath) {
+	for _, doc := range docSet.Items {
+		for _, path := range paths {
+			doc.Value = redactValue(doc.Value, path)
+		}
+	}
+}
+
+func redactValue(val interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return RedactedValue
+	}
+
+	token, rest := path[0], path[1:]
+
+	switch typedVal := val.(type) {
+	case *Map:
+		for _, item := range typedVal.Items {
+			if token == redactWildcard || token == sortKeyStr(item.Key) {
+				item.Value = redactValue(item.Value, rest)
+			}
+		}
+
+	case *Array:
+		for i, item := range typedVal.Items {
+			if token == redactWildcard || token == strconv.Itoa(i) {
+				item.Value = redactValue(item.Value, rest)
+			}
+		}
+	}
+
+	return val
+}
@@ -0,0 +1,117 @@
+package yamlmeta_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestRedact(t *testing.T) {
+	data := `
+kind: Secret
+data:
+  password: secret
+  user: admin
+stringData:
+  a/b: x
+items:
+- name: a
+  token: t1
+- name: b
+  token: t2
+---
+other: true
+`
+
+	examples := []struct {
+		Pointers []string
+		Expected string
+	}{
+		{[]string{"/data/password"}, `kind: Secret
+data:
+  password: <redacted>
+  user: admin
+stringData:
+  a/b: x
+items:
+- name: a
+  token: t1
+- name: b
+  token: t2
+---
+other: true
+`},
+		{[]string{"/data/*", "/stringData/a~1b"}, `kind: Secret
+data:
+  password: <redacted>
+  user: <redacted>
+stringData:
+  a/b: <redacted>
+items:
+- name: a
+  token: t1
+- name: b
+  token: t2
+---
+other: true
+`},
+		{[]string{"/items/*/token", "/missing/key", "/kind/nested"}, `kind: Secret
+data:
+  password: secret
+  user: admin
+stringData:
+  a/b: x
+items:
+- name: a
+  token: <redacted>
+- name: b
+  token: <redacted>
+---
+other: true
+`},
+		{[]string{"/items/1", "/*"}, `kind: <redacted>
+data: <redacted>
+stringData: <redacted>
+items: <redacted>
+---
+other: <redacted>
+`},
+	}
+
+	for _, ex := range examples {
+		docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+		if err != nil {
+			t.Fatalf("Expected parsing to succeed: %s", err)
+		}
+
+		var paths []yamlmeta.RedactPath
+
+		for _, pointer := range ex.Pointers {
+			path, err := yamlmeta.ParseRedactPath(pointer)
+			if err != nil {
+				t.Fatalf("Expected parsing '%s' to succeed: %s", pointer, err)
+			}
+			paths = append(paths, path)
+		}
+
+		yamlmeta.Redact(docSet, paths)
+
+		out, err := docSet.AsBytes()
+		if err != nil {
+			t.Fatalf("Expected printing to succeed: %s", err)
+		}
+		if string(out) != ex.Expected {
+			t.Fatalf("Expected redacted output for %v to match, but was: >>>%s<<<", ex.Pointers, out)
+		}
+	}
+}
+
+func TestParseRedactPathInvalid(t *testing.T) {
+	for _, pointer := range []string{"", "data/password"} {
+		_, err := yamlmeta.ParseRedactPath(pointer)
+		if err == nil || !strings.Contains(err.Error(), "Expected JSON pointer '"+pointer+"'") {
+			t.Fatalf("Expected parsing '%s' to fail, but was: %v", pointer, err)
+		}
+	}
+}
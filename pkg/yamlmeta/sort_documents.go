@@ -0,0 +1,58 @@
+package yamlmeta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortDocuments orders documents by values at dotted key paths (eg 'kind',
+// 'metadata.name'); later keys are only compared when earlier ones are equal.
+// Scalar values are compared in their string form. Documents lacking a key
+// (or having non-scalar value) sort after ones that have it; otherwise
+// ordering of equal documents is preserved.
+func SortDocuments(docSet *DocumentSet, keyPaths []string) error {
+	var paths [][]string
+
+	for _, keyPath := range keyPaths {
+		path := strings.Split(keyPath, ".")
+		for _, key := range path {
+			if len(key) == 0 {
+				return fmt.Errorf("Expected sort key '%s' to not have empty keys", keyPath)
+			}
+		}
+		paths = append(paths, path)
+	}
+
+	type sortValue struct {
+		val   string
+		found bool
+	}
+
+	docVals := map[*Document][]sortValue{}
+
+	for _, doc := range docSet.Items {
+		val := doc.AsInterface()
+		for _, path := range paths {
+			str, found := lookupScalarAtPath(val, path)
+			docVals[doc] = append(docVals[doc], sortValue{str, found})
+		}
+	}
+
+	sort.SliceStable(docSet.Items, func(i, j int) bool {
+		iVals, jVals := docVals[docSet.Items[i]], docVals[docSet.Items[j]]
+
+		for k := range paths {
+			iVal, jVal := iVals[k], jVals[k]
+			switch {
+			case iVal.found != jVal.found:
+				return iVal.found
+			case iVal.val != jVal.val:
+				return iVal.val < jVal.val
+			}
+		}
+		return false
+	})
+
+	return nil
+}
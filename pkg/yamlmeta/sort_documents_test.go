@@ -0,0 +1,74 @@
+package yamlmeta_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestSortDocuments(t *testing.T) {
+	data := `
+id: 1
+kind: Service
+metadata:
+  name: b
+---
+id: 2
+other: true
+---
+id: 3
+kind: Deployment
+metadata:
+  name: b
+---
+id: 4
+kind: Service
+metadata:
+  name: a
+---
+id: 5
+kind: Deployment
+---
+id: 6
+other: true
+`
+
+	examples := []struct {
+		Keys     []string
+		Expected string
+	}{
+		{[]string{"kind", "metadata.name"}, "3,5,4,1,2,6"},
+		{[]string{"metadata.name"}, "4,1,3,2,5,6"},
+		{[]string{"missing"}, "1,2,3,4,5,6"},
+		{nil, "1,2,3,4,5,6"},
+	}
+
+	for _, ex := range examples {
+		docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{})
+		if err != nil {
+			t.Fatalf("Expected parsing to succeed: %s", err)
+		}
+
+		err = yamlmeta.SortDocuments(docSet, ex.Keys)
+		if err != nil {
+			t.Fatalf("Expected sorting to succeed: %s", err)
+		}
+
+		var ids []string
+
+		for _, doc := range docSet.Items {
+			ids = append(ids, fmt.Sprintf("%v", doc.Value.(*yamlmeta.Map).Items[0].Value))
+		}
+
+		if strings.Join(ids, ",") != ex.Expected {
+			t.Fatalf("Expected sort keys %v to order documents as %s, but was %s", ex.Keys, ex.Expected, strings.Join(ids, ","))
+		}
+	}
+
+	err := yamlmeta.SortDocuments(&yamlmeta.DocumentSet{}, []string{"metadata..name"})
+	if err == nil || err.Error() != "Expected sort key 'metadata..name' to not have empty keys" {
+		t.Fatalf("Expected empty key err, but was: %v", err)
+	}
+}
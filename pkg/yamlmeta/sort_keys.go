@@ -0,0 +1,32 @@
+package yamlmeta
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortKeys recursively orders map items by their keys.
+// Ordering of array items and documents is preserved.
+func SortKeys(val interface{}) {
+	node, ok := val.(Node)
+	if !ok {
+		return
+	}
+
+	if typedMap, ok := node.(*Map); ok {
+		sort.SliceStable(typedMap.Items, func(i, j int) bool {
+			return sortKeyStr(typedMap.Items[i].Key) < sortKeyStr(typedMap.Items[j].Key)
+		})
+	}
+
+	for _, childVal := range node.GetValues() {
+		SortKeys(childVal)
+	}
+}
+
+func sortKeyStr(key interface{}) string {
+	if typedKey, ok := key.(string); ok {
+		return typedKey
+	}
+	return fmt.Sprintf("%v", key)
+}
@@ -0,0 +1,285 @@
+package yamlmeta
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+var (
+	tomlBareKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+type TOMLPrinter struct {
+	buf         io.Writer
+	writtenOnce bool
+}
+
+var _ DocumentPrinter = &TOMLPrinter{}
+
+func NewTOMLPrinter(writer io.Writer) *TOMLPrinter {
+	return &TOMLPrinter{writer, false}
+}
+
+func (p *TOMLPrinter) Print(item *Document) error {
+	if p.writtenOnce {
+		return fmt.Errorf("TOML output does not support multiple documents " +
+			"(use --output-directory to write documents from different files separately)")
+	}
+	p.writtenOnce = true
+
+	typedMap, ok := item.AsInterface().(*orderedmap.Map)
+	if !ok {
+		return fmt.Errorf("Expected document to be a map for TOML output, but was %T", item.AsInterface())
+	}
+
+	buf := new(bytes.Buffer)
+
+	err := p.printTable(buf, nil, typedMap)
+	if err != nil {
+		return fmt.Errorf("marshaling doc: %s", err)
+	}
+
+	p.buf.Write(buf.Bytes())
+	return nil
+}
+
+func (p *TOMLPrinter) printTable(buf *bytes.Buffer, path []string, val *orderedmap.Map) error {
+	// TOML requires all key-value pairs of a table
+	// to come before any of its sub-tables
+	err := val.IterateErr(func(k, v interface{}) error {
+		if p.isTable(v) || p.isArrayOfTables(v) {
+			return nil
+		}
+		key := p.keyStr(k)
+		valStr, err := p.valueStr(append(path, key), v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s = %s\n", p.quotedKey(key), valStr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return val.IterateErr(func(k, v interface{}) error {
+		subPath := append(append([]string{}, path...), p.keyStr(k))
+
+		switch {
+		case p.isTable(v):
+			p.printSeparator(buf)
+			fmt.Fprintf(buf, "[%s]\n", p.headerStr(subPath))
+			return p.printTable(buf, subPath, v.(*orderedmap.Map))
+
+		case p.isArrayOfTables(v):
+			for _, item := range v.([]interface{}) {
+				p.printSeparator(buf)
+				fmt.Fprintf(buf, "[[%s]]\n", p.headerStr(subPath))
+
+				err := p.printTable(buf, subPath, item.(*orderedmap.Map))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (p *TOMLPrinter) printSeparator(buf *bytes.Buffer) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+}
+
+func (p *TOMLPrinter) valueStr(path []string, val interface{}) (string, error) {
+	switch typedVal := val.(type) {
+	case nil:
+		return "", fmt.Errorf("TOML does not support null values (key '%s')", p.pathStr(path))
+
+	case string:
+		return p.quotedStr(typedVal), nil
+
+	case bool:
+		return strconv.FormatBool(typedVal), nil
+
+	case int:
+		return strconv.FormatInt(int64(typedVal), 10), nil
+
+	case int64:
+		return strconv.FormatInt(typedVal, 10), nil
+
+	case uint64:
+		if typedVal > math.MaxInt64 {
+			return "", fmt.Errorf("TOML does not support integers larger than int64 (key '%s')", p.pathStr(path))
+		}
+		return strconv.FormatUint(typedVal, 10), nil
+
+	case float64:
+		return p.floatStr(typedVal), nil
+
+	case *orderedmap.Map:
+		var result []string
+		err := typedVal.IterateErr(func(k, v interface{}) error {
+			key := p.keyStr(k)
+			valStr, err := p.valueStr(append(path, key), v)
+			if err != nil {
+				return err
+			}
+			result = append(result, p.quotedKey(key)+" = "+valStr)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(result) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(result, ", ") + " }", nil
+
+	case []interface{}:
+		var result []string
+		var firstKind string
+
+		for i, item := range typedVal {
+			kind := p.kind(item)
+			if i == 0 {
+				firstKind = kind
+			} else if kind != firstKind {
+				return "", fmt.Errorf("TOML does not support mixed-type arrays "+
+					"(key '%s' contains %s and %s values)", p.pathStr(path), firstKind, kind)
+			}
+
+			valStr, err := p.valueStr(append(path, strconv.Itoa(i)), item)
+			if err != nil {
+				return "", err
+			}
+			result = append(result, valStr)
+		}
+		return "[" + strings.Join(result, ", ") + "]", nil
+
+	default:
+		return "", fmt.Errorf("Unsupported value type %T for TOML output (key '%s')", val, p.pathStr(path))
+	}
+}
+
+func (p *TOMLPrinter) floatStr(val float64) string {
+	switch {
+	case math.IsNaN(val):
+		return "nan"
+	case math.IsInf(val, 1):
+		return "inf"
+	case math.IsInf(val, -1):
+		return "-inf"
+	}
+
+	result := strconv.FormatFloat(val, 'g', -1, 64)
+	if !strings.ContainsAny(result, ".e") {
+		// TOML requires floats to have fractional or exponent part
+		result += ".0"
+	}
+	return result
+}
+
+func (p *TOMLPrinter) kind(val interface{}) string {
+	switch val.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "float"
+	case *orderedmap.Map:
+		return "table"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
+
+func (p *TOMLPrinter) isTable(val interface{}) bool {
+	_, ok := val.(*orderedmap.Map)
+	return ok
+}
+
+func (p *TOMLPrinter) isArrayOfTables(val interface{}) bool {
+	typedVal, ok := val.([]interface{})
+	if !ok || len(typedVal) == 0 {
+		return false
+	}
+	for _, item := range typedVal {
+		if !p.isTable(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *TOMLPrinter) keyStr(key interface{}) string {
+	if typedKey, ok := key.(string); ok {
+		return typedKey
+	}
+	return fmt.Sprintf("%v", key)
+}
+
+func (p *TOMLPrinter) quotedKey(key string) string {
+	if tomlBareKeyRegexp.MatchString(key) {
+		return key
+	}
+	return p.quotedStr(key)
+}
+
+func (p *TOMLPrinter) headerStr(path []string) string {
+	var result []string
+	for _, piece := range path {
+		result = append(result, p.quotedKey(piece))
+	}
+	return strings.Join(result, ".")
+}
+
+func (p *TOMLPrinter) pathStr(path []string) string {
+	return strings.Join(path, ".")
+}
+
+func (p *TOMLPrinter) quotedStr(val string) string {
+	var buf strings.Builder
+	buf.WriteString(`"`)
+
+	for _, r := range val {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteString(`"`)
+	return buf.String()
+}
@@ -0,0 +1,97 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestTOMLPrinter(t *testing.T) {
+	data := `
+title: example
+count: 3
+ratio: 1.0
+enabled: true
+"key with space": val
+tags: [a, "b\"c"]
+owner:
+  name: Tom
+  dob:
+    year: 1979
+servers:
+- name: alpha
+  ip: 10.0.0.1
+- name: beta
+  ip: 10.0.0.2
+  ports: [80, 443]
+inline:
+- [1, 2]
+- {a: 1}
+`
+
+	expectedTOML := `title = "example"
+count = 3
+ratio = 1.0
+enabled = true
+"key with space" = "val"
+tags = ["a", "b\"c"]
+
+[owner]
+name = "Tom"
+
+[owner.dob]
+year = 1979
+
+[[servers]]
+name = "alpha"
+ip = "10.0.0.1"
+
+[[servers]]
+name = "beta"
+ip = "10.0.0.2"
+ports = [80, 443]
+`
+
+	_, err := printTOML(data)
+	if err == nil {
+		t.Fatalf("Expected mixed-type array to fail")
+	}
+	if err.Error() != "marshaling doc: TOML does not support mixed-type arrays (key 'inline' contains array and table values)" {
+		t.Fatalf("Expected err, but was: %s", err)
+	}
+
+	out, err := printTOML(data[:len(data)-len("inline:\n- [1, 2]\n- {a: 1}\n")])
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedTOML {
+		t.Fatalf("Expected TOML output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestTOMLPrinterErrors(t *testing.T) {
+	examples := []struct {
+		Data        string
+		ExpectedErr string
+	}{
+		{"a:\n  b: null", "marshaling doc: TOML does not support null values (key 'a.b')"},
+		{"[1, 2]", "Expected document to be a map for TOML output, but was []interface {}"},
+		{"a: 1\n---\nb: 2", "TOML output does not support multiple documents " +
+			"(use --output-directory to write documents from different files separately)"},
+	}
+
+	for _, ex := range examples {
+		_, err := printTOML(ex.Data)
+		if err == nil {
+			t.Fatalf("Expected printing of '%s' to fail", ex.Data)
+		}
+		if err.Error() != ex.ExpectedErr {
+			t.Fatalf("Expected err for '%s', but was: %s", ex.Data, err)
+		}
+	}
+}
+
+func printTOML(data string) (string, error) {
+	return printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter { return yamlmeta.NewTOMLPrinter(w) })
+}
@@ -0,0 +1,224 @@
+package yamlmeta
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+)
+
+const (
+	xmlDeclaration = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+	xmlIndent      = "  "
+
+	// Keys with attribute prefix become attributes of parent element;
+	// text key sets text content of an element that has attributes
+	xmlAttrKeyPrefix = "@"
+	xmlTextKey       = "#text"
+)
+
+var (
+	xmlNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.:-]*$`)
+)
+
+// XMLPrinter prints documents that are maps with a single key (root element name).
+// Maps become elements, arrays become repeated elements and scalars become text.
+// Multiple documents are separated by an empty line.
+type XMLPrinter struct {
+	buf         io.Writer
+	writtenOnce bool
+}
+
+var _ DocumentPrinter = &XMLPrinter{}
+
+func NewXMLPrinter(writer io.Writer) *XMLPrinter {
+	return &XMLPrinter{writer, false}
+}
+
+func (p *XMLPrinter) Print(item *Document) error {
+	val := item.AsInterface()
+	if val == nil {
+		return nil // skip empty documents
+	}
+
+	root, ok := val.(*orderedmap.Map)
+	if !ok {
+		return fmt.Errorf("Expected document to be a map with a single key (root element name) for XML output, but was %T", val)
+	}
+	if root.Len() != 1 {
+		return fmt.Errorf("Expected document to be a map with a single key (root element name) for XML output, but had %d keys", root.Len())
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(xmlDeclaration)
+
+	var err error
+
+	root.Iterate(func(k, v interface{}) {
+		if _, isArray := v.([]interface{}); isArray {
+			err = fmt.Errorf("Expected root element '%v' to not be an array for XML output", k)
+			return
+		}
+		err = p.element(buf, p.keyStr(k), v, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	if p.writtenOnce {
+		p.buf.Write([]byte("\n"))
+	}
+	p.writtenOnce = true
+
+	p.buf.Write(buf.Bytes())
+	return nil
+}
+
+func (p *XMLPrinter) element(buf *bytes.Buffer, name string, val interface{}, indent string) error {
+	if !xmlNameRegexp.MatchString(name) {
+		return fmt.Errorf("Expected key '%s' to be a valid XML element name", name)
+	}
+
+	switch typedVal := val.(type) {
+	case *orderedmap.Map:
+		return p.mapElement(buf, name, typedVal, indent)
+
+	case []interface{}:
+		for i, item := range typedVal {
+			if _, isArray := item.([]interface{}); isArray {
+				return fmt.Errorf("Expected array item %d of key '%s' to not be an array for XML output", i, name)
+			}
+			err := p.element(buf, name, item, indent)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case nil:
+		fmt.Fprintf(buf, "%s<%s/>\n", indent, name)
+		return nil
+
+	default:
+		text, err := p.scalarStr(name, val)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s<%s>%s</%s>\n", indent, name, p.escape(text), name)
+		return nil
+	}
+}
+
+func (p *XMLPrinter) mapElement(buf *bytes.Buffer, name string, val *orderedmap.Map, indent string) error {
+	var attrs []string
+	var text *string
+	var childKeys []string
+	var childVals []interface{}
+	var err error
+
+	val.Iterate(func(k, v interface{}) {
+		if err != nil {
+			return
+		}
+
+		key := p.keyStr(k)
+
+		switch {
+		case strings.HasPrefix(key, xmlAttrKeyPrefix):
+			attrName := strings.TrimPrefix(key, xmlAttrKeyPrefix)
+			if !xmlNameRegexp.MatchString(attrName) {
+				err = fmt.Errorf("Expected key '%s' to be a valid XML attribute name", key)
+				return
+			}
+			var attrVal string
+			if v != nil {
+				attrVal, err = p.scalarStr(key, v)
+				if err != nil {
+					return
+				}
+			}
+			attrs = append(attrs, fmt.Sprintf(` %s="%s"`, attrName, p.escape(attrVal)))
+
+		case key == xmlTextKey:
+			var textVal string
+			if v != nil {
+				textVal, err = p.scalarStr(key, v)
+				if err != nil {
+					return
+				}
+			}
+			text = &textVal
+
+		default:
+			childKeys = append(childKeys, key)
+			childVals = append(childVals, v)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	openTag := indent + "<" + name + strings.Join(attrs, "")
+
+	if text != nil {
+		if len(childKeys) > 0 {
+			return fmt.Errorf("Expected element '%s' to not have both '%s' and child elements for XML output", name, xmlTextKey)
+		}
+		fmt.Fprintf(buf, "%s>%s</%s>\n", openTag, p.escape(*text), name)
+		return nil
+	}
+
+	if len(childKeys) == 0 {
+		fmt.Fprintf(buf, "%s/>\n", openTag)
+		return nil
+	}
+
+	fmt.Fprintf(buf, "%s>\n", openTag)
+
+	for i, key := range childKeys {
+		err := p.element(buf, key, childVals[i], indent+xmlIndent)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(buf, "%s</%s>\n", indent, name)
+	return nil
+}
+
+func (p *XMLPrinter) scalarStr(key string, val interface{}) (string, error) {
+	switch typedVal := val.(type) {
+	case string:
+		return typedVal, nil
+
+	case bool:
+		return strconv.FormatBool(typedVal), nil
+
+	case int, int64, uint64:
+		return fmt.Sprintf("%d", typedVal), nil
+
+	case float64:
+		return strconv.FormatFloat(typedVal, 'g', -1, 64), nil
+
+	default:
+		return "", fmt.Errorf("Expected value of key '%s' to be a scalar for XML output, but was %T", key, val)
+	}
+}
+
+func (p *XMLPrinter) escape(str string) string {
+	buf := new(bytes.Buffer)
+	xml.EscapeText(buf, []byte(str))
+	return buf.String()
+}
+
+func (p *XMLPrinter) keyStr(key interface{}) string {
+	if typedKey, ok := key.(string); ok {
+		return typedKey
+	}
+	return fmt.Sprintf("%v", key)
+}
@@ -0,0 +1,105 @@
+package yamlmeta_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestXMLPrinter(t *testing.T) {
+	data := `
+config:
+  "@version": 1
+  name: "a <b> & c"
+  ports: [80, 443]
+  empty: null
+  labels:
+    "@env": "\"prod\""
+  item:
+  - "@id": x
+    "#text": first
+  - second
+  nested:
+    enabled: true
+---
+---
+other: text
+`
+
+	expectedOutput := `<?xml version="1.0" encoding="UTF-8"?>
+<config version="1">
+  <name>a &lt;b&gt; &amp; c</name>
+  <ports>80</ports>
+  <ports>443</ports>
+  <empty/>
+  <labels env="&#34;prod&#34;"/>
+  <item id="x">first</item>
+  <item>second</item>
+  <nested>
+    <enabled>true</enabled>
+  </nested>
+</config>
+
+<?xml version="1.0" encoding="UTF-8"?>
+<other>text</other>
+`
+
+	out, err := printDocSet(data, func(w io.Writer) yamlmeta.DocumentPrinter {
+		return yamlmeta.NewXMLPrinter(w)
+	})
+	if err != nil {
+		t.Fatalf("Expected printing to succeed: %s", err)
+	}
+	if out != expectedOutput {
+		t.Fatalf("Expected output to match, but was: >>>%s<<<", out)
+	}
+}
+
+func TestXMLPrinterErrors(t *testing.T) {
+	examples := []struct {
+		Data string
+		Err  string
+	}{
+		{
+			Data: "a: 1\nb: 2",
+			Err:  "Expected document to be a map with a single key (root element name) for XML output, but had 2 keys",
+		},
+		{
+			Data: "[1, 2]",
+			Err:  "Expected document to be a map with a single key (root element name) for XML output, but was []interface {}",
+		},
+		{
+			Data: "a: [1, 2]",
+			Err:  "Expected root element 'a' to not be an array for XML output",
+		},
+		{
+			Data: "a:\n  b: [[1]]",
+			Err:  "Expected array item 0 of key 'b' to not be an array for XML output",
+		},
+		{
+			Data: "a:\n  \"@x\": [1]",
+			Err:  "Expected value of key '@x' to be a scalar for XML output, but was []interface {}",
+		},
+		{
+			Data: "a:\n  \"b c\": 1",
+			Err:  "Expected key 'b c' to be a valid XML element name",
+		},
+		{
+			Data: "a:\n  \"#text\": x\n  b: 1",
+			Err:  "Expected element 'a' to not have both '#text' and child elements for XML output",
+		},
+	}
+
+	for _, ex := range examples {
+		_, err := printDocSet(ex.Data, func(w io.Writer) yamlmeta.DocumentPrinter {
+			return yamlmeta.NewXMLPrinter(w)
+		})
+		if err == nil {
+			t.Fatalf("Expected printing of %q to fail", ex.Data)
+		}
+		if err.Error() != ex.Err {
+			t.Fatalf("Expected error to match, but was: %s", err)
+		}
+	}
+}
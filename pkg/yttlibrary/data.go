@@ -21,8 +21,9 @@ func (b dataModule) AsModule() starlark.StringDict {
 		"data": &starlarkstruct.Module{
 			Name: "data",
 			Members: starlark.StringDict{
-				"list": starlark.NewBuiltin("data.list", core.ErrWrapper(b.List)),
-				"read": starlark.NewBuiltin("data.read", core.ErrWrapper(b.Read)),
+				"list":        starlark.NewBuiltin("data.list", core.ErrWrapper(b.List)),
+				"read":        starlark.NewBuiltin("data.read", core.ErrWrapper(b.Read)),
+				"annotations": starlark.NewBuiltin("data.annotations", core.ErrWrapper(b.Annotations)),
 				// TODO write?
 				"values": b.values,
 			},
@@ -41,3 +42,9 @@ func (b dataModule) Read(thread *starlark.Thread, f *starlark.Builtin,
 
 	return b.loader.LoadData(thread, f, args, kwargs)
 }
+
+func (b dataModule) Annotations(thread *starlark.Thread, f *starlark.Builtin,
+	args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+	return b.loader.FileAnnotations(thread, f, args, kwargs)
+}
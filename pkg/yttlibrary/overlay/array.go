@@ -1,6 +1,7 @@
 package overlay
 
 import (
+	"github.com/k14s/ytt/pkg/structmeta"
 	"github.com/k14s/ytt/pkg/yamlmeta"
 )
 
@@ -34,6 +35,7 @@ func (o OverlayOp) mergeArrayItem(
 		}
 		if replace {
 			leftArray.Items[leftIdx].Value = newItem.Value
+			o.Trace.record(leftArray.Items[leftIdx], AnnotationMerge, newItem)
 		}
 	}
 
@@ -58,6 +60,10 @@ func (o OverlayOp) removeArrayItem(
 		leftArray.Items[leftIdx] = nil
 	}
 
+	if len(leftIdxs) > 0 {
+		o.Trace.record(leftArray, AnnotationRemove, newItem)
+	}
+
 	// Prune out all nil items
 	updatedItems := []*yamlmeta.ArrayItem{}
 
@@ -99,6 +105,7 @@ func (o OverlayOp) replaceArrayItem(
 
 		leftArray.Items[leftIdx] = newItem.DeepCopy()
 		leftArray.Items[leftIdx].SetValue(newVal)
+		o.Trace.record(leftArray.Items[leftIdx], AnnotationReplace, newItem)
 	}
 
 	return nil
@@ -131,11 +138,11 @@ func (o OverlayOp) insertArrayItem(
 			if i == leftIdx {
 				matched = true
 				if insertAnn.IsBefore() {
-					updatedItems = append(updatedItems, newItem.DeepCopy())
+					updatedItems = append(updatedItems, o.tracedArrayItem(newItem.DeepCopy(), AnnotationInsert, newItem))
 				}
 				updatedItems = append(updatedItems, leftItem)
 				if insertAnn.IsAfter() {
-					updatedItems = append(updatedItems, newItem.DeepCopy())
+					updatedItems = append(updatedItems, o.tracedArrayItem(newItem.DeepCopy(), AnnotationInsert, newItem))
 				}
 				break
 			}
@@ -154,6 +161,13 @@ func (o OverlayOp) appendArrayItem(
 	leftArray *yamlmeta.Array, newItem *yamlmeta.ArrayItem) error {
 
 	// No need to traverse further
-	leftArray.Items = append(leftArray.Items, newItem.DeepCopy())
+	leftArray.Items = append(leftArray.Items, o.tracedArrayItem(newItem.DeepCopy(), AnnotationAppend, newItem))
 	return nil
 }
+
+func (o OverlayOp) tracedArrayItem(item *yamlmeta.ArrayItem,
+	op structmeta.AnnotationName, overlayItem *yamlmeta.ArrayItem) *yamlmeta.ArrayItem {
+
+	o.Trace.record(item, op, overlayItem)
+	return item
+}
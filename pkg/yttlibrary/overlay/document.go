@@ -1,6 +1,7 @@
 package overlay
 
 import (
+	"github.com/k14s/ytt/pkg/structmeta"
 	"github.com/k14s/ytt/pkg/yamlmeta"
 )
 
@@ -30,6 +31,7 @@ func (o OverlayOp) mergeDocument(
 		}
 		if replace {
 			leftDocSets[leftIdx[0]].Items[leftIdx[1]].Value = newDoc.Value
+			o.Trace.record(leftDocSets[leftIdx[0]].Items[leftIdx[1]], AnnotationMerge, newDoc)
 		}
 	}
 
@@ -97,6 +99,7 @@ func (o OverlayOp) replaceDocument(
 
 		leftDocSets[leftIdx[0]].Items[leftIdx[1]] = newDoc.DeepCopy()
 		leftDocSets[leftIdx[0]].Items[leftIdx[1]].SetValue(newVal)
+		o.Trace.record(leftDocSets[leftIdx[0]].Items[leftIdx[1]], AnnotationReplace, newDoc)
 	}
 
 	return nil
@@ -130,11 +133,11 @@ func (o OverlayOp) insertDocument(
 				if leftIdx[0] == i && leftIdx[1] == j {
 					matched = true
 					if insertAnn.IsBefore() {
-						updatedDocs = append(updatedDocs, newDoc.DeepCopy())
+						updatedDocs = append(updatedDocs, o.tracedDocument(newDoc.DeepCopy(), AnnotationInsert, newDoc))
 					}
 					updatedDocs = append(updatedDocs, leftItem)
 					if insertAnn.IsAfter() {
-						updatedDocs = append(updatedDocs, newDoc.DeepCopy())
+						updatedDocs = append(updatedDocs, o.tracedDocument(newDoc.DeepCopy(), AnnotationInsert, newDoc))
 					}
 					break
 				}
@@ -154,6 +157,14 @@ func (o OverlayOp) appendDocument(
 	leftDocSets []*yamlmeta.DocumentSet, newDoc *yamlmeta.Document) error {
 
 	// No need to traverse further
-	leftDocSets[len(leftDocSets)-1].Items = append(leftDocSets[len(leftDocSets)-1].Items, newDoc.DeepCopy())
+	leftDocSets[len(leftDocSets)-1].Items = append(leftDocSets[len(leftDocSets)-1].Items,
+		o.tracedDocument(newDoc.DeepCopy(), AnnotationAppend, newDoc))
 	return nil
 }
+
+func (o OverlayOp) tracedDocument(doc *yamlmeta.Document,
+	op structmeta.AnnotationName, overlayDoc *yamlmeta.Document) *yamlmeta.Document {
+
+	o.Trace.record(doc, op, overlayDoc)
+	return doc
+}
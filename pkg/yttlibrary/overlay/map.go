@@ -25,6 +25,7 @@ func (o OverlayOp) mergeMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapItem
 	if !found {
 		// No need to traverse further
 		leftMap.Items = append(leftMap.Items, newItem)
+		o.Trace.record(newItem, AnnotationMerge, newItem)
 		return nil
 	}
 
@@ -34,6 +35,7 @@ func (o OverlayOp) mergeMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapItem
 	}
 	if replace {
 		leftMap.Items[leftIdx].Value = newItem.Value
+		o.Trace.record(leftMap.Items[leftIdx], AnnotationMerge, newItem)
 	}
 
 	return nil
@@ -54,6 +56,7 @@ func (o OverlayOp) removeMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapIte
 
 	if found {
 		leftMap.Items = append(leftMap.Items[:leftIdx], leftMap.Items[leftIdx+1:]...)
+		o.Trace.record(leftMap, AnnotationRemove, newItem)
 	}
 
 	return nil
@@ -85,6 +88,7 @@ func (o OverlayOp) replaceMapItem(leftMap *yamlmeta.Map, newItem *yamlmeta.MapIt
 
 		leftMap.Items[leftIdx] = newItem.DeepCopy()
 		leftMap.Items[leftIdx].SetValue(newVal)
+		o.Trace.record(leftMap.Items[leftIdx], AnnotationReplace, newItem)
 	}
 
 	return nil
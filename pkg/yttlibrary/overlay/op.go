@@ -16,6 +16,9 @@ type OverlayOp struct {
 	Thread *starlark.Thread
 
 	ExactMatch bool
+
+	// Trace records overlay nodes that modified left nodes; optional
+	Trace *Trace
 }
 
 func (o OverlayOp) Apply() (interface{}, error) {
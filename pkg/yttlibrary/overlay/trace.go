@@ -0,0 +1,107 @@
+package overlay
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/structmeta"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// Trace records which overlay node last modified
+// nodes (documents, maps, arrays and their items)
+type Trace struct {
+	entries map[yamlmeta.Node]TraceEntry
+}
+
+type TraceEntry struct {
+	Op       structmeta.AnnotationName
+	Position *filepos.Position // position of overlay node
+}
+
+func NewTrace() *Trace {
+	return &Trace{entries: map[yamlmeta.Node]TraceEntry{}}
+}
+
+// record is a noop for nil trace so that tracing is only done when requested
+func (t *Trace) record(node yamlmeta.Node, op structmeta.AnnotationName, overlayNode yamlmeta.Node) {
+	if t == nil {
+		return
+	}
+	t.entries[node] = TraceEntry{Op: op, Position: overlayNode.GetPosition()}
+}
+
+func (t *Trace) Entry(node yamlmeta.Node) (TraceEntry, bool) {
+	entry, found := t.entries[node]
+	return entry, found
+}
+
+// TracePrinter prints YAML document per each document listing
+// nodes modified by overlays (as path of keys and indexes) with
+// operation and position of the overlay node that last modified them
+type TracePrinter struct {
+	writer      io.Writer
+	trace       *Trace
+	writtenOnce bool
+}
+
+var _ yamlmeta.DocumentPrinter = &TracePrinter{}
+
+func NewTracePrinter(writer io.Writer, trace *Trace) *TracePrinter {
+	return &TracePrinter{writer, trace, false}
+}
+
+func (p *TracePrinter) Print(doc *yamlmeta.Document) error {
+	nodes := []interface{}{}
+	p.collectNodes(doc, []interface{}{}, &nodes)
+
+	result := orderedmap.NewMap()
+	result.Set("document", doc.SourcePosition().AsCompactString())
+	result.Set("nodes", nodes)
+
+	bs, err := (&yamlmeta.Document{Value: yamlmeta.NewASTFromInterface(result)}).AsYAMLBytes()
+	if err != nil {
+		return fmt.Errorf("marshaling overlay trace: %s", err)
+	}
+
+	if p.writtenOnce {
+		bs = append([]byte("---\n"), bs...)
+	} else {
+		p.writtenOnce = true
+	}
+
+	_, err = p.writer.Write(bs)
+	if err != nil {
+		return fmt.Errorf("writing overlay trace: %s", err)
+	}
+	return nil
+}
+
+func (p *TracePrinter) collectNodes(node yamlmeta.Node, path []interface{}, result *[]interface{}) {
+	if entry, found := p.trace.Entry(node); found {
+		item := orderedmap.NewMap()
+		item.Set("path", path)
+		item.Set("op", string(entry.Op))
+		item.Set("overlay", entry.Position.AsCompactString())
+		*result = append(*result, item)
+	}
+
+	switch typedNode := node.(type) {
+	case *yamlmeta.Document, *yamlmeta.MapItem, *yamlmeta.ArrayItem:
+		if childNode, ok := typedNode.GetValues()[0].(yamlmeta.Node); ok {
+			p.collectNodes(childNode, path, result)
+		}
+
+	case *yamlmeta.Map:
+		for _, item := range typedNode.Items {
+			p.collectNodes(item, append(append([]interface{}{}, path...), item.Key), result)
+		}
+
+	case *yamlmeta.Array:
+		for i, item := range typedNode.Items {
+			p.collectNodes(item, append(append([]interface{}{}, path...), i), result)
+		}
+	}
+}